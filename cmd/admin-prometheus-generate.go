@@ -157,6 +157,7 @@ func generatePrometheusConfig(ctx *cli.Context) error {
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
 		return nil
 	}
+	fatalIf(unsealAliasCredentials(alias, hostConfig), "Unable to read the credentials of alias `"+alias+"`.")
 
 	u, e := url.Parse(hostConfig.URL)
 	if e != nil {
@@ -85,6 +85,7 @@ func printPrometheusMetrics(ctx *cli.Context) error {
 		fatalIf(errInvalidAliasedURL(alias), "No such alias `"+alias+"` found.")
 		return nil
 	}
+	fatalIf(unsealAliasCredentials(alias, hostConfig), "Unable to read the credentials of alias `"+alias+"`.")
 
 	token, e := getPrometheusToken(hostConfig)
 	if e != nil {
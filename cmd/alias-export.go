@@ -7,6 +7,13 @@ import (
 	"github.com/minio/pkg/v2/console"
 )
 
+var aliasExportFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "decrypt",
+		Usage: "export the secret key in plaintext instead of its encrypted or helper form",
+	},
+}
+
 var aliasExportCmd = cli.Command{
 	Name:            "export",
 	ShortName:       "e",
@@ -14,7 +21,7 @@ var aliasExportCmd = cli.Command{
 	Action:          mainAliasExport,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append(aliasExportFlags, globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
@@ -32,6 +39,9 @@ USAGE:
     "path": "auto"
   }
 
+  Encrypted credentials are exported as such, importing them again asks for the
+  same passphrase. Credentials kept by a credential helper export a reference to it.
+
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
@@ -41,6 +51,9 @@ EXAMPLES:
 
   2. Export the credentials to standard output and pipe it to import command
      {{ .Prompt }} {{ .HelpName }} alias1/  | mc alias import alias2/
+
+  3. Export the credentials of an alias using an encrypted secret key in plaintext
+     {{ .Prompt }} {{ .HelpName }} --decrypt myminio/ > credentials.json
 `,
 }
 
@@ -62,14 +75,17 @@ func checkAliasExportSyntax(ctx *cli.Context) {
 }
 
 // exportAlias - get an alias config
-func exportAlias(alias string) {
-	mcCfgV10, err := loadMcConfig()
+func exportAlias(alias string, decrypt bool) {
+	mcCfgV11, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
 
-	cfg, ok := mcCfgV10.Aliases[alias]
+	cfg, ok := mcCfgV11.Aliases[alias]
 	if !ok {
 		fatalIf(errInvalidArgument().Trace(alias), "Unable to export credentials")
 	}
+	if decrypt {
+		fatalIf(unsealAliasCredentials(alias, &cfg).Trace(alias), "Unable to decrypt credentials")
+	}
 
 	buf, e := json.Marshal(cfg)
 	fatalIf(probe.NewError(e).Trace(alias), "Unable to export credentials")
@@ -82,7 +98,7 @@ func mainAliasExport(cli *cli.Context) error {
 
 	checkAliasExportSyntax(cli)
 
-	exportAlias(cleanAlias(args.Get(0)))
+	exportAlias(cleanAlias(args.Get(0)), cli.Bool("decrypt"))
 
 	return nil
 }
@@ -33,7 +33,7 @@ var aliasImportCmd = cli.Command{
 	Action:          mainAliasImport,
 	OnUsageError:    onUsageError,
 	Before:          setGlobalsFromContext,
-	Flags:           globalFlags,
+	Flags:           append([]cli.Flag{credentialStoreFlag}, globalFlags...),
 	HideHelpCommand: true,
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
@@ -51,6 +51,9 @@ USAGE:
     "path": "auto"
   }
 
+  Credentials exported with an encrypted secret key or a credential helper
+  reference are imported as such.
+
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
@@ -60,6 +63,9 @@ EXAMPLES:
 
   2. Import the credentials through standard input as 'myminio' to the config:
      {{ .Prompt }} cat credentials.json | {{ .HelpName }} myminio/
+
+  3. Import the provided credentials.json file as 'myminio', keeping the secret key encrypted:
+     {{ .Prompt }} {{ .HelpName }} --store passphrase myminio/ ./credentials.json
 `,
 }
 
@@ -83,7 +89,7 @@ func checkAliasImportSyntax(ctx *cli.Context) {
 	}
 }
 
-func checkCredentialsSyntax(credentials aliasConfigV10) {
+func checkCredentialsSyntax(credentials aliasConfigV11) {
 	if !isValidHostURL(credentials.URL) {
 		fatalIf(errInvalidURL(credentials.URL), "Invalid URL.")
 	}
@@ -98,6 +104,10 @@ func checkCredentialsSyntax(credentials aliasConfigV10) {
 			"Invalid secret key.")
 	}
 
+	if !isValidCredentialStore(credentials.CredentialStore) {
+		fatalIf(errInvalidCredentialStore(credentials.CredentialStore), "Invalid credential store.")
+	}
+
 	if credentials.API != "" && !isValidAPI(credentials.API) { // Empty value set to default "S3v4".
 		fatalIf(errInvalidArgument().Trace(credentials.API),
 			"Unrecognized API signature. Valid options are `[S3v4, S3v2]`.")
@@ -109,22 +119,22 @@ func checkCredentialsSyntax(credentials aliasConfigV10) {
 }
 
 // importAlias - set an alias config based on imported values.
-func importAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
-	checkCredentialsSyntax(aliasCfgV10)
+func importAlias(alias string, aliasCfgV11 aliasConfigV11) aliasMessage {
+	checkCredentialsSyntax(aliasCfgV11)
 
-	mcCfgV10, err := loadMcConfig()
+	mcCfgV11, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
 
 	// Add new host.
-	mcCfgV10.Aliases[alias] = aliasCfgV10
-	fatalIf(saveMcConfig(mcCfgV10).Trace(alias), "Unable to import credentials to `"+mustGetMcConfigPath()+"`.")
+	mcCfgV11.Aliases[alias] = aliasCfgV11
+	fatalIf(saveMcConfig(mcCfgV11).Trace(alias), "Unable to import credentials to `"+mustGetMcConfigPath()+"`.")
 	return aliasMessage{
 		Alias:     alias,
-		URL:       mcCfgV10.Aliases[alias].URL,
-		AccessKey: mcCfgV10.Aliases[alias].AccessKey,
-		SecretKey: mcCfgV10.Aliases[alias].SecretKey,
-		API:       mcCfgV10.Aliases[alias].API,
-		Path:      mcCfgV10.Aliases[alias].Path,
+		URL:       mcCfgV11.Aliases[alias].URL,
+		AccessKey: mcCfgV11.Aliases[alias].AccessKey,
+		SecretKey: mcCfgV11.Aliases[alias].SecretKey,
+		API:       mcCfgV11.Aliases[alias].API,
+		Path:      mcCfgV11.Aliases[alias].Path,
 	}
 }
 
@@ -135,7 +145,7 @@ func mainAliasImport(cli *cli.Context) error {
 	)
 
 	checkAliasImportSyntax(cli)
-	var credentialsJSON aliasConfigV10
+	var credentialsJSON aliasConfigV11
 
 	credsFile := strings.TrimSpace(args.Get(1))
 	if credsFile == "" {
@@ -147,6 +157,12 @@ func mainAliasImport(cli *cli.Context) error {
 	e = json.Unmarshal(input, &credentialsJSON)
 	fatalIf(probe.NewError(e).Trace(args...), "Unable to parse input credentials")
 
+	if cli.IsSet("store") && cli.String("store") != credentialsJSON.CredentialStore {
+		checkCredentialsSyntax(credentialsJSON)
+		fatalIf(unsealAliasCredentials(alias, &credentialsJSON).Trace(alias), "Unable to read imported credentials")
+		fatalIf(sealAliasCredentials(alias, &credentialsJSON, cli.String("store")).Trace(alias), "Unable to store imported credentials")
+	}
+
 	msg := importAlias(alias, credentialsJSON)
 	msg.op = cli.Command.Name
 
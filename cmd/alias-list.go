@@ -99,7 +99,7 @@ func printAliases(aliases ...aliasMessage) {
 			// Format properly for alignment based on alias length only in non json mode.
 			alias.Alias = fmt.Sprintf("%-*.*s", maxAlias, maxAlias, alias.Alias)
 		}
-		if alias.AccessKey == "" || alias.SecretKey == "" {
+		if (alias.AccessKey == "" || (alias.SecretKey == "" && alias.Store == "")) && alias.Provider == "" {
 			alias.AccessKey = ""
 			alias.SecretKey = ""
 			alias.API = ""
@@ -130,6 +130,10 @@ func listAliases(alias string, deprecated bool) (aliases []aliasMessage) {
 				AccessKey:   v.AccessKey,
 				SecretKey:   v.SecretKey,
 				API:         v.API,
+				Store:       v.CredentialStore,
+			}
+			if v.CredentialProvider != nil {
+				aliasMsg.Provider = v.CredentialProvider.Type
 			}
 
 			if deprecated {
@@ -151,6 +155,10 @@ func listAliases(alias string, deprecated bool) (aliases []aliasMessage) {
 			AccessKey:   v.AccessKey,
 			SecretKey:   v.SecretKey,
 			API:         v.API,
+			Store:       v.CredentialStore,
+		}
+		if v.CredentialProvider != nil {
+			aliasMsg.Provider = v.CredentialProvider.Type
 		}
 
 		if deprecated {
@@ -73,6 +73,8 @@ type aliasMessage struct {
 	SecretKey   string `json:"secretKey,omitempty"`
 	API         string `json:"api,omitempty"`
 	Path        string `json:"path,omitempty"`
+	Store       string `json:"credentialStore,omitempty"`
+	Provider    string `json:"credentialProvider,omitempty"`
 	// Deprecated field, replaced by Path
 	Lookup string `json:"lookup,omitempty"`
 }
@@ -97,7 +99,14 @@ func (h aliasMessage) String() string {
 		if path == "" {
 			path = h.Lookup
 		}
-		return t.buildRecord(h.Alias, h.URL, h.AccessKey, h.SecretKey, h.API, path)
+		accessKey, secretKey := h.AccessKey, h.SecretKey
+		if h.Store != "" {
+			secretKey = "<" + h.Store + ">"
+		}
+		if h.Provider != "" && accessKey == "" {
+			accessKey = "<" + h.Provider + ">"
+		}
+		return t.buildRecord(h.Alias, h.URL, accessKey, secretKey, h.API, path)
 	case "remove":
 		return console.Colorize("AliasMessage", "Removed `"+h.Alias+"` successfully.")
 	case "add": // add is deprecated
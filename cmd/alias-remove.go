@@ -97,6 +97,11 @@ func removeAlias(alias string) aliasMessage {
 	// check if alias is valid
 	aliasMustExist(alias)
 
+	// Secrets kept by a credential helper are not removed along with the config.
+	if aliasCfg, ok := conf.Aliases[alias]; ok {
+		errorIf(eraseAliasCredentials(alias, &aliasCfg).Trace(alias), "Unable to erase the credentials of alias `"+alias+"`.")
+	}
+
 	// Remove the alias from the config.
 	delete(conf.Aliases, alias)
 
@@ -32,6 +32,7 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/limiter"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/console"
@@ -50,6 +51,59 @@ var aliasSetFlags = []cli.Flag{
 		Name:  "api",
 		Usage: "API signature. Valid options are '[S3v4, S3v2]'",
 	},
+	cli.StringFlag{
+		Name:  "upload-limit",
+		Usage: "upload bandwidth limit of the alias, a rate such as '1MiB' or a daily schedule such as '09:00-18:00=1MiB'",
+	},
+	cli.StringFlag{
+		Name:  "download-limit",
+		Usage: "download bandwidth limit of the alias, a rate such as '1MiB' or a daily schedule such as '09:00-18:00=1MiB'",
+	},
+	cli.DurationFlag{
+		Name:  "read-deadline",
+		Usage: "connection read deadline of the alias",
+	},
+	cli.DurationFlag{
+		Name:  "write-deadline",
+		Usage: "connection write deadline of the alias",
+	},
+	cli.BoolFlag{
+		Name:  "tls-skip-verify",
+		Usage: "disable TLS certificate verification for the alias",
+	},
+	cli.StringFlag{
+		Name:  "ca-bundle",
+		Usage: "path to a PEM bundle of CA certificates trusted for the alias",
+	},
+	cli.IntFlag{
+		Name:  "max-concurrency",
+		Usage: "maximum number of concurrent connections to the alias",
+	},
+	credentialStoreFlag,
+	cli.StringFlag{
+		Name:  "credential-process",
+		Usage: "command printing temporary credentials in the AWS 'credential_process' format",
+	},
+	cli.StringFlag{
+		Name:  "profile",
+		Usage: "profile of a shared credentials file to read credentials from",
+	},
+	cli.StringFlag{
+		Name:  "credentials-file",
+		Usage: "path to the shared credentials file, defaults to '~/.aws/credentials'",
+	},
+	cli.StringFlag{
+		Name:  "role-arn",
+		Usage: "role to assume with the alias or profile credentials",
+	},
+	cli.StringFlag{
+		Name:  "mfa-serial",
+		Usage: "MFA device serial required to assume the role, the code is prompted for",
+	},
+	cli.StringFlag{
+		Name:  "sts-endpoint",
+		Usage: "STS endpoint used to assume the role, defaults to the alias URL",
+	},
 }
 
 var aliasSetCmd = cli.Command{
@@ -96,6 +150,31 @@ EXAMPLES:
      {{.Prompt}} echo -e "BKIKJAA5BMMU2RHO6IBB\nV8f1CwQqAcwo80UEIJEjc5gVQUSSx5ohQ9GSrr12" | \
                  {{.HelpName}} mys3 https://s3.amazonaws.com --api "s3v4" --path "off"
      {{.EnableHistory}}
+  6. Add MinIO service under "myminio" alias, limiting uploads to 10MiB/s during office hours and
+     trusting a private CA. For security reasons turn off bash history momentarily.
+     {{.DisableHistory}}
+     {{.Prompt}} {{.HelpName}} myminio https://minio.example.com minio minio123 \
+                 --upload-limit "09:00-18:00=10MiB" --ca-bundle /etc/ssl/private-ca.pem
+     {{.EnableHistory}}
+  7. Add MinIO service under "myminio" alias, keeping the secret key encrypted with a passphrase.
+     {{.Prompt}} {{.HelpName}} myminio http://localhost:9000 --store passphrase
+     Enter Access Key: minio
+     Enter Secret Key: minio123
+     Enter credential passphrase:
+     Confirm credential passphrase:
+  8. Add MinIO service under "myminio" alias, keeping the secret key in the 'mc-credential-pass'
+     credential helper. Only a reference to the helper is stored in the config file.
+     {{.DisableHistory}}
+     {{.Prompt}} {{.HelpName}} myminio http://localhost:9000 minio minio123 --store helper:pass
+     {{.EnableHistory}}
+  9. Add Amazon S3 storage service under "mys3" alias, with temporary credentials printed by a command.
+     {{.Prompt}} {{.HelpName}} mys3 https://s3.amazonaws.com --credential-process "/usr/local/bin/get-creds --role s3"
+  10. Add Amazon S3 storage service under "mys3" alias, reading the "dev" profile of ~/.aws/credentials.
+      {{.Prompt}} {{.HelpName}} mys3 https://s3.amazonaws.com --profile dev
+  11. Add Amazon S3 storage service under "mys3" alias, assuming a role with the "dev" profile and
+      a MFA code prompted for when the temporary credentials expire.
+      {{.Prompt}} {{.HelpName}} mys3 https://s3.amazonaws.com --profile dev \
+                  --role-arn arn:aws:iam::123456789012:role/admin --mfa-serial arn:aws:iam::123456789012:mfa/user
 `,
 }
 
@@ -153,46 +232,65 @@ func checkAliasSetSyntax(ctx *cli.Context, accessKey, secretKey string, deprecat
 				"Unrecognized path value. Valid options are `[auto, on, off]`.")
 		}
 	}
+
+	for _, limit := range []string{"upload-limit", "download-limit"} {
+		if _, e := limiter.ParseSchedule(ctx.String(limit)); e != nil {
+			fatalIf(probe.NewError(e).Trace(ctx.String(limit)), "Invalid `--"+limit+"` value.")
+		}
+	}
+
+	if ctx.Int("max-concurrency") < 0 {
+		fatalIf(errInvalidArgument().Trace(ctx.String("max-concurrency")),
+			"Invalid `--max-concurrency` value, must not be negative.")
+	}
+
+	if provider := credentialProviderFromContext(ctx); provider != nil {
+		fatalIf(provider.validate(), "Invalid credential provider.")
+	}
+
+	if store := ctx.String("store"); !isValidCredentialStore(store) {
+		fatalIf(errInvalidCredentialStore(store), "Invalid `--store` value.")
+	}
+
+	if caBundle := ctx.String("ca-bundle"); caBundle != "" {
+		if _, e := os.Stat(caBundle); e != nil {
+			fatalIf(probe.NewError(e).Trace(caBundle), "Unable to access CA bundle.")
+		}
+	}
 }
 
 // setAlias - set an alias config.
-func setAlias(alias string, aliasCfgV10 aliasConfigV10) aliasMessage {
-	mcCfgV10, err := loadMcConfig()
+func setAlias(alias string, aliasCfgV11 aliasConfigV11) aliasMessage {
+	mcCfgV11, err := loadMcConfig()
 	fatalIf(err.Trace(globalMCConfigVersion), "Unable to load config `"+mustGetMcConfigPath()+"`.")
 
 	// Add new host.
-	mcCfgV10.Aliases[alias] = aliasCfgV10
+	mcCfgV11.Aliases[alias] = aliasCfgV11
 
-	err = saveMcConfig(mcCfgV10)
+	err = saveMcConfig(mcCfgV11)
 	fatalIf(err.Trace(alias), "Unable to update hosts in config version `"+mustGetMcConfigPath()+"`.")
 
 	return aliasMessage{
 		Alias:     alias,
-		URL:       aliasCfgV10.URL,
-		AccessKey: aliasCfgV10.AccessKey,
-		SecretKey: aliasCfgV10.SecretKey,
-		API:       aliasCfgV10.API,
-		Path:      aliasCfgV10.Path,
+		URL:       aliasCfgV11.URL,
+		AccessKey: aliasCfgV11.AccessKey,
+		SecretKey: aliasCfgV11.SecretKey,
+		API:       aliasCfgV11.API,
+		Path:      aliasCfgV11.Path,
 	}
 }
 
 // probeS3Signature - auto probe S3 server signature: issue a Stat call
 // using v4 signature then v2 in case of failure.
-func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, peerCert *x509.Certificate) (string, *probe.Error) {
+func probeS3Signature(ctx context.Context, alias string, aliasCfg *aliasConfigV11, peerCert *x509.Certificate) (string, *probe.Error) {
 	probeBucketName := randString(60, rand.NewSource(time.Now().UnixNano()), "probe-bsign-")
-	// Test s3 connection for API auto probe
-	s3Config := &Config{
-		// S3 connection parameters
-		Insecure:          globalInsecure,
-		AccessKey:         accessKey,
-		SecretKey:         secretKey,
-		HostURL:           urlJoinPath(url, probeBucketName),
-		Debug:             globalDebug,
-		ConnReadDeadline:  globalConnReadDeadline,
-		ConnWriteDeadline: globalConnWriteDeadline,
-		UploadLimit:       int64(globalLimitUpload),
-		DownloadLimit:     int64(globalLimitDownload),
+	// Test s3 connection for API auto probe, with the connection
+	// settings of the alias.
+	s3Config, err := NewS3Config(alias, urlJoinPath(aliasCfg.URL, probeBucketName), aliasCfg)
+	if err != nil {
+		return "", err
 	}
+	s3Config.Lookup = minio.BucketLookupAuto
 	if peerCert != nil {
 		configurePeerCertificate(s3Config, peerCert)
 	}
@@ -235,13 +333,11 @@ func probeS3Signature(ctx context.Context, accessKey, secretKey, url string, pee
 
 // BuildS3Config constructs an S3 Config and does
 // signature auto-probe when needed.
-func BuildS3Config(ctx context.Context, alias, url, accessKey, secretKey, api, path string, peerCert *x509.Certificate) (*Config, *probe.Error) {
-	s3Config := NewS3Config(alias, url, &aliasConfigV10{
-		AccessKey: accessKey,
-		SecretKey: secretKey,
-		URL:       url,
-		Path:      path,
-	})
+func BuildS3Config(ctx context.Context, alias string, aliasCfg aliasConfigV11, peerCert *x509.Certificate) (*Config, *probe.Error) {
+	s3Config, err := NewS3Config(alias, aliasCfg.URL, &aliasCfg)
+	if err != nil {
+		return nil, err
+	}
 
 	if peerCert != nil {
 		configurePeerCertificate(s3Config, peerCert)
@@ -249,14 +345,13 @@ func BuildS3Config(ctx context.Context, alias, url, accessKey, secretKey, api, p
 
 	// If api is provided we do not auto probe signature, this is
 	// required in situations when signature type is provided by the user.
-	if api != "" {
-		s3Config.Signature = api
+	if aliasCfg.API != "" {
 		return s3Config, nil
 	}
 	// Probe S3 signature version
-	api, err := probeS3Signature(ctx, accessKey, secretKey, url, peerCert)
+	api, err := probeS3Signature(ctx, alias, &aliasCfg, peerCert)
 	if err != nil {
-		return nil, err.Trace(url, accessKey, api, path)
+		return nil, err.Trace(aliasCfg.URL, aliasCfg.AccessKey, api, aliasCfg.Path)
 	}
 
 	s3Config.Signature = api
@@ -328,27 +423,51 @@ func mainAliasSet(cli *cli.Context, deprecated bool) error {
 		}
 	}
 
-	accessKey, secretKey := fetchAliasKeys(args)
+	var accessKey, secretKey string
+	provider := credentialProviderFromContext(cli)
+	if provider == nil || provider.needsStaticKeys() || len(args) > 2 {
+		accessKey, secretKey = fetchAliasKeys(args)
+	}
 	checkAliasSetSyntax(cli, accessKey, secretKey, deprecated)
 
 	ctx, cancelAliasAdd := context.WithCancel(globalContext)
 	defer cancelAliasAdd()
 
-	if !globalInsecure && !globalJSON && term.IsTerminal(int(os.Stdout.Fd())) {
+	aliasCfg := aliasConfigV11{
+		URL:            url,
+		AccessKey:      accessKey,
+		SecretKey:      secretKey,
+		API:            api,
+		Path:           path,
+		UploadLimit:    cli.String("upload-limit"),
+		DownloadLimit:  cli.String("download-limit"),
+		Insecure:       cli.Bool("tls-skip-verify"),
+		CABundle:       cli.String("ca-bundle"),
+		MaxConcurrency: cli.Int("max-concurrency"),
+
+		CredentialProvider: provider,
+	}
+	if d := cli.Duration("read-deadline"); d > 0 {
+		aliasCfg.ConnReadDeadline = d.String()
+	}
+	if d := cli.Duration("write-deadline"); d > 0 {
+		aliasCfg.ConnWriteDeadline = d.String()
+	}
+
+	if !globalInsecure && !aliasCfg.Insecure && aliasCfg.CABundle == "" && !globalJSON && term.IsTerminal(int(os.Stdout.Fd())) {
 		peerCert, err = promptTrustSelfSignedCert(ctx, url, alias)
 		fatalIf(err.Trace(alias, url, accessKey), "Unable to initialize new alias from the provided credentials.")
 	}
 
-	s3Config, err := BuildS3Config(ctx, alias, url, accessKey, secretKey, api, path, peerCert)
+	s3Config, err := BuildS3Config(ctx, alias, aliasCfg, peerCert)
 	fatalIf(err.Trace(alias, url, accessKey), "Unable to initialize new alias from the provided credentials.")
 
-	msg := setAlias(alias, aliasConfigV10{
-		URL:       s3Config.HostURL,
-		AccessKey: s3Config.AccessKey,
-		SecretKey: s3Config.SecretKey,
-		API:       s3Config.Signature,
-		Path:      path,
-	}) // Add an alias with specified credentials.
+	aliasCfg.URL = s3Config.HostURL
+	aliasCfg.API = s3Config.Signature
+	err = sealAliasCredentials(alias, &aliasCfg, cli.String("store"))
+	fatalIf(err.Trace(alias), "Unable to store the credentials of the alias.")
+
+	msg := setAlias(alias, aliasCfg) // Add an alias with specified credentials.
 
 	msg.op = "set"
 	if deprecated {
@@ -359,6 +478,30 @@ func mainAliasSet(cli *cli.Context, deprecated bool) error {
 	return nil
 }
 
+// credentialProviderFromContext - returns the credential provider set
+// with 'alias set' flags, or nil for static keys.
+func credentialProviderFromContext(ctx *cli.Context) *aliasCredentialProvider {
+	provider := &aliasCredentialProvider{
+		Process:     ctx.String("credential-process"),
+		File:        ctx.String("credentials-file"),
+		Profile:     ctx.String("profile"),
+		RoleARN:     ctx.String("role-arn"),
+		MFASerial:   ctx.String("mfa-serial"),
+		STSEndpoint: ctx.String("sts-endpoint"),
+	}
+	switch {
+	case provider.RoleARN != "" || provider.MFASerial != "" || provider.STSEndpoint != "":
+		provider.Type = credentialProviderAssumeRole
+	case provider.Process != "":
+		provider.Type = credentialProviderProcess
+	case provider.Profile != "" || provider.File != "":
+		provider.Type = credentialProviderProfile
+	default:
+		return nil
+	}
+	return provider
+}
+
 // configurePeerCertificate adds the peer certificate to the
 // TLS root CAs of s3Config. Once configured, any client
 // initialized with this config trusts the given peer certificate.
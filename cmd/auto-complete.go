@@ -219,11 +219,45 @@ func (al aliasComplete) Predict(a complete.Args) (prediction []string) {
 	return
 }
 
+// sessionComplete only completes saved session names
+type sessionComplete struct{}
+
+func (sc sessionComplete) Predict(a complete.Args) (prediction []string) {
+	names, err := getSessionNames()
+	if err != nil {
+		return nil
+	}
+	for _, name := range names {
+		if strings.HasPrefix(name, a.Last) {
+			prediction = append(prediction, name)
+		}
+	}
+	return
+}
+
+// scheduleComplete only completes scheduled job names
+type scheduleComplete struct{}
+
+func (sc scheduleComplete) Predict(a complete.Args) (prediction []string) {
+	jobs, err := loadScheduleJobs()
+	if err != nil {
+		return nil
+	}
+	for _, job := range jobs.Jobs {
+		if strings.HasPrefix(job.Name, a.Last) {
+			prediction = append(prediction, job.Name)
+		}
+	}
+	return
+}
+
 var (
 	adminConfigCompleter = adminConfigComplete{}
 	s3Completer          = s3Complete{}
 	aliasCompleter       = aliasComplete{}
 	fsCompleter          = fsComplete{}
+	sessionCompleter     = sessionComplete{}
+	scheduleCompleter    = scheduleComplete{}
 )
 
 // The list of all commands supported by mc with their mapping
@@ -235,17 +269,20 @@ var completeCmds = map[string]complete.Predictor{
 	"/mv":        complete.PredictOr(s3Completer, fsCompleter),
 	"/rm":        complete.PredictOr(s3Completer, fsCompleter),
 	"/rb":        complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
+	"/replay":    fsCompleter,
 	"/cat":       complete.PredictOr(s3Completer, fsCompleter),
 	"/head":      complete.PredictOr(s3Completer, fsCompleter),
 	"/diff":      complete.PredictOr(s3Completer, fsCompleter),
 	"/find":      complete.PredictOr(s3Completer, fsCompleter),
 	"/mirror":    complete.PredictOr(s3Completer, fsCompleter),
+	"/sync":      complete.PredictOr(s3Completer, fsCompleter),
 	"/pipe":      complete.PredictOr(s3Completer, fsCompleter),
 	"/stat":      complete.PredictOr(s3Completer, fsCompleter),
 	"/watch":     complete.PredictOr(s3Completer, fsCompleter),
 	"/anonymous": complete.PredictOr(s3Completer, fsCompleter),
 	"/tree":      complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
 	"/du":        complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
+	"/inventory": complete.PredictOr(s3Complete{deepLevel: 2}, fsCompleter),
 
 	"/retention/set":   s3Completer,
 	"/retention/clear": s3Completer,
@@ -491,6 +528,16 @@ var completeCmds = map[string]complete.Predictor{
 	"/support/top/net":      aliasCompleter,
 	"/support/upload":       aliasCompleter,
 
+	"/session/resume": sessionCompleter,
+	"/session/list":   nil,
+	"/session/clear":  sessionCompleter,
+
+	"/schedule/add":    nil,
+	"/schedule/list":   scheduleCompleter,
+	"/schedule/remove": scheduleCompleter,
+	"/schedule/run":    scheduleCompleter,
+	"/schedule/daemon": nil,
+
 	"/license/register": aliasCompleter,
 	"/license/info":     aliasCompleter,
 	"/license/update":   aliasCompleter,
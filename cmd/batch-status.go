@@ -41,6 +41,37 @@ EXAMPLES:
 `,
 }
 
+// batchStatusMetrics - turns the counts of a batch job, which only grow,
+// into the metrics of the command.
+type batchStatusMetrics struct {
+	objects, failed, bytes, retries int64
+}
+
+// observe - accounts the progress of the job since the last metrics.
+func (b *batchStatusMetrics) observe(job madmin.JobMetric) {
+	var objects, failed, bytes int64
+	switch {
+	case job.Replicate != nil:
+		objects, failed, bytes = job.Replicate.Objects, job.Replicate.ObjectsFailed, job.Replicate.BytesTransferred
+	case job.KeyRotate != nil:
+		objects, failed = job.KeyRotate.Objects, job.KeyRotate.ObjectsFailed
+	case job.Expired != nil:
+		objects, failed = job.Expired.Objects, job.Expired.ObjectsFailed
+	}
+	retries := int64(job.RetryAttempts)
+
+	if objects > b.objects {
+		metricsObjectsDone(objects-b.objects, bytes-b.bytes)
+	}
+	if failed > b.failed {
+		metricsErrorsCount("BatchJobObjectFailed", failed-b.failed)
+	}
+	if retries > b.retries {
+		metricsRetriesCount(retries - b.retries)
+	}
+	b.objects, b.failed, b.bytes, b.retries = objects, failed, bytes, retries
+}
+
 // checkBatchStatusSyntax - validate all the passed arguments
 func checkBatchStatusSyntax(ctx *cli.Context) {
 	if len(ctx.Args()) != 2 {
@@ -72,6 +103,7 @@ func mainBatchStatus(ctx *cli.Context) error {
 	fatalIf(probe.NewError(e), "Unable to lookup job status")
 
 	ui := tea.NewProgram(initBatchJobMetricsUI(jobID))
+	var jobMetrics batchStatusMetrics
 	go func() {
 		opts := madmin.MetricsOptions{
 			Type:     madmin.MetricsBatchJobs,
@@ -79,6 +111,11 @@ func mainBatchStatus(ctx *cli.Context) error {
 			Interval: time.Second,
 		}
 		e := client.Metrics(ctxt, opts, func(metrics madmin.RealtimeMetrics) {
+			if metrics.Aggregated.BatchJobs != nil {
+				if job, ok := metrics.Aggregated.BatchJobs.Jobs[jobID]; ok {
+					jobMetrics.observe(job)
+				}
+			}
 			if globalJSON {
 				if metrics.Aggregated.BatchJobs == nil {
 					cancel()
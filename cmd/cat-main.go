@@ -64,7 +64,7 @@ var catCmd = cli.Command{
 	Action:       mainCat,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(catFlags, encCFlag), globalFlags...),
+	Flags:        append(append(catFlags, encCFlag, encClientFlag), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -97,6 +97,9 @@ EXAMPLES:
 
   7. Display the content of a particular object version
      {{.Prompt}} {{.HelpName}} --vid "3ddac055-89a7-40fa-8cd3-530a5581b6b8" play/my-bucket/my-object
+
+  8. Display the content of an object encrypted on the client.
+     {{.Prompt}} {{.HelpName}} --enc-client "play/my-bucket/=/etc/mc/my-bucket.key" play/my-bucket/my-object
 `,
 }
 
@@ -241,6 +244,9 @@ func catURL(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixS
 			if o.versionID == "" {
 				versionID = content.VersionID
 			}
+			if alias, _ := url2Alias(sourceURL); getCSE(sourceURL, encKeyDB[alias]) != nil {
+				content.Size = clientSideDecryptedSize(content.Size)
+			}
 			if o.tailO > 0 && content.Size > 0 {
 				o.startO = content.Size - o.tailO
 				if o.startO < 0 {
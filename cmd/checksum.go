@@ -0,0 +1,176 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/base64"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+// checksumFlag - verify uploads with an S3 additional checksum.
+var checksumFlag = cli.StringFlag{
+	Name:  "checksum",
+	Usage: "verify uploaded data end-to-end with an additional checksum (crc32c, crc32, sha1, sha256)",
+}
+
+// checksumTypes - additional checksums supported by S3.
+var checksumTypes = []minio.ChecksumType{
+	minio.ChecksumCRC32C,
+	minio.ChecksumCRC32,
+	minio.ChecksumSHA1,
+	minio.ChecksumSHA256,
+}
+
+// parseChecksumType - parses the value of '--checksum'.
+func parseChecksumType(algo string) (minio.ChecksumType, *probe.Error) {
+	switch strings.ToLower(algo) {
+	case "":
+		return minio.ChecksumNone, nil
+	case "crc32c":
+		return minio.ChecksumCRC32C, nil
+	case "crc32":
+		return minio.ChecksumCRC32, nil
+	case "sha1":
+		return minio.ChecksumSHA1, nil
+	case "sha256":
+		return minio.ChecksumSHA256, nil
+	}
+	return minio.ChecksumNone, errInvalidArgument().Trace(algo)
+}
+
+// mustParseChecksumFlag - parses '--checksum' or dies.
+func mustParseChecksumFlag(ctx *cli.Context) minio.ChecksumType {
+	algo, err := parseChecksumType(ctx.String("checksum"))
+	fatalIf(err, "Invalid checksum algorithm, valid values are crc32c, crc32, sha1 and sha256.")
+	return algo
+}
+
+// compositeChecksum - computes the checksum of a multipart object the
+// way S3 does, a checksum of the concatenated raw part checksums with
+// the number of parts appended.
+func compositeChecksum(algo minio.ChecksumType, partSums [][]byte) string {
+	h := algo.Hasher()
+	for _, sum := range partSums {
+		h.Write(sum)
+	}
+	return base64.StdEncoding.EncodeToString(h.Sum(nil)) + "-" + strconv.Itoa(len(partSums))
+}
+
+// checksumMatches - compares two base64 checksums, composite checksums
+// are compared without their parts count as not all servers return it.
+func checksumMatches(expected, actual string) bool {
+	trim := func(s string) string {
+		if i := strings.LastIndex(s, "-"); i > 0 {
+			return s[:i]
+		}
+		return s
+	}
+	return actual != "" && trim(expected) == trim(actual)
+}
+
+// partChecksum - returns the checksum of an uploaded part.
+func partChecksum(algo minio.ChecksumType, part minio.ObjectPart) string {
+	switch algo {
+	case minio.ChecksumCRC32:
+		return part.ChecksumCRC32
+	case minio.ChecksumCRC32C:
+		return part.ChecksumCRC32C
+	case minio.ChecksumSHA1:
+		return part.ChecksumSHA1
+	case minio.ChecksumSHA256:
+		return part.ChecksumSHA256
+	}
+	return ""
+}
+
+// completePart - builds a complete part entry carrying its checksum.
+func completePart(algo minio.ChecksumType, partNumber int, etag string, sum minio.Checksum) minio.CompletePart {
+	part := minio.CompletePart{PartNumber: partNumber, ETag: etag}
+	switch algo {
+	case minio.ChecksumCRC32:
+		part.ChecksumCRC32 = sum.Encoded()
+	case minio.ChecksumCRC32C:
+		part.ChecksumCRC32C = sum.Encoded()
+	case minio.ChecksumSHA1:
+		part.ChecksumSHA1 = sum.Encoded()
+	case minio.ChecksumSHA256:
+		part.ChecksumSHA256 = sum.Encoded()
+	}
+	return part
+}
+
+// uploadInfoChecksum - returns the checksum reported by an upload.
+func uploadInfoChecksum(algo minio.ChecksumType, info minio.UploadInfo) string {
+	switch algo {
+	case minio.ChecksumCRC32:
+		return info.ChecksumCRC32
+	case minio.ChecksumCRC32C:
+		return info.ChecksumCRC32C
+	case minio.ChecksumSHA1:
+		return info.ChecksumSHA1
+	case minio.ChecksumSHA256:
+		return info.ChecksumSHA256
+	}
+	return ""
+}
+
+// objectInfoChecksum - returns the checksum reported by a HEAD request.
+func objectInfoChecksum(algo minio.ChecksumType, info minio.ObjectInfo) string {
+	switch algo {
+	case minio.ChecksumCRC32:
+		return info.ChecksumCRC32
+	case minio.ChecksumCRC32C:
+		return info.ChecksumCRC32C
+	case minio.ChecksumSHA1:
+		return info.ChecksumSHA1
+	case minio.ChecksumSHA256:
+		return info.ChecksumSHA256
+	}
+	return ""
+}
+
+// verifyFileChecksum - reads back a written file and compares its
+// checksum with the one computed while the data was streamed.
+func verifyFileChecksum(path string, algo minio.ChecksumType, expected []byte) *probe.Error {
+	f, e := os.Open(path)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	defer f.Close()
+
+	sum, e := algo.ChecksumReader(f)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	want := base64.StdEncoding.EncodeToString(expected)
+	if sum.Encoded() != want {
+		return probe.NewError(ChecksumMismatch{
+			Object:    path,
+			Algorithm: algo.String(),
+			Expected:  want,
+			Actual:    sum.Encoded(),
+		})
+	}
+	return nil
+}
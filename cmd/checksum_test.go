@@ -0,0 +1,295 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+func TestParseChecksumType(t *testing.T) {
+	testCases := []struct {
+		algo     string
+		expected minio.ChecksumType
+		fail     bool
+	}{
+		{"", minio.ChecksumNone, false},
+		{"crc32c", minio.ChecksumCRC32C, false},
+		{"CRC32", minio.ChecksumCRC32, false},
+		{"sha1", minio.ChecksumSHA1, false},
+		{"sha256", minio.ChecksumSHA256, false},
+		{"md5", minio.ChecksumNone, true},
+	}
+	for i, testCase := range testCases {
+		algo, err := parseChecksumType(testCase.algo)
+		if testCase.fail != (err != nil) {
+			t.Fatalf("Test %d: expected failure %t, got %v", i+1, testCase.fail, err)
+		}
+		if algo != testCase.expected {
+			t.Fatalf("Test %d: expected %s, got %s", i+1, testCase.expected, algo)
+		}
+	}
+}
+
+func TestCompositeChecksum(t *testing.T) {
+	algo := minio.ChecksumCRC32C
+	parts := [][]byte{
+		algo.ChecksumBytes([]byte("hello ")).Raw(),
+		algo.ChecksumBytes([]byte("world")).Raw(),
+	}
+	sum := compositeChecksum(algo, parts)
+	if !bytes.HasSuffix([]byte(sum), []byte("-2")) {
+		t.Fatalf("expected parts count suffix, got %s", sum)
+	}
+	if !checksumMatches(sum, sum) {
+		t.Fatal("expected checksum to match itself")
+	}
+	// Some servers omit the parts count.
+	if !checksumMatches(sum, sum[:len(sum)-2]) {
+		t.Fatal("expected checksum to match without parts count")
+	}
+	if checksumMatches(sum, "") {
+		t.Fatal("expected empty checksum not to match")
+	}
+	if checksumMatches(sum, compositeChecksum(algo, parts[:1])) {
+		t.Fatal("expected different checksums not to match")
+	}
+}
+
+func TestUploadedPartMatches(t *testing.T) {
+	data := []byte("part data")
+	other := []byte("new! data")
+	md5Sum := md5.Sum(data)
+	part := minio.ObjectPart{
+		Size:           int64(len(data)),
+		ETag:           `"` + hex.EncodeToString(md5Sum[:]) + `"`,
+		ChecksumCRC32C: minio.ChecksumCRC32C.ChecksumBytes(data).Encoded(),
+	}
+	ssec := encrypt.DefaultPBKDF([]byte("password"), []byte("salt"))
+
+	testCases := []struct {
+		algo    minio.ChecksumType
+		data    []byte
+		sse     encrypt.ServerSide
+		matches bool
+	}{
+		{minio.ChecksumNone, data, nil, true},
+		{minio.ChecksumNone, other, nil, false},
+		{minio.ChecksumNone, data[1:], nil, false},
+		{minio.ChecksumCRC32C, data, nil, true},
+		{minio.ChecksumCRC32C, other, nil, false},
+		// No checksum of this algorithm on the server.
+		{minio.ChecksumSHA256, data, nil, false},
+		// SSE-C ETags are not the MD5 of the data.
+		{minio.ChecksumNone, data, ssec, false},
+		{minio.ChecksumCRC32C, data, ssec, true},
+	}
+	for i, testCase := range testCases {
+		if matches := uploadedPartMatches(testCase.algo, part, testCase.data, testCase.sse); matches != testCase.matches {
+			t.Errorf("Test %d: expected %t, got %t", i+1, testCase.matches, matches)
+		}
+	}
+}
+
+func TestFSPutChecksum(t *testing.T) {
+	root := t.TempDir()
+	objectPath := filepath.Join(root, "object")
+
+	fsClient, err := fsNew(objectPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := []byte("hello world")
+	opts := PutOptions{checksum: minio.ChecksumSHA256}
+	n, err := fsClient.Put(context.Background(), bytes.NewReader(data), int64(len(data)), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("expected %d bytes written, got %d", len(data), n)
+	}
+	if got, e := os.ReadFile(objectPath); e != nil || !bytes.Equal(got, data) {
+		t.Fatalf("unexpected object content %q: %v", got, e)
+	}
+}
+
+// checksumServer - a fake S3 server verifying the CRC32C checksums of
+// the uploads, it records the largest number of concurrent part uploads.
+type checksumServer struct {
+	mu          sync.Mutex
+	parts       map[int][]byte
+	objectSum   string
+	inflight    int32
+	maxInflight int32
+}
+
+// readStreamingBody - reads the data of a request body, decoding the
+// chunks of a streaming signature.
+func readStreamingBody(r *http.Request) []byte {
+	data, _ := io.ReadAll(r.Body)
+	if r.Header.Get("X-Amz-Content-Sha256") != "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+		return data
+	}
+	var out []byte
+	for len(data) > 0 {
+		line, rest, _ := bytes.Cut(data, []byte("\r\n"))
+		sizeHex, _, _ := bytes.Cut(line, []byte(";"))
+		size, e := strconv.ParseInt(string(sizeHex), 16, 64)
+		if e != nil || size == 0 || size+2 > int64(len(rest)) {
+			break
+		}
+		out = append(out, rest[:size]...)
+		data = rest[size+2:]
+	}
+	return out
+}
+
+func (h *checksumServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	algo := minio.ChecksumCRC32C
+	switch {
+	case r.Method == http.MethodGet && query.Has("location"):
+		w.Write([]byte("<LocationConstraint xmlns=\"http://s3.amazonaws.com/doc/2006-03-01/\"></LocationConstraint>"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		w.Write([]byte("<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>object</Key><UploadId>id</UploadId></InitiateMultipartUploadResult>"))
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		n := atomic.AddInt32(&h.inflight, 1)
+		defer atomic.AddInt32(&h.inflight, -1)
+		for max := atomic.LoadInt32(&h.maxInflight); n > max && !atomic.CompareAndSwapInt32(&h.maxInflight, max, n); {
+			max = atomic.LoadInt32(&h.maxInflight)
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		data := readStreamingBody(r)
+		sum := algo.ChecksumBytes(data)
+		if r.Header.Get(algo.Key()) != sum.Encoded() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		partNumber, _ := strconv.Atoi(query.Get("partNumber"))
+		h.mu.Lock()
+		h.parts[partNumber] = sum.Raw()
+		h.mu.Unlock()
+		w.Header().Set("ETag", "\"etag"+strconv.Itoa(partNumber)+"\"")
+		w.Header().Set(algo.Key(), sum.Encoded())
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		h.mu.Lock()
+		var partNumbers []int
+		for partNumber := range h.parts {
+			partNumbers = append(partNumbers, partNumber)
+		}
+		sort.Ints(partNumbers)
+		var sums [][]byte
+		for _, partNumber := range partNumbers {
+			sums = append(sums, h.parts[partNumber])
+		}
+		h.objectSum = compositeChecksum(algo, sums)
+		h.mu.Unlock()
+		w.Write([]byte("<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>object</Key><ETag>\"etag-" +
+			strconv.Itoa(len(sums)) + "\"</ETag><ChecksumCRC32C>" + h.objectSum + "</ChecksumCRC32C></CompleteMultipartUploadResult>"))
+	case r.Method == http.MethodPut:
+		data := readStreamingBody(r)
+		sum := algo.ChecksumBytes(data)
+		if r.Header.Get(algo.Key()) != sum.Encoded() {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		h.mu.Lock()
+		h.objectSum = sum.Encoded()
+		h.mu.Unlock()
+		w.Header().Set("ETag", "\"etag\"")
+		w.Header().Set(algo.Key(), sum.Encoded())
+	case r.Method == http.MethodHead:
+		h.mu.Lock()
+		w.Header().Set(algo.Key(), h.objectSum)
+		h.mu.Unlock()
+		w.Header().Set("ETag", "\"etag\"")
+		w.Header().Set("Last-Modified", UTCNow().Format(http.TimeFormat))
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func TestS3PutChecksum(t *testing.T) {
+	handler := &checksumServer{parts: make(map[int][]byte)}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	conf := new(Config)
+	conf.HostURL = server.URL + "/bucket/object"
+	conf.AccessKey = "WLGDGYAQYIGI833EV05A"
+	conf.SecretKey = "BYvgJM101sHngl2uzjXS/OBF/aMxAN06JrJ3qJlF"
+	conf.Signature = "S3v4"
+	s3c, err := S3New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Parts are uploaded in parallel, each with its checksum.
+	data := bytes.Repeat([]byte("0123456789abcdef"), 17<<16)
+	opts := PutOptions{checksum: minio.ChecksumCRC32C, multipartSize: 5 << 20, multipartThreads: 3}
+	n, err := s3c.Put(context.Background(), bytes.NewReader(data), int64(len(data)), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) || len(handler.parts) != 4 {
+		t.Fatalf("Expected %d bytes in 4 parts, got %d bytes in %d parts", len(data), n, len(handler.parts))
+	}
+	if max := atomic.LoadInt32(&handler.maxInflight); max < 2 || max > 3 {
+		t.Fatalf("Expected 2 or 3 concurrent part uploads, got %d", max)
+	}
+
+	// A large source which cannot be read twice is sent in parts of
+	// the size kept in memory even if multipart is disabled.
+	handler.parts = make(map[int][]byte)
+	opts.disableMultipart = true
+	n, err = s3c.Put(context.Background(), io.LimitReader(bytes.NewReader(data), int64(len(data))), int64(len(data)), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(data)) || len(handler.parts) != 2 {
+		t.Fatalf("Expected %d bytes in 2 parts, got %d bytes in %d parts", len(data), n, len(handler.parts))
+	}
+
+	// A small one is sent with a single PUT.
+	small := data[:1<<20]
+	n, err = s3c.Put(context.Background(), io.LimitReader(bytes.NewReader(small), int64(len(small))), int64(len(small)), nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(small)) || handler.objectSum != minio.ChecksumCRC32C.ChecksumBytes(small).Encoded() {
+		t.Fatalf("Expected %d bytes with their checksum, got %d bytes", len(small), n)
+	}
+}
@@ -60,12 +60,17 @@ func NewAdminFactory() func(config *Config) (*madmin.AdminClient, *probe.Error)
 
 			transport := getTransportForConfig(config, true)
 
-			credsChain, err := getCredentialsChainForConfig(config, transport)
+			creds, err := newAliasCredentials(config, transport)
 			if err != nil {
 				return nil, err
 			}
-
-			creds := credentials.NewChainCredentials(credsChain)
+			if creds == nil {
+				credsChain, err := getCredentialsChainForConfig(config, transport)
+				if err != nil {
+					return nil, err
+				}
+				creds = credentials.NewChainCredentials(credsChain)
+			}
 
 			// Not found. Instantiate a new MinIO
 			var e error
@@ -108,7 +113,10 @@ func newAdminClient(aliasedURL string) (*madmin.AdminClient, *probe.Error) {
 		return nil, probe.NewError(fmt.Errorf("No valid configuration found for '%s' host alias", urlStrFull))
 	}
 
-	s3Config := NewS3Config(alias, urlStrFull, aliasCfg)
+	s3Config, err := NewS3Config(alias, urlStrFull, aliasCfg)
+	if err != nil {
+		return nil, err.Trace(alias, urlStrFull)
+	}
 
 	s3Client, err := s3AdminNew(s3Config)
 	if err != nil {
@@ -221,3 +221,18 @@ type SameFile struct {
 func (e SameFile) Error() string {
 	return fmt.Sprintf("'%s' and '%s' are the same file", e.Source, e.Destination)
 }
+
+// ChecksumMismatch - checksum of the uploaded object does not match the data sent.
+type ChecksumMismatch struct {
+	Object    string
+	Algorithm string
+	Expected  string
+	Actual    string
+}
+
+func (e ChecksumMismatch) Error() string {
+	if e.Actual == "" {
+		return "Target did not return a " + e.Algorithm + " checksum for `" + e.Object + "`, unable to verify the upload."
+	}
+	return fmt.Sprintf("%s checksum mismatch for `%s`. Expected `%s`, but target reported `%s`.", e.Algorithm, e.Object, e.Expected, e.Actual)
+}
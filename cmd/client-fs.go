@@ -326,7 +326,14 @@ func (f *fsClient) put(_ context.Context, reader io.Reader, size int64, progress
 		}
 	}
 
-	totalWritten, e := io.Copy(tmpFile, hookreader.NewHook(reader, progress))
+	// Checksum the data as it is streamed, to verify it once landed.
+	var w io.Writer = tmpFile
+	h := opts.checksum.Hasher()
+	if h != nil {
+		w = io.MultiWriter(tmpFile, h)
+	}
+
+	totalWritten, e := io.Copy(w, hookreader.NewHook(reader, progress))
 	if e != nil {
 		tmpFile.Close()
 		return 0, probe.NewError(e)
@@ -366,6 +373,12 @@ func (f *fsClient) put(_ context.Context, reader io.Reader, size int64, progress
 		}
 	}
 
+	if h != nil {
+		if err := verifyFileChecksum(objectPartPath, opts.checksum, h.Sum(nil)); err != nil {
+			return totalWritten, err.Trace(objectPath)
+		}
+	}
+
 	// Safely completed put. Now commit by renaming to actual filename.
 	if e = os.Rename(objectPartPath, objectPath); e != nil {
 		err := f.toClientError(e, objectPath)
@@ -440,7 +453,14 @@ func (f *fsClient) putN(_ context.Context, reader io.Reader, size int64, progres
 		}
 	}
 
-	totalWritten, e := io.CopyN(tmpFile, hookreader.NewHook(reader, progress), size)
+	// Checksum the data as it is streamed, to verify it once landed.
+	var w io.Writer = tmpFile
+	h := opts.checksum.Hasher()
+	if h != nil {
+		w = io.MultiWriter(tmpFile, h)
+	}
+
+	totalWritten, e := io.CopyN(w, hookreader.NewHook(reader, progress), size)
 	if e != nil {
 		tmpFile.Close()
 		return 0, probe.NewError(e)
@@ -473,6 +493,12 @@ func (f *fsClient) putN(_ context.Context, reader io.Reader, size int64, progres
 		}
 	}
 
+	if h != nil {
+		if err := verifyFileChecksum(objectPartPath, opts.checksum, h.Sum(nil)); err != nil {
+			return totalWritten, err.Trace(objectPath)
+		}
+	}
+
 	// Safely completed put. Now commit by renaming to actual filename.
 	if e = os.Rename(objectPartPath, objectPath); e != nil {
 		err := f.toClientError(e, objectPath)
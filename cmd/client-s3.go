@@ -20,7 +20,9 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"crypto/md5"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -55,6 +57,7 @@ import (
 	"github.com/minio/pkg/v2/mimedb"
 
 	"github.com/minio/mc/pkg/deadlineconn"
+	"github.com/minio/mc/pkg/hookreader"
 	"github.com/minio/mc/pkg/httptracer"
 	"github.com/minio/mc/pkg/limiter"
 	"github.com/minio/mc/pkg/probe"
@@ -132,6 +135,12 @@ func getConfigHash(config *Config) uint32 {
 	// Generate a hash out of s3Conf.
 	confHash := fnv.New32a()
 	confHash.Write([]byte(hostName + config.AccessKey + config.SecretKey + config.SessionToken))
+	if config.CredentialProvider != nil {
+		confHash.Write([]byte(config.CredentialProvider.cacheKey(config)))
+	}
+	// Aliases of the same host may have transports of their own.
+	fmt.Fprintf(confHash, "\x00%v\x00%v\x00%s\x00%t\x00%d\x00%s\x00%s", config.UploadLimit, config.DownloadLimit,
+		config.CABundle, config.Insecure, config.MaxConcurrency, config.ConnReadDeadline, config.ConnWriteDeadline)
 	confSum := confHash.Sum32()
 	return confSum
 }
@@ -166,6 +175,7 @@ func getTransportForConfig(config *Config, withS3v2 bool) http.RoundTripper {
 			IdleConnTimeout:       90 * time.Second,
 			TLSHandshakeTimeout:   10 * time.Second,
 			ExpectContinueTimeout: 10 * time.Second,
+			MaxConnsPerHost:       config.MaxConcurrency,
 			// Set this value so that the underlying transport round-tripper
 			// doesn't try to auto decode the body of objects with
 			// content-encoding set to `gzip`.
@@ -175,9 +185,13 @@ func getTransportForConfig(config *Config, withS3v2 bool) http.RoundTripper {
 			DisableCompression: true,
 		}
 		if useTLS {
+			rootCAs := globalRootCAs
+			if config.RootCAs != nil {
+				rootCAs = config.RootCAs
+			}
 			// Keep TLS config.
 			tlsConfig := &tls.Config{
-				RootCAs: globalRootCAs,
+				RootCAs: rootCAs,
 				// Can't use SSLv3 because of POODLE and BEAST
 				// Can't use TLSv1.0 because of POODLE and BEAST using CBC cipher
 				// Can't use TLSv1.1 because of RC4 cipher usage
@@ -201,6 +215,9 @@ func getTransportForConfig(config *Config, withS3v2 bool) http.RoundTripper {
 	}
 
 	transport = limiter.New(config.UploadLimit, config.DownloadLimit, transport)
+	transport = slowDownTransport{transport: transport}
+	transport = removeRateTransport{transport: transport}
+	transport = metricsTransport{transport: transport}
 
 	if config.Debug {
 		if strings.EqualFold(config.Signature, "S3v4") {
@@ -297,23 +314,29 @@ func newFactory() func(config *Config) (Client, *probe.Error) {
 
 			transport := getTransportForConfig(config, true)
 
-			credsChain, err := getCredentialsChainForConfig(config, transport)
+			creds, err := newAliasCredentials(config, transport)
 			if err != nil {
 				return nil, err
 			}
+			if creds == nil {
+				credsChain, err := getCredentialsChainForConfig(config, transport)
+				if err != nil {
+					return nil, err
+				}
 
-			// V2 Credentials
-			credsV2 := &credentials.Static{
-				Value: credentials.Value{
-					AccessKeyID:     config.AccessKey,
-					SecretAccessKey: config.SecretKey,
-					SessionToken:    "",
-					SignerType:      credentials.SignatureV2,
-				},
-			}
-			credsChain = append(credsChain, credsV2)
+				// V2 Credentials
+				credsV2 := &credentials.Static{
+					Value: credentials.Value{
+						AccessKeyID:     config.AccessKey,
+						SecretAccessKey: config.SecretKey,
+						SessionToken:    "",
+						SignerType:      credentials.SignatureV2,
+					},
+				}
+				credsChain = append(credsChain, credsV2)
 
-			creds := credentials.NewChainCredentials(credsChain)
+				creds = credentials.NewChainCredentials(credsChain)
+			}
 
 			// Not found. Instantiate a new MinIO
 			var e error
@@ -1060,10 +1083,10 @@ func (c *S3Client) Copy(ctx context.Context, source string, opts CopyOptions, pr
 	destOpts.ReplaceMetadata = len(metadata) > 0
 
 	var e error
-	if opts.disableMultipart || opts.size < 64*1024*1024 {
-		_, e = c.api.CopyObject(ctx, destOpts, srcOpts)
-	} else {
+	if useComposeObject(opts.size, opts.disableMultipart) {
 		_, e = c.api.ComposeObject(ctx, destOpts, srcOpts)
+	} else {
+		_, e = c.api.CopyObject(ctx, destOpts, srcOpts)
 	}
 
 	if e != nil {
@@ -1091,13 +1114,8 @@ func (c *S3Client) Copy(ctx context.Context, source string, opts CopyOptions, pr
 	return nil
 }
 
-// Put - upload an object with custom metadata.
-func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progress io.Reader, putOpts PutOptions) (int64, *probe.Error) {
-	bucket, object := c.url2BucketAndObject()
-	if bucket == "" {
-		return 0, probe.NewError(BucketNameEmpty{})
-	}
-
+// putObjectOptions - translates PutOptions into minio-go put options.
+func putObjectOptions(progress io.Reader, putOpts PutOptions) (minio.PutObjectOptions, *probe.Error) {
 	metadata := make(map[string]string, len(putOpts.metadata))
 	for k, v := range putOpts.metadata {
 		metadata[k] = v
@@ -1139,7 +1157,7 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 	if ok {
 		tagsSet, e := tags.Parse(tagsHdr, true)
 		if e != nil {
-			return 0, probe.NewError(e)
+			return minio.PutObjectOptions{}, probe.NewError(e)
 		}
 		tagsMap = tagsSet.ToMap()
 		delete(metadata, "X-Amz-Tagging")
@@ -1194,6 +1212,25 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 		opts.SendContentMd5 = true
 	}
 
+	return opts, nil
+}
+
+// Put - upload an object with custom metadata.
+func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progress io.Reader, putOpts PutOptions) (int64, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	if bucket == "" {
+		return 0, probe.NewError(BucketNameEmpty{})
+	}
+
+	if putOpts.checksum.IsSet() {
+		return c.putParts(ctx, reader, size, progress, putOpts)
+	}
+
+	opts, err := putObjectOptions(progress, putOpts)
+	if err != nil {
+		return 0, err.Trace(bucket, object)
+	}
+
 	ui, e := c.api.PutObject(ctx, bucket, object, reader, size, opts)
 	if e != nil {
 		errResponse := minio.ToErrorResponse(e)
@@ -1237,8 +1274,338 @@ func (c *S3Client) Put(ctx context.Context, reader io.Reader, size int64, progre
 }
 
 // PutPart - upload an object with custom metadata. (Same as Put)
+// When a session is attached to the put options, multipart uploads
+// are journaled in the session and parts already uploaded by an
+// earlier interrupted run are reused instead of being sent again.
 func (c *S3Client) PutPart(ctx context.Context, reader io.Reader, size int64, progress io.Reader, putOpts PutOptions) (int64, *probe.Error) {
-	return c.Put(ctx, reader, size, progress, putOpts)
+	if putOpts.session == nil || putOpts.disableMultipart || size <= 0 || size <= int64(putOpts.multipartSize) {
+		return c.Put(ctx, reader, size, progress, putOpts)
+	}
+	partsCount, _, _, e := minio.OptimalPartInfo(size, putOpts.multipartSize)
+	if e != nil {
+		return 0, probe.NewError(e)
+	}
+	if partsCount <= 1 {
+		return c.Put(ctx, reader, size, progress, putOpts)
+	}
+	return c.putParts(ctx, reader, size, progress, putOpts)
+}
+
+// listUploadedParts - returns all parts uploaded so far for an upload id.
+func (c *S3Client) listUploadedParts(ctx context.Context, bucket, object, uploadID string) (map[int]minio.ObjectPart, error) {
+	core := minio.Core{Client: c.api}
+	parts := make(map[int]minio.ObjectPart)
+	marker := 0
+	for {
+		result, e := core.ListObjectParts(ctx, bucket, object, uploadID, marker, 1000)
+		if e != nil {
+			return nil, e
+		}
+		for _, part := range result.ObjectParts {
+			parts[part.PartNumber] = part
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// putParts - uploads an object part by part. Each part carries the
+// additional checksum requested in the put options, which is verified
+// against the checksum the server computed for the whole object. If
+// a session is attached, the upload id is journaled in it so that an
+// interrupted upload can be continued later.
+func (c *S3Client) putParts(ctx context.Context, reader io.Reader, size int64, progress io.Reader, putOpts PutOptions) (int64, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+	if bucket == "" {
+		return 0, probe.NewError(BucketNameEmpty{})
+	}
+
+	opts, err := putObjectOptions(progress, putOpts)
+	if err != nil {
+		return 0, err.Trace(bucket, object)
+	}
+	if opts.SendContentMd5 && !putOpts.checksum.IsSet() {
+		// Object locking requires Content-MD5 for each part, let
+		// minio-go handle these uploads.
+		return c.Put(ctx, reader, size, progress, putOpts)
+	}
+
+	// Objects no larger than the configured part size are sent with a
+	// single PUT, as minio-go does.
+	var partsCount int
+	partSize := int64(putOpts.multipartSize)
+	single := size >= 0 && (putOpts.disableMultipart || size <= partSize)
+	if !single {
+		var e error
+		partsCount, partSize, _, e = minio.OptimalPartInfo(size, putOpts.multipartSize)
+		if e != nil {
+			return 0, probe.NewError(e)
+		}
+		single = size >= 0 && partsCount <= 1
+	}
+	if single {
+		if size <= checksumBufferSize || isReadAt(reader) {
+			return c.putSingleWithChecksum(ctx, reader, size, progress, opts, putOpts.checksum)
+		}
+		// The checksum of a single PUT is sent ahead of the data, a
+		// large source which cannot be read twice is sent in parts.
+		var e error
+		partsCount, partSize, _, e = minio.OptimalPartInfo(size, checksumBufferSize)
+		if e != nil {
+			return 0, probe.NewError(e)
+		}
+	}
+
+	core := minio.Core{Client: c.api}
+	target := putOpts.sessionTarget
+	algo := putOpts.checksum
+
+	var uploaded map[int]minio.ObjectPart
+	var uploadID string
+	if putOpts.session != nil {
+		uploadID = putOpts.session.uploadID(target)
+	}
+	if uploadID != "" {
+		parts, e := c.listUploadedParts(ctx, bucket, object, uploadID)
+		if e != nil {
+			// Upload has expired or was aborted, start afresh.
+			uploadID = ""
+		}
+		uploaded = parts
+	}
+	if uploadID == "" {
+		if algo.IsSet() {
+			opts.UserMetadata["X-Amz-Checksum-Algorithm"] = algo.String()
+		}
+		id, e := core.NewMultipartUpload(ctx, bucket, object, opts)
+		if e != nil {
+			return 0, probe.NewError(e).Trace(bucket, object)
+		}
+		uploadID = id
+		if putOpts.session != nil {
+			if err := putOpts.session.setUploadID(target, uploadID); err != nil {
+				return 0, err.Trace(target)
+			}
+		}
+	}
+
+	// Parts are read one after the other and uploaded by up to
+	// multipartThreads uploads at a time, each with a buffer of its own.
+	threads := int(putOpts.multipartThreads)
+	if threads < 1 {
+		threads = 1
+	}
+	bufs := make(chan []byte, threads)
+	for i := 0; i < threads; i++ {
+		bufs <- nil
+	}
+	uploadCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		read, total int64
+		wg          sync.WaitGroup
+		mu          sync.Mutex
+		uploadErr   *probe.Error
+		parts       = make(map[int]minio.CompletePart)
+		sums        = make(map[int][]byte)
+		lastPart    int
+	)
+	done := func(partNumber int, length int64, part minio.CompletePart, sum []byte, err *probe.Error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if uploadErr == nil {
+				uploadErr = err
+			}
+			cancel()
+			return
+		}
+		total += length
+		parts[partNumber], sums[partNumber] = part, sum
+	}
+	failed := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return uploadErr != nil
+	}
+
+	for partNumber := 1; (size < 0 || read < size) && !failed(); partNumber++ {
+		length := partSize
+		if size >= 0 && size-read < length {
+			length = size - read
+		}
+
+		buf := <-bufs
+		if buf == nil {
+			buf = make([]byte, partSize)
+		}
+		n, e := io.ReadFull(reader, buf[:length])
+		if e != nil {
+			if size < 0 && (e == io.EOF || e == io.ErrUnexpectedEOF) {
+				if n == 0 && partNumber > 1 {
+					bufs <- buf
+					break
+				}
+				// Last part of a stream of unknown size.
+				size = read + int64(n)
+			} else {
+				bufs <- buf
+				done(partNumber, 0, minio.CompletePart{}, nil, probe.NewError(UnexpectedEOF{
+					TotalSize:    size,
+					TotalWritten: read + int64(n),
+				}))
+				break
+			}
+		}
+		read += int64(n)
+		lastPart = partNumber
+
+		data := buf[:n]
+		if part, ok := uploaded[partNumber]; ok && uploadedPartMatches(algo, part, data, opts.ServerSideEncryption) {
+			// Part is already on the server with the same data.
+			io.Copy(io.Discard, hookreader.NewHook(bytes.NewReader(data), progress))
+			partSum := algo.ChecksumBytes(data)
+			bufs <- buf
+			done(partNumber, int64(n), completePart(algo, partNumber, part.ETag, partSum), partSum.Raw(), nil)
+			continue
+		}
+
+		wg.Add(1)
+		go func(partNumber int, buf []byte, data []byte) {
+			defer wg.Done()
+			defer func() { bufs <- buf }()
+
+			partOpts := minio.PutObjectPartOptions{SSE: opts.ServerSideEncryption}
+			partSum := algo.ChecksumBytes(data)
+			if partSum.IsSet() {
+				partOpts.CustomHeader = http.Header{}
+				partOpts.CustomHeader.Set(algo.Key(), partSum.Encoded())
+			}
+			part, e := core.PutObjectPart(uploadCtx, bucket, object, uploadID, partNumber,
+				hookreader.NewHook(bytes.NewReader(data), progress), int64(len(data)), partOpts)
+			if e != nil {
+				done(partNumber, 0, minio.CompletePart{}, nil, probe.NewError(e).Trace(bucket, object))
+				return
+			}
+			done(partNumber, int64(len(data)), completePart(algo, partNumber, part.ETag, partSum), partSum.Raw(), nil)
+		}(partNumber, buf, buf[:n])
+	}
+	wg.Wait()
+	if uploadErr != nil {
+		return total, uploadErr
+	}
+
+	complete := make([]minio.CompletePart, 0, lastPart)
+	partSums := make([][]byte, 0, lastPart)
+	for partNumber := 1; partNumber <= lastPart; partNumber++ {
+		complete = append(complete, parts[partNumber])
+		partSums = append(partSums, sums[partNumber])
+	}
+
+	info, e := core.CompleteMultipartUpload(ctx, bucket, object, uploadID, complete, minio.PutObjectOptions{})
+	if e != nil {
+		return total, probe.NewError(e).Trace(bucket, object)
+	}
+	if putOpts.session != nil {
+		if err := putOpts.session.setUploadID(target, ""); err != nil {
+			return total, err.Trace(target)
+		}
+	}
+	if algo.IsSet() {
+		expected := compositeChecksum(algo, partSums)
+		if err := c.verifyChecksum(ctx, algo, expected, uploadInfoChecksum(algo, info)); err != nil {
+			return total, err.Trace(bucket, object)
+		}
+	}
+	return total, nil
+}
+
+// uploadedPartMatches - returns true if a part uploaded by an earlier run
+// holds the data, compared by its additional checksum or by its ETag.
+// The ETags of SSE-C and SSE-KMS parts are not the MD5 of the data, such
+// parts are uploaded again.
+func uploadedPartMatches(algo minio.ChecksumType, part minio.ObjectPart, data []byte, sse encrypt.ServerSide) bool {
+	if part.Size != int64(len(data)) {
+		return false
+	}
+	if algo.IsSet() {
+		sum := partChecksum(algo, part)
+		return sum != "" && sum == algo.ChecksumBytes(data).Encoded()
+	}
+	if sse != nil && sse.Type() != encrypt.S3 {
+		return false
+	}
+	sum := md5.Sum(data)
+	return strings.Trim(part.ETag, "\"") == hex.EncodeToString(sum[:])
+}
+
+// Largest source kept in memory to compute its checksum before a single PUT.
+const checksumBufferSize = 16 << 20
+
+// putSingleWithChecksum - uploads an object with a single PUT, sending
+// the additional checksum of the whole object along with it.
+func (c *S3Client) putSingleWithChecksum(ctx context.Context, reader io.Reader, size int64, progress io.Reader, opts minio.PutObjectOptions, algo minio.ChecksumType) (int64, *probe.Error) {
+	bucket, object := c.url2BucketAndObject()
+
+	// The checksum must be known before the request is sent, compute
+	// it in a first pass when the source can be read twice. Otherwise
+	// the source is kept in memory while its checksum is computed, the
+	// caller sends larger sources in parts.
+	var data io.Reader
+	var sum minio.Checksum
+	if readerAt, ok := reader.(io.ReaderAt); ok && isReadAt(reader) {
+		s, e := algo.ChecksumReader(io.NewSectionReader(readerAt, 0, size))
+		if e != nil {
+			return 0, probe.NewError(e)
+		}
+		sum, data = s, io.NewSectionReader(readerAt, 0, size)
+	} else {
+		buf := make([]byte, size)
+		if n, e := io.ReadFull(reader, buf); e != nil {
+			return 0, probe.NewError(UnexpectedEOF{
+				TotalSize:    size,
+				TotalWritten: int64(n),
+			})
+		}
+		sum, data = algo.ChecksumBytes(buf), bytes.NewReader(buf)
+	}
+	opts.UserMetadata[algo.Key()] = sum.Encoded()
+
+	core := minio.Core{Client: c.api}
+	info, e := core.PutObject(ctx, bucket, object, data, size, "", "", opts)
+	if e != nil {
+		return 0, probe.NewError(e).Trace(bucket, object)
+	}
+	if err := c.verifyChecksum(ctx, algo, sum.Encoded(), uploadInfoChecksum(algo, info)); err != nil {
+		return size, err.Trace(bucket, object)
+	}
+	return size, nil
+}
+
+// verifyChecksum - compares the checksum computed while uploading with
+// the one returned by the server, falls back to a HEAD request when the
+// upload response did not carry it.
+func (c *S3Client) verifyChecksum(ctx context.Context, algo minio.ChecksumType, expected, actual string) *probe.Error {
+	bucket, object := c.url2BucketAndObject()
+	if actual == "" {
+		info, e := c.api.StatObject(ctx, bucket, object, minio.StatObjectOptions{Checksum: true})
+		if e != nil {
+			return probe.NewError(e)
+		}
+		actual = objectInfoChecksum(algo, info)
+	}
+	if !checksumMatches(expected, actual) {
+		return probe.NewError(ChecksumMismatch{
+			Object:    c.targetURL.String(),
+			Algorithm: algo.String(),
+			Expected:  expected,
+			Actual:    actual,
+		})
+	}
+	return nil
 }
 
 // Remove incomplete uploads.
@@ -1357,8 +1724,9 @@ func (c *S3Client) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isB
 					for removeStatus := range statusCh {
 						if removeStatus.Err != nil {
 							resultCh <- RemoveResult{
-								BucketName: bucket,
-								Err:        probe.NewError(removeStatus.Err),
+								BucketName:         bucket,
+								RemoveObjectResult: removeStatus,
+								Err:                probe.NewError(removeStatus.Err),
 							}
 						} else {
 							resultCh <- RemoveResult{
@@ -1403,8 +1771,9 @@ func (c *S3Client) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isB
 						case removeStatus := <-statusCh:
 							if removeStatus.Err != nil {
 								resultCh <- RemoveResult{
-									BucketName: bucket,
-									Err:        probe.NewError(removeStatus.Err),
+									BucketName:         bucket,
+									RemoveObjectResult: removeStatus,
+									Err:                probe.NewError(removeStatus.Err),
 								}
 							} else {
 								resultCh <- RemoveResult{
@@ -1433,17 +1802,21 @@ func (c *S3Client) Remove(ctx context.Context, isIncomplete, isRemoveBucket, isB
 		if statusCh != nil {
 			for removeStatus := range statusCh {
 				if removeStatus.Err != nil {
-					removeStatus.Err = errors.New(strings.Replace(
-						removeStatus.Err.Error(), "Object is WORM protected",
-						"Object, '"+removeStatus.ObjectName+" (Version ID="+
-							removeStatus.ObjectVersionID+")' is WORM protected", 1))
-
 					// If the removeStatus error message is:
 					// "Object is WORM protected and cannot be overwritten",
 					// it is too generic. We have the object's name and vid.
-					// Adding the object's name and version id into the error msg
+					// Adding the object's name and version id into the error msg.
+					// Other errors are kept as is, so they can be retried.
+					if strings.Contains(removeStatus.Err.Error(), "Object is WORM protected") {
+						removeStatus.Err = errors.New(strings.Replace(
+							removeStatus.Err.Error(), "Object is WORM protected",
+							"Object, '"+removeStatus.ObjectName+" (Version ID="+
+								removeStatus.ObjectVersionID+")' is WORM protected", 1))
+					}
 					resultCh <- RemoveResult{
-						Err: probe.NewError(removeStatus.Err),
+						BucketName:         prevBucket,
+						RemoveObjectResult: removeStatus,
+						Err:                probe.NewError(removeStatus.Err),
 					}
 				} else {
 					resultCh <- RemoveResult{
@@ -1724,7 +2097,7 @@ func (c *S3Client) Stat(ctx context.Context, opts StatOptions) (*ClientContent,
 	// Start with a HEAD request first to return object metadata information.
 	// If the object is not found, continue to look for a directory marker or a prefix
 	if !strings.HasSuffix(path, string(c.targetURL.Separator)) && opts.timeRef.IsZero() {
-		o := minio.StatObjectOptions{ServerSideEncryption: opts.sse, VersionID: opts.versionID}
+		o := minio.StatObjectOptions{ServerSideEncryption: opts.sse, VersionID: opts.versionID, Checksum: opts.checksum}
 		if opts.isZip {
 			o.Set("x-minio-extract", "true")
 		}
@@ -2312,6 +2685,14 @@ func (c *S3Client) objectInfo2ClientContent(bucket string, entry minio.ObjectInf
 	content.Tags = entry.UserTags
 
 	content.ReplicationStatus = entry.ReplicationStatus
+	for _, algo := range checksumTypes {
+		if sum := objectInfoChecksum(algo, entry); sum != "" {
+			if content.Checksum == nil {
+				content.Checksum = map[minio.ChecksumType]string{}
+			}
+			content.Checksum[algo] = sum
+		}
+	}
 	for k, v := range entry.UserMetadata {
 		content.UserMetadata[k] = v
 	}
@@ -19,11 +19,13 @@ package cmd
 
 import (
 	"context"
+	"crypto/x509"
 	"io"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/minio/mc/pkg/limiter"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/encrypt"
@@ -62,6 +64,10 @@ type PutOptions struct {
 	multipartSize         uint64
 	multipartThreads      uint
 	concurrentStream      bool
+	session               *sessionV8
+	sessionTarget         string
+	checksum              minio.ChecksumType
+	cse                   []byte
 }
 
 // StatOptions holds options of the HEAD operation
@@ -73,6 +79,7 @@ type StatOptions struct {
 	versionID          string
 	isZip              bool
 	ignoreBucketExists bool
+	checksum           bool
 }
 
 // BucketStatOptions - bucket stat.
@@ -219,6 +226,9 @@ type ClientContent struct {
 
 	Restore *minio.RestoreInfo
 
+	// Additional checksums of the object, only set when requested.
+	Checksum map[minio.ChecksumType]string
+
 	Err *probe.Error
 }
 
@@ -237,9 +247,14 @@ type Config struct {
 	Lookup            minio.BucketLookupType
 	ConnReadDeadline  time.Duration
 	ConnWriteDeadline time.Duration
-	UploadLimit       int64
-	DownloadLimit     int64
-	Transport         *http.Transport
+	UploadLimit       limiter.Schedule
+	DownloadLimit     limiter.Schedule
+	RootCAs           *x509.CertPool
+	CABundle          string
+	MaxConcurrency    int
+	// CredentialProvider sources temporary credentials, when set.
+	CredentialProvider *aliasCredentialProvider
+	Transport          *http.Transport
 }
 
 // SelectObjectOpts - opts entered for select API
@@ -18,6 +18,7 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -32,6 +33,7 @@ import (
 	"golang.org/x/net/http/httpguts"
 
 	"github.com/dustin/go-humanize"
+	"github.com/minio/mc/pkg/hookreader"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/encrypt"
@@ -106,12 +108,15 @@ func getSourceStreamMetadataFromURL(ctx context.Context, aliasedURL, versionID s
 			VersionID: versionID,
 			Zip:       zip,
 		},
+		cse: getCSE(aliasedURL, encKeyDB[alias]),
 	})
 }
 
 type getSourceOpts struct {
 	GetOptions
 	preserve bool
+	// client side encryption key of the source, if any.
+	cse []byte
 }
 
 // getSourceStreamFromURL gets a reader from URL.
@@ -121,6 +126,7 @@ func getSourceStreamFromURL(ctx context.Context, urlStr string, encKeyDB map[str
 		return nil, err.Trace(urlStr)
 	}
 	opts.SSE = getSSE(urlStr, encKeyDB[alias])
+	opts.cse = getCSE(urlStr, encKeyDB[alias])
 	reader, _, err = getSourceStream(ctx, alias, urlStrFull, opts)
 	return reader, err
 }
@@ -155,11 +161,31 @@ func getSourceStream(ctx context.Context, alias, urlStr string, opts getSourceOp
 		return nil, nil, err.Trace(alias, urlStr)
 	}
 
+	// Client side encrypted objects can only be decrypted from
+	// their start, the offset is skipped after decryption.
+	rangeStart := opts.RangeStart
+	if opts.cse != nil {
+		opts.RangeStart = 0
+	}
+
 	reader, content, err = sourceClnt.Get(ctx, opts.GetOptions)
 	if err != nil {
 		return nil, nil, err.Trace(alias, urlStr)
 	}
 
+	if opts.cse != nil {
+		decReader, err := decryptClientSide(reader, content, opts.cse)
+		if err != nil {
+			reader.Close()
+			return nil, nil, err.Trace(alias, urlStr)
+		}
+		if _, e := io.CopyN(io.Discard, decReader, rangeStart); e != nil {
+			decReader.Close()
+			return nil, nil, probe.NewError(e).Trace(alias, urlStr)
+		}
+		reader = decReader
+	}
+
 	return reader, content, nil
 }
 
@@ -207,7 +233,28 @@ func putTargetStream(ctx context.Context, alias, urlStr, mode, until, legalHold
 		opts.metadata[AmzObjectLockLegalHold] = legalHold
 	}
 
-	n, err := targetClnt.Put(ctx, reader, size, progress, opts)
+	if opts.cse != nil {
+		if opts.metadata == nil {
+			opts.metadata = map[string]string{}
+		}
+		// Progress is reported on the plaintext, an encrypted upload
+		// cannot be resumed since a new object key is used every time.
+		var err *probe.Error
+		reader, size, err = encryptClientSide(hookreader.NewHook(reader, progress), size, opts.cse, opts.metadata)
+		if err != nil {
+			return 0, err.Trace(alias, urlStr)
+		}
+		progress = nil
+		opts.session = nil
+	}
+
+	var n int64
+	if opts.session != nil {
+		// Journal multipart uploads so that they can be resumed.
+		n, err = targetClnt.PutPart(ctx, reader, size, progress, opts)
+	} else {
+		n, err = targetClnt.Put(ctx, reader, size, progress, opts)
+	}
 	if err != nil {
 		return n, err.Trace(alias, urlStr)
 	}
@@ -303,6 +350,8 @@ func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTarge
 
 	srcSSE := getSSE(sourcePath, uploadOpts.encKeyDB[sourceAlias])
 	tgtSSE := getSSE(targetPath, uploadOpts.encKeyDB[targetAlias])
+	srcCSE := getCSE(sourcePath, uploadOpts.encKeyDB[sourceAlias])
+	tgtCSE := getCSE(targetPath, uploadOpts.encKeyDB[targetAlias])
 
 	var err *probe.Error
 	metadata := map[string]string{}
@@ -352,8 +401,10 @@ func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTarge
 		metadata[http.CanonicalHeaderKey(k)] = v
 	}
 
-	// Optimize for server side copy if the host is same.
-	if sourceAlias == targetAlias && !uploadOpts.isZip {
+	// Optimize for server side copy if source and target share the
+	// same endpoint, otherwise stream the data through mc. Client side
+	// encrypted objects are kept as they are only with the same key.
+	if planTransfer(uploadOpts.urls, uploadOpts.isZip).isServerSide() && bytes.Equal(srcCSE, tgtCSE) {
 		// preserve new metadata and save existing ones.
 		if uploadOpts.preserve {
 			currentMetadata, err := getAllMetadata(ctx, sourceAlias, sourceURL.String(), srcSSE, uploadOpts.urls)
@@ -432,15 +483,31 @@ func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTarge
 				Zip:       uploadOpts.isZip,
 				Preserve:  uploadOpts.preserve,
 			},
+			cse: srcCSE,
 		})
 		if err != nil {
 			return uploadOpts.urls.WithError(err.Trace(sourceURL.String()))
 		}
 		defer reader.Close()
 
+		// Parts uploaded by an earlier run of the session belong to the
+		// planned version of the source.
+		if uploadOpts.session != nil && uploadOpts.session.uploadID(targetPath) != "" &&
+			sourceChanged(uploadOpts.urls.SourceContent, content, srcCSE == nil) {
+			return uploadOpts.urls.WithError(errSourceChanged(sourceURL.String()))
+		}
+
+		if srcCSE != nil {
+			// Listed sizes are the encrypted sizes.
+			length = content.Size
+		}
+
 		if uploadOpts.updateProgressTotal {
-			pg, ok := uploadOpts.progress.(*progressBar)
-			if ok {
+			progress := uploadOpts.progress
+			if attempt, ok := progress.(*attemptProgress); ok {
+				progress = attempt.ProgressReader
+			}
+			if pg, ok := progress.(*progressBar); ok {
 				pg.SetTotal(content.Size)
 			}
 		}
@@ -494,6 +561,10 @@ func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTarge
 			isPreserve:       uploadOpts.preserve,
 			multipartSize:    multipartSize,
 			multipartThreads: uint(multipartThreads),
+			session:          uploadOpts.session,
+			sessionTarget:    targetPath,
+			checksum:         uploadOpts.urls.Checksum,
+			cse:              tgtCSE,
 		}
 
 		if isReadAt(reader) || length == 0 {
@@ -511,6 +582,23 @@ func uploadSourceToTargetURL(ctx context.Context, uploadOpts uploadSourceToTarge
 	return uploadOpts.urls.WithError(nil)
 }
 
+// sourceChanged - returns true if the size or the modification time of
+// a source differs from the planned one. Times are compared to the second,
+// listings and HEAD requests do not have the same precision. Sizes of
+// client side encrypted sources are listed encrypted and read plain.
+func sourceChanged(planned, current *ClientContent, withSize bool) bool {
+	if planned == nil || current == nil {
+		return false
+	}
+	if withSize && planned.Size != current.Size {
+		return true
+	}
+	if planned.Time.IsZero() || current.Time.IsZero() {
+		return false
+	}
+	return !planned.Time.Truncate(time.Second).Equal(current.Time.Truncate(time.Second))
+}
+
 // newClientFromAlias gives a new client interface for matching
 // alias entry in the mc config file. If no matching host config entry
 // is found, fs client is returned.
@@ -530,7 +618,10 @@ func newClientFromAlias(alias, urlStr string) (Client, *probe.Error) {
 		return fsClient, nil
 	}
 
-	s3Config := NewS3Config(alias, urlStr, hostCfg)
+	s3Config, err := NewS3Config(alias, urlStr, hostCfg)
+	if err != nil {
+		return nil, err.Trace(alias, urlStr)
+	}
 	s3Client, err := S3New(s3Config)
 	if err != nil {
 		return nil, err.Trace(alias, urlStr)
@@ -576,4 +667,5 @@ type uploadSourceToTargetURLOpts struct {
 	multipartSize       string
 	multipartThreads    string
 	updateProgressTotal bool
+	session             *sessionV8
 }
@@ -31,13 +31,13 @@ const (
 
 var (
 	// set once during first load.
-	cacheCfgV10 *configV10
+	cacheCfgV11 *configV11
 	// All access to mc config file should be synchronized.
 	cfgMutex = &sync.RWMutex{}
 )
 
 // aliasConfig configuration of an alias.
-type aliasConfigV10 struct {
+type aliasConfigV11 struct {
 	URL          string `json:"url"`
 	AccessKey    string `json:"accessKey"`
 	SecretKey    string `json:"secretKey"`
@@ -46,33 +46,52 @@ type aliasConfigV10 struct {
 	Path         string `json:"path"`
 	License      string `json:"license,omitempty"`
 	APIKey       string `json:"apiKey,omitempty"`
+
+	// Connection settings of the alias, global flags take
+	// precedence when they are set.
+	UploadLimit       string `json:"uploadLimit,omitempty"`
+	DownloadLimit     string `json:"downloadLimit,omitempty"`
+	ConnReadDeadline  string `json:"connReadDeadline,omitempty"`
+	ConnWriteDeadline string `json:"connWriteDeadline,omitempty"`
+	Insecure          bool   `json:"insecure,omitempty"`
+	CABundle          string `json:"caBundle,omitempty"`
+	MaxConcurrency    int    `json:"maxConcurrency,omitempty"`
+
+	// Where the secret key and session token are kept when they
+	// are not stored in plaintext, see credential-store.go.
+	CredentialStore   string `json:"credentialStore,omitempty"`
+	SealedCredentials string `json:"sealedCredentials,omitempty"`
+
+	// Where temporary credentials are sourced from, instead
+	// of the static keys, see credential-providers.go.
+	CredentialProvider *aliasCredentialProvider `json:"credentialProvider,omitempty"`
 }
 
-// configV10 config version.
-type configV10 struct {
+// configV11 config version.
+type configV11 struct {
 	Version string                    `json:"version"`
-	Aliases map[string]aliasConfigV10 `json:"aliases"`
+	Aliases map[string]aliasConfigV11 `json:"aliases"`
 }
 
-// newConfigV10 - new config version.
-func newConfigV10() *configV10 {
-	cfg := new(configV10)
+// newConfigV11 - new config version.
+func newConfigV11() *configV11 {
+	cfg := new(configV11)
 	cfg.Version = globalMCConfigVersion
-	cfg.Aliases = make(map[string]aliasConfigV10)
+	cfg.Aliases = make(map[string]aliasConfigV11)
 	return cfg
 }
 
 // SetAlias sets host config if not empty.
-func (c *configV10) setAlias(alias string, cfg aliasConfigV10) {
+func (c *configV11) setAlias(alias string, cfg aliasConfigV11) {
 	if _, ok := c.Aliases[alias]; !ok {
 		c.Aliases[alias] = cfg
 	}
 }
 
 // load default values for missing entries.
-func (c *configV10) loadDefaults() {
+func (c *configV11) loadDefaults() {
 	// MinIO server running locally.
-	c.setAlias("local", aliasConfigV10{
+	c.setAlias("local", aliasConfigV11{
 		URL:       "http://localhost:9000",
 		AccessKey: "",
 		SecretKey: "",
@@ -81,7 +100,7 @@ func (c *configV10) loadDefaults() {
 	})
 
 	// Amazon S3 cloud storage service.
-	c.setAlias("s3", aliasConfigV10{
+	c.setAlias("s3", aliasConfigV11{
 		URL:       "https://s3.amazonaws.com",
 		AccessKey: defaultAccessKey,
 		SecretKey: defaultSecretKey,
@@ -90,7 +109,7 @@ func (c *configV10) loadDefaults() {
 	})
 
 	// Google cloud storage service.
-	c.setAlias("gcs", aliasConfigV10{
+	c.setAlias("gcs", aliasConfigV11{
 		URL:       "https://storage.googleapis.com",
 		AccessKey: defaultAccessKey,
 		SecretKey: defaultSecretKey,
@@ -99,7 +118,7 @@ func (c *configV10) loadDefaults() {
 	})
 
 	// MinIO anonymous server for demo.
-	c.setAlias("play", aliasConfigV10{
+	c.setAlias("play", aliasConfigV11{
 		URL:       "https://play.min.io",
 		AccessKey: "Q3AM3UQ867SPQQA43P2F",
 		SecretKey: "zuf+tfteSlswRu7BJ86wekitnifILbZam1KYY3TG",
@@ -108,14 +127,14 @@ func (c *configV10) loadDefaults() {
 	})
 }
 
-// loadConfigV10 - loads a new config.
-func loadConfigV10() (*configV10, *probe.Error) {
+// loadConfigV11 - loads a new config.
+func loadConfigV11() (*configV11, *probe.Error) {
 	cfgMutex.RLock()
 	defer cfgMutex.RUnlock()
 
 	// If already cached, return the cached value.
-	if cacheCfgV10 != nil {
-		return cacheCfgV10, nil
+	if cacheCfgV11 != nil {
+		return cacheCfgV11, nil
 	}
 
 	if !isMcConfigExists() {
@@ -123,7 +142,7 @@ func loadConfigV10() (*configV10, *probe.Error) {
 	}
 
 	// Initialize a new config loader.
-	qc, e := quick.NewConfig(newConfigV10(), nil)
+	qc, e := quick.NewConfig(newConfigV11(), nil)
 	if e != nil {
 		return nil, probe.NewError(e)
 	}
@@ -134,27 +153,27 @@ func loadConfigV10() (*configV10, *probe.Error) {
 		return nil, probe.NewError(e)
 	}
 
-	cfgV10 := qc.Data().(*configV10)
+	cfgV11 := qc.Data().(*configV11)
 
 	// Cache config.
-	cacheCfgV10 = cfgV10
+	cacheCfgV11 = cfgV11
 
 	// Success.
-	return cfgV10, nil
+	return cfgV11, nil
 }
 
-// saveConfigV10 - saves an updated config.
-func saveConfigV10(cfgV10 *configV10) *probe.Error {
+// saveConfigV11 - saves an updated config.
+func saveConfigV11(cfgV11 *configV11) *probe.Error {
 	cfgMutex.Lock()
 	defer cfgMutex.Unlock()
 
-	qs, e := quick.NewConfig(cfgV10, nil)
+	qs, e := quick.NewConfig(cfgV11, nil)
 	if e != nil {
 		return probe.NewError(e)
 	}
 
 	// update the cache.
-	cacheCfgV10 = cfgV10
+	cacheCfgV11 = cfgV11
 
 	e = qs.Save(mustGetMcConfigPath())
 	if e != nil {
@@ -20,10 +20,13 @@ package cmd
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/limiter"
 )
 
 // Check if version of the config is valid
-func validateConfigVersion(config *configV10) (bool, string) {
+func validateConfigVersion(config *configV11) (bool, string) {
 	if config.Version != globalMCConfigVersion {
 		return false, fmt.Sprintf("Config version '%s' does not match mc config version '%s', please update your binary.\n",
 			config.Version, globalMCConfigVersion)
@@ -32,7 +35,7 @@ func validateConfigVersion(config *configV10) (bool, string) {
 }
 
 // Verifies the config file of the MinIO Client
-func validateConfigFile(config *configV10) (bool, []string) {
+func validateConfigFile(config *configV11) (bool, []string) {
 	ok, err := validateConfigVersion(config)
 	validationSuccessful := true
 	var errors []string
@@ -51,7 +54,7 @@ func validateConfigFile(config *configV10) (bool, []string) {
 	return validationSuccessful, errors
 }
 
-func validateConfigHost(host aliasConfigV10) (bool, []string) {
+func validateConfigHost(host aliasConfigV11) (bool, []string) {
 	validationSuccessful := true
 	var hostErrors []string
 	if !isValidAPI(strings.ToLower(host.API)) {
@@ -62,5 +65,38 @@ func validateConfigHost(host aliasConfigV10) (bool, []string) {
 		validationSuccessful = false
 		hostErrors = append(hostErrors, errInvalidURL(host.URL).ToGoError().Error())
 	}
+	for _, limit := range []string{host.UploadLimit, host.DownloadLimit} {
+		if _, e := limiter.ParseSchedule(limit); e != nil {
+			validationSuccessful = false
+			hostErrors = append(hostErrors, fmt.Sprintf("Invalid bandwidth limit `%s` for `%s`: %v", limit, host.URL, e))
+		}
+	}
+	for _, deadline := range []string{host.ConnReadDeadline, host.ConnWriteDeadline} {
+		if deadline == "" {
+			continue
+		}
+		if _, e := time.ParseDuration(deadline); e != nil {
+			validationSuccessful = false
+			hostErrors = append(hostErrors, fmt.Sprintf("Invalid connection deadline `%s` for `%s`: %v", deadline, host.URL, e))
+		}
+	}
+	if !isValidCredentialStore(host.CredentialStore) {
+		validationSuccessful = false
+		hostErrors = append(hostErrors, errInvalidCredentialStore(host.CredentialStore).ToGoError().Error())
+	}
+	if host.CredentialStore == credentialStorePassphrase && host.SealedCredentials == "" {
+		validationSuccessful = false
+		hostErrors = append(hostErrors, fmt.Sprintf("Missing sealed credentials for `%s`", host.URL))
+	}
+	if host.CredentialProvider != nil {
+		if err := host.CredentialProvider.validate(); err != nil {
+			validationSuccessful = false
+			hostErrors = append(hostErrors, fmt.Sprintf("Invalid credential provider for `%s`: %v", host.URL, err.ToGoError()))
+		}
+	}
+	if host.MaxConcurrency < 0 {
+		validationSuccessful = false
+		hostErrors = append(hostErrors, fmt.Sprintf("Invalid max concurrency `%d` for `%s`", host.MaxConcurrency, host.URL))
+	}
 	return validationSuccessful, hostErrors
 }
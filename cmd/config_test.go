@@ -17,7 +17,13 @@
 
 package cmd
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/limiter"
+)
 
 // Tests valid host URL functionality.
 func TestParseEnvURLStr(t *testing.T) {
@@ -142,3 +148,60 @@ func TestParseEnvURLStrInvalid(t *testing.T) {
 		t.Fatalf("Expected failure")
 	}
 }
+
+func TestNewS3ConfigAliasSettings(t *testing.T) {
+	aliasCfg := &aliasConfigV11{
+		URL:              "https://localhost:9000",
+		UploadLimit:      "1MiB",
+		DownloadLimit:    "09:00-18:00=2MiB",
+		ConnReadDeadline: "30s",
+		Insecure:         true,
+		MaxConcurrency:   8,
+	}
+
+	s3Config, err := NewS3Config("myminio", aliasCfg.URL, aliasCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s3Config.UploadLimit.RateAt(time.Now()) != 1<<20 {
+		t.Fatalf("Expected alias upload limit, got %v", s3Config.UploadLimit)
+	}
+	if len(s3Config.DownloadLimit) != 1 {
+		t.Fatalf("Expected alias download schedule, got %v", s3Config.DownloadLimit)
+	}
+	if s3Config.ConnReadDeadline != 30*time.Second {
+		t.Fatalf("Expected alias read deadline, got %s", s3Config.ConnReadDeadline)
+	}
+	if !s3Config.Insecure || s3Config.MaxConcurrency != 8 {
+		t.Fatalf("Expected alias TLS and concurrency settings, got %t and %d", s3Config.Insecure, s3Config.MaxConcurrency)
+	}
+
+	// Settings passed on the command line take precedence.
+	defer func(upload limiter.Schedule, deadline time.Duration) {
+		globalLimitUpload, globalConnReadDeadline = upload, deadline
+	}(globalLimitUpload, globalConnReadDeadline)
+	globalLimitUpload = limiter.Fixed(4 << 20)
+	globalConnReadDeadline = time.Minute
+
+	globalConfig, err := NewS3Config("myminio", aliasCfg.URL, aliasCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if globalConfig.UploadLimit.RateAt(time.Now()) != 4<<20 {
+		t.Fatalf("Expected global upload limit, got %v", globalConfig.UploadLimit)
+	}
+	if globalConfig.ConnReadDeadline != time.Minute {
+		t.Fatalf("Expected global read deadline, got %s", globalConfig.ConnReadDeadline)
+	}
+
+	// Clients with different transport settings are not shared.
+	if getConfigHash(s3Config) == getConfigHash(globalConfig) {
+		t.Fatal("Expected different transport settings to have different hashes")
+	}
+
+	// Invalid settings are returned instead of exiting.
+	aliasCfg.CABundle = filepath.Join(t.TempDir(), "missing.pem")
+	if _, err = NewS3Config("myminio", aliasCfg.URL, aliasCfg); err == nil {
+		t.Fatal("Expected a missing CA bundle to fail")
+	}
+}
@@ -0,0 +1,488 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/pkg/v2/console"
+)
+
+// archiveFormat - client side archive formats.
+type archiveFormat string
+
+const (
+	archiveTar   archiveFormat = "tar"
+	archiveTarGz archiveFormat = "tar.gz"
+	archiveZip   archiveFormat = "zip"
+)
+
+// parseArchiveFormat - parses the value of '--archive'.
+func parseArchiveFormat(format string) (archiveFormat, *probe.Error) {
+	switch f := archiveFormat(strings.ToLower(format)); f {
+	case archiveTar, archiveTarGz, archiveZip:
+		return f, nil
+	case "tgz":
+		return archiveTarGz, nil
+	}
+	return "", errInvalidArgument().Trace(format)
+}
+
+// archiveFormatFromName - guesses the format of an archive from its name.
+func archiveFormatFromName(name string) (archiveFormat, bool) {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return archiveTarGz, true
+	case strings.HasSuffix(name, ".tar"):
+		return archiveTar, true
+	case strings.HasSuffix(name, ".zip"):
+		return archiveZip, true
+	}
+	return "", false
+}
+
+// archiveEntryName - returns a safe relative name for an archive entry,
+// entries escaping the target prefix are rejected.
+func archiveEntryName(name string) (string, bool) {
+	name = path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" || name == "." {
+		return "", false
+	}
+	return name, true
+}
+
+// archiveMessage container for archive and extract messages.
+type archiveMessage struct {
+	Status  string        `json:"status"`
+	Op      string        `json:"operation"`
+	Source  []string      `json:"source"`
+	Target  string        `json:"target"`
+	Format  archiveFormat `json:"format"`
+	Entries int64         `json:"entries"`
+	Size    int64         `json:"size"`
+}
+
+// String colorized archive message.
+func (a archiveMessage) String() string {
+	if a.Op == "extract" {
+		return console.Colorize("Copy", fmt.Sprintf("Extracted %d objects (%s) from `%s` into `%s`.",
+			a.Entries, humanize.IBytes(uint64(a.Size)), a.Source[0], a.Target))
+	}
+	return console.Colorize("Copy", fmt.Sprintf("Archived %d objects (%s) into `%s`.",
+		a.Entries, humanize.IBytes(uint64(a.Size)), a.Target))
+}
+
+// JSON jsonified archive message.
+func (a archiveMessage) JSON() string {
+	a.Status = "success"
+	buf, e := json.MarshalIndent(a, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(buf)
+}
+
+// archiveWriter - writes entries in one of the archive formats.
+type archiveWriter struct {
+	tw *tar.Writer
+	zw *zip.Writer
+	gw *gzip.Writer
+}
+
+func newArchiveWriter(w io.Writer, format archiveFormat) *archiveWriter {
+	switch format {
+	case archiveZip:
+		return &archiveWriter{zw: zip.NewWriter(w)}
+	case archiveTarGz:
+		gw := gzip.NewWriter(w)
+		return &archiveWriter{tw: tar.NewWriter(gw), gw: gw}
+	}
+	return &archiveWriter{tw: tar.NewWriter(w)}
+}
+
+// add - writes an entry of the given size from reader.
+func (a *archiveWriter) add(name string, content *ClientContent, reader io.Reader) error {
+	if a.zw != nil {
+		w, e := a.zw.CreateHeader(&zip.FileHeader{
+			Name:     name,
+			Method:   zip.Deflate,
+			Modified: content.Time,
+		})
+		if e != nil {
+			return e
+		}
+		_, e = io.Copy(w, reader)
+		return e
+	}
+
+	e := a.tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     content.Size,
+		Mode:     0o644,
+		ModTime:  content.Time,
+	})
+	if e != nil {
+		return e
+	}
+	_, e = io.CopyN(a.tw, reader, content.Size)
+	return e
+}
+
+func (a *archiveWriter) Close() error {
+	if a.zw != nil {
+		return a.zw.Close()
+	}
+	if e := a.tw.Close(); e != nil {
+		return e
+	}
+	if a.gw != nil {
+		return a.gw.Close()
+	}
+	return nil
+}
+
+// checkCopyArchiveSyntax - verifies the arguments of 'cp --archive' and
+// 'cp --extract'.
+func checkCopyArchiveSyntax(cliCtx *cli.Context) {
+	args := cliCtx.Args()
+	if cliCtx.Bool("extract") && len(args) != 2 {
+		fatalIf(errInvalidArgument().Trace(args...), "Only one archive can be extracted at a time.")
+	}
+	for _, flag := range []string{"zip", "rewind", "version-id", "session", "plan"} {
+		if cliCtx.IsSet(flag) {
+			fatalIf(errInvalidArgument().Trace(flag), "--"+flag+" cannot be used with --archive or --extract.")
+		}
+	}
+}
+
+// doCopyArchive - packs the sources into a single archive object streamed
+// to the target, or unpacks an archive object into the target prefix.
+func doCopyArchive(ctx context.Context, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) error {
+	checkCopyArchiveSyntax(cliCtx)
+
+	args := cliCtx.Args()
+	sourceURLs, targetURL := args[:len(args)-1], args[len(args)-1]
+
+	var format archiveFormat
+	if cliCtx.IsSet("archive") {
+		var err *probe.Error
+		format, err = parseArchiveFormat(cliCtx.String("archive"))
+		fatalIf(err, "Invalid archive format, valid formats are tar, tar.gz and zip.")
+	}
+
+	op := "create"
+	var msg archiveMessage
+	var err *probe.Error
+	if cliCtx.Bool("extract") {
+		op = "extract"
+		if format == "" {
+			var ok bool
+			if format, ok = archiveFormatFromName(sourceURLs[0]); !ok {
+				fatalIf(errInvalidArgument().Trace(sourceURLs[0]), "Unable to guess the archive format, please use --archive.")
+			}
+		}
+		msg, err = extractArchive(ctx, sourceURLs[0], targetURL, format, encKeyDB, cliCtx.String("storage-class"))
+	} else {
+		msg, err = createArchive(ctx, sourceURLs, targetURL, format, encKeyDB, cliCtx.String("storage-class"))
+	}
+	if err != nil {
+		errorIf(err.Trace(args...), "Unable to "+op+" archive.")
+		return exitStatus(globalErrorExitStatus)
+	}
+	printMsg(msg)
+	return nil
+}
+
+// createArchive - lists the sources recursively and streams their
+// objects into an archive uploaded to targetURL, without temporary files.
+func createArchive(ctx context.Context, sourceURLs []string, targetURL string, format archiveFormat, encKeyDB map[string][]prefixSSEPair, storageClass string) (archiveMessage, *probe.Error) {
+	msg := archiveMessage{Op: "archive", Source: sourceURLs, Target: targetURL, Format: format}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	reader, writer := io.Pipe()
+	go func() {
+		aw := newArchiveWriter(writer, format)
+		e := writeArchiveEntries(ctx, aw, sourceURLs, encKeyDB, &msg)
+		if e == nil {
+			e = aw.Close()
+		}
+		writer.CloseWithError(e)
+	}()
+
+	alias, _ := url2Alias(targetURL)
+	opts := PutOptions{
+		sse:          getSSE(targetURL, encKeyDB[alias]),
+		cse:          getCSE(targetURL, encKeyDB[alias]),
+		storageClass: storageClass,
+	}
+
+	var progress io.Reader = reader
+	if !globalQuiet && !globalJSON {
+		pg := newProgressBar(0)
+		defer pg.Finish()
+		progress = io.TeeReader(reader, pg)
+	}
+
+	if _, err := putTargetStreamWithURL(targetURL, progress, -1, opts); err != nil {
+		// Unblock the archive writer if the upload failed first.
+		reader.CloseWithError(err.ToGoError())
+		return msg, err.Trace(targetURL)
+	}
+	return msg, nil
+}
+
+// writeArchiveEntries - adds every object found under the sources to
+// the archive, entries are named relative to their source.
+func writeArchiveEntries(ctx context.Context, aw *archiveWriter, sourceURLs []string, encKeyDB map[string][]prefixSSEPair, msg *archiveMessage) error {
+	for _, sourceURL := range sourceURLs {
+		alias, _ := url2Alias(sourceURL)
+		clnt, err := newClient(sourceURL)
+		if err != nil {
+			return err.ToGoError()
+		}
+
+		sourcePath := clnt.GetURL().Path
+		content, err := clnt.Stat(ctx, StatOptions{sse: getSSE(sourceURL, encKeyDB[alias])})
+		if err == nil && !content.Type.IsDir() {
+			// A single object is named after its base name.
+			sourcePath = strings.TrimSuffix(sourcePath, path.Base(filepath.ToSlash(sourcePath)))
+			if e := addArchiveEntry(ctx, aw, alias, sourcePath, content, encKeyDB, msg); e != nil {
+				return e
+			}
+			continue
+		}
+
+		for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+			if content.Err != nil {
+				return content.Err.ToGoError()
+			}
+			if content.Type.IsDir() {
+				continue
+			}
+			if e := addArchiveEntry(ctx, aw, alias, sourcePath, content, encKeyDB, msg); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// addArchiveEntry - streams one object into the archive.
+func addArchiveEntry(ctx context.Context, aw *archiveWriter, alias, sourcePath string, content *ClientContent, encKeyDB map[string][]prefixSSEPair, msg *archiveMessage) error {
+	relPath := strings.TrimPrefix(filepath.ToSlash(content.URL.Path), filepath.ToSlash(sourcePath))
+	name, ok := archiveEntryName(relPath)
+	if !ok {
+		return nil
+	}
+
+	var sse encrypt.ServerSide
+	var cse []byte
+	if alias != "" {
+		resource := filepath.ToSlash(filepath.Join(alias, content.URL.Path))
+		sse, cse = getSSE(resource, encKeyDB[alias]), getCSE(resource, encKeyDB[alias])
+	}
+	reader, plain, err := getSourceStream(ctx, alias, content.URL.String(), getSourceOpts{GetOptions: GetOptions{SSE: sse}, cse: cse})
+	if err != nil {
+		return err.ToGoError()
+	}
+	defer reader.Close()
+	if cse != nil {
+		// Listed sizes are the encrypted sizes.
+		content.Size = plain.Size
+	}
+
+	if e := aw.add(name, content, reader); e != nil {
+		return fmt.Errorf("%s: %w", content.URL.String(), e)
+	}
+	msg.Entries++
+	msg.Size += content.Size
+	return nil
+}
+
+// extractArchive - unpacks the archive object at sourceURL into the
+// targetURL prefix, entries are uploaded one by one as they are read.
+func extractArchive(ctx context.Context, sourceURL, targetURL string, format archiveFormat, encKeyDB map[string][]prefixSSEPair, storageClass string) (archiveMessage, *probe.Error) {
+	msg := archiveMessage{Op: "extract", Source: []string{sourceURL}, Target: targetURL, Format: format}
+
+	alias, _ := url2Alias(targetURL)
+	putEntry := func(name string, reader io.Reader, size int64) *probe.Error {
+		name, ok := archiveEntryName(name)
+		if !ok {
+			return nil
+		}
+		entryURL := urlJoinPath(targetURL, name)
+		opts := PutOptions{
+			sse:          getSSE(entryURL, encKeyDB[alias]),
+			cse:          getCSE(entryURL, encKeyDB[alias]),
+			storageClass: storageClass,
+		}
+		n, err := putTargetStreamWithURL(entryURL, reader, size, opts)
+		if err != nil {
+			return err.Trace(entryURL)
+		}
+		msg.Entries++
+		msg.Size += n
+		return nil
+	}
+
+	if format == archiveZip {
+		return msg, extractZip(ctx, sourceURL, encKeyDB, putEntry)
+	}
+
+	reader, err := getSourceStreamFromURL(ctx, sourceURL, encKeyDB, getSourceOpts{})
+	if err != nil {
+		return msg, err.Trace(sourceURL)
+	}
+	defer reader.Close()
+
+	var archive io.Reader = reader
+	if format == archiveTarGz {
+		gr, e := gzip.NewReader(reader)
+		if e != nil {
+			return msg, probe.NewError(e).Trace(sourceURL)
+		}
+		defer gr.Close()
+		archive = gr
+	}
+
+	tr := tar.NewReader(archive)
+	for {
+		hdr, e := tr.Next()
+		if errors.Is(e, io.EOF) {
+			return msg, nil
+		}
+		if e != nil {
+			return msg, probe.NewError(e).Trace(sourceURL)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err = putEntry(hdr.Name, tr, hdr.Size); err != nil {
+			return msg, err
+		}
+	}
+}
+
+// extractZip - unpacks a zip archive, which is read through ranged GETs
+// since its central directory is at the end.
+func extractZip(ctx context.Context, sourceURL string, encKeyDB map[string][]prefixSSEPair, putEntry func(string, io.Reader, int64) *probe.Error) *probe.Error {
+	alias, urlStr, _, err := expandAlias(sourceURL)
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	clnt, err := newClientFromAlias(alias, urlStr)
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+	sse := getSSE(sourceURL, encKeyDB[alias])
+	content, err := clnt.Stat(ctx, StatOptions{sse: sse})
+	if err != nil {
+		return err.Trace(sourceURL)
+	}
+
+	ra := &rangeReaderAt{ctx: ctx, clnt: clnt, opts: GetOptions{SSE: sse, VersionID: content.VersionID}}
+	defer ra.Close()
+
+	var archive io.ReaderAt = ra
+	size := content.Size
+	if cse := getCSE(sourceURL, encKeyDB[alias]); cse != nil {
+		stream, nonce, err := clientSideStream(cse, content.Metadata)
+		if err != nil {
+			return err.Trace(sourceURL)
+		}
+		archive = stream.DecryptReaderAt(ra, nonce, nil)
+		size = clientSideDecryptedSize(size)
+	}
+
+	zr, e := zip.NewReader(archive, size)
+	if e != nil {
+		return probe.NewError(e).Trace(sourceURL)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		reader, e := f.Open()
+		if e != nil {
+			return probe.NewError(e).Trace(sourceURL, f.Name)
+		}
+		err = putEntry(f.Name, reader, int64(f.UncompressedSize64))
+		reader.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rangeReaderAt - an io.ReaderAt over an object. Sequential reads are
+// served from a single GET, a new ranged GET is issued when reading
+// elsewhere.
+type rangeReaderAt struct {
+	ctx    context.Context
+	clnt   Client
+	opts   GetOptions
+	reader io.ReadCloser
+	offset int64
+}
+
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if r.reader == nil || r.offset != off {
+		r.Close()
+		opts := r.opts
+		opts.RangeStart = off
+		reader, _, err := r.clnt.Get(r.ctx, opts)
+		if err != nil {
+			return 0, err.ToGoError()
+		}
+		r.reader, r.offset = reader, off
+	}
+	n, e := io.ReadFull(r.reader, p)
+	r.offset += int64(n)
+	if errors.Is(e, io.ErrUnexpectedEOF) {
+		e = io.EOF
+	}
+	return n, e
+}
+
+func (r *rangeReaderAt) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	e := r.reader.Close()
+	r.reader = nil
+	return e
+}
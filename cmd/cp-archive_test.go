@@ -0,0 +1,82 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveEntryName(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected string
+		ok       bool
+	}{
+		{"a/b.log", "a/b.log", true},
+		{"/a/b.log", "a/b.log", true},
+		{"../../etc/passwd", "etc/passwd", true},
+		{"a\\..\\..\\b", "b", true},
+		{"./", "", false},
+	}
+	for i, testCase := range testCases {
+		name, ok := archiveEntryName(testCase.name)
+		if name != testCase.expected || ok != testCase.ok {
+			t.Fatalf("Test %d: expected %q %t, got %q %t", i+1, testCase.expected, testCase.ok, name, ok)
+		}
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	defer func(quiet bool) { globalQuiet = quiet }(globalQuiet)
+	globalQuiet = true
+
+	files := map[string]string{
+		"a.log":       "hello",
+		"dir/b.log":   "world",
+		"dir/c/d.log": "",
+	}
+	root, srcDir := newTestFolder(t, files)
+
+	for _, format := range []archiveFormat{archiveTar, archiveTarGz, archiveZip} {
+		archive := filepath.Join(root, "backup."+string(format))
+		msg, err := createArchive(context.Background(), []string{srcDir + string(filepath.Separator)}, archive, format, nil, "")
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if msg.Entries != int64(len(files)) {
+			t.Fatalf("%s: expected %d entries, got %d", format, len(files), msg.Entries)
+		}
+
+		target := filepath.Join(root, "dst-"+string(format)) + string(filepath.Separator)
+		if msg, err = extractArchive(context.Background(), archive, target, format, nil, ""); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if msg.Entries != int64(len(files)) {
+			t.Fatalf("%s: expected %d extracted entries, got %d", format, len(files), msg.Entries)
+		}
+		for name, data := range files {
+			got, e := os.ReadFile(filepath.Join(target, filepath.FromSlash(name)))
+			if e != nil || string(got) != data {
+				t.Fatalf("%s: unexpected content of %s: %q %v", format, name, got, e)
+			}
+		}
+	}
+}
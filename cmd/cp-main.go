@@ -24,6 +24,7 @@ import (
 	"io"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 
 	"github.com/fatih/color"
 	"github.com/minio/cli"
@@ -75,6 +76,7 @@ var (
 			Name:  "md5",
 			Usage: "force all upload(s) to calculate md5sum checksum",
 		},
+		checksumFlag,
 		cli.StringFlag{
 			Name:  "tags",
 			Usage: "apply one or more tags to the uploaded objects",
@@ -95,6 +97,22 @@ var (
 			Name:  "zip",
 			Usage: "Extract from remote zip file (MinIO server source only)",
 		},
+		cli.StringFlag{
+			Name:  "session",
+			Usage: "journal the copy in a named session, resumable with 'mc session resume'",
+		},
+		cli.BoolFlag{
+			Name:  "plan",
+			Usage: "print the transfer strategy chosen for each object, server side copy or streaming, without copying",
+		},
+		cli.StringFlag{
+			Name:  "archive",
+			Usage: "pack the sources into a single streamed 'tar', 'tar.gz' or 'zip' archive object",
+		},
+		cli.BoolFlag{
+			Name:  "extract",
+			Usage: "unpack a 'tar', 'tar.gz' or 'zip' archive object into the target prefix",
+		},
 	}
 )
 
@@ -114,7 +132,7 @@ var cpCmd = cli.Command{
 	Action:       mainCopy,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(cpFlags, encFlags...), globalFlags...),
+	Flags:        append(append(append(append(append(cpFlags, encFlags...), concurrencyFlags...), retryFlags...), failedReportFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -187,6 +205,33 @@ EXAMPLES:
   19. Set tags to the uploaded objects
       {{.Prompt}} {{.HelpName}} -r --tags "category=prod&type=backup" ./data/ play/another-bucket/
 
+  20. Copy a folder recursively in a resumable session named "backup", continue it with 'mc session resume backup' if interrupted.
+      {{.Prompt}} {{.HelpName}} --recursive --session backup ./data/ play/backups/
+
+  21. Copy a file verifying the uploaded data end-to-end with a SHA256 checksum.
+      {{.Prompt}} {{.HelpName}} --checksum sha256 ./data/dataset.parquet play/mybucket/
+
+  22. Show whether objects are copied server side or streamed between two aliases of the same cluster.
+      {{.Prompt}} {{.HelpName}} --recursive --plan site1/mybucket/ site1-admin/backup/
+
+  23. Pack a folder of log files into a single compressed tar object, streamed without temporary files.
+      {{.Prompt}} {{.HelpName}} --archive tar.gz ./logs/ play/mybucket/backup/logs.tar.gz
+
+  24. Unpack a zip object into a prefix.
+      {{.Prompt}} {{.HelpName}} --extract play/mybucket/backup/logs.zip play/mybucket/logs/
+
+  25. Copy a folder encrypting the objects on the client with a key read from a file.
+      {{.Prompt}} {{.HelpName}} --recursive --enc-client "myminio/documents/=/etc/mc/documents.key" documents/ myminio/documents/
+
+  26. Copy a folder with exactly 8 parallel workers.
+      {{.Prompt}} {{.HelpName}} --recursive --concurrency-mode fixed --max-workers 8 backup/ myminio/backup/
+
+  27. Copy a folder, retrying every object up to 5 times on network errors and slow down responses.
+      {{.Prompt}} {{.HelpName}} --recursive --max-retries 5 backup/ myminio/backup/
+
+  28. Copy a folder, writing the objects which failed to a report, retry them with 'mc replay'.
+      {{.Prompt}} {{.HelpName}} --recursive --failed-report failed.json backup/ myminio/backup/
+
 `,
 }
 
@@ -241,11 +286,11 @@ func doCopy(ctx context.Context, copyOpts doCopyOpts) URLs {
 	targetURL := copyOpts.cpURLs.TargetContent.URL
 	length := copyOpts.cpURLs.SourceContent.Size
 	sourcePath := filepath.ToSlash(filepath.Join(sourceAlias, sourceURL.Path))
+	targetPath := filepath.ToSlash(filepath.Join(targetAlias, targetURL.Path))
 
 	if progressReader, ok := copyOpts.pg.(*progressBar); ok {
 		progressReader.SetCaption(copyOpts.cpURLs.SourceContent.URL.String() + ":")
 	} else {
-		targetPath := filepath.ToSlash(filepath.Join(targetAlias, targetURL.Path))
 		printMsg(copyMessage{
 			Source:     sourcePath,
 			Target:     targetPath,
@@ -255,15 +300,26 @@ func doCopy(ctx context.Context, copyOpts doCopyOpts) URLs {
 		})
 	}
 
-	urls := uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{
-		urls:                copyOpts.cpURLs,
-		progress:            copyOpts.pg,
-		encKeyDB:            copyOpts.encryptionKeys,
-		preserve:            copyOpts.preserve,
-		isZip:               copyOpts.isZip,
-		multipartSize:       copyOpts.multipartSize,
-		multipartThreads:    copyOpts.multipartThreads,
-		updateProgressTotal: copyOpts.updateProgressTotal,
+	// Retries are reported by retry messages.
+	var urls URLs
+	progress := &attemptProgress{ProgressReader: copyOpts.pg}
+	retryObjectWithMessage(ctx, copyOpts.retry, sourcePath, targetPath, func(retries int) *probe.Error {
+		if retries > 0 {
+			// Bytes of the failed attempt are uploaded again.
+			progress.rewind()
+		}
+		urls = uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{
+			urls:                copyOpts.cpURLs,
+			progress:            progress,
+			encKeyDB:            copyOpts.encryptionKeys,
+			preserve:            copyOpts.preserve,
+			isZip:               copyOpts.isZip,
+			multipartSize:       copyOpts.multipartSize,
+			multipartThreads:    copyOpts.multipartThreads,
+			updateProgressTotal: copyOpts.updateProgressTotal,
+			session:             copyOpts.session,
+		})
+		return urls.Error
 	})
 	if copyOpts.isMvCmd && urls.Error == nil {
 		rmManager.add(ctx, sourceAlias, sourceURL.String())
@@ -272,6 +328,31 @@ func doCopy(ctx context.Context, copyOpts doCopyOpts) URLs {
 	return urls
 }
 
+// attemptProgress - forwards the progress of an upload attempt, the
+// bytes of a failed attempt are rewound before it is retried so that
+// they are not counted twice.
+type attemptProgress struct {
+	ProgressReader
+	n int64
+}
+
+func (a *attemptProgress) Read(p []byte) (n int, err error) {
+	n, err = a.ProgressReader.Read(p)
+	atomic.AddInt64(&a.n, int64(n))
+	return n, err
+}
+
+// rewind - removes the bytes of the attempt from the progress.
+func (a *attemptProgress) rewind() {
+	n := atomic.SwapInt64(&a.n, 0)
+	switch pg := a.ProgressReader.(type) {
+	case *progressBar:
+		pg.ProgressBar.Add64(-n)
+	case *accounter:
+		pg.Add(-n)
+	}
+}
+
 // doCopyFake - Perform a fake copy to update the progress bar appropriately.
 func doCopyFake(cpURLs URLs, pg Progress) URLs {
 	if progressReader, ok := pg.(*progressBar); ok {
@@ -298,10 +379,40 @@ func printCopyURLsError(cpURLs *URLs) {
 	}
 }
 
-func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.Context, encryptionKeys map[string][]prefixSSEPair, isMvCmd bool) error {
+// copyPair - a single source copied to its target, as replayed from a
+// failed objects report.
+type copyPair struct {
+	source, target, versionID string
+}
+
+// doCopySession - copies sources to target. If a session is given, the
+// planned copies and the completed ones are journaled in it, and copies
+// completed by an earlier run of the same session are skipped. If pairs
+// are given, they are copied instead of the command line arguments.
+func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.Context, encryptionKeys map[string][]prefixSSEPair, isMvCmd bool, session *sessionV8, pairs []copyPair) error {
 	var isCopied func(string) bool
 	var totalObjects, totalBytes int64
 
+	checksum := mustParseChecksumFlag(cli)
+
+	parallelOpts, err := parallelOptionsFromContext(cli)
+	fatalIf(err, "Unable to parse concurrency flags.")
+	retryPolicy := retryPolicyFromContext(cli)
+
+	report, err := newFailedReport(cli)
+	fatalIf(err, "Unable to create the failed objects report.")
+	defer func() {
+		errorIf(report.Close(), "Unable to close the failed objects report.")
+	}()
+
+	if session != nil {
+		isCopied = session.isCopied
+		if session.Header.PlanComplete {
+			totalObjects = session.Header.TotalObjects
+			totalBytes = session.Header.TotalBytes
+		}
+	}
+
 	cpURLsCh := make(chan URLs, 10000)
 	errSeen := false
 
@@ -315,11 +426,29 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 		pg = newAccounter(totalBytes)
 	}
 
-	sourceURLs := cli.Args()[:len(cli.Args())-1]
-	targetURL := cli.Args()[len(cli.Args())-1] // Last one is target
+	var sourceURLs []string
+	var targetURL string
+	if pairs == nil {
+		sourceURLs = cli.Args()[:len(cli.Args())-1]
+		targetURL = cli.Args()[len(cli.Args())-1] // Last one is target
+	}
 
-	// Check if the target path has object locking enabled
+	// Check if the target path has object locking enabled, the targets
+	// of pairs are checked once per bucket.
 	withLock, _ := isBucketLockEnabled(ctx, targetURL)
+	lockedBuckets := make(map[string]bool)
+	isLocked := func(cpURLs URLs) bool {
+		if pairs == nil {
+			return withLock
+		}
+		bucket := cpURLs.TargetAlias + "/" + strings.SplitN(strings.TrimPrefix(cpURLs.TargetContent.URL.Path, "/"), "/", 2)[0]
+		locked, ok := lockedBuckets[bucket]
+		if !ok {
+			locked, _ = isBucketLockEnabled(ctx, bucket)
+			lockedBuckets[bucket] = locked
+		}
+		return locked
+	}
 
 	isRecursive := cli.Bool("recursive")
 	olderThan := cli.String("older-than")
@@ -328,6 +457,23 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 	versionID := cli.String("version-id")
 
 	go func() {
+		if session != nil && session.Header.PlanComplete {
+			// Replay the plan saved by the session.
+			for cpURLs := range session.planned() {
+				if cpURLs.Error != nil {
+					errSeen = true
+					errorIf(cpURLs.Error.Trace(session.Name), "Unable to read the plan of session `"+session.Name+"`.")
+					break
+				}
+				cpURLsCh <- cpURLs
+			}
+			close(cpURLsCh)
+			return
+		}
+		if session != nil {
+			fatalIf(session.resetPlan().Trace(session.Name), "Unable to save session `"+session.Name+"`.")
+		}
+
 		totalBytes := int64(0)
 		opts := prepareCopyURLsOpts{
 			sourceURLs:  sourceURLs,
@@ -341,24 +487,53 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 			isZip:       cli.Bool("zip"),
 		}
 
+		if pairs != nil {
+			// Every pair is prepared on its own, a pair which fails
+			// does not stop the others.
+			for _, pair := range pairs {
+				opts.sourceURLs, opts.targetURL, opts.versionID = []string{pair.source}, pair.target, pair.versionID
+				for cpURLs := range prepareCopyURLs(ctx, opts) {
+					if cpURLs.Error != nil {
+						errSeen = true
+						printCopyURLsError(&cpURLs)
+						continue
+					}
+					totalBytes += cpURLs.SourceContent.Size
+					pg.SetTotal(totalBytes)
+					totalObjects++
+					cpURLsCh <- cpURLs
+				}
+			}
+			close(cpURLsCh)
+			return
+		}
+
 		for cpURLs := range prepareCopyURLs(ctx, opts) {
 			if cpURLs.Error != nil {
 				errSeen = true
 				printCopyURLsError(&cpURLs)
+				report.addURLs(cpURLs)
 				break
 			}
 
+			if session != nil {
+				fatalIf(session.addPlanned(cpURLs).Trace(session.Name), "Unable to save session `"+session.Name+"`.")
+			}
+
 			totalBytes += cpURLs.SourceContent.Size
 			pg.SetTotal(totalBytes)
 			totalObjects++
 			cpURLsCh <- cpURLs
 		}
+		if session != nil && !errSeen && ctx.Err() == nil {
+			fatalIf(session.completePlan().Trace(session.Name), "Unable to save session `"+session.Name+"`.")
+		}
 		close(cpURLsCh)
 	}()
 
 	quitCh := make(chan struct{})
 	statusCh := make(chan URLs)
-	parallel := newParallelManager(statusCh)
+	parallel := newParallelManager(statusCh, parallelOpts)
 
 	go func() {
 		gracefulStop := func() {
@@ -419,7 +594,8 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 					}
 				}
 
-				cpURLs.MD5 = cli.Bool("md5") || withLock
+				cpURLs.MD5 = cli.Bool("md5") || isLocked(cpURLs)
+				cpURLs.Checksum = checksum
 				cpURLs.DisableMultipart = cli.Bool("disable-multipart")
 
 				// Verify if previously copied, notify progress bar.
@@ -437,6 +613,8 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 							isMvCmd:        isMvCmd,
 							preserve:       preserve,
 							isZip:          isZip,
+							session:        session,
+							retry:          retryPolicy,
 						})
 					}, cpURLs.SourceContent.Size)
 				}
@@ -445,12 +623,14 @@ func doCopySession(ctx context.Context, cancelCopy context.CancelFunc, cli *cli.
 	}()
 
 	var retErr error
+	var interrupted bool
 	cpAllFilesErr := true
 
 loop:
 	for {
 		select {
 		case <-globalContext.Done():
+			interrupted = true
 			close(quitCh)
 			cancelCopy()
 			// Receive interrupt notification.
@@ -465,10 +645,15 @@ loop:
 			}
 			if cpURLs.Error == nil {
 				cpAllFilesErr = false
+				if session != nil {
+					errorIf(session.markCopied(cpURLs.SourceContent.URL.String()).Trace(session.Name),
+						"Unable to save session `"+session.Name+"`.")
+				}
 			} else {
 
 				// Set exit status for any copy error
 				retErr = exitStatus(globalErrorExitStatus)
+				report.addURLs(cpURLs)
 
 				// Print in new line and adjust to top so that we
 				// don't print over the ongoing progress bar.
@@ -522,10 +707,22 @@ loop:
 	}
 
 	// Source has error
-	if errSeen && totalObjects == 0 && retErr == nil {
+	if errSeen && (totalObjects == 0 || pairs != nil) && retErr == nil {
 		retErr = exitStatus(globalErrorExitStatus)
 	}
 
+	if session != nil {
+		if interrupted || errSeen || retErr != nil {
+			// Keep the session around to be resumed later.
+			errorIf(session.Close().Trace(session.Name), "Unable to save session `"+session.Name+"`.")
+			if !globalQuiet && !globalJSON {
+				console.Infoln("Session `" + session.Name + "` saved. To resume, run `mc session resume " + session.Name + "`.")
+			}
+		} else {
+			errorIf(session.Delete().Trace(session.Name), "Unable to remove session `"+session.Name+"`.")
+		}
+	}
+
 	return retErr
 }
 
@@ -546,7 +743,28 @@ func mainCopy(cliCtx *cli.Context) error {
 	}
 	fatalIf(err, "SSE Error")
 
-	return doCopySession(ctx, cancelCopy, cliCtx, encryptionKeyMap, false)
+	// Archive copies are checked first, they have no plan.
+	if cliCtx.IsSet("archive") || cliCtx.Bool("extract") {
+		return doCopyArchive(ctx, cliCtx, encryptionKeyMap)
+	}
+
+	if cliCtx.Bool("plan") {
+		return doCopyPlan(ctx, cliCtx, encryptionKeyMap)
+	}
+
+	var session *sessionV8
+	if name := cliCtx.String("session"); name != "" {
+		if !isValidSessionName(name) {
+			fatalIf(errInvalidArgument().Trace(name), "Invalid session name `"+name+"`.")
+		}
+		session, err = newSessionV8(name)
+		fatalIf(err.Trace(name), "Unable to create session `"+name+"`.")
+		session.Header.CommandType = "cp"
+		session.Header.CommandArgs = sessionCommandArgs(cliCtx, append(append(append(append(cpFlags, encFlags...), concurrencyFlags...), retryFlags...), failedReportFlags...))
+		fatalIf(session.Save().Trace(name), "Unable to save session `"+name+"`.")
+	}
+
+	return doCopySession(ctx, cancelCopy, cliCtx, encryptionKeyMap, false, session, nil)
 }
 
 type doCopyOpts struct {
@@ -557,4 +775,6 @@ type doCopyOpts struct {
 	updateProgressTotal      bool
 	multipartSize            string
 	multipartThreads         string
+	session                  *sessionV8
+	retry                    retryPolicy
 }
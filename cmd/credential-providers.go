@@ -0,0 +1,420 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/signer"
+	"github.com/minio/pkg/v2/env"
+	"golang.org/x/term"
+)
+
+// Credential provider types of an alias.
+const (
+	credentialProviderProcess    = "process"     // external credential_process command
+	credentialProviderProfile    = "profile"     // AWS style shared credentials file
+	credentialProviderAssumeRole = "assume-role" // STS AssumeRole, optionally with MFA
+)
+
+const (
+	mcEnvMFAToken = "MC_MFA_TOKEN"
+
+	// Temporary credentials are refreshed this long before they expire.
+	credentialExpiryWindow = time.Minute
+
+	defaultAssumeRoleDuration = 3600
+	awsSTSEndpoint            = "https://sts.amazonaws.com"
+)
+
+// aliasCredentialProvider - where an alias sources its credentials from
+// instead of the static keys stored in the config.
+type aliasCredentialProvider struct {
+	Type            string `json:"type"`
+	Process         string `json:"process,omitempty"`
+	File            string `json:"file,omitempty"`
+	Profile         string `json:"profile,omitempty"`
+	RoleARN         string `json:"roleArn,omitempty"`
+	MFASerial       string `json:"mfaSerial,omitempty"`
+	STSEndpoint     string `json:"stsEndpoint,omitempty"`
+	DurationSeconds int    `json:"durationSeconds,omitempty"`
+}
+
+// validate - checks that the provider has what its type needs.
+func (p *aliasCredentialProvider) validate() *probe.Error {
+	switch p.Type {
+	case credentialProviderProcess:
+		if strings.TrimSpace(p.Process) == "" {
+			return probe.NewError(errors.New("credential process command is missing"))
+		}
+	case credentialProviderProfile:
+	case credentialProviderAssumeRole:
+		if p.RoleARN == "" {
+			return probe.NewError(errors.New("role ARN is missing"))
+		}
+		if p.STSEndpoint != "" && !isValidHostURL(p.STSEndpoint) {
+			return errInvalidURL(p.STSEndpoint)
+		}
+	default:
+		return probe.NewError(fmt.Errorf("unknown credential provider `%s`, valid providers are `%s`, `%s` and `%s`",
+			p.Type, credentialProviderProcess, credentialProviderProfile, credentialProviderAssumeRole))
+	}
+	if p.DurationSeconds < 0 {
+		return probe.NewError(errors.New("role duration must not be negative"))
+	}
+	return nil
+}
+
+// needsStaticKeys - returns true if the provider is given the static
+// keys of the alias.
+func (p *aliasCredentialProvider) needsStaticKeys() bool {
+	return p.Type == credentialProviderAssumeRole && p.Profile == "" && p.File == ""
+}
+
+// cacheKey - identifies the temporary credentials of the provider, the
+// static keys of the alias are part of it when they are the source
+// credentials so that changing them does not reuse stale credentials.
+func (p *aliasCredentialProvider) cacheKey(config *Config) string {
+	buf, _ := json.Marshal(p)
+	key := config.Alias + "\x00" + config.HostURL + "\x00"
+	if p.needsStaticKeys() {
+		key += config.AccessKey + "\x00" + config.SecretKey + "\x00" + config.SessionToken + "\x00"
+	}
+	sum := sha256.Sum256(append([]byte(key), buf...))
+	return hex.EncodeToString(sum[:16])
+}
+
+// newAliasCredentialProvider - returns the provider of the credentials
+// of an alias, temporary credentials are cached on disk until they expire.
+func newAliasCredentialProvider(config *Config, transport http.RoundTripper) (credentials.Provider, *probe.Error) {
+	p := config.CredentialProvider
+	if err := p.validate(); err != nil {
+		return nil, err.Trace(config.Alias)
+	}
+
+	cached := &cachedCredentials{alias: config.Alias}
+	if dir, err := getCredentialsCacheDir(); err == nil {
+		cached.cacheFile = filepath.Join(dir, p.cacheKey(config)+".json")
+	}
+
+	switch p.Type {
+	case credentialProviderProcess:
+		cached.retrieve = func() (credentials.Value, error) {
+			return runCredentialProcess(p.Process)
+		}
+	case credentialProviderProfile:
+		// Only credentials with an expiration are cached, that is
+		// when the profile runs a credential_process.
+		fileCreds := &credentials.FileAWSCredentials{Filename: p.File, Profile: p.Profile}
+		cached.retrieve = fileCreds.Retrieve
+	case credentialProviderAssumeRole:
+		source := credentials.Provider(&credentials.Static{Value: credentials.Value{
+			AccessKeyID:     config.AccessKey,
+			SecretAccessKey: config.SecretKey,
+			SessionToken:    config.SessionToken,
+			SignerType:      credentials.SignatureV4,
+		}})
+		if !p.needsStaticKeys() {
+			source = &credentials.FileAWSCredentials{Filename: p.File, Profile: p.Profile}
+		}
+		endpoint := p.STSEndpoint
+		if endpoint == "" {
+			endpoint = config.HostURL
+			if isAmazon(newClientURL(config.HostURL).Host) {
+				endpoint = awsSTSEndpoint
+			}
+		}
+		client := &http.Client{Transport: transport}
+		cached.retrieve = func() (credentials.Value, error) {
+			return assumeRole(client, endpoint, config.Alias, p, source)
+		}
+	}
+	return cached, nil
+}
+
+// newAliasCredentials - returns the credentials of an alias with a
+// credential provider, nil if it has none. The provider is not chained
+// with the static keys of the alias, its failures are returned instead
+// of falling back to them.
+func newAliasCredentials(config *Config, transport http.RoundTripper) (*credentials.Credentials, *probe.Error) {
+	if config.CredentialProvider == nil {
+		return nil, nil
+	}
+	provider, err := newAliasCredentialProvider(config, transport)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.New(provider), nil
+}
+
+// cachedCredentials - a credentials.Provider retrieving credentials once
+// until they expire, across mc invocations when a cache file is set.
+type cachedCredentials struct {
+	sync.Mutex
+	alias      string
+	cacheFile  string
+	retrieve   func() (credentials.Value, error)
+	retrieved  bool
+	expiration time.Time
+}
+
+// Retrieve - returns cached credentials if they are still valid,
+// fetches new ones otherwise. Failures are returned to the caller to
+// be reported with the failed request.
+func (c *cachedCredentials) Retrieve() (credentials.Value, error) {
+	c.Lock()
+	defer c.Unlock()
+
+	if value, ok := c.load(); ok {
+		c.retrieved, c.expiration = true, value.Expiration
+		return value, nil
+	}
+
+	value, e := c.retrieve()
+	if e != nil {
+		return credentials.Value{}, fmt.Errorf("unable to retrieve the credentials of alias `%s`: %w", c.alias, e)
+	}
+	if value.SignerType == credentials.SignatureDefault {
+		value.SignerType = credentials.SignatureV4
+	}
+	c.retrieved, c.expiration = true, value.Expiration
+	if !value.Expiration.IsZero() {
+		c.save(value)
+	}
+	return value, nil
+}
+
+// IsExpired - credentials without expiration never expire.
+func (c *cachedCredentials) IsExpired() bool {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.retrieved {
+		return true
+	}
+	return !c.expiration.IsZero() && time.Now().Add(credentialExpiryWindow).After(c.expiration)
+}
+
+// load - reads unexpired credentials from the cache file.
+func (c *cachedCredentials) load() (credentials.Value, bool) {
+	var value credentials.Value
+	if c.cacheFile == "" {
+		return value, false
+	}
+	buf, e := os.ReadFile(c.cacheFile)
+	if e != nil {
+		return value, false
+	}
+	if e = json.Unmarshal(buf, &value); e != nil {
+		return value, false
+	}
+	if value.Expiration.IsZero() || time.Now().Add(credentialExpiryWindow).After(value.Expiration) {
+		return value, false
+	}
+	return value, true
+}
+
+// save - writes temporary credentials to the cache file, only readable
+// by the current user.
+func (c *cachedCredentials) save(value credentials.Value) {
+	if c.cacheFile == "" {
+		return
+	}
+	buf, e := json.Marshal(value)
+	if e != nil {
+		return
+	}
+	if e = os.MkdirAll(filepath.Dir(c.cacheFile), 0o700); e != nil {
+		return
+	}
+	// Caching is best effort, credentials are retrieved again if missing.
+	_ = os.WriteFile(c.cacheFile, buf, 0o600)
+}
+
+// Get credentials cache dir name.
+func getCredentialsCacheDir() (string, *probe.Error) {
+	configDir, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	return filepath.Join(configDir, globalCredentialsCacheDir), nil
+}
+
+// processCredentials - the output of a credential_process command, as
+// documented for the AWS CLI.
+type processCredentials struct {
+	Version         int
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// runCredentialProcess - runs the command and parses the credentials it
+// prints on stdout.
+func runCredentialProcess(process string) (credentials.Value, error) {
+	args := strings.Fields(process)
+	if len(args) == 0 {
+		return credentials.Value{}, errors.New("empty credential process command")
+	}
+
+	cmd := exec.CommandContext(globalContext, args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	out, e := cmd.Output()
+	if e != nil {
+		return credentials.Value{}, fmt.Errorf("credential process `%s` failed: %w", args[0], e)
+	}
+
+	var creds processCredentials
+	if e = json.Unmarshal(out, &creds); e != nil {
+		return credentials.Value{}, fmt.Errorf("credential process `%s` printed invalid credentials: %w", args[0], e)
+	}
+	if creds.Version != 1 {
+		return credentials.Value{}, fmt.Errorf("credential process `%s` printed unsupported version %d", args[0], creds.Version)
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return credentials.Value{}, fmt.Errorf("credential process `%s` printed no keys", args[0])
+	}
+	return credentials.Value{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expiration:      creds.Expiration,
+		SignerType:      credentials.SignatureV4,
+	}, nil
+}
+
+// readMFAToken - returns the MFA code from the environment, or prompts
+// for it on a terminal.
+func readMFAToken(serial string) (string, error) {
+	if token := env.Get(mcEnvMFAToken, ""); token != "" {
+		return token, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", fmt.Errorf("an MFA code is required for `%s`, set `%s` when not running in a terminal", serial, mcEnvMFAToken)
+	}
+	fmt.Fprintf(os.Stderr, "Enter MFA code for %s: ", serial)
+	token, e := bufio.NewReader(os.Stdin).ReadString('\n')
+	if e != nil && e != io.EOF {
+		return "", e
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// assumeRole - calls STS AssumeRole with the source credentials, adding
+// the MFA serial and code when the role requires them.
+func assumeRole(client *http.Client, endpoint, alias string, p *aliasCredentialProvider, source credentials.Provider) (credentials.Value, error) {
+	sourceCreds, e := source.Retrieve()
+	if e != nil {
+		return credentials.Value{}, e
+	}
+	if sourceCreds.AccessKeyID == "" || sourceCreds.SecretAccessKey == "" {
+		return credentials.Value{}, errors.New("assume role needs source credentials")
+	}
+
+	duration := p.DurationSeconds
+	if duration == 0 {
+		duration = defaultAssumeRoleDuration
+	}
+
+	v := url.Values{}
+	v.Set("Action", "AssumeRole")
+	v.Set("Version", credentials.STSVersion)
+	v.Set("RoleArn", p.RoleARN)
+	v.Set("RoleSessionName", "mc-"+alias+"-"+randString(8, rand.NewSource(time.Now().UnixNano()), ""))
+	v.Set("DurationSeconds", strconv.Itoa(duration))
+	if p.MFASerial != "" {
+		token, e := readMFAToken(p.MFASerial)
+		if e != nil {
+			return credentials.Value{}, e
+		}
+		v.Set("SerialNumber", p.MFASerial)
+		v.Set("TokenCode", token)
+	}
+
+	u, e := url.Parse(endpoint)
+	if e != nil {
+		return credentials.Value{}, e
+	}
+	u.Path = "/"
+
+	body := v.Encode()
+	bodySum := sha256.Sum256([]byte(body))
+	req, e := http.NewRequestWithContext(globalContext, http.MethodPost, u.String(), strings.NewReader(body))
+	if e != nil {
+		return credentials.Value{}, e
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(bodySum[:]))
+	if sourceCreds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sourceCreds.SessionToken)
+	}
+	// STS requests are signed for us-east-1 unless a region is set.
+	region := env.Get("MC_REGION", env.Get("AWS_REGION", "us-east-1"))
+	req = signer.SignV4STS(*req, sourceCreds.AccessKeyID, sourceCreds.SecretAccessKey, region)
+
+	resp, e := client.Do(req)
+	if e != nil {
+		return credentials.Value{}, e
+	}
+	defer resp.Body.Close()
+
+	buf, e := io.ReadAll(resp.Body)
+	if e != nil {
+		return credentials.Value{}, e
+	}
+	if resp.StatusCode != http.StatusOK {
+		var errResp credentials.ErrorResponse
+		if xml.Unmarshal(buf, &errResp) == nil && errResp.STSError.Code != "" {
+			return credentials.Value{}, errResp
+		}
+		return credentials.Value{}, errors.New(resp.Status)
+	}
+
+	var result credentials.AssumeRoleResponse
+	if e = xml.Unmarshal(buf, &result); e != nil {
+		return credentials.Value{}, e
+	}
+	creds := result.Result.Credentials
+	return credentials.Value{
+		AccessKeyID:     creds.AccessKey,
+		SecretAccessKey: creds.SecretKey,
+		SessionToken:    creds.SessionToken,
+		Expiration:      creds.Expiration,
+		SignerType:      credentials.SignatureV4,
+	}, nil
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+func TestCredentialProviderValidate(t *testing.T) {
+	testCases := []struct {
+		provider aliasCredentialProvider
+		fail     bool
+	}{
+		{aliasCredentialProvider{Type: credentialProviderProcess, Process: "get-creds"}, false},
+		{aliasCredentialProvider{Type: credentialProviderProcess}, true},
+		{aliasCredentialProvider{Type: credentialProviderProfile}, false},
+		{aliasCredentialProvider{Type: credentialProviderAssumeRole, RoleARN: "arn:aws:iam::1:role/r"}, false},
+		{aliasCredentialProvider{Type: credentialProviderAssumeRole}, true},
+		{aliasCredentialProvider{Type: credentialProviderAssumeRole, RoleARN: "r", STSEndpoint: "sts"}, true},
+		{aliasCredentialProvider{Type: "sso"}, true},
+	}
+	for i, testCase := range testCases {
+		if err := testCase.provider.validate(); testCase.fail != (err != nil) {
+			t.Fatalf("Test %d: expected failure %t, got %v", i+1, testCase.fail, err)
+		}
+	}
+}
+
+func TestCachedCredentials(t *testing.T) {
+	cacheFile := filepath.Join(t.TempDir(), "creds.json")
+	expiration := time.Now().Add(time.Hour).Round(time.Second)
+
+	calls := 0
+	retrieve := func() (credentials.Value, error) {
+		calls++
+		return credentials.Value{AccessKeyID: "minio", SecretAccessKey: "minio123", Expiration: expiration}, nil
+	}
+
+	first := &cachedCredentials{alias: "myminio", cacheFile: cacheFile, retrieve: retrieve}
+	if !first.IsExpired() {
+		t.Fatal("Expected credentials to be retrieved first")
+	}
+	if _, e := first.Retrieve(); e != nil {
+		t.Fatal(e)
+	}
+	if first.IsExpired() {
+		t.Fatal("Expected credentials not to be expired")
+	}
+
+	// Another mc invocation reads the cached credentials.
+	second := &cachedCredentials{alias: "myminio", cacheFile: cacheFile, retrieve: retrieve}
+	value, e := second.Retrieve()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if calls != 1 || value.AccessKeyID != "minio" || !value.Expiration.Equal(expiration) {
+		t.Fatalf("Expected cached credentials, got %+v after %d calls", value, calls)
+	}
+	if fi, e := os.Stat(cacheFile); e != nil || (runtime.GOOS != "windows" && fi.Mode().Perm() != 0o600) {
+		t.Fatalf("Expected private cache file, got %v", e)
+	}
+}
+
+func TestCredentialProviderCacheKey(t *testing.T) {
+	config := func(accessKey string, provider *aliasCredentialProvider) *Config {
+		return &Config{Alias: "role", HostURL: "https://s3.amazonaws.com", AccessKey: accessKey, SecretKey: "secret", CredentialProvider: provider}
+	}
+	role := &aliasCredentialProvider{Type: credentialProviderAssumeRole, RoleARN: "arn:aws:iam::1:role/r"}
+	profileRole := &aliasCredentialProvider{Type: credentialProviderAssumeRole, RoleARN: "arn:aws:iam::1:role/r", Profile: "dev"}
+	process := &aliasCredentialProvider{Type: credentialProviderProcess, Process: "get-creds"}
+
+	testCases := []struct {
+		first, second *Config
+		same          bool
+	}{
+		// The static keys are the source credentials of the role.
+		{config("key1", role), config("key2", role), false},
+		{config("key1", role), config("key1", role), true},
+		// The static keys are not used by these providers.
+		{config("key1", profileRole), config("key2", profileRole), true},
+		{config("key1", process), config("key2", process), true},
+		{config("key1", role), config("key1", profileRole), false},
+	}
+	for i, testCase := range testCases {
+		first := testCase.first.CredentialProvider.cacheKey(testCase.first)
+		second := testCase.second.CredentialProvider.cacheKey(testCase.second)
+		if (first == second) != testCase.same {
+			t.Fatalf("Test %d: expected same cache key %t, got %s and %s", i+1, testCase.same, first, second)
+		}
+	}
+}
+
+func TestCachedCredentialsFailure(t *testing.T) {
+	cached := &cachedCredentials{alias: "myminio", retrieve: func() (credentials.Value, error) {
+		return credentials.Value{}, errors.New("process failed")
+	}}
+	_, e := cached.Retrieve()
+	if e == nil || !strings.Contains(e.Error(), "`myminio`") || !strings.Contains(e.Error(), "process failed") {
+		t.Fatalf("Expected the failure of the alias to be returned, got %v", e)
+	}
+	if !cached.IsExpired() {
+		t.Fatal("Expected credentials to be retrieved again after a failure")
+	}
+}
+
+func TestRunCredentialProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential process test uses a shell script")
+	}
+
+	process := filepath.Join(t.TempDir(), "get-creds")
+	script := `#!/bin/sh
+echo '{"Version": 1, "AccessKeyId": "minio", "SecretAccessKey": "minio123", "SessionToken": "token", "Expiration": "2030-01-01T00:00:00Z"}'
+`
+	if e := os.WriteFile(process, []byte(script), 0o700); e != nil {
+		t.Fatal(e)
+	}
+
+	value, e := runCredentialProcess(process + " --role s3")
+	if e != nil {
+		t.Fatal(e)
+	}
+	if value.AccessKeyID != "minio" || value.SecretAccessKey != "minio123" || value.SessionToken != "token" {
+		t.Fatalf("Unexpected credentials %+v", value)
+	}
+	if value.Expiration.Year() != 2030 {
+		t.Fatalf("Unexpected expiration %s", value.Expiration)
+	}
+}
+
+func TestAssumeRoleFailure(t *testing.T) {
+	defer setMcConfigDir(mcCustomConfigDir)
+	setMcConfigDir(t.TempDir())
+	t.Setenv("MC_REGION", "")
+	t.Setenv("AWS_REGION", "")
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<ErrorResponse><Error><Code>AccessDenied</Code><Message>Not allowed</Message></Error></ErrorResponse>"))
+	}))
+	defer server.Close()
+
+	config := &Config{
+		Alias:     "role",
+		HostURL:   server.URL,
+		AccessKey: "minio",
+		SecretKey: "minio123",
+		CredentialProvider: &aliasCredentialProvider{
+			Type:        credentialProviderAssumeRole,
+			RoleARN:     "arn:aws:iam::1:role/r",
+			STSEndpoint: server.URL,
+		},
+	}
+	creds, err := newAliasCredentials(config, http.DefaultTransport)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The static keys of the alias are not used in place of the role.
+	if value, e := creds.Get(); e == nil {
+		t.Fatalf("Expected the assume role to fail, got %+v", value)
+	}
+	if !strings.Contains(authorization, "/us-east-1/sts/aws4_request") {
+		t.Fatalf("Expected a request signed for us-east-1, got %q", authorization)
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/env"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/term"
+)
+
+const (
+	// Secrets are sealed with a key derived from a passphrase.
+	credentialStorePassphrase = "passphrase"
+	// Secrets are kept by an external credential helper.
+	credentialStoreHelperPrefix = "helper:"
+
+	// Credential helpers are executables named mc-credential-NAME.
+	credentialHelperPrefix = "mc-credential-"
+
+	mcEnvCredentialPassphrase = "MC_CREDENTIAL_PASSPHRASE"
+
+	credentialSaltLen = 32
+	credentialScryptN = 1 << 15
+	credentialScryptR = 8
+	credentialScryptP = 1
+)
+
+// credentialStoreFlag - where to keep the secrets of an alias.
+var credentialStoreFlag = cli.StringFlag{
+	Name:  "store",
+	Usage: "keep the secret key encrypted with a 'passphrase' or in a credential helper 'helper:NAME'",
+}
+
+var validHelperName = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9._-]*$")
+
+// aliasSecrets - the secrets of an alias kept out of config.json.
+type aliasSecrets struct {
+	SecretKey    string `json:"secretKey"`
+	SessionToken string `json:"sessionToken,omitempty"`
+}
+
+// Secrets already read from their store, so that the passphrase is
+// asked and helpers are run only once per alias.
+var (
+	unsealedSecretsMu sync.Mutex
+	unsealedSecrets   = make(map[string]aliasSecrets)
+	cachedPassphrase  string
+)
+
+// isValidCredentialStore - returns true for an empty (plaintext),
+// passphrase or helper:NAME store.
+func isValidCredentialStore(store string) bool {
+	switch {
+	case store == "", store == credentialStorePassphrase:
+		return true
+	case strings.HasPrefix(store, credentialStoreHelperPrefix):
+		name := strings.TrimPrefix(store, credentialStoreHelperPrefix)
+		return validHelperName.MatchString(name) || filepath.IsAbs(name)
+	}
+	return false
+}
+
+// sealAliasCredentials - moves the secrets of an alias to the store,
+// only a reference to them is kept in the alias config.
+func sealAliasCredentials(alias string, aliasCfg *aliasConfigV11, store string) *probe.Error {
+	if !isValidCredentialStore(store) {
+		return errInvalidCredentialStore(store)
+	}
+
+	secrets := aliasSecrets{SecretKey: aliasCfg.SecretKey, SessionToken: aliasCfg.SessionToken}
+	switch {
+	case store == "":
+		aliasCfg.SealedCredentials = ""
+	case store == credentialStorePassphrase:
+		passphrase, err := credentialPassphrase(true)
+		if err != nil {
+			return err.Trace(alias)
+		}
+		sealed, err := sealSecrets(passphrase, aliasCfg.AccessKey, secrets)
+		if err != nil {
+			return err.Trace(alias)
+		}
+		aliasCfg.SealedCredentials = sealed
+		aliasCfg.SecretKey, aliasCfg.SessionToken = "", ""
+	default:
+		helper := strings.TrimPrefix(store, credentialStoreHelperPrefix)
+		if _, err := runCredentialHelper(helper, "store", alias, aliasCfg, &secrets); err != nil {
+			return err.Trace(alias, helper)
+		}
+		aliasCfg.SealedCredentials = ""
+		aliasCfg.SecretKey, aliasCfg.SessionToken = "", ""
+	}
+	aliasCfg.CredentialStore = store
+	return nil
+}
+
+// unsealAliasCredentials - fills in the secrets of an alias from its
+// store, the alias config is turned into its plaintext form.
+func unsealAliasCredentials(alias string, aliasCfg *aliasConfigV11) *probe.Error {
+	store := aliasCfg.CredentialStore
+	if store == "" {
+		return nil
+	}
+
+	unsealedSecretsMu.Lock()
+	defer unsealedSecretsMu.Unlock()
+
+	cacheKey := alias + "/" + aliasCfg.AccessKey
+	secrets, ok := unsealedSecrets[cacheKey]
+	if !ok {
+		switch {
+		case store == credentialStorePassphrase:
+			passphrase, err := credentialPassphrase(false)
+			if err != nil {
+				return err.Trace(alias)
+			}
+			if secrets, err = unsealSecrets(passphrase, aliasCfg.AccessKey, aliasCfg.SealedCredentials); err != nil {
+				// Do not keep a wrong passphrase.
+				cachedPassphrase = ""
+				return errCredentialUnseal(alias)
+			}
+		case isValidCredentialStore(store):
+			helper := strings.TrimPrefix(store, credentialStoreHelperPrefix)
+			var err *probe.Error
+			if secrets, err = runCredentialHelper(helper, "get", alias, aliasCfg, nil); err != nil {
+				return err.Trace(alias, helper)
+			}
+		default:
+			return errInvalidCredentialStore(store)
+		}
+		unsealedSecrets[cacheKey] = secrets
+	}
+
+	aliasCfg.SecretKey, aliasCfg.SessionToken = secrets.SecretKey, secrets.SessionToken
+	aliasCfg.CredentialStore, aliasCfg.SealedCredentials = "", ""
+	return nil
+}
+
+// eraseAliasCredentials - asks the credential helper of an alias to
+// forget its secrets, sealed secrets go away with the alias config.
+func eraseAliasCredentials(alias string, aliasCfg *aliasConfigV11) *probe.Error {
+	if !strings.HasPrefix(aliasCfg.CredentialStore, credentialStoreHelperPrefix) {
+		return nil
+	}
+	helper := strings.TrimPrefix(aliasCfg.CredentialStore, credentialStoreHelperPrefix)
+	_, err := runCredentialHelper(helper, "erase", alias, aliasCfg, nil)
+	return err
+}
+
+// credentialPassphrase - returns the passphrase from the environment,
+// or prompts for it on a terminal.
+func credentialPassphrase(confirm bool) (string, *probe.Error) {
+	if passphrase := env.Get(mcEnvCredentialPassphrase, ""); passphrase != "" {
+		return passphrase, nil
+	}
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		return "", errCredentialPassphrase()
+	}
+
+	readPassphrase := func(prompt string) string {
+		fmt.Fprint(os.Stderr, prompt)
+		passphrase, _ := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Fprintln(os.Stderr)
+		return string(passphrase)
+	}
+
+	passphrase := readPassphrase("Enter credential passphrase: ")
+	if passphrase == "" {
+		return "", errCredentialPassphrase()
+	}
+	if confirm && readPassphrase("Confirm credential passphrase: ") != passphrase {
+		return "", probe.NewError(errors.New("passphrases do not match"))
+	}
+	cachedPassphrase = passphrase
+	return passphrase, nil
+}
+
+// credentialKey - derives the sealing key from the passphrase.
+func credentialKey(passphrase string, salt []byte) (cipher.AEAD, *probe.Error) {
+	key, e := scrypt.Key([]byte(passphrase), salt, credentialScryptN, credentialScryptR, credentialScryptP, 32)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return aead, nil
+}
+
+// sealSecrets - encrypts the secrets with AES-GCM, the access key is
+// authenticated along so that sealed secrets cannot be moved to another
+// access key. The result is base64(salt | nonce | ciphertext).
+func sealSecrets(passphrase, accessKey string, secrets aliasSecrets) (string, *probe.Error) {
+	plaintext, e := json.Marshal(secrets)
+	if e != nil {
+		return "", probe.NewError(e)
+	}
+
+	salt := make([]byte, credentialSaltLen)
+	if _, e = io.ReadFull(rand.Reader, salt); e != nil {
+		return "", probe.NewError(e)
+	}
+	aead, err := credentialKey(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, e = io.ReadFull(rand.Reader, nonce); e != nil {
+		return "", probe.NewError(e)
+	}
+
+	sealed := append(salt, nonce...)
+	sealed = aead.Seal(sealed, nonce, plaintext, []byte(accessKey))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unsealSecrets - decrypts secrets sealed by sealSecrets.
+func unsealSecrets(passphrase, accessKey, sealed string) (aliasSecrets, *probe.Error) {
+	var secrets aliasSecrets
+	data, e := base64.StdEncoding.DecodeString(sealed)
+	if e != nil {
+		return secrets, probe.NewError(e)
+	}
+	if len(data) < credentialSaltLen {
+		return secrets, errInvalidArgument().Trace(sealed)
+	}
+	aead, err := credentialKey(passphrase, data[:credentialSaltLen])
+	if err != nil {
+		return secrets, err
+	}
+	data = data[credentialSaltLen:]
+	if len(data) < aead.NonceSize() {
+		return secrets, errInvalidArgument().Trace(sealed)
+	}
+	plaintext, e := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(accessKey))
+	if e != nil {
+		return secrets, probe.NewError(e)
+	}
+	if e = json.Unmarshal(plaintext, &secrets); e != nil {
+		return secrets, probe.NewError(e)
+	}
+	return secrets, nil
+}
+
+// runCredentialHelper - runs 'mc-credential-NAME ACTION' the way git runs
+// its credential helpers. The alias is described on stdin with key=value
+// lines ended by a blank line, 'store' also receives the secrets and
+// 'get' prints them back as key=value lines.
+func runCredentialHelper(helper, action, alias string, aliasCfg *aliasConfigV11, secrets *aliasSecrets) (aliasSecrets, *probe.Error) {
+	path := helper
+	if !filepath.IsAbs(helper) {
+		path = credentialHelperPrefix + helper
+	}
+
+	var input bytes.Buffer
+	fmt.Fprintf(&input, "alias=%s\nurl=%s\naccessKey=%s\n", alias, aliasCfg.URL, aliasCfg.AccessKey)
+	if secrets != nil {
+		fmt.Fprintf(&input, "secretKey=%s\n", secrets.SecretKey)
+		if secrets.SessionToken != "" {
+			fmt.Fprintf(&input, "sessionToken=%s\n", secrets.SessionToken)
+		}
+	}
+	input.WriteString("\n")
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(globalContext, path, action)
+	cmd.Stdin = &input
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	if e := cmd.Run(); e != nil {
+		return aliasSecrets{}, probe.NewError(e).Trace(path, action)
+	}
+	if action != "get" {
+		return aliasSecrets{}, nil
+	}
+
+	var result aliasSecrets
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "secretKey":
+			result.SecretKey = value
+		case "sessionToken":
+			result.SessionToken = value
+		}
+	}
+	if result.SecretKey == "" {
+		return result, probe.NewError(errors.New("credential helper returned no secret key")).Trace(path)
+	}
+	return result, nil
+}
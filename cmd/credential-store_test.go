@@ -0,0 +1,104 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSealSecrets(t *testing.T) {
+	secrets := aliasSecrets{SecretKey: "minio123", SessionToken: "token"}
+	sealed, err := sealSecrets("passphrase", "minio", secrets)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := unsealSecrets("passphrase", "minio", sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != secrets {
+		t.Fatalf("Expected %v, got %v", secrets, got)
+	}
+	if _, err = unsealSecrets("wrong", "minio", sealed); err == nil {
+		t.Fatal("Expected wrong passphrase to fail")
+	}
+	if _, err = unsealSecrets("passphrase", "other", sealed); err == nil {
+		t.Fatal("Expected another access key to fail")
+	}
+}
+
+func TestAliasCredentialStore(t *testing.T) {
+	t.Setenv(mcEnvCredentialPassphrase, "passphrase")
+
+	aliasCfg := aliasConfigV11{URL: "http://localhost:9000", AccessKey: "minio", SecretKey: "minio123"}
+	if err := sealAliasCredentials("sealed", &aliasCfg, credentialStorePassphrase); err != nil {
+		t.Fatal(err)
+	}
+	if aliasCfg.SecretKey != "" || aliasCfg.SealedCredentials == "" {
+		t.Fatalf("Expected secret key to be sealed, got %+v", aliasCfg)
+	}
+	if err := unsealAliasCredentials("sealed", &aliasCfg); err != nil {
+		t.Fatal(err)
+	}
+	if aliasCfg.SecretKey != "minio123" || aliasCfg.CredentialStore != "" {
+		t.Fatalf("Expected secret key to be unsealed, got %+v", aliasCfg)
+	}
+
+	if err := sealAliasCredentials("invalid", &aliasCfg, "helper:../x"); err == nil {
+		t.Fatal("Expected invalid helper name to fail")
+	}
+}
+
+func TestAliasCredentialHelper(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("credential helper test uses a shell script")
+	}
+
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "helper")
+	script := "#!/bin/sh\ncase \"$1\" in\nstore) cat > " + filepath.Join(dir, "stored") + " ;;\nget) echo secretKey=minio123 ;;\nesac\n"
+	if e := os.WriteFile(helper, []byte(script), 0o700); e != nil {
+		t.Fatal(e)
+	}
+
+	aliasCfg := aliasConfigV11{URL: "http://localhost:9000", AccessKey: "minio", SecretKey: "minio123"}
+	if err := sealAliasCredentials("helped", &aliasCfg, credentialStoreHelperPrefix+helper); err != nil {
+		t.Fatal(err)
+	}
+	if aliasCfg.SecretKey != "" || aliasCfg.CredentialStore != credentialStoreHelperPrefix+helper {
+		t.Fatalf("Expected only a helper reference, got %+v", aliasCfg)
+	}
+	stored, e := os.ReadFile(filepath.Join(dir, "stored"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if string(stored) != "alias=helped\nurl=http://localhost:9000\naccessKey=minio\nsecretKey=minio123\n\n" {
+		t.Fatalf("Unexpected helper input %q", stored)
+	}
+
+	if err := unsealAliasCredentials("helped", &aliasCfg); err != nil {
+		t.Fatal(err)
+	}
+	if aliasCfg.SecretKey != "minio123" {
+		t.Fatalf("Expected secret key from helper, got %+v", aliasCfg)
+	}
+}
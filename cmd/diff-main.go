@@ -32,7 +32,9 @@ import (
 
 // diff specific flags.
 var (
-	diffFlags = []cli.Flag{}
+	diffFlags = []cli.Flag{
+		compareFlag,
+	}
 )
 
 // Compute differences in object name, size, and date between two buckets.
@@ -53,12 +55,14 @@ FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
 DESCRIPTION:
-  Diff only calculates differences in object name, size and time. It *DOES NOT* compare objects' contents.
+  Diff calculates differences in object name, size and time. It *DOES NOT* compare objects' contents
+  unless '--compare' is set, objects of the same size are then compared by their ETag, their
+  additional checksum or their content, in which case the data of both objects is read.
 
 LEGEND:
   < - object is only in source.
   > - object is only in destination.
-  ! - newer object is in source.
+  ! - newer object is in source, or object differs.
 
 EXAMPLES:
   1. Compare a local folder with a folder on Amazon S3 cloud storage.
@@ -66,6 +70,12 @@ EXAMPLES:
 
   2. Compare two folders on a local filesystem.
      {{.Prompt}} {{.HelpName}} ~/Photos /Media/Backup/Photos
+
+  3. Compare a local folder with a folder on Amazon S3 cloud storage by the MD5 sum of the files.
+     {{.Prompt}} {{.HelpName}} --compare etag ~/Photos s3/mybucket/Photos
+
+  4. Find objects of the same size with different content between two buckets.
+     {{.Prompt}} {{.HelpName}} --compare content s3/mybucket play/mybucket
 `,
 }
 
@@ -96,6 +106,8 @@ func (d diffMessage) String() string {
 		msg = console.Colorize("DiffMetadata", "! "+d.SecondURL)
 	case differInAASourceMTime:
 		msg = console.Colorize("DiffMMSourceMTime", "! "+d.SecondURL)
+	case differInContent:
+		msg = console.Colorize("DiffContent", "! "+d.SecondURL)
 	case differInNone:
 		msg = console.Colorize("DiffInNone", "= "+d.FirstURL)
 	default:
@@ -156,7 +168,7 @@ func checkDiffSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[stri
 }
 
 // doDiffMain runs the diff.
-func doDiffMain(ctx context.Context, firstURL, secondURL string) error {
+func doDiffMain(ctx context.Context, firstURL, secondURL string, compare compareMode, encKeyDB map[string][]prefixSSEPair) error {
 	// Source and targets are always directories
 	sourceSeparator := string(newClientURL(firstURL).Separator)
 	if !strings.HasSuffix(firstURL, sourceSeparator) {
@@ -184,7 +196,8 @@ func doDiffMain(ctx context.Context, firstURL, secondURL string) error {
 	}
 
 	// Diff first and second urls.
-	for diffMsg := range objectDifference(ctx, firstClient, secondClient, true) {
+	cmp := newContentComparer(compare, firstAlias, secondAlias, encKeyDB)
+	for diffMsg := range objectDifference(ctx, firstClient, secondClient, true, cmp) {
 		if diffMsg.Error != nil {
 			errorIf(diffMsg.Error, "Unable to calculate objects difference.")
 			// Ignore error and proceed to next object.
@@ -207,6 +220,7 @@ func mainDiff(cliCtx *cli.Context) error {
 
 	// check 'diff' cli arguments.
 	checkDiffSyntax(ctx, cliCtx, encKeyDB)
+	compare := mustParseCompareFlag(cliCtx)
 
 	// Additional command specific theme customization.
 	console.SetColor("DiffMessage", color.New(color.FgGreen, color.Bold))
@@ -216,10 +230,11 @@ func mainDiff(cliCtx *cli.Context) error {
 	console.SetColor("DiffSize", color.New(color.FgYellow, color.Bold))
 	console.SetColor("DiffMetadata", color.New(color.FgYellow, color.Bold))
 	console.SetColor("DiffMMSourceMTime", color.New(color.FgYellow, color.Bold))
+	console.SetColor("DiffContent", color.New(color.FgYellow, color.Bold))
 
 	URLs := cliCtx.Args()
 	firstURL := URLs.Get(0)
 	secondURL := URLs.Get(1)
 
-	return doDiffMain(ctx, firstURL, secondURL)
+	return doDiffMain(ctx, firstURL, secondURL, compare, encKeyDB)
 }
@@ -0,0 +1,264 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+)
+
+// compareMode - how objects of the same name and size are compared.
+type compareMode string
+
+const (
+	compareDefault  compareMode = ""         // size and modtime
+	compareETag     compareMode = "etag"     // etag, local md5 for files
+	compareChecksum compareMode = "checksum" // additional S3 checksums
+	compareContent  compareMode = "content"  // hash of the data on both sides
+)
+
+// compareFlag - select how objects of the same size are compared.
+var compareFlag = cli.StringFlag{
+	Name:  "compare",
+	Usage: "compare objects of the same size by their 'etag', 'checksum' or 'content'",
+}
+
+// parseCompareMode - parses the value of '--compare'.
+func parseCompareMode(mode string) (compareMode, *probe.Error) {
+	switch m := compareMode(strings.ToLower(mode)); m {
+	case compareDefault, compareETag, compareChecksum, compareContent:
+		return m, nil
+	}
+	return compareDefault, errInvalidArgument().Trace(mode)
+}
+
+// mustParseCompareFlag - parses '--compare' or dies.
+func mustParseCompareFlag(ctx *cli.Context) compareMode {
+	mode, err := parseCompareMode(ctx.String("compare"))
+	fatalIf(err, "Invalid compare mode, valid values are etag, checksum and content.")
+	return mode
+}
+
+// contentComparer - compares the data of two objects of the same size,
+// objects are opened through their aliases to reuse the configured
+// credentials.
+type contentComparer struct {
+	mode                     compareMode
+	sourceAlias, targetAlias string
+	encKeyDB                 map[string][]prefixSSEPair
+}
+
+// newContentComparer - returns a comparer for the mode, or nil if objects
+// should only be compared by size and modtime.
+func newContentComparer(mode compareMode, sourceAlias, targetAlias string, encKeyDB map[string][]prefixSSEPair) *contentComparer {
+	if mode == compareDefault {
+		return nil
+	}
+	return &contentComparer{
+		mode:        mode,
+		sourceAlias: sourceAlias,
+		targetAlias: targetAlias,
+		encKeyDB:    encKeyDB,
+	}
+}
+
+// equal - returns true if both objects hold the same data.
+func (c *contentComparer) equal(ctx context.Context, src, tgt *ClientContent) (bool, *probe.Error) {
+	switch c.mode {
+	case compareETag:
+		return c.etagEqual(ctx, src, tgt)
+	case compareChecksum:
+		return c.checksumEqual(ctx, src, tgt)
+	}
+	return c.contentEqual(ctx, src, tgt)
+}
+
+// etagEqual - compares ETags, files are represented by their MD5 sum.
+// Multipart and encrypted ETags are not a MD5 of the data, when they do
+// not match the data itself is compared.
+func (c *contentComparer) etagEqual(ctx context.Context, src, tgt *ClientContent) (bool, *probe.Error) {
+	srcETag, err := c.etag(ctx, c.sourceAlias, src)
+	if err != nil {
+		return false, err.Trace(src.URL.String())
+	}
+	tgtETag, err := c.etag(ctx, c.targetAlias, tgt)
+	if err != nil {
+		return false, err.Trace(tgt.URL.String())
+	}
+	if srcETag == tgtETag {
+		return true, nil
+	}
+	if isMD5ETag(srcETag) && isMD5ETag(tgtETag) {
+		return false, nil
+	}
+	return c.contentEqual(ctx, src, tgt)
+}
+
+// etag - returns the ETag of an object, or the MD5 sum of a file.
+func (c *contentComparer) etag(ctx context.Context, alias string, content *ClientContent) (string, *probe.Error) {
+	if content.URL.Type == fileSystem {
+		sum, err := c.hash(ctx, alias, content, md5.New())
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(sum), nil
+	}
+	return strings.ToLower(strings.Trim(content.ETag, "\"")), nil
+}
+
+// isMD5ETag - returns true if the ETag looks like a plain MD5 sum.
+func isMD5ETag(etag string) bool {
+	if len(etag) != 2*md5.Size {
+		return false
+	}
+	_, e := hex.DecodeString(etag)
+	return e == nil
+}
+
+// checksumEqual - compares the additional checksums stored with the
+// objects, files are checksummed locally with the algorithm of the other
+// side. The data is compared when no usable checksum is found.
+func (c *contentComparer) checksumEqual(ctx context.Context, src, tgt *ClientContent) (bool, *probe.Error) {
+	srcSums, err := c.checksums(ctx, c.sourceAlias, src)
+	if err != nil {
+		return false, err.Trace(src.URL.String())
+	}
+	tgtSums, err := c.checksums(ctx, c.targetAlias, tgt)
+	if err != nil {
+		return false, err.Trace(tgt.URL.String())
+	}
+
+	for _, algo := range checksumTypes {
+		srcSum, tgtSum := srcSums[algo], tgtSums[algo]
+		switch {
+		case srcSum != "" && tgtSum != "":
+			if srcSum == tgtSum {
+				return true, nil
+			}
+			if !isCompositeChecksum(srcSum) && !isCompositeChecksum(tgtSum) {
+				return false, nil
+			}
+		case srcSum != "" && tgt.URL.Type == fileSystem && !isCompositeChecksum(srcSum):
+			sum, err := c.hash(ctx, c.targetAlias, tgt, algo.Hasher())
+			if err != nil {
+				return false, err.Trace(tgt.URL.String())
+			}
+			return base64.StdEncoding.EncodeToString(sum) == srcSum, nil
+		case tgtSum != "" && src.URL.Type == fileSystem && !isCompositeChecksum(tgtSum):
+			sum, err := c.hash(ctx, c.sourceAlias, src, algo.Hasher())
+			if err != nil {
+				return false, err.Trace(src.URL.String())
+			}
+			return base64.StdEncoding.EncodeToString(sum) == tgtSum, nil
+		}
+	}
+	return c.contentEqual(ctx, src, tgt)
+}
+
+// checksums - returns the additional checksums of an object.
+func (c *contentComparer) checksums(ctx context.Context, alias string, content *ClientContent) (map[minio.ChecksumType]string, *probe.Error) {
+	if content.URL.Type == fileSystem {
+		return nil, nil
+	}
+	if content.Checksum != nil {
+		return content.Checksum, nil
+	}
+	clnt, err := c.client(alias, content)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := clnt.Stat(ctx, StatOptions{sse: c.sse(alias, content), versionID: content.VersionID, checksum: true})
+	if err != nil {
+		return nil, err
+	}
+	return stat.Checksum, nil
+}
+
+// isCompositeChecksum - returns true for checksums of multipart objects,
+// which depend on the part size used to upload the object.
+func isCompositeChecksum(sum string) bool {
+	return strings.Contains(sum, "-")
+}
+
+// contentEqual - hashes the data of both objects and compares the sums.
+func (c *contentComparer) contentEqual(ctx context.Context, src, tgt *ClientContent) (bool, *probe.Error) {
+	var tgtSum []byte
+	var tgtErr *probe.Error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tgtSum, tgtErr = c.hash(ctx, c.targetAlias, tgt, sha256.New())
+	}()
+
+	srcSum, err := c.hash(ctx, c.sourceAlias, src, sha256.New())
+	<-done
+	if err != nil {
+		return false, err.Trace(src.URL.String())
+	}
+	if tgtErr != nil {
+		return false, tgtErr.Trace(tgt.URL.String())
+	}
+	return bytes.Equal(srcSum, tgtSum), nil
+}
+
+// hash - streams an object through the hash and returns its sum.
+func (c *contentComparer) hash(ctx context.Context, alias string, content *ClientContent, h hash.Hash) ([]byte, *probe.Error) {
+	clnt, err := c.client(alias, content)
+	if err != nil {
+		return nil, err
+	}
+	reader, _, err := clnt.Get(ctx, GetOptions{SSE: c.sse(alias, content), VersionID: content.VersionID})
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	if _, e := io.Copy(h, reader); e != nil {
+		return nil, probe.NewError(e)
+	}
+	return h.Sum(nil), nil
+}
+
+// client - returns a client for an object.
+func (c *contentComparer) client(alias string, content *ClientContent) (Client, *probe.Error) {
+	if content.URL.Type == fileSystem {
+		return fsNew(content.URL.Path)
+	}
+	return newClientFromAlias(alias, content.URL.String())
+}
+
+// sse - returns the encryption key configured for an object.
+func (c *contentComparer) sse(alias string, content *ClientContent) encrypt.ServerSide {
+	if alias == "" {
+		return nil
+	}
+	return getSSE(filepath.ToSlash(filepath.Join(alias, content.URL.Path)), c.encKeyDB[alias])
+}
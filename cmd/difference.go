@@ -42,6 +42,7 @@ const (
 	differInFirst                    // only in source (FIRST)
 	differInSecond                   // only in target (SECOND)
 	differInAASourceMTime            // differs in active-active source modtime
+	differInContent                  // differs in content, same size
 )
 
 func (d differType) String() string {
@@ -54,6 +55,8 @@ func (d differType) String() string {
 		return "metadata"
 	case differInAASourceMTime:
 		return "mm-source-mtime"
+	case differInContent:
+		return "content"
 	case differInType:
 		return "type"
 	case differInFirst:
@@ -161,14 +164,14 @@ func metadataEqual(m1, m2 map[string]string) bool {
 	return true
 }
 
-func objectDifference(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool) (diffCh chan diffMessage) {
+func objectDifference(ctx context.Context, sourceClnt, targetClnt Client, isMetadata bool, cmp *contentComparer) (diffCh chan diffMessage) {
 	sourceURL := sourceClnt.GetURL().String()
 	sourceCh := sourceClnt.List(ctx, ListOptions{Recursive: true, WithMetadata: isMetadata, ShowDir: DirNone})
 
 	targetURL := targetClnt.GetURL().String()
 	targetCh := targetClnt.List(ctx, ListOptions{Recursive: true, WithMetadata: isMetadata, ShowDir: DirNone})
 
-	return difference(sourceURL, sourceCh, targetURL, targetCh, isMetadata, false)
+	return difference(ctx, sourceURL, sourceCh, targetURL, targetCh, isMetadata, false, cmp)
 }
 
 func bucketDifference(ctx context.Context, sourceClnt, targetClnt Client) (diffCh chan diffMessage) {
@@ -215,11 +218,59 @@ func bucketDifference(ctx context.Context, sourceClnt, targetClnt Client) (diffC
 		}
 	}()
 
-	return difference(sourceURL, sourceCh, targetURL, targetCh, false, false)
+	return difference(ctx, sourceURL, sourceCh, targetURL, targetCh, false, false, nil)
 }
 
-func differenceInternal(sourceURL string, srcCh <-chan *ClientContent, targetURL string, tgtCh <-chan *ClientContent,
-	cmpMetadata, returnSimilar bool, diffCh chan<- diffMessage,
+// contentCompareWorkers - maximum number of objects compared by their
+// data at the same time.
+const contentCompareWorkers = 16
+
+// diffSender - sends the messages of a difference in the order of the
+// listings, a message waiting on the comparison of two objects does not
+// hold back the merge of the listings. The number of pending messages,
+// hence of running comparisons, is bounded by the queue.
+type diffSender struct {
+	pending chan chan diffMessage
+}
+
+func newDiffSender(workers int) *diffSender {
+	return &diffSender{pending: make(chan chan diffMessage, workers)}
+}
+
+// send - queues a message.
+func (s *diffSender) send(msg diffMessage) {
+	ch := make(chan diffMessage, 1)
+	ch <- msg
+	close(ch)
+	s.pending <- ch
+}
+
+// sendLater - queues the message returned by compare, it is dropped
+// when compare returns false.
+func (s *diffSender) sendLater(compare func() (diffMessage, bool)) {
+	ch := make(chan diffMessage, 1)
+	s.pending <- ch
+	go func() {
+		defer close(ch)
+		if msg, ok := compare(); ok {
+			ch <- msg
+		}
+	}()
+}
+
+// run - sends the queued messages in order to diffCh until the queue is
+// closed, diffCh is closed then.
+func (s *diffSender) run(diffCh chan<- diffMessage) {
+	defer close(diffCh)
+	for ch := range s.pending {
+		if msg, ok := <-ch; ok {
+			diffCh <- msg
+		}
+	}
+}
+
+func differenceInternal(ctx context.Context, sourceURL string, srcCh <-chan *ClientContent, targetURL string, tgtCh <-chan *ClientContent,
+	cmpMetadata, returnSimilar bool, cmp *contentComparer, out *diffSender,
 ) *probe.Error {
 	// Pop first entries from the source and targets
 	srcCtnt, srcOk := <-srcCh
@@ -246,22 +297,22 @@ func differenceInternal(sourceURL string, srcCh <-chan *ClientContent, targetURL
 
 		// If source doesn't have objects anymore, comparison becomes obvious
 		if srcEOF {
-			diffCh <- diffMessage{
+			out.send(diffMessage{
 				SecondURL:     tgtCtnt.URL.String(),
 				Diff:          differInSecond,
 				secondContent: tgtCtnt,
-			}
+			})
 			tgtCtnt, tgtOk = <-tgtCh
 			continue
 		}
 
 		// The same for target
 		if tgtEOF {
-			diffCh <- diffMessage{
+			out.send(diffMessage{
 				FirstURL:     srcCtnt.URL.String(),
 				Diff:         differInFirst,
 				firstContent: srcCtnt,
-			}
+			})
 			srcCtnt, srcOk = <-srcCh
 			continue
 		}
@@ -274,13 +325,13 @@ func differenceInternal(sourceURL string, srcCh <-chan *ClientContent, targetURL
 
 		if !utf8.ValidString(srcSuffix) {
 			// Error. Keys must be valid UTF-8.
-			diffCh <- diffMessage{Error: errInvalidSource(current).Trace()}
+			out.send(diffMessage{Error: errInvalidSource(current).Trace()})
 			srcCtnt, srcOk = <-srcCh
 			continue
 		}
 		if !utf8.ValidString(tgtSuffix) {
 			// Error. Keys must be valid UTF-8.
-			diffCh <- diffMessage{Error: errInvalidTarget(expected).Trace()}
+			out.send(diffMessage{Error: errInvalidTarget(expected).Trace()})
 			tgtCtnt, tgtOk = <-tgtCh
 			continue
 		}
@@ -292,11 +343,11 @@ func differenceInternal(sourceURL string, srcCh <-chan *ClientContent, targetURL
 		normalizedExpected := norm.NFC.String(expected)
 
 		if normalizedExpected > normalizedCurrent {
-			diffCh <- diffMessage{
+			out.send(diffMessage{
 				FirstURL:     srcCtnt.URL.String(),
 				Diff:         differInFirst,
 				firstContent: srcCtnt,
-			}
+			})
 			srcCtnt, srcOk = <-srcCh
 			continue
 		}
@@ -306,66 +357,80 @@ func differenceInternal(sourceURL string, srcCh <-chan *ClientContent, targetURL
 			if srcType.IsRegular() && !tgtType.IsRegular() ||
 				!srcType.IsRegular() && tgtType.IsRegular() {
 				// Type differs. Source is never a directory.
-				diffCh <- diffMessage{
+				out.send(diffMessage{
 					FirstURL:      srcCtnt.URL.String(),
 					SecondURL:     tgtCtnt.URL.String(),
 					Diff:          differInType,
 					firstContent:  srcCtnt,
 					secondContent: tgtCtnt,
-				}
+				})
+				continue
+			}
+			// Compare the data of regular files of the same size, this
+			// replaces the modtime comparison as touched files which are
+			// unchanged must not be reported. Data is compared by the
+			// workers of the sender, while the listings are merged.
+			if cmp != nil && srcSize == tgtSize && srcType.IsRegular() {
+				src, tgt := srcCtnt, tgtCtnt
+				out.sendLater(func() (diffMessage, bool) {
+					same, err := cmp.equal(ctx, src, tgt)
+					if err != nil {
+						return diffMessage{Error: err.Trace(src.URL.String(), tgt.URL.String())}, true
+					}
+					diff := differInNone
+					if !same {
+						// Regular files of the same size differing in content.
+						diff = differInContent
+					} else if cmpMetadata &&
+						!metadataEqual(src.UserMetadata, tgt.UserMetadata) &&
+						!metadataEqual(src.Metadata, tgt.Metadata) {
+						diff = differInMetadata
+					}
+					return diffMessage{
+						FirstURL:      src.URL.String(),
+						SecondURL:     tgt.URL.String(),
+						Diff:          diff,
+						firstContent:  src,
+						secondContent: tgt,
+					}, diff != differInNone || returnSimilar
+				})
+				srcCtnt, srcOk = <-srcCh
+				tgtCtnt, tgtOk = <-tgtCh
 				continue
 			}
+			diff := differInNone
 			if srcSize != tgtSize {
 				// Regular files differing in size.
-				diffCh <- diffMessage{
-					FirstURL:      srcCtnt.URL.String(),
-					SecondURL:     tgtCtnt.URL.String(),
-					Diff:          differInSize,
-					firstContent:  srcCtnt,
-					secondContent: tgtCtnt,
-				}
-			} else if activeActiveModTimeUpdated(srcCtnt, tgtCtnt) {
-				diffCh <- diffMessage{
-					FirstURL:      srcCtnt.URL.String(),
-					SecondURL:     tgtCtnt.URL.String(),
-					Diff:          differInAASourceMTime,
-					firstContent:  srcCtnt,
-					secondContent: tgtCtnt,
-				}
+				diff = differInSize
+			} else if cmp == nil && activeActiveModTimeUpdated(srcCtnt, tgtCtnt) {
+				diff = differInAASourceMTime
 			} else if cmpMetadata &&
 				!metadataEqual(srcCtnt.UserMetadata, tgtCtnt.UserMetadata) &&
 				!metadataEqual(srcCtnt.Metadata, tgtCtnt.Metadata) {
-
 				// Regular files user requesting additional metadata to same file.
-				diffCh <- diffMessage{
-					FirstURL:      srcCtnt.URL.String(),
-					SecondURL:     tgtCtnt.URL.String(),
-					Diff:          differInMetadata,
-					firstContent:  srcCtnt,
-					secondContent: tgtCtnt,
-				}
+				diff = differInMetadata
 			}
 
-			// No differ
-			if returnSimilar {
-				diffCh <- diffMessage{
+			// Similar objects are only sent if requested.
+			if diff != differInNone || returnSimilar {
+				out.send(diffMessage{
 					FirstURL:      srcCtnt.URL.String(),
 					SecondURL:     tgtCtnt.URL.String(),
-					Diff:          differInNone,
+					Diff:          diff,
 					firstContent:  srcCtnt,
 					secondContent: tgtCtnt,
-				}
+				})
 			}
 			srcCtnt, srcOk = <-srcCh
 			tgtCtnt, tgtOk = <-tgtCh
 			continue
 		}
 		// Differ in second
-		diffCh <- diffMessage{
+		out.send(diffMessage{
 			SecondURL:     tgtCtnt.URL.String(),
 			Diff:          differInSecond,
 			secondContent: tgtCtnt,
-		}
+		})
 		tgtCtnt, tgtOk = <-tgtCh
 		continue
 	}
@@ -375,27 +440,29 @@ func differenceInternal(sourceURL string, srcCh <-chan *ClientContent, targetURL
 
 // objectDifference function finds the difference between all objects
 // recursively in sorted order from source and target.
-func difference(sourceURL string, sourceCh <-chan *ClientContent, targetURL string, targetCh <-chan *ClientContent, cmpMetadata, returnSimilar bool) (diffCh chan diffMessage) {
+func difference(ctx context.Context, sourceURL string, sourceCh <-chan *ClientContent, targetURL string, targetCh <-chan *ClientContent, cmpMetadata, returnSimilar bool, cmp *contentComparer) (diffCh chan diffMessage) {
 	diffCh = make(chan diffMessage, 10000)
+	out := newDiffSender(contentCompareWorkers)
+	go out.run(diffCh)
 
 	go func() {
-		defer close(diffCh)
+		defer close(out.pending)
 
-		err := differenceInternal(sourceURL, sourceCh, targetURL, targetCh, cmpMetadata, returnSimilar, diffCh)
+		err := differenceInternal(ctx, sourceURL, sourceCh, targetURL, targetCh, cmpMetadata, returnSimilar, cmp, out)
 		if err != nil {
 			// handle this specifically for filesystem related errors.
 			switch v := err.ToGoError().(type) {
 			case PathNotFound, PathInsufficientPermission, PathNotADirectory:
-				diffCh <- diffMessage{
+				out.send(diffMessage{
 					Error: err,
-				}
+				})
 				return
 			case minio.ErrorResponse:
 				switch v.Code {
 				case "NoSuchBucket", "NoSuchKey":
-					diffCh <- diffMessage{
+					out.send(diffMessage{
 						Error: err,
-					}
+					})
 					return
 				}
 			}
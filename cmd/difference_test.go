@@ -18,7 +18,11 @@
 package cmd
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 var testCases = []struct {
@@ -61,3 +65,78 @@ func TestExcludeOptions(t *testing.T) {
 		}
 	}
 }
+
+func TestObjectDifferenceCompare(t *testing.T) {
+	srcDir, tgtDir := t.TempDir(), t.TempDir()
+	writeFile := func(dir, name, data string, modTime time.Time) {
+		path := filepath.Join(dir, name)
+		if e := os.WriteFile(path, []byte(data), 0o600); e != nil {
+			t.Fatal(e)
+		}
+		if e := os.Chtimes(path, modTime, modTime); e != nil {
+			t.Fatal(e)
+		}
+	}
+	older, newer := time.Now().Add(-time.Hour), time.Now()
+	// Touched on source but unchanged.
+	writeFile(srcDir, "touched", "same data", newer)
+	writeFile(tgtDir, "touched", "same data", older)
+	// Corrupted on target, same size.
+	writeFile(srcDir, "corrupted", "good data", older)
+	writeFile(tgtDir, "corrupted", "bad! data", older)
+
+	for _, mode := range []compareMode{compareETag, compareChecksum, compareContent} {
+		srcClnt, err := fsNew(srcDir + string(filepath.Separator))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tgtClnt, err := fsNew(tgtDir + string(filepath.Separator))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		diffs := map[string]differType{}
+		cmp := newContentComparer(mode, "", "", nil)
+		for diffMsg := range objectDifference(context.Background(), srcClnt, tgtClnt, false, cmp) {
+			if diffMsg.Error != nil {
+				t.Fatalf("%s: %v", mode, diffMsg.Error)
+			}
+			diffs[filepath.Base(diffMsg.FirstURL)] = diffMsg.Diff
+		}
+		if len(diffs) != 1 || diffs["corrupted"] != differInContent {
+			t.Fatalf("%s: expected only `corrupted` to differ in content, got %v", mode, diffs)
+		}
+	}
+}
+
+func TestDiffSenderOrder(t *testing.T) {
+	diffCh := make(chan diffMessage)
+	out := newDiffSender(4)
+	go out.run(diffCh)
+
+	go func() {
+		defer close(out.pending)
+		for i := 0; i < 20; i++ {
+			name := string(rune('a' + i))
+			if i%3 == 0 {
+				out.send(diffMessage{FirstURL: name})
+				continue
+			}
+			// Later comparisons finish first, every fifth one is similar.
+			delay := time.Duration(20-i) * time.Millisecond
+			similar := i%5 == 0
+			out.sendLater(func() (diffMessage, bool) {
+				time.Sleep(delay)
+				return diffMessage{FirstURL: name}, !similar
+			})
+		}
+	}()
+
+	var got string
+	for msg := range diffCh {
+		got += msg.FirstURL
+	}
+	if expected := "abcdeghijlmnopqrst"; got != expected {
+		t.Fatalf("Expected messages %s, got %s", expected, got)
+	}
+}
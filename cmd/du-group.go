@@ -0,0 +1,262 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Breakdowns of du --group-by.
+const (
+	duGroupStorageClass = "storage-class"
+	duGroupTier         = "tier"
+	duGroupVersionState = "version-state"
+	duGroupAgeBucket    = "age-bucket"
+	duGroupExtension    = "extension"
+	duGroupTag          = "tag"
+)
+
+// Group of the objects without a value, like an extension or a tag.
+const duGroupNone = "(none)"
+
+// duAgeBuckets - the buckets of --group-by age-bucket, by the time since
+// the objects were last modified.
+var duAgeBuckets = []struct {
+	name   string
+	before time.Duration
+}{
+	{"0-1d", 24 * time.Hour},
+	{"1d-7d", 7 * 24 * time.Hour},
+	{"7d-30d", 30 * 24 * time.Hour},
+	{"30d-90d", 90 * 24 * time.Hour},
+	{"90d-1y", 365 * 24 * time.Hour},
+	{"1y+", 1<<63 - 1},
+}
+
+// duGroupBy - sums the size and the number of objects of a target per
+// group of --group-by.
+type duGroupBy struct {
+	name   string
+	tagKey string
+	now    time.Time
+	groups map[string]*duGroup
+}
+
+// duGroup - the totals of a group.
+type duGroup struct {
+	size    int64
+	objects int64
+}
+
+// parseDuGroupBy - parses the value of --group-by.
+func parseDuGroupBy(value string) (*duGroupBy, *probe.Error) {
+	g := &duGroupBy{name: value, now: UTCNow(), groups: map[string]*duGroup{}}
+	switch value {
+	case duGroupStorageClass, duGroupTier, duGroupVersionState, duGroupAgeBucket, duGroupExtension:
+	default:
+		name, key, found := strings.Cut(value, ":")
+		if name != duGroupTag || !found || key == "" {
+			return nil, errInvalidArgument().Trace(value)
+		}
+		g.name, g.tagKey = duGroupTag, key
+	}
+	return g, nil
+}
+
+// withVersions - returns true if all the versions and the delete markers
+// must be listed.
+func (g *duGroupBy) withVersions() bool {
+	return g != nil && g.name == duGroupVersionState
+}
+
+// withMetadata - returns true if objects must be listed with their tags.
+func (g *duGroupBy) withMetadata() bool {
+	return g != nil && g.name == duGroupTag
+}
+
+// key - returns the group of an object.
+func (g *duGroupBy) key(content *ClientContent) string {
+	switch g.name {
+	case duGroupStorageClass:
+		if content.StorageClass == "" {
+			return "STANDARD"
+		}
+		return content.StorageClass
+	case duGroupTier:
+		// Transitioned objects are listed with the storage class set to
+		// the name of their remote tier.
+		switch content.StorageClass {
+		case "", "STANDARD", "REDUCED_REDUNDANCY":
+			return "local"
+		}
+		return content.StorageClass
+	case duGroupVersionState:
+		switch {
+		case content.IsDeleteMarker:
+			return "delete-marker"
+		case content.IsLatest || content.VersionID == "":
+			return "current"
+		}
+		return "noncurrent"
+	case duGroupAgeBucket:
+		age := g.now.Sub(content.Time)
+		for _, bucket := range duAgeBuckets {
+			if age < bucket.before {
+				return bucket.name
+			}
+		}
+	case duGroupExtension:
+		if ext := strings.ToLower(path.Ext(content.URL.Path)); ext != "" {
+			return ext
+		}
+	case duGroupTag:
+		if value, ok := content.Tags[g.tagKey]; ok {
+			return value
+		}
+	}
+	return duGroupNone
+}
+
+// add - accounts an object in its group, delete markers are only
+// accounted by version state.
+func (g *duGroupBy) add(content *ClientContent) {
+	if content.IsDeleteMarker && g.name != duGroupVersionState {
+		return
+	}
+	key := g.key(content)
+	group, ok := g.groups[key]
+	if !ok {
+		group = &duGroup{}
+		g.groups[key] = group
+	}
+	group.size += content.Size
+	group.objects++
+}
+
+// reset - clears the groups before a new target.
+func (g *duGroupBy) reset() {
+	g.groups = map[string]*duGroup{}
+}
+
+// messages - returns the breakdown of a target, the largest groups first
+// or the age buckets in order.
+func (g *duGroupBy) messages(prefix string, isVersions bool) []duGroupMessage {
+	var total int64
+	for _, group := range g.groups {
+		total += group.size
+	}
+	msgs := make([]duGroupMessage, 0, len(g.groups))
+	for key, group := range g.groups {
+		msg := duGroupMessage{
+			Prefix:     prefix,
+			GroupBy:    g.name,
+			Group:      key,
+			Size:       group.size,
+			Objects:    group.objects,
+			Status:     "success",
+			IsVersions: isVersions,
+		}
+		if g.name == duGroupTag {
+			msg.GroupBy = duGroupTag + ":" + g.tagKey
+		}
+		if total > 0 {
+			msg.Percent = float64(group.size) * 100 / float64(total)
+		}
+		msgs = append(msgs, msg)
+	}
+
+	order := func(key string) int {
+		for i, bucket := range duAgeBuckets {
+			if bucket.name == key {
+				return i
+			}
+		}
+		return len(duAgeBuckets)
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		if g.name == duGroupAgeBucket {
+			return order(msgs[i].Group) < order(msgs[j].Group)
+		}
+		if msgs[i].Size != msgs[j].Size {
+			return msgs[i].Size > msgs[j].Size
+		}
+		return msgs[i].Group < msgs[j].Group
+	})
+	return msgs
+}
+
+// duGroupMessage - the totals of a group of --group-by, the percent is
+// the share of the size of the target.
+type duGroupMessage struct {
+	Prefix     string  `json:"prefix"`
+	GroupBy    string  `json:"groupBy"`
+	Group      string  `json:"group"`
+	Size       int64   `json:"size"`
+	Objects    int64   `json:"objects"`
+	Percent    float64 `json:"percent"`
+	Status     string  `json:"status"`
+	IsVersions bool    `json:"isVersions"`
+}
+
+// Colorized message for console printing.
+func (r duGroupMessage) String() string {
+	humanSize := strings.Join(strings.Fields(humanize.IBytes(uint64(r.Size))), "")
+	cnt := fmt.Sprintf("%d object", r.Objects)
+	if r.IsVersions {
+		cnt = fmt.Sprintf("%d version", r.Objects)
+	}
+	if r.Objects != 1 {
+		cnt += "s" // pluralize
+	}
+	return fmt.Sprintf("%s\t%s\t%5.1f%%\t%s", console.Colorize("Size", humanSize),
+		console.Colorize("Objects", cnt), r.Percent,
+		console.Colorize("Group", r.GroupBy+"="+r.Group))
+}
+
+// JSON'ified message for scripting.
+func (r duGroupMessage) JSON() string {
+	msgBytes, e := json.MarshalIndent(r, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// duTopPrefixes - returns the n largest prefixes below a target, the
+// total of the target is the last of the prefixes.
+func duTopPrefixes(prefixes []duMessage, n int) []duMessage {
+	if len(prefixes) == 0 {
+		return nil
+	}
+	target := prefixes[len(prefixes)-1]
+	below := append([]duMessage(nil), prefixes[:len(prefixes)-1]...)
+	sort.SliceStable(below, func(i, j int) bool {
+		return below[i].Size > below[j].Size
+	})
+	if len(below) > n {
+		below = below[:n]
+	}
+	return append(below, target)
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+func TestParseDuGroupBy(t *testing.T) {
+	g, err := parseDuGroupBy("tag:team")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.name != duGroupTag || g.tagKey != "team" || !g.withMetadata() || g.withVersions() {
+		t.Fatalf("Unexpected group by %+v", g)
+	}
+	if g, err = parseDuGroupBy(duGroupVersionState); err != nil || !g.withVersions() {
+		t.Fatalf("Expected version-state to list versions: %v", err)
+	}
+	for _, value := range []string{"", "size", "tag", "tag:", "owner:root"} {
+		if _, err := parseDuGroupBy(value); err == nil {
+			t.Errorf("%s: expected an invalid --group-by", value)
+		}
+	}
+}
+
+func TestDuGroupBy(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	contents := []*ClientContent{
+		{URL: ClientURL{Path: "/b/a.CSV"}, Size: 100, Time: now.Add(-time.Hour), IsLatest: true, VersionID: "v2", Tags: map[string]string{"team": "ops"}},
+		{URL: ClientURL{Path: "/b/a.CSV"}, Size: 300, Time: now.Add(-40 * 24 * time.Hour), VersionID: "v1", StorageClass: "WARM"},
+		{URL: ClientURL{Path: "/b/c"}, IsDeleteMarker: true, IsLatest: true, VersionID: "v4", Time: now},
+		{URL: ClientURL{Path: "/b/c"}, Size: 50, Time: now.Add(-400 * 24 * time.Hour), VersionID: "v3", StorageClass: "REDUCED_REDUNDANCY"},
+	}
+	type group struct {
+		name    string
+		size    int64
+		objects int64
+	}
+	testCases := []struct {
+		groupBy  string
+		expected []group
+	}{
+		{duGroupStorageClass, []group{{"WARM", 300, 1}, {"STANDARD", 100, 1}, {"REDUCED_REDUNDANCY", 50, 1}}},
+		{duGroupTier, []group{{"WARM", 300, 1}, {"local", 150, 2}}},
+		{duGroupVersionState, []group{{"noncurrent", 350, 2}, {"current", 100, 1}, {"delete-marker", 0, 1}}},
+		{duGroupAgeBucket, []group{{"0-1d", 100, 1}, {"30d-90d", 300, 1}, {"1y+", 50, 1}}},
+		{duGroupExtension, []group{{".csv", 400, 2}, {duGroupNone, 50, 1}}},
+		{"tag:team", []group{{duGroupNone, 350, 2}, {"ops", 100, 1}}},
+	}
+	for _, testCase := range testCases {
+		g, err := parseDuGroupBy(testCase.groupBy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		g.now = now
+		for _, content := range contents {
+			g.add(content)
+		}
+		var groups []group
+		var percent float64
+		for _, msg := range g.messages("b", true) {
+			groups = append(groups, group{msg.Group, msg.Size, msg.Objects})
+			percent += msg.Percent
+		}
+		if !reflect.DeepEqual(groups, testCase.expected) {
+			t.Errorf("%s: expected %v, got %v", testCase.groupBy, testCase.expected, groups)
+		}
+		if percent < 99.99 || percent > 100.01 {
+			t.Errorf("%s: expected the groups to add up to 100%%, got %f", testCase.groupBy, percent)
+		}
+		g.reset()
+		if len(g.messages("b", true)) != 0 {
+			t.Errorf("%s: expected no groups after reset", testCase.groupBy)
+		}
+	}
+}
+
+func TestDuTopPrefixes(t *testing.T) {
+	prefixes := []duMessage{
+		{Prefix: "b/x/y", Size: 10},
+		{Prefix: "b/x", Size: 30},
+		{Prefix: "b/z", Size: 20},
+		{Prefix: "b", Size: 50},
+	}
+	var names []string
+	for _, msg := range duTopPrefixes(prefixes, 2) {
+		names = append(names, msg.Prefix)
+	}
+	if expected := []string{"b/x", "b/z", "b"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+	if top := duTopPrefixes(prefixes[3:], 2); len(top) != 1 || top[0].Prefix != "b" {
+		t.Fatalf("Expected only the target, got %v", top)
+	}
+}
+
+func TestDuTopSiblings(t *testing.T) {
+	_, srcDir := newTestFolder(t, map[string]string{
+		"a/x/big": strings.Repeat("x", 100),
+		"a/small": "x",
+		"b/c":     strings.Repeat("x", 50),
+		"d/e":     strings.Repeat("x", 10),
+	})
+
+	testCases := []struct {
+		args     []string
+		depth    int
+		expected []string
+	}{
+		// Siblings one level below the target are ranked, a/x is never
+		// ranked against its ancestor a.
+		{[]string{"--top", "2"}, 2, []string{"a", "b", ""}},
+		{[]string{"--top", "2", "--depth", "3"}, 3, []string{"a", "a/x", ""}},
+	}
+	for i, testCase := range testCases {
+		set := flag.NewFlagSet("du", flag.ContinueOnError)
+		for _, f := range duFlags {
+			f.Apply(set)
+		}
+		if e := set.Parse(testCase.args); e != nil {
+			t.Fatal(e)
+		}
+		depth := duDepth(cli.NewContext(nil, set, nil))
+		if depth != testCase.depth {
+			t.Fatalf("Test %d: expected depth %d, got %d", i+1, testCase.depth, depth)
+		}
+
+		opts := &duOptions{top: 2}
+		if _, _, e := du(context.Background(), srcDir, opts, depth); e != nil {
+			t.Fatal(e)
+		}
+		var names []string
+		for _, msg := range duTopPrefixes(opts.prefixes, opts.top) {
+			names = append(names, strings.TrimPrefix(strings.TrimPrefix(msg.Prefix, duPrefix(srcDir)), "/"))
+		}
+		if !reflect.DeepEqual(names, testCase.expected) {
+			t.Fatalf("Test %d: expected %v, got %v", i+1, testCase.expected, names)
+		}
+	}
+}
@@ -52,6 +52,14 @@ var (
 			Name:  "versions",
 			Usage: "include all object versions",
 		},
+		cli.StringFlag{
+			Name:  "group-by",
+			Usage: "break down the usage of each target by storage-class, tier, version-state, age-bucket, extension or tag:KEY",
+		},
+		cli.IntFlag{
+			Name:  "top",
+			Usage: "print only the N largest prefixes one level below each target, down to --depth if specified",
+		},
 	}
 )
 
@@ -72,6 +80,16 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+GROUPS
+  --group-by prints the size, the number of objects and the share of the size of
+  each group after the total of a target.
+
+     storage-class --> Storage class of the objects.
+     tier          --> Remote tier of transitioned objects, 'local' for the others.
+     version-state --> current, noncurrent and delete-marker, implies --versions.
+     age-bucket    --> Time since last modified: 0-1d, 1d-7d, 7d-30d, 30d-90d, 90d-1y and 1y+.
+     extension     --> Extension of the object names.
+     tag:KEY       --> Value of the tag KEY. MinIO server only.
 
 EXAMPLES:
   1. Summarize disk usage of 'jazz-songs' bucket recursively.
@@ -85,6 +103,15 @@ EXAMPLES:
 
   4. Summarize disk usage of 'jazz-songs' bucket with all objects versions
      {{.Prompt}} {{.HelpName}} --versions s3/jazz-songs/
+
+  5. Summarize disk usage of 'jazz-songs' bucket by current, noncurrent versions and delete markers
+     {{.Prompt}} {{.HelpName}} --group-by version-state s3/jazz-songs/
+
+  6. Summarize disk usage of 'jazz-songs' bucket by the time since the objects were last modified
+     {{.Prompt}} {{.HelpName}} --group-by age-bucket s3/jazz-songs/
+
+  7. Show the 10 largest top level prefixes of 'jazz-songs' bucket
+     {{.Prompt}} {{.HelpName}} --top 10 s3/jazz-songs/
 `,
 }
 
@@ -119,7 +146,17 @@ func (r duMessage) JSON() string {
 	return string(msgBytes)
 }
 
-func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool, depth int) (sz, objs int64, err error) {
+// duOptions - options of du shared by all the prefixes of a target.
+type duOptions struct {
+	timeRef      time.Time
+	withVersions bool
+	groupBy      *duGroupBy
+	// Totals of the prefixes collected for --top instead of printed.
+	top      int
+	prefixes []duMessage
+}
+
+func du(ctx context.Context, urlStr string, opts *duOptions, depth int) (sz, objs int64, err error) {
 	targetAlias, targetURL, _ := mustExpandAlias(urlStr)
 
 	if !strings.HasSuffix(targetURL, "/") {
@@ -139,8 +176,10 @@ func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool
 	targetAbsolutePath := path.Clean(clnt.GetURL().String())
 
 	contentCh := clnt.List(ctx, ListOptions{
-		TimeRef:           timeRef,
-		WithOlderVersions: withVersions,
+		TimeRef:           opts.timeRef,
+		WithOlderVersions: opts.withVersions,
+		WithDeleteMarkers: opts.groupBy.withVersions(),
+		WithMetadata:      opts.groupBy.withMetadata(),
 		Recursive:         recursive,
 		ShowDir:           DirFirst,
 	})
@@ -174,38 +213,73 @@ func du(ctx context.Context, urlStr string, timeRef time.Time, withVersions bool
 			if targetAlias != "" {
 				subDirAlias = targetAlias + "/" + content.URL.Path
 			}
-			used, n, err := du(ctx, subDirAlias, timeRef, withVersions, depth)
+			used, n, err := du(ctx, subDirAlias, opts, depth)
 			if err != nil {
 				return 0, 0, err
 			}
 			size += used
 			objects += n
-		} else {
-			if !content.IsDeleteMarker && !content.Type.IsDir() {
+		} else if !content.Type.IsDir() {
+			if opts.groupBy != nil {
+				opts.groupBy.add(content)
+			}
+			if !content.IsDeleteMarker {
 				size += content.Size
 				objects++
+				metricsObjectDone(content.Size)
 			}
 		}
 	}
 
 	if depth != 0 {
-		u, e := url.Parse(targetURL)
-		if e != nil {
-			panic(e)
-		}
-
-		printMsg(duMessage{
-			Prefix:     strings.Trim(u.Path, "/"),
+		msg := duMessage{
+			Prefix:     duPrefix(urlStr),
 			Size:       size,
 			Objects:    objects,
 			Status:     "success",
-			IsVersions: withVersions,
-		})
+			IsVersions: opts.withVersions,
+		}
+		if opts.top > 0 {
+			opts.prefixes = append(opts.prefixes, msg)
+		} else {
+			printMsg(msg)
+		}
 	}
 
 	return size, objects, nil
 }
 
+// duPrefix - returns the prefix printed for a folder.
+func duPrefix(urlStr string) string {
+	_, targetURL, _ := mustExpandAlias(urlStr)
+	u, e := url.Parse(targetURL)
+	if e != nil {
+		panic(e)
+	}
+	return strings.Trim(u.Path, "/")
+}
+
+// duDepth - returns the depth of the prefixes summarized by du, --top
+// ranks the prefixes of one level below the target unless --depth is set.
+func duDepth(cliCtx *cli.Context) int {
+	depth := cliCtx.Int("depth")
+	if depth == 0 {
+		if cliCtx.Bool("recursive") || cliCtx.Int("top") > 0 {
+			if !cliCtx.IsSet("depth") {
+				depth = -1
+				if cliCtx.Int("top") > 0 {
+					// Prefixes of one level below the target, nested
+					// prefixes would be ranked against their ancestors.
+					depth = 2
+				}
+			}
+		} else {
+			depth = 1
+		}
+	}
+	return depth
+}
+
 // main for du command.
 func mainDu(cliCtx *cli.Context) error {
 	if !cliCtx.Args().Present() {
@@ -221,20 +295,26 @@ func mainDu(cliCtx *cli.Context) error {
 	ctx, cancelRm := context.WithCancel(globalContext)
 	defer cancelRm()
 
+	console.SetColor("Group", color.New(color.FgCyan, color.Bold))
+
 	// du specific flags.
-	depth := cliCtx.Int("depth")
-	if depth == 0 {
-		if cliCtx.Bool("recursive") {
-			if !cliCtx.IsSet("depth") {
-				depth = -1
-			}
-		} else {
-			depth = 1
-		}
+	top := cliCtx.Int("top")
+	if top < 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("top")), "--top should be greater than 0.")
 	}
+	depth := duDepth(cliCtx)
 
-	withVersions := cliCtx.Bool("versions")
-	timeRef := parseRewindFlag(cliCtx.String("rewind"))
+	opts := &duOptions{
+		timeRef:      parseRewindFlag(cliCtx.String("rewind")),
+		withVersions: cliCtx.Bool("versions"),
+		top:          top,
+	}
+	if groupBy := cliCtx.String("group-by"); groupBy != "" {
+		var err *probe.Error
+		opts.groupBy, err = parseDuGroupBy(groupBy)
+		fatalIf(err, "Unable to parse --group-by.")
+		opts.withVersions = opts.withVersions || opts.groupBy.withVersions()
+	}
 
 	var duErr error
 	var isDir bool
@@ -244,8 +324,26 @@ func mainDu(cliCtx *cli.Context) error {
 			fatalIf(errInvalidArgument().Trace(urlStr), fmt.Sprintf("Source `%s` is not a folder. Only folders are supported by 'du' command.", urlStr))
 		}
 
-		if _, _, err := du(ctx, urlStr, timeRef, withVersions, depth); duErr == nil {
-			duErr = err
+		opts.prefixes = nil
+		if opts.groupBy != nil {
+			opts.groupBy.reset()
+		}
+		_, _, err := du(ctx, urlStr, opts, depth)
+		if err != nil {
+			if duErr == nil {
+				duErr = err
+			}
+			continue
+		}
+		if opts.top > 0 {
+			for _, msg := range duTopPrefixes(opts.prefixes, opts.top) {
+				printMsg(msg)
+			}
+		}
+		if opts.groupBy != nil {
+			for _, msg := range opts.groupBy.messages(duPrefix(urlStr), opts.withVersions) {
+				printMsg(msg)
+			}
 		}
 	}
 
@@ -0,0 +1,244 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/secure-io/sio-go"
+)
+
+// Client side encrypted objects are stored in the sio format (DARE),
+// encrypted with a random object key. The object key is sealed with
+// the key read from the key file and kept in the object metadata.
+const (
+	cseAlgorithm = "AES-256-GCM-SIO"
+	cseKeyLen    = 32
+
+	cseMetaAlgorithm = "X-Amz-Meta-Mc-Cse-Algorithm"
+	cseMetaKey       = "X-Amz-Meta-Mc-Cse-Key"
+	cseMetaNonce     = "X-Amz-Meta-Mc-Cse-Nonce"
+)
+
+// getCSE - returns the client side encryption key of the prefix
+// matching the given resource.
+func getCSE(resource string, encKeys []prefixSSEPair) []byte {
+	for _, k := range encKeys {
+		if strings.HasPrefix(resource, k.Prefix) {
+			return k.CSE
+		}
+	}
+	return nil
+}
+
+// readClientSideKey - reads a 32 bytes key from a file, the key is
+// either stored as is or hex or base64 encoded.
+func readClientSideKey(keyFile string) ([]byte, *probe.Error) {
+	data, e := os.ReadFile(keyFile)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(keyFile)
+	}
+	if len(data) == cseKeyLen {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, e := hex.DecodeString(text); e == nil && len(key) == cseKeyLen {
+		return key, nil
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding} {
+		if key, e := encoding.DecodeString(text); e == nil && len(key) == cseKeyLen {
+			return key, nil
+		}
+	}
+	return nil, errCSEKeyFormat(keyFile)
+}
+
+// metadataValue - looks up a metadata entry regardless of its case.
+func metadataValue(metadata map[string]string, key string) (string, bool) {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// isClientSideEncrypted - returns true if the metadata describes a
+// client side encrypted object.
+func isClientSideEncrypted(metadata map[string]string) bool {
+	_, ok := metadataValue(metadata, cseMetaKey)
+	return ok
+}
+
+// removeClientSideMetadata - drops the client side encryption entries,
+// they do not apply anymore once an object is decrypted.
+func removeClientSideMetadata(metadata map[string]string) {
+	for k := range metadata {
+		for _, key := range []string{cseMetaAlgorithm, cseMetaKey, cseMetaNonce} {
+			if strings.EqualFold(k, key) {
+				delete(metadata, k)
+			}
+		}
+	}
+}
+
+// clientSideDecryptedSize - returns the plaintext size of an encrypted
+// object of the given size.
+func clientSideDecryptedSize(size int64) int64 {
+	if size <= 0 {
+		return size
+	}
+	const fragmentSize = sio.BufSize + 16
+	fragments := (size + fragmentSize - 1) / fragmentSize
+	return size - fragments*16
+}
+
+// clientSidePlainSize - returns the plaintext size of a listed object,
+// objects under a client side encrypted prefix are listed encrypted.
+func clientSidePlainSize(alias string, content *ClientContent, encKeyDB map[string][]prefixSSEPair) int64 {
+	if alias != "" && getCSE(filepath.ToSlash(filepath.Join(alias, content.URL.Path)), encKeyDB[alias]) != nil {
+		return clientSideDecryptedSize(content.Size)
+	}
+	return content.Size
+}
+
+// sealObjectKey - wraps the object key with the client side key.
+func sealObjectKey(key, objectKey []byte) (string, *probe.Error) {
+	aead, err := newClientSideAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, e := io.ReadFull(rand.Reader, nonce); e != nil {
+		return "", probe.NewError(e)
+	}
+	sealed := aead.Seal(nonce, nonce, objectKey, []byte(cseAlgorithm))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unsealObjectKey - unwraps an object key sealed by sealObjectKey.
+func unsealObjectKey(key []byte, sealedKey string) ([]byte, *probe.Error) {
+	aead, err := newClientSideAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	sealed, e := base64.StdEncoding.DecodeString(sealedKey)
+	if e != nil || len(sealed) < aead.NonceSize() {
+		return nil, errCSEDecrypt()
+	}
+	objectKey, e := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(cseAlgorithm))
+	if e != nil {
+		return nil, errCSEDecrypt()
+	}
+	return objectKey, nil
+}
+
+func newClientSideAEAD(key []byte) (cipher.AEAD, *probe.Error) {
+	block, e := aes.NewCipher(key)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return aead, nil
+}
+
+// encryptClientSide - returns a reader encrypting the given stream and
+// its encrypted size, the sealed object key is added to the metadata.
+func encryptClientSide(reader io.Reader, size int64, key []byte, metadata map[string]string) (io.Reader, int64, *probe.Error) {
+	objectKey := make([]byte, cseKeyLen)
+	if _, e := io.ReadFull(rand.Reader, objectKey); e != nil {
+		return nil, 0, probe.NewError(e)
+	}
+	stream, e := sio.AES_256_GCM.Stream(objectKey)
+	if e != nil {
+		return nil, 0, probe.NewError(e)
+	}
+	nonce := make([]byte, stream.NonceSize())
+	if _, e = io.ReadFull(rand.Reader, nonce); e != nil {
+		return nil, 0, probe.NewError(e)
+	}
+	sealedKey, err := sealObjectKey(key, objectKey)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	removeClientSideMetadata(metadata)
+	metadata[cseMetaAlgorithm] = cseAlgorithm
+	metadata[cseMetaKey] = sealedKey
+	metadata[cseMetaNonce] = base64.StdEncoding.EncodeToString(nonce)
+
+	if size >= 0 {
+		size += stream.Overhead(size)
+	}
+	return stream.EncryptReader(reader, nonce, nil), size, nil
+}
+
+// clientSideStream - returns the decryption stream and nonce of a
+// client side encrypted object.
+func clientSideStream(key []byte, metadata map[string]string) (*sio.Stream, []byte, *probe.Error) {
+	if !isClientSideEncrypted(metadata) {
+		return nil, nil, errCSENotEncrypted()
+	}
+	if algorithm, _ := metadataValue(metadata, cseMetaAlgorithm); algorithm != cseAlgorithm {
+		return nil, nil, errCSEDecrypt().Trace(algorithm)
+	}
+
+	sealedKey, _ := metadataValue(metadata, cseMetaKey)
+	objectKey, err := unsealObjectKey(key, sealedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	stream, e := sio.AES_256_GCM.Stream(objectKey)
+	if e != nil {
+		return nil, nil, probe.NewError(e)
+	}
+	encodedNonce, _ := metadataValue(metadata, cseMetaNonce)
+	nonce, e := base64.StdEncoding.DecodeString(encodedNonce)
+	if e != nil || len(nonce) != stream.NonceSize() {
+		return nil, nil, errCSEDecrypt()
+	}
+	return stream, nonce, nil
+}
+
+// decryptClientSide - returns a reader decrypting a client side encrypted
+// object, the content is updated to describe the plaintext.
+func decryptClientSide(reader io.ReadCloser, content *ClientContent, key []byte) (io.ReadCloser, *probe.Error) {
+	stream, nonce, err := clientSideStream(key, content.Metadata)
+	if err != nil {
+		return nil, err.Trace(content.URL.String())
+	}
+	content.Size = clientSideDecryptedSize(content.Size)
+	removeClientSideMetadata(content.Metadata)
+	return struct {
+		io.Reader
+		io.Closer
+	}{stream.DecryptReader(reader, nonce, nil), reader}, nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/secure-io/sio-go"
+)
+
+func TestReadClientSideKey(t *testing.T) {
+	key := bytes.Repeat([]byte{'k'}, cseKeyLen)
+	testCases := []struct {
+		data []byte
+		fail bool
+	}{
+		{key, false},
+		{[]byte(hex.EncodeToString(key) + "\n"), false},
+		{[]byte(base64.StdEncoding.EncodeToString(key)), false},
+		{[]byte(base64.RawStdEncoding.EncodeToString(key)), false},
+		{[]byte("short"), true},
+	}
+	for i, testCase := range testCases {
+		keyFile := filepath.Join(t.TempDir(), "key")
+		if e := os.WriteFile(keyFile, testCase.data, 0o600); e != nil {
+			t.Fatal(e)
+		}
+		got, err := readClientSideKey(keyFile)
+		if testCase.fail != (err != nil) {
+			t.Fatalf("Test %d: expected failure %t, got %v", i+1, testCase.fail, err)
+		}
+		if !testCase.fail && !bytes.Equal(got, key) {
+			t.Fatalf("Test %d: unexpected key %x", i+1, got)
+		}
+	}
+}
+
+func TestClientSideEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{'k'}, cseKeyLen)
+	for _, size := range []int{0, 1, sio.BufSize - 1, sio.BufSize, sio.BufSize + 1, 3*sio.BufSize + 5} {
+		plaintext := bytes.Repeat([]byte{'a'}, size)
+		metadata := map[string]string{"Content-Type": "text/plain"}
+		reader, encSize, err := encryptClientSide(bytes.NewReader(plaintext), int64(size), key, metadata)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ciphertext, e := io.ReadAll(reader)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if int64(len(ciphertext)) != encSize {
+			t.Fatalf("Size %d: expected encrypted size %d, got %d", size, encSize, len(ciphertext))
+		}
+		if got := clientSideDecryptedSize(encSize); got != int64(size) {
+			t.Fatalf("Size %d: expected decrypted size %d, got %d", size, size, got)
+		}
+
+		content := &ClientContent{Size: encSize, Metadata: metadata}
+		decReader, err := decryptClientSide(io.NopCloser(bytes.NewReader(ciphertext)), content, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, e := io.ReadAll(decReader)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !bytes.Equal(got, plaintext) || content.Size != int64(size) || isClientSideEncrypted(content.Metadata) {
+			t.Fatalf("Size %d: unexpected decrypted object", size)
+		}
+	}
+}
+
+func TestClientSideEncryptionWrongKey(t *testing.T) {
+	key := bytes.Repeat([]byte{'k'}, cseKeyLen)
+	metadata := map[string]string{}
+	if _, _, err := encryptClientSide(bytes.NewReader([]byte("data")), 4, key, metadata); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := clientSideStream(bytes.Repeat([]byte{'x'}, cseKeyLen), metadata); err == nil {
+		t.Fatal("Expected another key to fail")
+	}
+	if _, _, err := clientSideStream(key, map[string]string{}); err == nil {
+		t.Fatal("Expected a plaintext object to fail")
+	}
+}
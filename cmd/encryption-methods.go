@@ -35,12 +35,15 @@ const (
 	sseC
 	sseKMS
 	sseS3
+	cseClient
 )
 
 // struct representing object prefix and sse keys association.
 type prefixSSEPair struct {
 	Prefix string
 	SSE    encrypt.ServerSide
+	// CSE is the key of client side encrypted prefixes.
+	CSE []byte
 }
 
 // byPrefixLength implements sort.Interface.
@@ -93,6 +96,14 @@ func validateAndCreateEncryptionKeys(ctx *cli.Context) (encMap map[string][]pref
 		encMap[alias] = append(encMap[alias], *prefixPair)
 	}
 
+	for _, v := range ctx.StringSlice("enc-client") {
+		prefixPair, alias, err := validateAndParseKey(ctx, v, cseClient)
+		if err != nil {
+			return nil, err
+		}
+		encMap[alias] = append(encMap[alias], *prefixPair)
+	}
+
 	for i := range encMap {
 		err = validateOverLappingSSEKeys(encMap[i])
 		if err != nil {
@@ -128,6 +139,9 @@ func validateAndParseKey(ctx *cli.Context, key string, keyType sseKeyType) (SSEP
 	if (keyType == sseKMS || keyType == sseC) && encKey == "" {
 		return nil, "", errSSEClientKeyFormat("SSE-C/KMS key should be of the form alias/prefix=key,... ").Trace(key)
 	}
+	if keyType == cseClient && encKey == "" {
+		return nil, "", errSSEKeyMissing().Trace(key)
+	}
 
 	for _, arg := range ctx.Args() {
 		if strings.HasPrefix(arg, alias+"/"+prefix) {
@@ -144,6 +158,15 @@ func validateAndParseKey(ctx *cli.Context, key string, keyType sseKeyType) (SSEP
 	var err error
 
 	switch keyType {
+	case cseClient:
+		cse, err := readClientSideKey(encKey)
+		if err != nil {
+			return nil, "", err.Trace(key)
+		}
+		return &prefixSSEPair{
+			Prefix: ssePairPrefix,
+			CSE:    cse,
+		}, alias, nil
 	case sseC:
 		sse, err = encrypt.NewSSEC([]byte(encKey))
 	case sseKMS:
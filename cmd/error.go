@@ -54,6 +54,7 @@ func fatalIf(err *probe.Error, msg string, data ...interface{}) {
 }
 
 func fatal(err *probe.Error, msg string, data ...interface{}) {
+	metricsError(err)
 	if globalJSON {
 		errorMsg := errorMessage{
 			Message: msg,
@@ -132,6 +133,7 @@ func errorIf(err *probe.Error, msg string, data ...interface{}) {
 	if err == nil {
 		return
 	}
+	metricsError(err)
 	if globalJSON {
 		errorMsg := errorMessage{
 			Message: fmt.Sprintf(msg, data...),
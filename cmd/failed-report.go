@@ -0,0 +1,178 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+var failedReportFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "failed-report",
+		Usage: "append the failed objects as JSON lines to a file, retry them with 'mc replay'",
+	},
+}
+
+// failedReportEntry - one failed object of a failed objects report.
+type failedReportEntry struct {
+	Command   string   `json:"command"`
+	Flags     []string `json:"flags,omitempty"`
+	Source    string   `json:"source,omitempty"`
+	Target    string   `json:"target,omitempty"`
+	VersionID string   `json:"versionId,omitempty"`
+	ErrorCode string   `json:"errorCode,omitempty"`
+	Error     string   `json:"error"`
+}
+
+// failedReport - writes the objects a bulk command failed on, along
+// with the command line options, so that they can be replayed later.
+type failedReport struct {
+	mu      sync.Mutex
+	file    *os.File
+	command string
+	flags   []string
+}
+
+// newFailedReport - creates the report set by --failed-report, a nil
+// report is returned when the flag is not set. All report methods are
+// no-op on a nil report.
+func newFailedReport(ctx *cli.Context) (*failedReport, *probe.Error) {
+	name := ctx.String("failed-report")
+	if name == "" {
+		return nil, nil
+	}
+	// Appends to the report, a resumed session keeps the objects which
+	// failed before it was interrupted.
+	file, e := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o666)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+
+	var flags []cli.Flag
+	for _, f := range ctx.Command.Flags {
+		if f.GetName() != "failed-report" {
+			flags = append(flags, f)
+		}
+	}
+	return &failedReport{
+		file:    file,
+		command: ctx.Command.Name,
+		flags:   commandFlagArgs(ctx, flags),
+	}, nil
+}
+
+// add - records a failed object.
+func (r *failedReport) add(source, target, versionID string, err *probe.Error) {
+	if r == nil || err == nil {
+		return
+	}
+	e := err.ToGoError()
+	entry := failedReportEntry{
+		Command:   r.command,
+		Flags:     r.flags,
+		Source:    source,
+		Target:    target,
+		VersionID: versionID,
+		ErrorCode: minio.ToErrorResponse(e).Code,
+		Error:     e.Error(),
+	}
+	data, e := json.Marshal(entry)
+	if e != nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, e = r.file.Write(append(data, '\n'))
+	errorIf(probe.NewError(e).Trace(r.file.Name()), "Unable to write the failed objects report.")
+}
+
+// failedReportURL - returns the aliased URL of an object. The path is
+// not cleaned, keys like 'a//b' or 'a/' are kept as they are.
+func failedReportURL(alias string, u ClientURL) string {
+	if alias == "" {
+		return u.Path
+	}
+	return urlJoinPath(alias, u.Path)
+}
+
+// addURLs - records a failed copy or removal.
+func (r *failedReport) addURLs(urls URLs) {
+	var source, target, versionID string
+	if urls.SourceContent != nil {
+		source = failedReportURL(urls.SourceAlias, urls.SourceContent.URL)
+		versionID = urls.SourceContent.VersionID
+	}
+	if urls.TargetContent != nil {
+		target = failedReportURL(urls.TargetAlias, urls.TargetContent.URL)
+		if urls.SourceContent == nil {
+			versionID = urls.TargetContent.VersionID
+		}
+	}
+	if source == "" && target == "" {
+		return
+	}
+	r.add(source, target, versionID, urls.Error)
+}
+
+// Close - closes the report file.
+func (r *failedReport) Close() *probe.Error {
+	if r == nil {
+		return nil
+	}
+	if e := r.file.Close(); e != nil {
+		return probe.NewError(e).Trace(r.file.Name())
+	}
+	return nil
+}
+
+// readFailedReport - reads all the entries of a failed objects report.
+func readFailedReport(name string) ([]failedReportEntry, *probe.Error) {
+	file, e := os.Open(name)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	defer file.Close()
+
+	var entries []failedReportEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry failedReportEntry
+		if e = json.Unmarshal([]byte(line), &entry); e != nil {
+			return nil, probe.NewError(e).Trace(name)
+		}
+		entries = append(entries, entry)
+	}
+	if e = scanner.Err(); e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	return entries, nil
+}
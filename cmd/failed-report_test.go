@@ -0,0 +1,180 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestFailedReport(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "failed.json")
+
+	command := cli.Command{Name: "cp", Flags: append(append(cpFlags, retryFlags...), failedReportFlags...)}
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	for _, f := range command.Flags {
+		f.Apply(set)
+	}
+	if e := set.Parse([]string{"--recursive", "--max-retries=3", "--failed-report=" + name, "src/", "play/bucket/"}); e != nil {
+		t.Fatal(e)
+	}
+	ctx := cli.NewContext(nil, set, nil)
+	ctx.Command = command
+
+	report, err := newFailedReport(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report.addURLs(URLs{
+		SourceAlias:   "",
+		SourceContent: &ClientContent{URL: *newClientURL("src/a.txt"), VersionID: "v1"},
+		TargetAlias:   "play",
+		TargetContent: &ClientContent{URL: *newClientURL("/bucket/a.txt")},
+		Error:         probe.NewError(minio.ErrorResponse{StatusCode: 403, Code: "AccessDenied", Message: "Access Denied."}),
+	})
+	report.addURLs(URLs{
+		TargetAlias:   "play",
+		TargetContent: &ClientContent{URL: *newClientURL("/bucket/a//../b/")},
+		Error:         probe.NewError(minio.ErrorResponse{StatusCode: 403, Code: "AccessDenied", Message: "Access Denied."}),
+	})
+	report.add("", "play/bucket/b.txt", "", probe.NewError(minio.ErrorResponse{StatusCode: 503, Code: "SlowDown", Message: "Slow down."}))
+	if err = report.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readFailedReport(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []failedReportEntry{
+		{Command: "cp", Flags: []string{"--recursive", "--max-retries=3"}, Source: "src/a.txt", Target: "play/bucket/a.txt", VersionID: "v1", ErrorCode: "AccessDenied", Error: "Access Denied."},
+		{Command: "cp", Flags: []string{"--recursive", "--max-retries=3"}, Target: "play/bucket/a//../b/", ErrorCode: "AccessDenied", Error: "Access Denied."},
+		{Command: "cp", Flags: []string{"--recursive", "--max-retries=3"}, Target: "play/bucket/b.txt", ErrorCode: "SlowDown", Error: "Slow down."},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, entries)
+	}
+
+	// A resumed command appends to the report.
+	if report, err = newFailedReport(ctx); err != nil {
+		t.Fatal(err)
+	}
+	report.add("", "play/bucket/c.txt", "", probe.NewError(minio.ErrorResponse{StatusCode: 503, Code: "SlowDown", Message: "Slow down."}))
+	if err = report.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if entries, err = readFailedReport(name); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 || entries[3].Target != "play/bucket/c.txt" {
+		t.Fatalf("Expected the entry to be appended, got %+v", entries)
+	}
+}
+
+func TestGroupReplayEntries(t *testing.T) {
+	entries := []failedReportEntry{
+		{Command: "cp", Flags: []string{"--recursive", "--max-retries=3", "--rewind=1d"}, Source: "src/a.txt", Target: "play/bucket/a.txt", VersionID: "v1"},
+		{Command: "mirror", Flags: []string{"--overwrite", "--storage-class=REDUCED_REDUNDANCY", "-a"}, Source: "src/a.txt", Target: "play/bucket/a.txt"},
+		{Command: "mv", Flags: []string{"--recursive"}, Source: "src/a.txt", Target: "play/bucket/a.txt", VersionID: "v1"},
+		{Command: "rm", Flags: []string{"--recursive", "--force", "--bypass"}, Target: "play/bucket/a.txt", VersionID: "v2"},
+		{Command: "cp", Flags: []string{"--max-retries=3"}, Source: "src/b.txt", Target: "play/bucket/b.txt"},
+		{Command: "rm", Flags: []string{"--force", "--bypass"}, Target: "play/bucket/b.txt"},
+	}
+	expected := []struct {
+		command string
+		flags   []string
+		entries []int
+	}{
+		{"cp", []string{"--max-retries=3"}, []int{0, 4}},
+		{"cp", []string{"--storage-class=REDUCED_REDUNDANCY", "-a"}, []int{1}},
+		{"mv", nil, []int{2}},
+		{"rm", []string{"--force", "--bypass"}, []int{3, 5}},
+	}
+
+	groups := groupReplayEntries(entries)
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %d", len(expected), len(groups))
+	}
+	for i, group := range groups {
+		var groupEntries []failedReportEntry
+		for _, j := range expected[i].entries {
+			groupEntries = append(groupEntries, entries[j])
+		}
+		if group.command.Name != expected[i].command || !reflect.DeepEqual(group.flags, expected[i].flags) || !reflect.DeepEqual(group.entries, groupEntries) {
+			t.Fatalf("Group %d: expected %s %v with entries %v, got %s %v with %+v", i+1,
+				expected[i].command, expected[i].flags, expected[i].entries, group.command.Name, group.flags, group.entries)
+		}
+	}
+}
+
+func TestReplayFailures(t *testing.T) {
+	root, srcDir := newTestFolder(t, map[string]string{"a.txt": "a", "b.txt": "b"})
+	dstDir := filepath.Join(root, "dst")
+	writeTestFile(t, filepath.Join(dstDir, "old.txt"), "old")
+
+	defer func(quiet bool) { globalQuiet = quiet }(globalQuiet)
+	globalQuiet = true
+
+	// The entries are replayed in process, a missing source does not
+	// stop the replay of the other objects.
+	entries := []failedReportEntry{
+		{Command: "cp", Flags: []string{"--recursive"}, Source: filepath.Join(srcDir, "a.txt"), Target: filepath.Join(dstDir, "a.txt")},
+		{Command: "cp", Source: filepath.Join(srcDir, "missing.txt"), Target: filepath.Join(dstDir, "missing.txt")},
+		{Command: "rm", Flags: []string{"--recursive", "--force"}, Target: filepath.Join(dstDir, "old.txt")},
+		{Command: "mirror", Flags: []string{"--overwrite"}, Source: filepath.Join(srcDir, "b.txt"), Target: filepath.Join(dstDir, "b.txt")},
+	}
+	var report []byte
+	for _, entry := range entries {
+		data, e := json.Marshal(entry)
+		if e != nil {
+			t.Fatal(e)
+		}
+		report = append(append(report, data...), '\n')
+	}
+	name := filepath.Join(root, "failed.json")
+	if e := os.WriteFile(name, report, 0o600); e != nil {
+		t.Fatal(e)
+	}
+
+	set := flag.NewFlagSet("replay", flag.ContinueOnError)
+	if e := set.Parse([]string{name}); e != nil {
+		t.Fatal(e)
+	}
+	ctx := cli.NewContext(cli.NewApp(), set, nil)
+	ctx.Command = replayCmd
+
+	if e := mainReplay(ctx); e == nil {
+		t.Fatal("Expected the replay to fail")
+	}
+	for file, expected := range map[string]string{"a.txt": "a", "b.txt": "b"} {
+		if data, e := os.ReadFile(filepath.Join(dstDir, file)); e != nil || string(data) != expected {
+			t.Fatalf("Expected %s to be copied, got %q (%v)", file, data, e)
+		}
+	}
+	if _, e := os.Stat(filepath.Join(dstDir, "old.txt")); !os.IsNotExist(e) {
+		t.Fatalf("Expected old.txt to be removed, got %v", e)
+	}
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/shlex"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+)
+
+// Built-in actions of find --action.
+const (
+	findActionRm        = "rm"
+	findActionTag       = "tag"
+	findActionRetention = "retention"
+	findActionRestore   = "restore"
+	findActionCp        = "cp"
+)
+
+// findAction - a built-in action run in process on the matching objects
+// with the Client interface, instead of spawning a process per object.
+type findAction struct {
+	name        string
+	tags        string
+	mode        minio.RetentionMode
+	retainUntil time.Time
+	days        int
+	target      string
+}
+
+// parseFindAction - parses the value of --action, one of 'rm',
+// 'tag:k=v&k2=v2', 'retention:MODE,VALIDITY', 'restore[:DAYS]' or
+// 'cp:TARGET'.
+func parseFindAction(value string) (*findAction, *probe.Error) {
+	name, arg, hasArg := strings.Cut(value, ":")
+	a := &findAction{name: name}
+	switch name {
+	case findActionRm:
+		if hasArg {
+			return nil, errInvalidArgument().Trace(value)
+		}
+	case findActionTag:
+		if !strings.Contains(arg, "=") {
+			return nil, errInvalidArgument().Trace(value)
+		}
+		a.tags = arg
+	case findActionRetention:
+		mode, validityStr, _ := strings.Cut(arg, ",")
+		a.mode = minio.RetentionMode(strings.ToUpper(mode))
+		if !a.mode.IsValid() || validityStr == "" {
+			return nil, errInvalidArgument().Trace(value)
+		}
+		validity, unit, err := parseRetentionValidity(validityStr)
+		if err != nil {
+			return nil, err.Trace(value)
+		}
+		until, err := getRetainUntilDate(validity, unit)
+		if err != nil {
+			return nil, err.Trace(value)
+		}
+		a.retainUntil, _ = time.Parse(time.RFC3339, until)
+	case findActionRestore:
+		a.days = 1
+		if hasArg {
+			days, e := strconv.Atoi(arg)
+			if e != nil || days < 1 {
+				return nil, errInvalidArgument().Trace(value)
+			}
+			a.days = days
+		}
+	case findActionCp:
+		if arg == "" {
+			return nil, errInvalidArgument().Trace(value)
+		}
+		a.target = arg
+	default:
+		return nil, errInvalidArgument().Trace(value)
+	}
+	return a, nil
+}
+
+// findActionMessage - the result of a built-in action on an object.
+type findActionMessage struct {
+	Status    string `json:"status"`
+	Action    string `json:"action"`
+	Key       string `json:"key"`
+	VersionID string `json:"versionID,omitempty"`
+	Target    string `json:"target,omitempty"`
+}
+
+// String colorized action message.
+func (m findActionMessage) String() string {
+	var msg string
+	switch m.Action {
+	case findActionTag:
+		msg = "Tags set for `" + m.Key + "`"
+	case findActionRetention:
+		msg = "Retention set for `" + m.Key + "`"
+	case findActionRestore:
+		msg = "Restore requested for `" + m.Key + "`"
+	case findActionCp:
+		msg = "Copied `" + m.Key + "` to `" + m.Target + "`"
+	}
+	if m.VersionID != "" {
+		msg += " (" + m.VersionID + ")"
+	}
+	return console.Colorize("Find", msg+".")
+}
+
+// JSON jsonified action message.
+func (m findActionMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// findPlaceholder - matches the substitutions of --exec, see stringsReplace.
+var findPlaceholder = regexp.MustCompile(`{"?(base|dir|size|time|url|version)?"?}`)
+
+// findJob - a matching object handed to the workers.
+type findJob struct {
+	msg     contentMessage
+	content *ClientContent
+}
+
+// findExecutor - runs --exec or --action on the matching objects with
+// --exec-parallel workers. --exec gets up to --exec-batch objects per
+// process. Without --continue-on-error the first failure stops find.
+type findExecutor struct {
+	ctx             context.Context
+	cancel          context.CancelFunc
+	findCtx         *findContext
+	execArgs        []string
+	action          *findAction
+	batchSize       int
+	continueOnError bool
+	retry           retryPolicy
+	encKeyDB        map[string][]prefixSSEPair
+
+	batch   []findJob
+	jobsCh  chan []findJob
+	wg      sync.WaitGroup
+	remover *bulkRemover
+	rmStats *rmStats
+
+	mu         sync.Mutex
+	exitStatus int
+}
+
+// newFindExecutor - parses the --exec and --action flags and starts the
+// workers, returns nil when there is nothing to run. cancel stops the
+// listing after a failure.
+func newFindExecutor(ctx context.Context, cancel context.CancelFunc, findCtx *findContext, cliCtx *cli.Context, encKeyDB map[string][]prefixSSEPair) (*findExecutor, *probe.Error) {
+	execCmd, actionStr := cliCtx.String("exec"), cliCtx.String("action")
+	parallel, batchSize := cliCtx.Int("exec-parallel"), cliCtx.Int("exec-batch")
+	switch {
+	case execCmd != "" && actionStr != "":
+		return nil, errInvalidArgument().Trace("--exec", "--action")
+	case parallel < 1:
+		return nil, errInvalidArgument().Trace(strconv.Itoa(parallel))
+	case batchSize < 0 || (batchSize > 0 && execCmd == ""):
+		return nil, errInvalidArgument().Trace("--exec-batch", strconv.Itoa(batchSize))
+	case execCmd == "" && actionStr == "":
+		return nil, nil
+	}
+	if batchSize == 0 {
+		batchSize = 1
+	}
+
+	e := &findExecutor{
+		ctx:             ctx,
+		cancel:          cancel,
+		findCtx:         findCtx,
+		batchSize:       batchSize,
+		continueOnError: cliCtx.Bool("continue-on-error"),
+		retry:           retryPolicyFromContext(cliCtx),
+		encKeyDB:        encKeyDB,
+		jobsCh:          make(chan []findJob),
+	}
+	if execCmd != "" {
+		split, err := shlex.Split(execCmd)
+		if err != nil {
+			return nil, probe.NewError(err).Trace(execCmd)
+		}
+		e.execArgs = split
+	} else {
+		action, err := parseFindAction(actionStr)
+		if err != nil {
+			return nil, err
+		}
+		e.action = action
+	}
+
+	if e.action != nil && e.action.name == findActionRm {
+		// Removals are sent in bulk by the workers of rm.
+		e.rmStats = &rmStats{}
+		e.remover = newBulkRemover(ctx, cancel, findCtx.clnt, findCtx.targetAlias, removeOpts{
+			retry:           e.retry,
+			workers:         parallel,
+			stats:           e.rmStats,
+			continueOnError: e.continueOnError,
+		})
+		return e, nil
+	}
+	for i := 0; i < parallel; i++ {
+		e.wg.Add(1)
+		go e.run()
+	}
+	return e, nil
+}
+
+// add - hands a matching object to the workers, once a batch is full.
+func (e *findExecutor) add(msg contentMessage, content *ClientContent) {
+	if e.ctx.Err() != nil {
+		return
+	}
+	if e.action != nil && content.Type.IsDir() {
+		// Actions only apply to objects.
+		return
+	}
+	if e.remover != nil {
+		e.remover.send(content)
+		return
+	}
+	metricsObjectDone(content.Size)
+	e.batch = append(e.batch, findJob{msg: msg, content: content})
+	if len(e.batch) >= e.batchSize {
+		e.flush()
+	}
+}
+
+// flush - hands the pending objects to the workers, like the events of
+// --watch which may not fill a batch.
+func (e *findExecutor) flush() {
+	if e == nil || len(e.batch) == 0 {
+		return
+	}
+	select {
+	case e.jobsCh <- e.batch:
+	case <-e.ctx.Done():
+	}
+	e.batch = nil
+}
+
+// run - runs the batches until all are sent, the remaining batches are
+// skipped after a failure.
+func (e *findExecutor) run() {
+	defer e.wg.Done()
+	for jobs := range e.jobsCh {
+		if e.ctx.Err() != nil {
+			continue
+		}
+		if e.execArgs != nil {
+			e.exec(jobs)
+			continue
+		}
+		for _, job := range jobs {
+			e.act(job)
+		}
+	}
+}
+
+// fail - records a failure with the exit status of find, find stops
+// unless --continue-on-error.
+func (e *findExecutor) fail(status int) {
+	e.mu.Lock()
+	if e.exitStatus == 0 {
+		e.exitStatus = status
+	}
+	e.mu.Unlock()
+	if !e.continueOnError {
+		e.cancel()
+	}
+}
+
+// exec - spawns --exec for a batch of objects.
+func (e *findExecutor) exec(jobs []findJob) {
+	contents := make([]contentMessage, len(jobs))
+	for i, job := range jobs {
+		contents[i] = job.msg
+	}
+	if err := execFind(e.ctx, e.execArgs, contents); err != nil {
+		e.fail(getExitStatus(err))
+	}
+}
+
+// act - runs the built-in action on an object.
+func (e *findExecutor) act(job findJob) {
+	msg := findActionMessage{
+		Action:    e.action.name,
+		Key:       job.msg.Key,
+		VersionID: job.content.VersionID,
+	}
+	err := retryWithMessage(e.ctx, e.retry, "", msg.Key, func() *probe.Error {
+		if e.action.name == findActionCp {
+			msg.Target = urlJoinPath(e.action.target, e.relativePath(job.content))
+			return e.copy(job.content, msg.Target)
+		}
+		clnt, err := newClientFromAlias(e.findCtx.targetAlias, job.content.URL.String())
+		if err != nil {
+			return err
+		}
+		switch e.action.name {
+		case findActionTag:
+			return clnt.SetTags(e.ctx, job.content.VersionID, e.action.tags)
+		case findActionRetention:
+			return clnt.PutObjectRetention(e.ctx, job.content.VersionID, e.action.mode, e.action.retainUntil, false)
+		default:
+			return clnt.Restore(e.ctx, job.content.VersionID, e.action.days)
+		}
+	})
+	if err != nil {
+		if e.ctx.Err() == nil {
+			errorIf(err.Trace(msg.Key), "Unable to run --action "+e.action.name+" on `"+msg.Key+"`.")
+		}
+		e.fail(globalErrorExitStatus)
+		return
+	}
+	printMsg(msg)
+}
+
+// relativePath - returns the name of an object relative to the find
+// target.
+func (e *findExecutor) relativePath(content *ClientContent) string {
+	clntURL := e.findCtx.clnt.GetURL()
+	name := strings.TrimPrefix(content.URL.Path, clntURL.Path)
+	return strings.TrimLeft(name, string(clntURL.Separator))
+}
+
+// copy - copies an object to the target of --action cp.
+func (e *findExecutor) copy(content *ClientContent, targetPath string) *probe.Error {
+	targetAlias, targetURL, _ := mustExpandAlias(targetPath)
+	urls := URLs{
+		SourceAlias:   e.findCtx.targetAlias,
+		SourceContent: content,
+		TargetAlias:   targetAlias,
+		TargetContent: &ClientContent{URL: *newClientURL(targetURL)},
+	}
+	return uploadSourceToTargetURL(e.ctx, uploadSourceToTargetURLOpts{urls: urls, encKeyDB: e.encKeyDB}).Error
+}
+
+// wait - runs the pending objects and waits for the workers, returns
+// the exit status of the first failure.
+func (e *findExecutor) wait() error {
+	if e == nil {
+		return nil
+	}
+	if e.remover != nil {
+		if err := e.remover.wait(); err != nil {
+			return err
+		}
+		if e.rmStats.failed > 0 {
+			return exitStatus(globalErrorExitStatus)
+		}
+		return nil
+	}
+	e.flush()
+	close(e.jobsCh)
+	e.wg.Wait()
+	if e.exitStatus != 0 {
+		return exitStatus(e.exitStatus)
+	}
+	return nil
+}
+
+// findExecArgs - formats the arguments of --exec in accordance with
+// the substitution arguments. Arguments with substitutions are repeated
+// for each object of a batch, like xargs.
+func findExecArgs(ctx context.Context, args []string, contents []contentMessage) []string {
+	var split []string
+	for _, arg := range args {
+		if !findPlaceholder.MatchString(arg) {
+			split = append(split, arg)
+			continue
+		}
+		for _, fileContent := range contents {
+			split = append(split, stringsReplace(ctx, arg, fileContent))
+		}
+	}
+	return split
+}
+
+// execFind executes the input command line for a batch of objects,
+// returns the error of the command. The command is killed when ctx
+// is canceled.
+func execFind(ctx context.Context, args []string, contents []contentMessage) error {
+	if len(args) == 0 {
+		return nil
+	}
+	split := findExecArgs(ctx, args, contents)
+	cmd := exec.CommandContext(ctx, split[0], split[1:]...)
+	var out bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			console.Println(console.Colorize("FindExecErr", strings.TrimSpace(stderr.String())))
+		}
+		console.Println(console.Colorize("FindExecErr", fmt.Sprintf("%s: %v", strings.Join(split, " "), err)))
+		return err
+	}
+	console.PrintC(out.String())
+	return nil
+}
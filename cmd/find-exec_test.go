@@ -0,0 +1,177 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestParseFindAction(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected findAction
+	}{
+		{"rm", findAction{name: findActionRm}},
+		{"tag:tier=hot&team=ops", findAction{name: findActionTag, tags: "tier=hot&team=ops"}},
+		{"restore", findAction{name: findActionRestore, days: 1}},
+		{"restore:7", findAction{name: findActionRestore, days: 7}},
+		{"cp:s3/archive/", findAction{name: findActionCp, target: "s3/archive/"}},
+	}
+	for _, testCase := range testCases {
+		a, err := parseFindAction(testCase.value)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.value, err)
+		}
+		if !reflect.DeepEqual(*a, testCase.expected) {
+			t.Errorf("%s: expected %+v, got %+v", testCase.value, testCase.expected, *a)
+		}
+	}
+
+	a, err := parseFindAction("retention:governance,30d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.mode != minio.Governance || a.retainUntil.Before(UTCNow().AddDate(0, 0, 29)) {
+		t.Fatalf("Unexpected retention %s until %s", a.mode, a.retainUntil)
+	}
+
+	for _, value := range []string{"", "rm:now", "tag", "tag:hot", "retention:forever,1d", "retention:compliance", "retention:compliance,1w", "restore:0", "restore:x", "cp", "cp:", "mv:s3/b"} {
+		if _, err := parseFindAction(value); err == nil {
+			t.Errorf("%s: expected an invalid action", value)
+		}
+	}
+}
+
+func TestFindExecArgs(t *testing.T) {
+	contents := []contentMessage{{Key: "s3/b/x.csv"}, {Key: "s3/b/y.csv"}}
+	args := findExecArgs(context.Background(), []string{"mc", "cp", "{}", "--attr", "k={base}", "s3/dst/"}, contents)
+	expected := []string{"mc", "cp", "s3/b/x.csv", "s3/b/y.csv", "--attr", "k=x.csv", "k=y.csv", "s3/dst/"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected %v, got %v", expected, args)
+	}
+}
+
+func TestExecFindCanceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the command is a shell command")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	start := time.Now()
+	if e := execFind(ctx, []string{"sleep", "10"}, nil); e == nil {
+		t.Fatal("Expected a canceled command to fail")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("Expected a canceled command to be killed")
+	}
+}
+
+func TestFindExecutor(t *testing.T) {
+	root, srcDir := newTestFolder(t, nil)
+	for _, name := range []string{"a.csv", "b.csv", "c.log", "d/e.csv"} {
+		name = filepath.Join(srcDir, name)
+		writeTestFile(t, name, name)
+	}
+
+	runFind := func(args ...string) error {
+		t.Helper()
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("exec", "", "")
+		set.String("action", "", "")
+		set.Int("exec-parallel", 1, "")
+		set.Int("exec-batch", 0, "")
+		set.Bool("continue-on-error", false, "")
+		set.Int("max-retries", 0, "")
+		if e := set.Parse(args); e != nil {
+			t.Fatal(e)
+		}
+		clnt, err := newClient(srcDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		findCtx := &findContext{namePattern: "*.csv", targetURL: srcDir, clnt: clnt}
+		findCtx.executor, err = newFindExecutor(ctx, cancel, findCtx, cli.NewContext(nil, set, nil), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := doFind(ctx, findCtx); e != nil {
+			t.Fatal(e)
+		}
+		return findCtx.executor.wait()
+	}
+
+	dstDir := filepath.Join(root, "dst")
+	if e := runFind("--action", "cp:"+dstDir, "--exec-parallel", "4"); e != nil {
+		t.Fatal(e)
+	}
+	for _, name := range []string{"a.csv", "b.csv", "d/e.csv"} {
+		if _, e := os.Stat(filepath.Join(dstDir, name)); e != nil {
+			t.Errorf("Expected %s to be copied: %v", name, e)
+		}
+	}
+	if _, e := os.Stat(filepath.Join(dstDir, "c.log")); !os.IsNotExist(e) {
+		t.Errorf("Expected c.log not to be copied: %v", e)
+	}
+
+	// Tags are not supported by local folders, all the objects fail.
+	if e := runFind("--action", "tag:k=v", "--continue-on-error"); e == nil {
+		t.Fatal("Expected the tagging to fail")
+	}
+
+	if e := runFind("--action", "rm", "--exec-parallel", "2"); e != nil {
+		t.Fatal(e)
+	}
+	if _, e := os.Stat(filepath.Join(srcDir, "c.log")); e != nil {
+		t.Errorf("Expected c.log to be kept: %v", e)
+	}
+	for _, name := range []string{"a.csv", "b.csv", "d/e.csv"} {
+		if _, e := os.Stat(filepath.Join(srcDir, name)); !os.IsNotExist(e) {
+			t.Errorf("Expected %s to be removed: %v", name, e)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"--exec", "echo {}", "--action", "rm"},
+		{"--action", "rm", "--exec-parallel", "0"},
+		{"--action", "rm", "--exec-batch", "10"},
+	} {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.String("exec", "", "")
+		set.String("action", "", "")
+		set.Int("exec-parallel", 1, "")
+		set.Int("exec-batch", 0, "")
+		if e := set.Parse(args); e != nil {
+			t.Fatal(e)
+		}
+		if _, err := newFindExecutor(context.Background(), func() {}, &findContext{}, cli.NewContext(nil, set, nil), nil); err == nil {
+			t.Errorf("%v: expected invalid flags", args)
+		}
+	}
+}
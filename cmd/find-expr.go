@@ -0,0 +1,526 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// findObject - an object matched by find, the path is the name
+// relative to the find target. The number of versions of the object
+// is only counted when an expression uses 'versions'.
+type findObject struct {
+	*ClientContent
+	path     string
+	versions int
+}
+
+// findMatcher - returns true if the object matches.
+type findMatcher func(o findObject) bool
+
+// findAnd - matches objects matching all the matchers.
+func findAnd(matchers ...findMatcher) findMatcher {
+	if len(matchers) == 1 {
+		return matchers[0]
+	}
+	return func(o findObject) bool {
+		for _, m := range matchers {
+			if !m(o) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// findOr - matches objects matching any of the matchers.
+func findOr(matchers ...findMatcher) findMatcher {
+	if len(matchers) == 1 {
+		return matchers[0]
+	}
+	return func(o findObject) bool {
+		for _, m := range matchers {
+			if m(o) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// findNot - matches objects not matching the matcher.
+func findNot(m findMatcher) findMatcher {
+	return func(o findObject) bool {
+		return !m(o)
+	}
+}
+
+// findName - matches the base name, or any component of the path.
+func findName(pattern string) findMatcher {
+	return func(o findObject) bool {
+		return nameMatch(pattern, o.path)
+	}
+}
+
+// findPath - matches the path with a wildcard pattern.
+func findPath(pattern string) findMatcher {
+	return func(o findObject) bool {
+		return pathMatch(pattern, o.path)
+	}
+}
+
+// findRegex - matches the path with a RE2 regex.
+func findRegex(re *regexp.Regexp) findMatcher {
+	return func(o findObject) bool {
+		return re.MatchString(o.path)
+	}
+}
+
+// findAge - compares the age of objects, the time since they were last
+// modified, with a duration.
+func findAge(op string, d time.Duration) findMatcher {
+	return func(o findObject) bool {
+		return findCompare(op, int64(time.Since(o.Time)), int64(d))
+	}
+}
+
+// findSize - compares the size of objects.
+func findSize(op string, size int64) findMatcher {
+	return func(o findObject) bool {
+		return findCompare(op, o.Size, size)
+	}
+}
+
+// findVersions - compares the number of versions of objects.
+func findVersions(op string, versions int64) findMatcher {
+	return func(o findObject) bool {
+		return findCompare(op, int64(o.versions), versions)
+	}
+}
+
+// findAttrs - matches the sizes, metadata and tags of the flags.
+func findAttrs(f objectAttrFilter) findMatcher {
+	return func(o findObject) bool {
+		return f.matchAttrs(o.Size, o.UserMetadata, o.Tags)
+	}
+}
+
+// findFold - matches a value of objects, case insensitive.
+func findFold(value func(o findObject) string, want string) findMatcher {
+	return func(o findObject) bool {
+		return strings.EqualFold(value(o), want)
+	}
+}
+
+// findCompare - compares a and b with a comparison operator.
+func findCompare(op string, a, b int64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "!=":
+		return a != b
+	}
+	return a == b
+}
+
+// findHeader - returns a value of the metadata listed by MinIO, the
+// keys are case insensitive.
+func findHeader(metadata map[string]string, key string) string {
+	for k, v := range metadata {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// findRetentionMode - returns the retention mode of an object.
+func findRetentionMode(o findObject) string {
+	if o.RetentionMode != "" {
+		return o.RetentionMode
+	}
+	return findHeader(o.Metadata, AmzObjectLockMode)
+}
+
+// findLegalHold - returns the legal hold status of an object, OFF when
+// not set.
+func findLegalHold(o findObject) string {
+	status := o.LegalHold
+	if status == "" {
+		status = findHeader(o.Metadata, AmzObjectLockLegalHold)
+	}
+	if status == "" {
+		status = "OFF"
+	}
+	return status
+}
+
+// findExpr - a compiled --expr, with the names of the predicates it
+// uses.
+type findExpr struct {
+	match findMatcher
+	names map[string]bool
+}
+
+// uses - returns true if the expression uses the predicate.
+func (e *findExpr) uses(name string) bool {
+	return e != nil && e.names[name]
+}
+
+// withMetadata - returns true if objects must be listed with their
+// metadata and tags to be matched.
+func (e *findExpr) withMetadata() bool {
+	for _, name := range []string{"tag", "metadata", "retention", "legalHold", "replicationStatus"} {
+		if e.uses(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// findToken - a token of --expr, the position is the offset in the
+// expression.
+type findToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+// isFindOperator - returns true for the characters of comparison
+// operators.
+func isFindOperator(c byte) bool {
+	return c == '<' || c == '>' || c == '=' || c == '!'
+}
+
+// tokenizeFindExpr - splits an expression into parentheses, commas,
+// comparison operators, quoted strings and words. In quoted strings a
+// backslash only escapes the quote, so regexes are written as is.
+func tokenizeFindExpr(s string) ([]findToken, *probe.Error) {
+	var tokens []findToken
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, findToken{text: string(c), pos: i})
+			i++
+		case c == '"' || c == '\'':
+			var text strings.Builder
+			j := i + 1
+			for ; j < len(s) && s[j] != c; j++ {
+				if s[j] == '\\' && j+1 < len(s) && s[j+1] == c {
+					j++
+				}
+				text.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, probe.NewError(fmt.Errorf("unterminated string at position %d", i+1))
+			}
+			tokens = append(tokens, findToken{text: text.String(), quoted: true, pos: i})
+			i = j + 1
+		case isFindOperator(c):
+			j := i + 1
+			for j < len(s) && isFindOperator(s[j]) {
+				j++
+			}
+			tokens = append(tokens, findToken{text: s[i:j], pos: i})
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n(),\"'", rune(s[j])) && !isFindOperator(s[j]) {
+				j++
+			}
+			tokens = append(tokens, findToken{text: s[i:j], pos: i})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// findExprParser - parses the tokens of an expression, 'not' binds
+// tighter than 'and' which binds tighter than 'or'.
+type findExprParser struct {
+	tokens []findToken
+	pos    int
+	names  map[string]bool
+}
+
+// peek - returns the next token, an empty token at the end.
+func (p *findExprParser) peek() findToken {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return findToken{pos: -1}
+}
+
+// next - returns the next token and moves past it.
+func (p *findExprParser) next() findToken {
+	t := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return t
+}
+
+// isKeyword - returns true if the next token is the keyword.
+func (p *findExprParser) isKeyword(keyword string) bool {
+	t := p.peek()
+	return !t.quoted && strings.EqualFold(t.text, keyword)
+}
+
+// errorf - returns a syntax error at a token.
+func (p *findExprParser) errorf(t findToken, format string, args ...interface{}) *probe.Error {
+	msg := fmt.Sprintf(format, args...)
+	if t.pos < 0 {
+		return probe.NewError(fmt.Errorf("%s at the end of the expression", msg))
+	}
+	return probe.NewError(fmt.Errorf("%s at position %d", msg, t.pos+1))
+}
+
+// expect - moves past the next token, which must be text.
+func (p *findExprParser) expect(text string) *probe.Error {
+	if t := p.next(); t.quoted || t.text != text {
+		return p.errorf(t, "expected `%s`", text)
+	}
+	return nil
+}
+
+func (p *findExprParser) parseOr() (findMatcher, *probe.Error) {
+	m, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	matchers := []findMatcher{m}
+	for p.isKeyword("or") {
+		p.next()
+		if m, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return findOr(matchers...), nil
+}
+
+func (p *findExprParser) parseAnd() (findMatcher, *probe.Error) {
+	m, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	matchers := []findMatcher{m}
+	for p.isKeyword("and") {
+		p.next()
+		if m, err = p.parseNot(); err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+	return findAnd(matchers...), nil
+}
+
+func (p *findExprParser) parseNot() (findMatcher, *probe.Error) {
+	if !p.isKeyword("not") {
+		return p.parsePrimary()
+	}
+	p.next()
+	m, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return findNot(m), nil
+}
+
+// parsePrimary - parses a group, a predicate call like name("*.log")
+// or a comparison like size > 1GiB.
+func (p *findExprParser) parsePrimary() (findMatcher, *probe.Error) {
+	t := p.next()
+	switch {
+	case t.pos < 0:
+		return nil, p.errorf(t, "expected a predicate")
+	case !t.quoted && t.text == "(":
+		m, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		return m, nil
+	case t.quoted || strings.ContainsAny(t.text, "(),") || isFindOperator(t.text[0]):
+		return nil, p.errorf(t, "unexpected `%s`", t.text)
+	}
+
+	if next := p.peek(); !next.quoted && next.text == "(" {
+		p.next()
+		var args []string
+		for {
+			arg := p.next()
+			if !arg.quoted && arg.text == ")" && len(args) == 0 {
+				break
+			}
+			if arg.pos < 0 || (!arg.quoted && strings.ContainsAny(arg.text, "(),")) || (!arg.quoted && isFindOperator(arg.text[0])) {
+				return nil, p.errorf(arg, "expected an argument of %s", t.text)
+			}
+			args = append(args, arg.text)
+			if sep := p.next(); sep.quoted || (sep.text != "," && sep.text != ")") {
+				return nil, p.errorf(sep, "expected `,` or `)`")
+			} else if sep.text == ")" {
+				break
+			}
+		}
+		m, err := newFindPredicate(t.text, args)
+		if err != nil {
+			return nil, p.errorf(t, "%s", err.ToGoError())
+		}
+		p.names[t.text] = true
+		return m, nil
+	}
+
+	op := p.next()
+	value := p.next()
+	if op.pos < 0 || op.quoted || !isFindOperator(op.text[0]) {
+		return nil, p.errorf(op, "expected a comparison operator after `%s`", t.text)
+	}
+	if value.pos < 0 {
+		return nil, p.errorf(value, "expected a value after `%s`", op.text)
+	}
+	m, err := newFindComparison(t.text, op.text, value.text)
+	if err != nil {
+		return nil, p.errorf(t, "%s", err.ToGoError())
+	}
+	p.names[t.text] = true
+	return m, nil
+}
+
+// newFindPredicate - returns the matcher of a predicate call.
+func newFindPredicate(name string, args []string) (findMatcher, *probe.Error) {
+	minArgs, maxArgs := 1, 1
+	switch name {
+	case "name", "path", "regex", "storageClass", "retention", "replicationStatus":
+	case "tag", "metadata":
+		minArgs, maxArgs = 2, 2
+	case "legalHold":
+		minArgs = 0
+	default:
+		return nil, probe.NewError(fmt.Errorf("unknown predicate %s()", name))
+	}
+	if len(args) < minArgs || len(args) > maxArgs {
+		return nil, probe.NewError(fmt.Errorf("wrong number of arguments for %s()", name))
+	}
+
+	switch name {
+	case "name":
+		return findName(args[0]), nil
+	case "path":
+		return findPath(args[0]), nil
+	case "regex":
+		re, e := regexp.Compile(args[0])
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		return findRegex(re), nil
+	case "storageClass":
+		return findFold(func(o findObject) string { return o.StorageClass }, args[0]), nil
+	case "retention":
+		return findFold(findRetentionMode, args[0]), nil
+	case "legalHold":
+		status := "ON"
+		if len(args) > 0 {
+			status = args[0]
+		}
+		return findFold(findLegalHold, status), nil
+	case "replicationStatus":
+		return findFold(func(o findObject) string { return o.ReplicationStatus }, args[0]), nil
+	default:
+		// tag and metadata, same as --tags and --metadata an empty
+		// regex matches missing or empty values.
+		re, err := parseRegexMap([]string{args[0] + "=" + args[1]})
+		if err != nil {
+			return nil, err
+		}
+		if name == "tag" {
+			return func(o findObject) bool { return matchRegexMaps(re, o.Tags) }, nil
+		}
+		return func(o findObject) bool { return matchRegexMaps(re, o.UserMetadata) }, nil
+	}
+}
+
+// newFindComparison - returns the matcher of a comparison of the size,
+// the age or the number of versions.
+func newFindComparison(name, op, value string) (findMatcher, *probe.Error) {
+	switch op {
+	case "<", "<=", ">", ">=", "=", "==", "!=":
+	default:
+		return nil, probe.NewError(fmt.Errorf("unknown operator %s", op))
+	}
+	switch name {
+	case "size":
+		size, e := humanize.ParseBytes(value)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		return findSize(op, int64(size)), nil
+	case "age":
+		d, e := ParseDuration(value)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		return findAge(op, time.Duration(d)), nil
+	case "versions":
+		n, e := strconv.ParseInt(value, 10, 64)
+		if e != nil {
+			return nil, probe.NewError(e)
+		}
+		return findVersions(op, n), nil
+	}
+	return nil, probe.NewError(fmt.Errorf("unknown comparison %s", name))
+}
+
+// parseFindExpr - compiles the value of --expr, an empty expression
+// returns nil.
+func parseFindExpr(s string) (*findExpr, *probe.Error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	tokens, err := tokenizeFindExpr(s)
+	if err != nil {
+		return nil, err.Trace(s)
+	}
+	p := &findExprParser{tokens: tokens, names: map[string]bool{}}
+	m, err := p.parseOr()
+	if err != nil {
+		return nil, err.Trace(s)
+	}
+	if t := p.peek(); t.pos >= 0 {
+		return nil, p.errorf(t, "unexpected `%s`", t.text).Trace(s)
+	}
+	return &findExpr{match: m, names: p.names}, nil
+}
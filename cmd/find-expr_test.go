@@ -0,0 +1,114 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFindExpr(t *testing.T) {
+	now := time.Now()
+	log := &ClientContent{Size: 2 << 30, Time: now.Add(-48 * time.Hour), StorageClass: "STANDARD"}
+	archived := &ClientContent{Size: 2 << 30, Time: now, StorageClass: "GLACIER"}
+	locked := &ClientContent{
+		Size: 10,
+		Time: now,
+		Metadata: map[string]string{
+			"X-Amz-Object-Lock-Mode":       "GOVERNANCE",
+			"X-Amz-Object-Lock-Legal-Hold": "ON",
+		},
+		Tags:              map[string]string{"tier": "hot"},
+		ReplicationStatus: "COMPLETED",
+	}
+
+	testCases := []struct {
+		expr     string
+		path     string
+		content  *ClientContent
+		versions int
+		match    bool
+	}{
+		{`(name("*.log") or name("*.gz")) and not storageClass("GLACIER") and size > 1GiB`, "app/a.log", log, 1, true},
+		{`(name("*.log") or name("*.gz")) and not storageClass("GLACIER") and size > 1GiB`, "app/a.gz", archived, 1, false},
+		{`(name("*.log") or name("*.gz")) and not storageClass("GLACIER") and size > 1GiB`, "app/a.txt", log, 1, false},
+		{`name("*.log") or name("*.gz") and size < 1KiB`, "app/a.log", log, 1, true},
+		{`(name("*.log") or name("*.gz")) and size < 1KiB`, "app/a.log", log, 1, false},
+		{`not not name("*.log")`, "a.log", log, 1, true},
+		{`NOT path('app/*') AND storageClass("glacier")`, "app/a.gz", archived, 1, false},
+		{`path('app/*') and storageClass("glacier")`, "app/a.gz", archived, 1, true},
+		{`regex("\.(log|gz)$")`, "app/a.log", log, 1, true},
+		{`regex("\.(log|gz)$")`, "app/a.log.1", log, 1, false},
+		{`age > 1d and age <= 3d`, "a", log, 1, true},
+		{`age > 1d`, "a", archived, 1, false},
+		{`versions > 3`, "a", log, 4, true},
+		{`versions >= 5 or versions = 1`, "a", log, 4, false},
+		{`versions != 4`, "a", log, 4, false},
+		{`retention("governance") and legalHold()`, "a", locked, 1, true},
+		{`retention("COMPLIANCE")`, "a", locked, 1, false},
+		{`legalHold("OFF")`, "a", log, 1, true},
+		{`tag("tier", "^hot$") and replicationStatus("COMPLETED")`, "a", locked, 1, true},
+		{`tag("tier", "")`, "a", log, 1, true},
+		{`metadata("content-type", "json")`, "a", locked, 1, false},
+	}
+	for i, testCase := range testCases {
+		expr, err := parseFindExpr(testCase.expr)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		o := findObject{ClientContent: testCase.content, path: testCase.path, versions: testCase.versions}
+		if match := expr.match(o); match != testCase.match {
+			t.Errorf("Test %d: %s: expected %t, got %t", i+1, testCase.expr, testCase.match, match)
+		}
+	}
+
+	for _, invalid := range []string{
+		`name("*.log"`,
+		`name("*.log") or`,
+		`name("*.log) and size > 1`,
+		`(name("*.log")`,
+		`name("*.log"))`,
+		`name("*.log") size > 1GiB`,
+		`owner("root")`,
+		`name()`,
+		`tag("tier")`,
+		`size > ten`,
+		`size >`,
+		`size => 1`,
+		`age < 7x`,
+		`versions > 1.5`,
+		`regex("(")`,
+		`not`,
+		`and name("*")`,
+	} {
+		if _, err := parseFindExpr(invalid); err == nil {
+			t.Errorf("%s: expected a syntax error", invalid)
+		}
+	}
+
+	expr, err := parseFindExpr(`versions > 1 or tag("tier", "hot")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expr.uses("versions") || expr.uses("storageClass") || !expr.withMetadata() {
+		t.Fatalf("Unexpected predicates %v", expr.names)
+	}
+	if expr, err = parseFindExpr(" "); err != nil || expr != nil || expr.uses("versions") {
+		t.Fatal("Expected no expression")
+	}
+}
@@ -23,10 +23,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 	"github.com/minio/cli"
-	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 )
 
@@ -37,6 +35,23 @@ var (
 			Name:  "exec",
 			Usage: "spawn an external process for each matching object (see FORMAT)",
 		},
+		cli.IntFlag{
+			Name:  "exec-parallel",
+			Usage: "run --exec or --action on this many objects in parallel",
+			Value: 1,
+		},
+		cli.IntFlag{
+			Name:  "exec-batch",
+			Usage: "pass up to this many objects to each --exec process, like xargs (see FORMAT)",
+		},
+		cli.StringFlag{
+			Name:  "action",
+			Usage: "run a built-in action on each matching object: rm, tag:k=v, retention:MODE,VALIDITY, restore[:DAYS] or cp:TARGET (see ACTIONS)",
+		},
+		cli.BoolFlag{
+			Name:  "continue-on-error",
+			Usage: "keep going after a failure of --exec or --action, the exit status is the one of the first failure",
+		},
 		cli.StringFlag{
 			Name:  "ignore",
 			Usage: "exclude objects matching the wildcard pattern",
@@ -93,6 +108,10 @@ var (
 			Name:  "tags",
 			Usage: "match tags with RE2 regex pattern. Specify each with key=regex. MinIO server only.",
 		},
+		cli.StringFlag{
+			Name:  "expr",
+			Usage: "match objects with an expression of predicates, along with the other flags (see EXPRESSION)",
+		},
 	}
 )
 
@@ -102,7 +121,7 @@ var findCmd = cli.Command{
 	Action:       mainFind,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(findFlags, globalFlags...),
+	Flags:        append(append(append(findFlags, listFormatFlags...), retryFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -137,6 +156,39 @@ FORMAT
 
      {url} --> Substitutes to a shareable URL of the path.
 
+  With --exec-batch, arguments with keywords are repeated for each object of the batch.
+
+ACTIONS
+  --action runs in process, without spawning a command per object.
+
+     rm                        --> Remove the object, or the version with --versions.
+     tag:KEY=VALUE[&KEY=VALUE] --> Replace the tags of the object.
+     retention:MODE,VALIDITY   --> Set the retention, e.g. retention:GOVERNANCE,30d.
+     restore[:DAYS]            --> Restore a transitioned object, for 1 day by default.
+     cp:TARGET                 --> Copy the object under TARGET, with its path relative to the find target.
+
+EXPRESSION
+  --expr combines predicates with 'and', 'or', 'not' and parentheses, 'not' binds
+  tighter than 'and' which binds tighter than 'or'. Strings are quoted with " or ',
+  a backslash only escapes the quote.
+
+     name("PATTERN")               --> Base name matches the wildcard pattern, like --name.
+     path("PATTERN")               --> Path matches the wildcard pattern, like --path.
+     regex("RE2")                  --> Path matches the RE2 regex, like --regex.
+     storageClass("CLASS")         --> Storage class of the object.
+     tag("KEY", "RE2")             --> Tag matches the RE2 regex, like --tags.
+     metadata("KEY", "RE2")        --> Metadata matches the RE2 regex, like --metadata.
+     retention("MODE")             --> Retention mode, GOVERNANCE or COMPLIANCE.
+     legalHold(), legalHold("OFF") --> Legal hold is ON, or OFF.
+     replicationStatus("STATUS")   --> Replication status, e.g. COMPLETED or FAILED.
+     size OP SIZE                  --> Size in units (see UNITS).
+     age OP DURATION               --> Time since last modified, e.g. 7d10h.
+     versions OP N                 --> Number of versions of the object.
+
+  OP is one of <, <=, >, >=, = or !=. tag, metadata, retention, legalHold and
+  replicationStatus are MinIO server only.
+
+` + listFormatHelp + `
 EXAMPLES:
   01. Find all "foo.jpg" in all buckets under "s3" account.
       {{.Prompt}} {{.HelpName}} s3 --name "foo.jpg"
@@ -171,6 +223,21 @@ EXAMPLES:
 
   11. Copy all versions of all objects in bucket in the local machine
       {{.Prompt}} {{.HelpName}} s3/bucket --versions --exec "mc cp --version-id {version} {} /tmp/dir/{}.{version}"
+
+  12. Find all objects larger than 1GiB under "s3/bucket", printing the key, size and storage class of the largest first.
+      {{.Prompt}} {{.HelpName}} s3/bucket --larger 1GiB --sort size --reverse --columns key,size,storageClass
+
+  13. Find all logs larger than 1GiB under "s3/bucket" which are not archived.
+      {{.Prompt}} {{.HelpName}} s3/bucket --expr '(name("*.log") or name("*.gz")) and not storageClass("GLACIER") and size > 1GiB'
+
+  14. Find all objects with more than 10 versions, or without retention, under "s3/bucket".
+      {{.Prompt}} {{.HelpName}} s3/bucket --expr 'versions > 10 or not (retention("GOVERNANCE") or retention("COMPLIANCE"))'
+
+  15. Tag all objects with ".csv" extension under "s3/bucket" with 32 objects in parallel, going on after failures.
+      {{.Prompt}} {{.HelpName}} s3/bucket --name "*.csv" --action "tag:format=csv" --exec-parallel 32 --continue-on-error
+
+  16. Compress the logs under "/var/log", 100 files per gzip process with 4 processes in parallel.
+      {{.Prompt}} {{.HelpName}} /var/log --name "*.log" --exec "gzip {}" --exec-batch 100 --exec-parallel 4
 `,
 }
 
@@ -207,7 +274,6 @@ func checkFindSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[stri
 // ease of repurposing.
 type findContext struct {
 	*cli.Context
-	execCmd           string
 	ignorePattern     string
 	namePattern       string
 	pathPattern       string
@@ -216,12 +282,15 @@ type findContext struct {
 	printFmt          string
 	olderThan         string
 	newerThan         string
-	largerSize        uint64
-	smallerSize       uint64
 	watch             bool
 	withOlderVersions bool
-	matchMeta         map[string]*regexp.Regexp
-	matchTags         map[string]*regexp.Regexp
+	objectAttrFilter
+	expr      *findExpr
+	formatter *listFormatter
+	executor  *findExecutor
+
+	// Compiled flags and expression.
+	matcher findMatcher
 
 	// Internal values
 	targetAlias   string
@@ -264,20 +333,9 @@ func mainFind(cliCtx *cli.Context) error {
 		newerThan = cliCtx.String("newer-than")
 	}
 
-	// Use 'e' to indicate Go error, this is a convention followed in `mc`. For probe.Error we call it
-	// 'err' and regular Go error is called as 'e'.
-	var e error
-	var largerSize, smallerSize uint64
-
-	if cliCtx.String("larger") != "" {
-		largerSize, e = humanize.ParseBytes(cliCtx.String("larger"))
-		fatalIf(probe.NewError(e).Trace(cliCtx.String("larger")), "Unable to parse input bytes.")
-	}
-
-	if cliCtx.String("smaller") != "" {
-		smallerSize, e = humanize.ParseBytes(cliCtx.String("smaller"))
-		fatalIf(probe.NewError(e).Trace(cliCtx.String("smaller")), "Unable to parse input bytes.")
-	}
+	attrFilter, err := parseObjectAttrFilter(cliCtx.String("larger"), cliCtx.String("smaller"),
+		cliCtx.StringSlice("metadata"), cliCtx.StringSlice("tags"))
+	fatalIf(err, "Unable to parse input bytes or key=regex values.")
 
 	// Get --versions flag
 	withVersions := cliCtx.Bool("versions")
@@ -289,15 +347,20 @@ func mainFind(cliCtx *cli.Context) error {
 	if hostCfg != nil {
 		targetFullURL = hostCfg.URL
 	}
+	formatter, err := newListFormatter(cliCtx)
+	fatalIf(err, "Unable to parse --sort, --columns or --format.")
+
+	expr, err := parseFindExpr(cliCtx.String("expr"))
+	fatalIf(err, "Unable to parse --expr.")
+
 	var regMatch *regexp.Regexp
 	if cliCtx.String("regex") != "" {
 		regMatch = regexp.MustCompile(cliCtx.String("regex"))
 	}
 
-	return doFind(ctx, &findContext{
+	findCtx := &findContext{
 		Context:           cliCtx,
 		maxDepth:          cliCtx.Uint("maxdepth"),
-		execCmd:           cliCtx.String("exec"),
 		printFmt:          cliCtx.String("print"),
 		namePattern:       cliCtx.String("name"),
 		pathPattern:       cliCtx.String("path"),
@@ -306,14 +369,20 @@ func mainFind(cliCtx *cli.Context) error {
 		withOlderVersions: withVersions,
 		olderThan:         olderThan,
 		newerThan:         newerThan,
-		largerSize:        largerSize,
-		smallerSize:       smallerSize,
 		watch:             cliCtx.Bool("watch"),
 		targetAlias:       targetAlias,
 		targetURL:         args[0],
 		targetFullURL:     targetFullURL,
 		clnt:              clnt,
-		matchMeta:         getRegexMap(cliCtx, "metadata"),
-		matchTags:         getRegexMap(cliCtx, "tags"),
-	})
+		objectAttrFilter:  attrFilter,
+		expr:              expr,
+		formatter:         formatter,
+	}
+	findCtx.executor, err = newFindExecutor(ctx, cancelFind, findCtx, cliCtx, encKeyDB)
+	fatalIf(err, "Unable to parse --exec or --action.")
+
+	if e := doFind(ctx, findCtx); e != nil {
+		return e
+	}
+	return findCtx.executor.wait()
 }
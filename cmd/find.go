@@ -18,21 +18,15 @@
 package cmd
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/dustin/go-humanize"
-	"github.com/google/shlex"
-	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 
@@ -123,36 +117,6 @@ func getExitStatus(err error) int {
 	return 1
 }
 
-// execFind executes the input command line, additionally formats input
-// for the command line in accordance with subsititution arguments.
-func execFind(ctx context.Context, args string, fileContent contentMessage) {
-	split, err := shlex.Split(args)
-	if err != nil {
-		console.Println(console.Colorize("FindExecErr", "Unable to parse --exec: "+err.Error()))
-		os.Exit(getExitStatus(err))
-	}
-	if len(split) == 0 {
-		return
-	}
-	for i, arg := range split {
-		split[i] = stringsReplace(ctx, arg, fileContent)
-	}
-	cmd := exec.Command(split[0], split[1:]...)
-	var out bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &out
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		if stderr.Len() > 0 {
-			console.Println(console.Colorize("FindExecErr", strings.TrimSpace(stderr.String())))
-		}
-		console.Println(console.Colorize("FindExecErr", err.Error()))
-		// Return exit status of the command run
-		os.Exit(getExitStatus(err))
-	}
-	console.PrintC(out.String())
-}
-
 // watchFind - enables listening on the input path, listens for all file/object
 // created actions. Asynchronously executes the input command line, also allows
 // formatting for the command line in accordance with subsititution arguments.
@@ -191,8 +155,10 @@ func watchFind(ctxCtx context.Context, ctx *findContext) {
 					Key:  getAliasedPath(ctx, event.Path),
 					Time: time,
 					Size: event.Size,
-				})
+				}, &ClientContent{URL: *newClientURL(event.Path), Time: time, Size: event.Size}, 0)
 			}
+			// Events may not fill a batch of --exec-batch.
+			ctx.executor.flush()
 		case err, ok := <-watchObj.Errors():
 			if !ok {
 				return
@@ -245,21 +211,22 @@ func getAliasedPath(ctx *findContext, path string) string {
 	return trimSuffixAtMaxDepth(ctx.targetURL, aliasedPath, separator, ctx.maxDepth)
 }
 
-func find(ctxCtx context.Context, ctx *findContext, fileContent contentMessage) {
+func find(ctxCtx context.Context, ctx *findContext, fileContent contentMessage, content *ClientContent, versions int) {
 	// Match the incoming content, didn't match return.
-	if !matchFind(ctx, fileContent) {
+	if !matchFind(ctx, fileContent.Key, content, versions) {
 		return
 	} // For all matching content
 
-	// proceed to either exec, format the output string.
-	if ctx.execCmd != "" {
-		execFind(ctxCtx, ctx.execCmd, fileContent)
+	// proceed to either exec, an action or format the output string.
+	if ctx.executor != nil {
+		ctx.executor.add(fileContent, content)
 		return
 	}
+	metricsObjectDone(fileContent.Size)
 	if ctx.printFmt != "" {
 		fileContent.Key = stringsReplace(ctxCtx, ctx.printFmt, fileContent)
 	}
-	printMsg(findMessage{fileContent})
+	ctx.formatter.print(contentListEntry(fileContent, findMessage{fileContent}))
 }
 
 // doFind - find is main function body which interprets and executes
@@ -270,16 +237,39 @@ func doFind(ctxCtx context.Context, ctx *findContext) error {
 	// following defer is a no-op.
 	defer watchFind(ctxCtx, ctx)
 
+	// Counting versions needs all the versions of the objects, they
+	// are listed one after the other.
+	countVersions := ctx.expr.uses("versions")
 	lstOptions := ListOptions{
-		WithOlderVersions: ctx.withOlderVersions,
+		WithOlderVersions: ctx.withOlderVersions || countVersions,
 		WithDeleteMarkers: false,
 		Recursive:         true,
 		ShowDir:           DirFirst,
-		WithMetadata:      len(ctx.matchMeta) > 0 || len(ctx.matchTags) > 0,
+		WithMetadata:      ctx.withMetadata() || ctx.formatter.withMetadata() || ctx.expr.withMetadata(),
+	}
+
+	var versions []*ClientContent
+	findVersions := func() {
+		for _, content := range versions {
+			// Only the latest versions without --versions.
+			if ctx.withOlderVersions {
+				find(ctxCtx, ctx, findContentMessage(ctx, content), content, len(versions))
+			} else if content.IsLatest || content.VersionID == "" {
+				// Listed like without versions.
+				latest := *content
+				latest.VersionID = ""
+				find(ctxCtx, ctx, findContentMessage(ctx, &latest), &latest, len(versions))
+			}
+		}
+		versions = versions[:0]
 	}
 
 	// iterate over all content which is within the given directory
-	for content := range ctx.clnt.List(globalContext, lstOptions) {
+	for content := range ctx.clnt.List(ctxCtx, lstOptions) {
+		if ctxCtx.Err() != nil {
+			// Stopped by a failure of --exec or --action.
+			break
+		}
 		if content.Err != nil {
 			switch content.Err.ToGoError().(type) {
 			// handle this specifically for filesystem related errors.
@@ -299,42 +289,53 @@ func doFind(ctxCtx context.Context, ctx *findContext) error {
 			fatalIf(content.Err.Trace(ctx.clnt.GetURL().String()), "Unable to list folder.")
 			continue
 		}
-		if content.StorageClass == s3StorageClassGlacier {
+		// Archived objects are only matched by expressions on the
+		// storage class.
+		if content.StorageClass == s3StorageClassGlacier && !ctx.expr.uses("storageClass") {
 			continue
 		}
 
-		fileKeyName := getAliasedPath(ctx, content.URL.String())
-		fileContent := contentMessage{
-			Key:       fileKeyName,
-			VersionID: content.VersionID,
-			Time:      content.Time.Local(),
-			Size:      content.Size,
-			Metadata:  content.UserMetadata,
-			Tags:      content.Tags,
-		}
-
-		// Match the incoming content, didn't match return.
-		if !matchFind(ctx, fileContent) {
-			continue
-		} // For all matching content
-
-		// proceed to either exec, format the output string.
-		if ctx.execCmd != "" {
-			execFind(ctxCtx, ctx.execCmd, fileContent)
+		if !countVersions {
+			find(ctxCtx, ctx, findContentMessage(ctx, content), content, 0)
 			continue
 		}
-		if ctx.printFmt != "" {
-			fileContent.Key = stringsReplace(ctxCtx, ctx.printFmt, fileContent)
+		if len(versions) > 0 && versions[0].URL.String() != content.URL.String() {
+			findVersions()
 		}
-
-		printMsg(findMessage{fileContent})
+		versions = append(versions, content)
 	}
+	findVersions()
+
+	// Events of --watch are printed as they come.
+	ctx.formatter.unsorted()
 
 	// Success, notice watch will execute in defer only if enabled and this call
 	// will return after watch is canceled.
 	return nil
 }
 
+// findContentMessage - returns the message of listed content, named
+// with the aliased path.
+func findContentMessage(ctx *findContext, content *ClientContent) contentMessage {
+	fileContent := contentMessage{
+		Key:               getAliasedPath(ctx, content.URL.String()),
+		VersionID:         content.VersionID,
+		Time:              content.Time.Local(),
+		Size:              content.Size,
+		Metadata:          content.UserMetadata,
+		Tags:              content.Tags,
+		ETag:              strings.Trim(content.ETag, "\""),
+		StorageClass:      content.StorageClass,
+		IsDeleteMarker:    content.IsDeleteMarker,
+		ReplicationStatus: content.ReplicationStatus,
+		Filetype:          "file",
+	}
+	if content.Type.IsDir() {
+		fileContent.Filetype = "folder"
+	}
+	return fileContent
+}
+
 // stringsReplace - formats the string to remove {} and replace each
 // with the appropriate argument
 func stringsReplace(ctx context.Context, args string, fileContent contentMessage) string {
@@ -389,49 +390,55 @@ func stringsReplace(ctx context.Context, args string, fileContent contentMessage
 	return str
 }
 
-// matchFind matches whether fileContent matches appropriately with standard
-// "pattern matching" flags requested by the user, such as "name", "path", "regex" ..etc.
-func matchFind(ctx *findContext, fileContent contentMessage) (match bool) {
-	match = true
-	prefixPath := ctx.targetURL
-	// Add separator only if targetURL doesn't already have separator.
-	if !strings.HasPrefix(prefixPath, string(ctx.clnt.GetURL().Separator)) {
-		prefixPath = ctx.targetURL + string(ctx.clnt.GetURL().Separator)
+// compileMatcher - compiles the pattern matching flags such as "name",
+// "path", "regex" ..etc. into the predicates of --expr, all of them
+// and the expression must match.
+func (ctx *findContext) compileMatcher() findMatcher {
+	var matchers []findMatcher
+	if ctx.ignorePattern != "" {
+		matchers = append(matchers, findNot(findPath(ctx.ignorePattern)))
 	}
-	// Trim the prefix such that we will apply file path matching techniques
-	// on path excluding the starting prefix.
-	path := strings.TrimPrefix(fileContent.Key, prefixPath)
-	if match && ctx.ignorePattern != "" {
-		match = !pathMatch(ctx.ignorePattern, path)
-	}
-	if match && ctx.namePattern != "" {
-		match = nameMatch(ctx.namePattern, path)
+	if ctx.namePattern != "" {
+		matchers = append(matchers, findName(ctx.namePattern))
 	}
-	if match && ctx.pathPattern != "" {
-		match = pathMatch(ctx.pathPattern, path)
+	if ctx.pathPattern != "" {
+		matchers = append(matchers, findPath(ctx.pathPattern))
 	}
-	if match && ctx.regexPattern != nil {
-		match = ctx.regexPattern.MatchString(path)
+	if ctx.regexPattern != nil {
+		matchers = append(matchers, findRegex(ctx.regexPattern))
 	}
-	if match && ctx.olderThan != "" {
-		match = !isOlder(fileContent.Time, ctx.olderThan)
+	if ctx.olderThan != "" {
+		olderThan, e := ParseDuration(ctx.olderThan)
+		fatalIf(probe.NewError(e), "Unable to parse olderThan=`"+ctx.olderThan+"`.")
+		matchers = append(matchers, findAge(">=", time.Duration(olderThan)))
 	}
-	if match && ctx.newerThan != "" {
-		match = !isNewer(fileContent.Time, ctx.newerThan)
+	if ctx.newerThan != "" {
+		newerThan, e := ParseDuration(ctx.newerThan)
+		fatalIf(probe.NewError(e), "Unable to parse newerThan=`"+ctx.newerThan+"`.")
+		matchers = append(matchers, findAge("<", time.Duration(newerThan)))
 	}
-	if match && ctx.largerSize > 0 {
-		match = int64(ctx.largerSize) < fileContent.Size
+	matchers = append(matchers, findAttrs(ctx.objectAttrFilter))
+	if ctx.expr != nil {
+		matchers = append(matchers, ctx.expr.match)
 	}
-	if match && ctx.smallerSize > 0 {
-		match = int64(ctx.smallerSize) > fileContent.Size
-	}
-	if match && len(ctx.matchMeta) > 0 {
-		match = matchRegexMaps(ctx.matchMeta, fileContent.Metadata)
+	return findAnd(matchers...)
+}
+
+// matchFind matches whether the content of key matches appropriately
+// with the flags and the expression requested by the user.
+func matchFind(ctx *findContext, key string, content *ClientContent, versions int) bool {
+	if ctx.matcher == nil {
+		ctx.matcher = ctx.compileMatcher()
 	}
-	if match && len(ctx.matchTags) > 0 {
-		match = matchRegexMaps(ctx.matchTags, fileContent.Tags)
+	prefixPath := ctx.targetURL
+	// Add separator only if targetURL doesn't already have separator.
+	if !strings.HasPrefix(prefixPath, string(ctx.clnt.GetURL().Separator)) {
+		prefixPath = ctx.targetURL + string(ctx.clnt.GetURL().Separator)
 	}
-	return match
+	// Trim the prefix such that we will apply file path matching techniques
+	// on path excluding the starting prefix.
+	path := strings.TrimPrefix(key, prefixPath)
+	return ctx.matcher(findObject{ClientContent: content, path: path, versions: versions})
 }
 
 // 7 days in seconds.
@@ -464,51 +471,3 @@ func getShareURL(ctx context.Context, path string) string {
 
 	return shareURL
 }
-
-// getRegexMap returns a map from the StringSlice key.
-// Each entry must be key=regex.
-// Will exit with error if an un-parsable entry is found.
-func getRegexMap(cliCtx *cli.Context, key string) map[string]*regexp.Regexp {
-	sl := cliCtx.StringSlice(key)
-	if len(sl) == 0 {
-		return nil
-	}
-	reMap := make(map[string]*regexp.Regexp, len(sl))
-	for _, v := range sl {
-		split := strings.SplitN(v, "=", 2)
-		if len(split) < 2 {
-			err := probe.NewError(fmt.Errorf("want one = separator, got none"))
-			fatalIf(err.Trace(v), "Unable to split key+value. Must be key=regex")
-		}
-		// No value means it should not exist or be empty.
-		if len(split[1]) == 0 {
-			reMap[split[0]] = nil
-			continue
-		}
-		var err error
-		reMap[split[0]], err = regexp.Compile(split[1])
-		if err != nil {
-			fatalIf(probe.NewError(err), fmt.Sprintf("Unable to compile metadata regex for %s=%s", split[0], split[1]))
-		}
-	}
-	return reMap
-}
-
-// matchRegexMaps will check if all regexes in 'm' match values in 'v' with the same key.
-// If a regex is nil, it must either not exist in v or have a 0 length value.
-func matchRegexMaps(m map[string]*regexp.Regexp, v map[string]string) bool {
-	for k, reg := range m {
-		if reg == nil {
-			if v[k] != "" {
-				return false
-			}
-			// Does not exist or empty, that is fine.
-			continue
-		}
-		val, ok := v[k]
-		if !ok || !reg.MatchString(val) {
-			return false
-		}
-	}
-	return true
-}
@@ -73,13 +73,13 @@ func TestMatchFind(t *testing.T) {
 			clnt: &S3Client{
 				targetURL: &ClientURL{},
 			},
-			largerSize: 1024 * 1024,
+			objectAttrFilter: objectAttrFilter{largerSize: 1024 * 1024},
 		},
 		{
 			clnt: &S3Client{
 				targetURL: &ClientURL{},
 			},
-			smallerSize: 1024,
+			objectAttrFilter: objectAttrFilter{smallerSize: 1024},
 		},
 		{
 			clnt: &S3Client{
@@ -170,7 +170,8 @@ func TestMatchFind(t *testing.T) {
 
 	// Runs all the test cases and validate the expected conditions.
 	for i, testCase := range testCases {
-		gotMatch := matchFind(listFindContexts[i], testCase.content)
+		content := &ClientContent{Time: testCase.content.Time, Size: testCase.content.Size}
+		gotMatch := matchFind(listFindContexts[i], testCase.content.Key, content, 0)
 		if testCase.expectedMatch != gotMatch {
 			t.Errorf("Test: %d, expected match %t, got %t", i+1, testCase.expectedMatch, gotMatch)
 		}
@@ -66,14 +66,24 @@ var globalFlags = []cli.Flag{
 	},
 	cli.StringFlag{
 		Name:   "limit-upload",
-		Usage:  "limits uploads to a maximum rate in KiB/s, MiB/s, GiB/s. (default: unlimited)",
+		Usage:  "limits uploads to a maximum rate in KiB/s, MiB/s, GiB/s, or per time of day e.g. \"09:00-18:00=10MiB,18:00-09:00=0\". (default: unlimited)",
 		EnvVar: envPrefix + "LIMIT_UPLOAD",
 	},
 	cli.StringFlag{
 		Name:   "limit-download",
-		Usage:  "limits downloads to a maximum rate in KiB/s, MiB/s, GiB/s. (default: unlimited)",
+		Usage:  "limits downloads to a maximum rate in KiB/s, MiB/s, GiB/s, or per time of day e.g. \"09:00-18:00=10MiB,18:00-09:00=0\". (default: unlimited)",
 		EnvVar: envPrefix + "LIMIT_DOWNLOAD",
 	},
+	cli.StringFlag{
+		Name:   "limit-schedule",
+		Usage:  "limits uploads and downloads per time of day e.g. \"09:00-18:00=10MiB,18:00-09:00=0\", a rate of 0 is unlimited",
+		EnvVar: envPrefix + "LIMIT_SCHEDULE",
+	},
+	cli.StringFlag{
+		Name:   "metrics-address",
+		Usage:  "serve Prometheus metrics on /metrics and the command status on /healthz at this address (eg: localhost:8081)",
+		EnvVar: envPrefix + "METRICS_ADDRESS",
+	},
 	cli.DurationFlag{
 		Name:   "conn-read-deadline",
 		Usage:  "custom connection READ deadline",
@@ -93,6 +103,7 @@ var encFlags = []cli.Flag{
 	encCFlag,
 	encKSMFlag,
 	encS3Flag,
+	encClientFlag,
 }
 
 var encCFlag = cli.StringSliceFlag{
@@ -100,6 +111,11 @@ var encCFlag = cli.StringSliceFlag{
 	Usage: "encrypt/decrypt objects using client provided keys. (multiple keys can be provided) Format: Raw base64 encoding.",
 }
 
+var encClientFlag = cli.StringSliceFlag{
+	Name:  "enc-client",
+	Usage: "encrypt/decrypt objects on the client with keys read from files. (multiple keys can be provided) Format: alias/prefix=keyfile",
+}
+
 var encKSMFlag = cli.StringSliceFlag{
 	Name:   "enc-kms",
 	Usage:  "encrypt/decrypt objects using specific server-side encryption keys. (multiple keys can be provided)",
@@ -37,7 +37,7 @@ var getCmd = cli.Command{
 	Action:       mainGet,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(globalFlags, encCFlag), getFlags...),
+	Flags:        append(append(append(globalFlags, encCFlag, encClientFlag), retryFlags...), getFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -54,6 +54,9 @@ EXAMPLES:
 
   2. Get an object from MinIO storage using encryption
     {{.Prompt}} {{.HelpName}} --enc-c "play/mybucket/object=MDEyMzQ1Njc4OTAxMjM0NTY3ODkwMTIzNDU2Nzg5MDA" play/mybucket/object path-to/object
+
+  3. Get an object encrypted on the client
+    {{.Prompt}} {{.HelpName}} --enc-client "play/mybucket/object=/etc/mc/object.key" play/mybucket/object path-to/object
 `,
 }
 
@@ -126,6 +129,7 @@ func mainGet(cliCtx *cli.Context) (e error) {
 				pg:                  pg,
 				encryptionKeys:      encryptionKeys,
 				updateProgressTotal: true,
+				retry:               retryPolicyFromContext(cliCtx),
 			})
 			if urls.Error != nil {
 				e = urls.Error.ToGoError()
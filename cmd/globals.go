@@ -25,15 +25,15 @@ import (
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
-	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
 	"github.com/minio/madmin-go/v3"
+	"github.com/minio/mc/pkg/limiter"
 	"github.com/minio/pkg/v2/console"
 	"github.com/muesli/termenv"
 )
 
 const (
-	globalMCConfigVersion = "10"
+	globalMCConfigVersion = "11"
 
 	globalMCConfigFile = "config.json"
 	globalMCCertsDir   = "certs"
@@ -44,6 +44,9 @@ const (
 	globalSharedURLsDataDir    = "share"
 	globalSessionConfigVersion = "8"
 
+	// Temporary credentials cached until they expire.
+	globalCredentialsCacheDir = "credentials-cache"
+
 	// Profile directory for dumping profiler outputs.
 	globalProfileDir = "profile"
 
@@ -79,8 +82,8 @@ var (
 	globalConnReadDeadline  time.Duration
 	globalConnWriteDeadline time.Duration
 
-	globalLimitUpload   uint64
-	globalLimitDownload uint64
+	globalLimitUpload   limiter.Schedule
+	globalLimitDownload limiter.Schedule
 
 	globalContext, globalCancel = context.WithCancel(context.Background())
 )
@@ -140,13 +143,23 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 		globalConnWriteDeadline = ctx.GlobalDuration("conn-write-deadline")
 	}
 
+	// A schedule applies to both directions unless a direction
+	// has its own limit.
+	limitScheduleStr := ctx.String("limit-schedule")
+	if limitScheduleStr == "" {
+		limitScheduleStr = ctx.GlobalString("limit-schedule")
+	}
+
 	limitUploadStr := ctx.String("limit-upload")
 	if limitUploadStr == "" {
 		limitUploadStr = ctx.GlobalString("limit-upload")
 	}
+	if limitUploadStr == "" {
+		limitUploadStr = limitScheduleStr
+	}
 	if limitUploadStr != "" {
 		var e error
-		globalLimitUpload, e = humanize.ParseBytes(limitUploadStr)
+		globalLimitUpload, e = limiter.ParseSchedule(limitUploadStr)
 		if e != nil {
 			return e
 		}
@@ -156,14 +169,29 @@ func setGlobalsFromContext(ctx *cli.Context) error {
 	if limitDownloadStr == "" {
 		limitDownloadStr = ctx.GlobalString("limit-download")
 	}
+	if limitDownloadStr == "" {
+		limitDownloadStr = limitScheduleStr
+	}
 
 	if limitDownloadStr != "" {
 		var e error
-		globalLimitDownload, e = humanize.ParseBytes(limitDownloadStr)
+		globalLimitDownload, e = limiter.ParseSchedule(limitDownloadStr)
 		if e != nil {
 			return e
 		}
 	}
 
+	// Subcommands label the metrics with their full name.
+	if name := ctx.Command.FullName(); name != "" {
+		globalMetricsCommand = name
+	}
+	metricsAddress := ctx.String("metrics-address")
+	if metricsAddress == "" {
+		metricsAddress = ctx.GlobalString("metrics-address")
+	}
+	if metricsAddress != "" {
+		startMetricsServer(metricsAddress)
+	}
+
 	return nil
 }
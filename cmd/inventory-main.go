@@ -0,0 +1,229 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+// inventory specific flags.
+var inventoryFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "format",
+		Value: inventoryCSV,
+		Usage: "format of the inventory, one of 'csv', 'jsonl' or 'parquet'",
+	},
+	cli.StringFlag{
+		Name:  "fields",
+		Value: "key,size,lastModified,etag,storageClass",
+		Usage: "comma separated fields of the inventory, any of key, size, lastModified, etag, versionId, isLatest, isDeleteMarker, storageClass, tags, metadata and replicationStatus",
+	},
+	cli.BoolFlag{
+		Name:  "versions",
+		Usage: "include all object versions and delete markers",
+	},
+	cli.BoolFlag{
+		Name:  "gzip",
+		Usage: "compress the inventory, parquet files compress their pages",
+	},
+	cli.IntFlag{
+		Name:  "rows-per-file",
+		Usage: "shard the inventory in files of N rows, numbered before the extension of TARGET",
+	},
+}
+
+// Export an inventory of the objects of a bucket.
+var inventoryCmd = cli.Command{
+	Name:         "inventory",
+	Usage:        "export an inventory of the objects in a bucket",
+	Action:       mainInventory,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(inventoryFlags, globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] ALIAS/BUCKET[/PREFIX] [TARGET]
+
+  The inventory is written to STDOUT when TARGET is not specified, TARGET
+  is a local file or an object uploaded while the bucket is listed.
+
+FIELDS:
+  key, size, lastModified, etag, versionId, isLatest, isDeleteMarker,
+  storageClass, tags, metadata and replicationStatus. Tags, metadata and
+  replication status are listed with the MinIO metadata extension.
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+
+EXAMPLES:
+  1. Export the inventory of the bucket 'jazz-songs' as CSV to STDOUT.
+     {{.Prompt}} {{.HelpName}} s3/jazz-songs
+
+  2. Export the inventory of all versions of the bucket 'jazz-songs' to a gzipped JSON lines file.
+     {{.Prompt}} {{.HelpName}} --versions --format jsonl --gzip --fields key,versionId,isLatest,size s3/jazz-songs inventory.jsonl.gz
+
+  3. Export a daily parquet inventory with tags and metadata to the bucket 'audit', one million rows per object.
+     {{.Prompt}} {{.HelpName}} --format parquet --fields key,size,etag,tags,metadata,replicationStatus --rows-per-file 1000000 s3/jazz-songs s3/audit/jazz-songs/$(date +%F).parquet
+`,
+}
+
+// inventoryMessage - the summary of an exported inventory.
+type inventoryMessage struct {
+	Status string   `json:"status"`
+	Source string   `json:"source"`
+	Format string   `json:"format"`
+	Rows   int64    `json:"rows"`
+	Files  []string `json:"files"`
+}
+
+// Colorized message for console printing.
+func (i inventoryMessage) String() string {
+	files := fmt.Sprintf("`%s`", i.Files[0])
+	if len(i.Files) > 1 {
+		files = fmt.Sprintf("%s files from `%s` to `%s`", humanize.Comma(int64(len(i.Files))), i.Files[0], i.Files[len(i.Files)-1])
+	}
+	return fmt.Sprintf("Exported the %s inventory of %s of `%s` to %s.", i.Format,
+		console.Colorize("InventoryRows", humanize.Comma(i.Rows)+" objects"),
+		i.Source, files)
+}
+
+// JSON'ified message for scripting.
+func (i inventoryMessage) JSON() string {
+	i.Status = "success"
+	msgBytes, e := json.MarshalIndent(i, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// checkInventorySyntax - validate all the passed arguments
+func checkInventorySyntax(cliCtx *cli.Context) {
+	if len(cliCtx.Args()) < 1 || len(cliCtx.Args()) > 2 {
+		showCommandHelpAndExit(cliCtx, 1) // last argument is exit code
+	}
+	switch cliCtx.String("format") {
+	case inventoryCSV, inventoryJSONL, inventoryParquet:
+	default:
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("format")), "Unknown inventory format, expected one of 'csv', 'jsonl' or 'parquet'.")
+	}
+	if cliCtx.Int("rows-per-file") < 0 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("rows-per-file")), "--rows-per-file must not be negative.")
+	}
+	if cliCtx.Int("rows-per-file") > 0 && len(cliCtx.Args()) < 2 {
+		fatalIf(errInvalidArgument().Trace(), "--rows-per-file requires a TARGET.")
+	}
+}
+
+// inventoryKeyPrefix - returns the prefix of the listed paths which is
+// not part of the object keys, the bucket or the local folder.
+func inventoryKeyPrefix(u ClientURL) string {
+	separator := string(u.Separator)
+	if u.Type == objectStorage {
+		bucket, _, _ := strings.Cut(strings.TrimPrefix(u.Path, separator), separator)
+		return separator + bucket + separator
+	}
+	if strings.HasSuffix(u.Path, separator) {
+		return u.Path
+	}
+	return u.Path + separator
+}
+
+// exportInventory - lists the objects of urlStr into the inventory output.
+func exportInventory(ctx context.Context, urlStr string, withVersions bool, o *inventoryOutput) *probe.Error {
+	clnt, err := newClient(urlStr)
+	if err != nil {
+		return err.Trace(urlStr)
+	}
+	if clnt.GetURL().Type == objectStorage && inventoryKeyPrefix(clnt.GetURL()) == "//" {
+		return errInvalidArgument().Trace(urlStr)
+	}
+
+	withMetadata := false
+	for _, field := range o.fields {
+		withMetadata = withMetadata || field.withMetadata
+	}
+	keyPrefix := inventoryKeyPrefix(clnt.GetURL())
+
+	for content := range clnt.List(ctx, ListOptions{
+		Recursive:         true,
+		WithMetadata:      withMetadata,
+		WithOlderVersions: withVersions,
+		WithDeleteMarkers: withVersions,
+		ShowDir:           DirNone,
+	}) {
+		if content.Err != nil {
+			return o.abort(content.Err.Trace(urlStr))
+		}
+		if content.Type.IsDir() {
+			continue
+		}
+		key := filepath.ToSlash(strings.TrimPrefix(content.URL.Path, keyPrefix))
+		if err = o.write(inventoryRow(o.fields, key, content)); err != nil {
+			return o.abort(err)
+		}
+		metricsObjectDone(content.Size)
+	}
+	return o.close()
+}
+
+// mainInventory is the entry point for inventory command.
+func mainInventory(cliCtx *cli.Context) error {
+	ctx, cancelInventory := context.WithCancel(globalContext)
+	defer cancelInventory()
+
+	checkInventorySyntax(cliCtx)
+	console.SetColor("InventoryRows", color.New(color.FgGreen, color.Bold))
+
+	fields, err := parseInventoryFields(cliCtx.String("fields"))
+	fatalIf(err, "Unable to parse --fields.")
+
+	args := cliCtx.Args()
+	o := &inventoryOutput{
+		ctx:         ctx,
+		target:      args.Get(1),
+		format:      cliCtx.String("format"),
+		fields:      fields,
+		compress:    cliCtx.Bool("gzip"),
+		rowsPerFile: int64(cliCtx.Int("rows-per-file")),
+	}
+	err = exportInventory(ctx, args.Get(0), cliCtx.Bool("versions"), o)
+	fatalIf(err, "Unable to export the inventory of `"+args.Get(0)+"`.")
+
+	// The inventory itself is on STDOUT without a target.
+	if o.target != "" {
+		printMsg(inventoryMessage{
+			Source: args.Get(0),
+			Format: o.format,
+			Rows:   o.total,
+			Files:  o.files,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,387 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/parquet"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// Formats of the inventory files.
+const (
+	inventoryCSV     = "csv"
+	inventoryJSONL   = "jsonl"
+	inventoryParquet = "parquet"
+)
+
+// inventoryField - a column of the inventory.
+type inventoryField struct {
+	name  string
+	typ   parquet.Type
+	value func(key string, content *ClientContent) interface{}
+	// Listed only with the metadata extension of MinIO.
+	withMetadata bool
+}
+
+var inventoryFields = []inventoryField{
+	{name: "key", typ: parquet.String, value: func(key string, _ *ClientContent) interface{} { return key }},
+	{name: "size", typ: parquet.Int64, value: func(_ string, c *ClientContent) interface{} { return c.Size }},
+	{name: "lastModified", typ: parquet.Timestamp, value: func(_ string, c *ClientContent) interface{} { return c.Time.UTC() }},
+	{name: "etag", typ: parquet.String, value: func(_ string, c *ClientContent) interface{} { return c.ETag }},
+	{name: "versionId", typ: parquet.String, value: func(_ string, c *ClientContent) interface{} { return c.VersionID }},
+	{name: "isLatest", typ: parquet.Boolean, value: func(_ string, c *ClientContent) interface{} { return c.IsLatest }},
+	{name: "isDeleteMarker", typ: parquet.Boolean, value: func(_ string, c *ClientContent) interface{} { return c.IsDeleteMarker }},
+	{name: "storageClass", typ: parquet.String, value: func(_ string, c *ClientContent) interface{} { return c.StorageClass }},
+	{name: "tags", typ: parquet.String, value: func(_ string, c *ClientContent) interface{} { return c.Tags }, withMetadata: true},
+	{name: "metadata", typ: parquet.String, value: func(_ string, c *ClientContent) interface{} { return c.UserMetadata }, withMetadata: true},
+	{name: "replicationStatus", typ: parquet.String, value: func(_ string, c *ClientContent) interface{} { return c.ReplicationStatus }, withMetadata: true},
+}
+
+// parseInventoryFields - returns the fields of a comma separated list of
+// field names.
+func parseInventoryFields(value string) ([]inventoryField, *probe.Error) {
+	var fields []inventoryField
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, field := range inventoryFields {
+			if strings.EqualFold(field.name, name) {
+				fields = append(fields, field)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errInvalidArgument().Trace(name)
+		}
+	}
+	return fields, nil
+}
+
+// inventoryRow - returns the values of the fields of a listed object.
+func inventoryRow(fields []inventoryField, key string, content *ClientContent) []interface{} {
+	row := make([]interface{}, len(fields))
+	for i, field := range fields {
+		row[i] = field.value(key, content)
+	}
+	return row
+}
+
+// inventoryString - formats a value for the text columns of CSV and
+// parquet, tags and metadata are JSON objects.
+func inventoryString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case map[string]string:
+		if len(v) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// inventoryEncoder - encodes the rows of an inventory file.
+type inventoryEncoder interface {
+	write(row []interface{}) error
+	// close - flushes the file, the underlying writer is not closed.
+	close() error
+}
+
+// csvInventoryEncoder - writes a header and a CSV record per row.
+type csvInventoryEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+func (e *csvInventoryEncoder) write(row []interface{}) error {
+	for i, value := range row {
+		e.record[i] = inventoryString(value)
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvInventoryEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonlInventoryEncoder - writes a JSON object per line, the fields are
+// in the order of --fields.
+type jsonlInventoryEncoder struct {
+	w     io.Writer
+	names []string
+}
+
+func (e *jsonlInventoryEncoder) write(row []interface{}) error {
+	var line strings.Builder
+	line.WriteByte('{')
+	for i, value := range row {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line.WriteString(strconv.Quote(e.names[i]))
+		line.WriteByte(':')
+		line.Write(data)
+	}
+	line.WriteString("}\n")
+	_, err := io.WriteString(e.w, line.String())
+	return err
+}
+
+func (e *jsonlInventoryEncoder) close() error {
+	return nil
+}
+
+// parquetInventoryEncoder - writes the rows in parquet row groups.
+type parquetInventoryEncoder struct {
+	w *parquet.Writer
+}
+
+func (e *parquetInventoryEncoder) write(row []interface{}) error {
+	for i, value := range row {
+		if m, ok := value.(map[string]string); ok {
+			row[i] = inventoryString(m)
+		}
+	}
+	return e.w.Write(row)
+}
+
+func (e *parquetInventoryEncoder) close() error {
+	return e.w.Close()
+}
+
+// gzipInventoryEncoder - compresses the file of an encoder.
+type gzipInventoryEncoder struct {
+	inventoryEncoder
+	zw *gzip.Writer
+}
+
+func (e *gzipInventoryEncoder) close() error {
+	if err := e.inventoryEncoder.close(); err != nil {
+		return err
+	}
+	return e.zw.Close()
+}
+
+// newInventoryEncoder - returns an encoder writing a file to w. Parquet
+// files compress their pages, the other formats are gzipped as a whole.
+func newInventoryEncoder(w io.Writer, format string, fields []inventoryField, compress bool) (inventoryEncoder, error) {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.name
+	}
+	if format == inventoryParquet {
+		columns := make([]parquet.Column, len(fields))
+		for i, field := range fields {
+			columns[i] = parquet.Column{Name: field.name, Type: field.typ}
+		}
+		return &parquetInventoryEncoder{w: parquet.NewWriter(w, columns, compress)}, nil
+	}
+
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(w)
+		w = zw
+	}
+	var enc inventoryEncoder
+	switch format {
+	case inventoryCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(names); err != nil {
+			return nil, err
+		}
+		enc = &csvInventoryEncoder{w: cw, record: make([]string, len(fields))}
+	case inventoryJSONL:
+		enc = &jsonlInventoryEncoder{w: w, names: names}
+	default:
+		return nil, fmt.Errorf("unknown inventory format %s", format)
+	}
+	if zw != nil {
+		return &gzipInventoryEncoder{inventoryEncoder: enc, zw: zw}, nil
+	}
+	return enc, nil
+}
+
+// inventoryShardName - returns the name of the n-th file of a sharded
+// inventory, the number goes before the extensions of the name.
+func inventoryShardName(target string, n int) string {
+	dir, base := path.Split(filepath.ToSlash(target))
+	name, ext, _ := strings.Cut(base, ".")
+	if ext != "" {
+		ext = "." + ext
+	}
+	return dir + fmt.Sprintf("%s-%05d%s", name, n, ext)
+}
+
+// inventoryContentType - returns the content type of the inventory files.
+func inventoryContentType(format string, compress bool) string {
+	switch {
+	case format == inventoryParquet:
+		return "application/vnd.apache.parquet"
+	case compress:
+		return "application/gzip"
+	case format == inventoryCSV:
+		return "text/csv"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// inventoryOutput - writes the rows of an inventory to STDOUT or to a
+// target file or object, uploaded with Put while the rows are written.
+// With rowsPerFile the inventory is sharded in files of as many rows.
+type inventoryOutput struct {
+	ctx         context.Context
+	target      string
+	format      string
+	fields      []inventoryField
+	compress    bool
+	rowsPerFile int64
+
+	enc    inventoryEncoder
+	pw     *io.PipeWriter
+	doneCh chan *probe.Error
+	rows   int64
+	total  int64
+	files  []string
+}
+
+// open - starts the next inventory file.
+func (o *inventoryOutput) open() *probe.Error {
+	o.rows = 0
+	if o.target == "" {
+		enc, e := newInventoryEncoder(os.Stdout, o.format, o.fields, o.compress)
+		o.enc = enc
+		return probe.NewError(e)
+	}
+
+	name := o.target
+	if o.rowsPerFile > 0 {
+		name = inventoryShardName(o.target, len(o.files))
+	}
+	alias, urlStr, _, err := expandAlias(name)
+	if err != nil {
+		return err.Trace(name)
+	}
+	o.files = append(o.files, name)
+
+	pr, pw := io.Pipe()
+	o.pw = pw
+	o.doneCh = make(chan *probe.Error, 1)
+	go func() {
+		opts := PutOptions{metadata: map[string]string{"Content-Type": inventoryContentType(o.format, o.compress)}}
+		_, err := putTargetStream(o.ctx, alias, urlStr, "", "", "", pr, -1, nil, opts)
+		if err != nil {
+			// Fail the writes of the rows left.
+			pr.CloseWithError(err.ToGoError())
+			err = err.Trace(name)
+		}
+		o.doneCh <- err
+	}()
+
+	enc, e := newInventoryEncoder(pw, o.format, o.fields, o.compress)
+	if e != nil {
+		return o.abort(probe.NewError(e))
+	}
+	o.enc = enc
+	return nil
+}
+
+// closeFile - completes the current inventory file, an upload error
+// takes precedence over the encoding error it causes.
+func (o *inventoryOutput) closeFile() *probe.Error {
+	e := o.enc.close()
+	o.enc = nil
+	if o.pw == nil {
+		return probe.NewError(e)
+	}
+	o.pw.CloseWithError(e)
+	o.pw = nil
+	if err := <-o.doneCh; err != nil {
+		return err
+	}
+	return probe.NewError(e)
+}
+
+// abort - fails the upload of the current inventory file.
+func (o *inventoryOutput) abort(err *probe.Error) *probe.Error {
+	if o.pw != nil {
+		o.pw.CloseWithError(err.ToGoError())
+		<-o.doneCh
+		o.pw = nil
+	}
+	o.enc = nil
+	return err
+}
+
+// write - writes a row, starting a new file when the current one is full.
+func (o *inventoryOutput) write(row []interface{}) *probe.Error {
+	if o.enc != nil && o.rowsPerFile > 0 && o.rows >= o.rowsPerFile {
+		if err := o.closeFile(); err != nil {
+			return err
+		}
+	}
+	if o.enc == nil {
+		if err := o.open(); err != nil {
+			return err
+		}
+	}
+	if e := o.enc.write(row); e != nil {
+		// The file misses rows, it is never completed.
+		return o.abort(probe.NewError(e))
+	}
+	o.rows++
+	o.total++
+	return nil
+}
+
+// close - completes the inventory, an empty inventory still has a file.
+func (o *inventoryOutput) close() *probe.Error {
+	if o.enc == nil {
+		if err := o.open(); err != nil {
+			return err
+		}
+	}
+	return o.closeFile()
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestInventoryShardName(t *testing.T) {
+	testCases := []struct {
+		target   string
+		n        int
+		expected string
+	}{
+		{"inventory.csv", 0, "inventory-00000.csv"},
+		{"s3/audit/2024-05-06.csv.gz", 12, "s3/audit/2024-05-06-00012.csv.gz"},
+		{"/tmp/inventory", 1, "/tmp/inventory-00001"},
+	}
+	for _, testCase := range testCases {
+		if name := inventoryShardName(testCase.target, testCase.n); name != testCase.expected {
+			t.Errorf("%s: expected %s, got %s", testCase.target, testCase.expected, name)
+		}
+	}
+}
+
+func TestParseInventoryFields(t *testing.T) {
+	fields, err := parseInventoryFields("key, size,versionID,tags")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, field := range fields {
+		names = append(names, field.name)
+	}
+	if !reflect.DeepEqual(names, []string{"key", "size", "versionId", "tags"}) {
+		t.Fatalf("Unexpected fields %v", names)
+	}
+	if _, err = parseInventoryFields("key,owner"); err == nil {
+		t.Fatal("Expected an unknown field to fail")
+	}
+}
+
+func TestExportInventory(t *testing.T) {
+	root, srcDir := newTestFolder(t, map[string]string{"a": "data", "b/c": "data", "b/d": "data"})
+	fields, err := parseInventoryFields("key,size")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sharded and gzipped CSV files.
+	o := &inventoryOutput{
+		ctx:         context.Background(),
+		target:      filepath.Join(root, "inventory.csv.gz"),
+		format:      inventoryCSV,
+		fields:      fields,
+		compress:    true,
+		rowsPerFile: 2,
+	}
+	if err = exportInventory(context.Background(), srcDir, false, o); err != nil {
+		t.Fatal(err)
+	}
+	if o.total != 3 || len(o.files) != 2 {
+		t.Fatalf("Expected 3 rows in 2 files, got %d rows in %v", o.total, o.files)
+	}
+	var records [][]string
+	for _, name := range o.files {
+		f, e := os.Open(name)
+		if e != nil {
+			t.Fatal(e)
+		}
+		zr, e := gzip.NewReader(f)
+		if e != nil {
+			t.Fatal(e)
+		}
+		rows, e := csv.NewReader(zr).ReadAll()
+		f.Close()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if !reflect.DeepEqual(rows[0], []string{"key", "size"}) {
+			t.Fatalf("Expected a header, got %v", rows[0])
+		}
+		records = append(records, rows[1:]...)
+	}
+	expected := [][]string{{"a", "4"}, {"b/c", "4"}, {"b/d", "4"}}
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("Expected %v, got %v", expected, records)
+	}
+
+	// A single JSON lines file.
+	o = &inventoryOutput{
+		ctx:    context.Background(),
+		target: filepath.Join(root, "inventory.jsonl"),
+		format: inventoryJSONL,
+		fields: fields,
+	}
+	if err = exportInventory(context.Background(), srcDir, false, o); err != nil {
+		t.Fatal(err)
+	}
+	data, e := os.ReadFile(o.target)
+	if e != nil {
+		t.Fatal(e)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 || lines[1] != `{"key":"b/c","size":4}` {
+		t.Fatalf("Unexpected JSON lines %q", lines)
+	}
+}
+
+// failingInventoryEncoder - fails to encode every row.
+type failingInventoryEncoder struct{}
+
+func (failingInventoryEncoder) write(row []interface{}) error { return errors.New("encoding failed") }
+func (failingInventoryEncoder) close() error                  { return nil }
+
+func TestInventoryOutputAbort(t *testing.T) {
+	root, _ := newTestFolder(t, nil)
+	fields, err := parseInventoryFields("key,size")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(root, "inventory.csv")
+	o := &inventoryOutput{
+		ctx:    context.Background(),
+		target: name,
+		format: inventoryCSV,
+		fields: fields,
+	}
+	if err = o.open(); err != nil {
+		t.Fatal(err)
+	}
+	if err = o.write([]interface{}{"a", int64(4)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A row which fails to encode fails the upload of the file.
+	o.enc = failingInventoryEncoder{}
+	if err = o.write([]interface{}{"b", int64(4)}); err == nil {
+		t.Fatal("Expected the row to fail")
+	}
+	if _, e := os.Stat(name); !os.IsNotExist(e) {
+		t.Fatalf("Expected the truncated file not to be committed, got %v", e)
+	}
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+)
+
+// listFormatFlags - sort, select columns and format the output of ls,
+// find and stat.
+var listFormatFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "sort",
+		Usage: "sort the output by 'name', 'size', 'time' or 'version'",
+	},
+	cli.BoolFlag{
+		Name:  "reverse",
+		Usage: "reverse the order of the output, by name unless --sort is specified",
+	},
+	cli.StringFlag{
+		Name:  "columns",
+		Usage: "print the comma separated columns, any of key, size, lastModified, etag, versionId, isDeleteMarker, storageClass, type, tags, metadata and replicationStatus",
+	},
+	cli.StringFlag{
+		Name:  "format",
+		Usage: "print each object with a Go template, e.g. '{{.Key}}\\t{{.Size}}' (see TEMPLATE)",
+	},
+}
+
+// listFormatHelp - the TEMPLATE section of the help of ls, find and stat.
+const listFormatHelp = `TEMPLATE:
+  --format takes a Go template executed for each object, '\t' and '\n' are
+  replaced by a tab and a newline. The fields are .Key, .Size, .LastModified,
+  .ETag, .VersionID, .IsDeleteMarker, .StorageClass, .Type, .Tags, .Metadata
+  and .ReplicationStatus. The function 'human' formats a size in units and
+  'json' formats any value in JSON.
+`
+
+// listEntry - an object printed by ls, find or stat, the fields are
+// the ones of --columns and --format.
+type listEntry struct {
+	Key               string
+	Size              int64
+	LastModified      time.Time
+	ETag              string
+	VersionID         string
+	VersionOrdinal    int
+	IsDeleteMarker    bool
+	StorageClass      string
+	Type              string
+	Tags              map[string]string
+	Metadata          map[string]string
+	ReplicationStatus string
+
+	// The message printed without --columns and --format.
+	msg message
+}
+
+// contentListEntry - returns the entry of a listed object of ls and find.
+func contentListEntry(c contentMessage, msg message) listEntry {
+	return listEntry{
+		Key:               c.Key,
+		Size:              c.Size,
+		LastModified:      c.Time,
+		ETag:              c.ETag,
+		VersionID:         c.VersionID,
+		VersionOrdinal:    c.VersionOrd,
+		IsDeleteMarker:    c.IsDeleteMarker,
+		StorageClass:      c.StorageClass,
+		Type:              c.Filetype,
+		Tags:              c.Tags,
+		Metadata:          c.Metadata,
+		ReplicationStatus: c.ReplicationStatus,
+		msg:               msg,
+	}
+}
+
+// listColumn - a column of --columns.
+type listColumn struct {
+	name  string
+	value func(e listEntry) interface{}
+	// Listed only with the metadata extension of MinIO.
+	withMetadata bool
+}
+
+var listColumns = []listColumn{
+	{name: "key", value: func(e listEntry) interface{} { return e.Key }},
+	{name: "size", value: func(e listEntry) interface{} { return e.Size }},
+	{name: "lastModified", value: func(e listEntry) interface{} { return e.LastModified }},
+	{name: "etag", value: func(e listEntry) interface{} { return e.ETag }},
+	{name: "versionId", value: func(e listEntry) interface{} { return e.VersionID }},
+	{name: "isDeleteMarker", value: func(e listEntry) interface{} { return e.IsDeleteMarker }},
+	{name: "storageClass", value: func(e listEntry) interface{} { return e.StorageClass }},
+	{name: "type", value: func(e listEntry) interface{} { return e.Type }},
+	{name: "tags", value: func(e listEntry) interface{} { return e.Tags }, withMetadata: true},
+	{name: "metadata", value: func(e listEntry) interface{} { return e.Metadata }, withMetadata: true},
+	{name: "replicationStatus", value: func(e listEntry) interface{} { return e.ReplicationStatus }, withMetadata: true},
+}
+
+// listColumnsMessage - the selected columns of an entry, tab separated
+// on the console and in the order of --columns in JSON.
+type listColumnsMessage struct {
+	columns []listColumn
+	entry   listEntry
+}
+
+// String colorized string message.
+func (m listColumnsMessage) String() string {
+	values := make([]string, len(m.columns))
+	for i, column := range m.columns {
+		values[i] = inventoryString(column.value(m.entry))
+	}
+	return strings.Join(values, "\t")
+}
+
+// JSON jsonified columns message.
+func (m listColumnsMessage) JSON() string {
+	var buf bytes.Buffer
+	buf.WriteString(`{"status":"success"`)
+	for _, column := range m.columns {
+		data, e := json.Marshal(column.value(m.entry))
+		fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+		buf.WriteString("," + strconv.Quote(column.name) + ":")
+		buf.Write(data)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+// listTemplateMessage - an entry formatted with --format.
+type listTemplateMessage struct {
+	Status string `json:"status"`
+	Output string `json:"output"`
+}
+
+// String colorized string message.
+func (m listTemplateMessage) String() string {
+	return m.Output
+}
+
+// JSON jsonified template message.
+func (m listTemplateMessage) JSON() string {
+	m.Status = "success"
+	msgBytes, e := json.MarshalIndent(m, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// listFormatter - prints the entries of ls, find and stat. Entries are
+// buffered until flush when the output is sorted.
+type listFormatter struct {
+	sortBy  string
+	reverse bool
+	columns []listColumn
+	tmpl    *template.Template
+	// Metadata and tags are needed by the columns or the template.
+	metadata bool
+
+	entries []listEntry
+}
+
+// listTemplateFuncs - the functions of --format templates.
+var listTemplateFuncs = template.FuncMap{
+	"human": func(size int64) string {
+		return humanize.IBytes(uint64(size))
+	},
+	"json": func(v interface{}) (string, error) {
+		data, e := json.Marshal(v)
+		return string(data), e
+	},
+}
+
+// newListFormatter - parses --sort, --reverse, --columns and --format.
+func newListFormatter(cliCtx *cli.Context) (*listFormatter, *probe.Error) {
+	f := &listFormatter{
+		sortBy:  cliCtx.String("sort"),
+		reverse: cliCtx.Bool("reverse"),
+	}
+	switch f.sortBy {
+	case "":
+		if f.reverse {
+			f.sortBy = "name"
+		}
+	case "name", "size", "time", "version":
+	default:
+		return nil, errInvalidArgument().Trace(f.sortBy)
+	}
+
+	if value := cliCtx.String("columns"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			found := false
+			for _, column := range listColumns {
+				if strings.EqualFold(column.name, name) {
+					f.columns = append(f.columns, column)
+					f.metadata = f.metadata || column.withMetadata
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, errInvalidArgument().Trace(name)
+			}
+		}
+	}
+
+	if format := cliCtx.String("format"); format != "" {
+		if len(f.columns) > 0 {
+			return nil, errInvalidArgument().Trace("--columns", "--format")
+		}
+		format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+		tmpl, e := template.New("format").Funcs(listTemplateFuncs).Option("missingkey=zero").Parse(format)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(format)
+		}
+		f.tmpl = tmpl
+		for _, field := range []string{".Tags", ".Metadata", ".ReplicationStatus"} {
+			f.metadata = f.metadata || strings.Contains(format, field)
+		}
+	}
+	return f, nil
+}
+
+// withMetadata - returns true if objects must be listed with their
+// metadata and tags.
+func (f *listFormatter) withMetadata() bool {
+	return f != nil && f.metadata
+}
+
+// message - returns the message printing an entry.
+func (f *listFormatter) message(entry listEntry) message {
+	switch {
+	case f.tmpl != nil:
+		var buf bytes.Buffer
+		if e := f.tmpl.Execute(&buf, entry); e != nil {
+			fatalIf(probe.NewError(e), "Unable to execute --format.")
+		}
+		return listTemplateMessage{Output: buf.String()}
+	case len(f.columns) > 0:
+		return listColumnsMessage{columns: f.columns, entry: entry}
+	}
+	return entry.msg
+}
+
+// print - prints an entry, or buffers it until flush if the output is
+// sorted. A nil formatter prints the default message.
+func (f *listFormatter) print(entry listEntry) {
+	switch {
+	case f == nil:
+		printMsg(entry.msg)
+	case f.sortBy != "":
+		f.entries = append(f.entries, entry)
+	default:
+		printMsg(f.message(entry))
+	}
+}
+
+// less - compares entries by the --sort key, ties are broken by key.
+func (f *listFormatter) less(a, b listEntry) bool {
+	switch f.sortBy {
+	case "size":
+		if a.Size != b.Size {
+			return a.Size < b.Size
+		}
+	case "time":
+		if !a.LastModified.Equal(b.LastModified) {
+			return a.LastModified.Before(b.LastModified)
+		}
+	case "version":
+		// Versions of an object from the oldest to the latest.
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		if !a.LastModified.Equal(b.LastModified) {
+			return a.LastModified.Before(b.LastModified)
+		}
+		return a.VersionOrdinal < b.VersionOrdinal
+	}
+	return a.Key < b.Key
+}
+
+// sort - sorts the buffered entries by the --sort key.
+func (f *listFormatter) sort() {
+	sort.SliceStable(f.entries, func(i, j int) bool {
+		if f.reverse {
+			return f.less(f.entries[j], f.entries[i])
+		}
+		return f.less(f.entries[i], f.entries[j])
+	})
+}
+
+// flush - prints the buffered entries in order.
+func (f *listFormatter) flush() {
+	if f == nil {
+		return
+	}
+	f.sort()
+	for _, entry := range f.entries {
+		printMsg(f.message(entry))
+	}
+	f.entries = nil
+}
+
+// unsorted - prints the next entries as they come, like the events of
+// find --watch.
+func (f *listFormatter) unsorted() {
+	if f != nil {
+		f.flush()
+		f.sortBy = ""
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+func newTestListFormatter(t *testing.T, args ...string) (*listFormatter, error) {
+	t.Helper()
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.String("sort", "", "")
+	set.Bool("reverse", false, "")
+	set.String("columns", "", "")
+	set.String("format", "", "")
+	if e := set.Parse(args); e != nil {
+		t.Fatal(e)
+	}
+	f, err := newListFormatter(cli.NewContext(nil, set, nil))
+	if err != nil {
+		return nil, err.ToGoError()
+	}
+	return f, nil
+}
+
+func TestNewListFormatter(t *testing.T) {
+	testCases := []struct {
+		args     []string
+		fail     bool
+		sortBy   string
+		metadata bool
+	}{
+		{args: nil},
+		{args: []string{"--sort", "size"}, sortBy: "size"},
+		{args: []string{"--reverse"}, sortBy: "name"},
+		{args: []string{"--sort", "owner"}, fail: true},
+		{args: []string{"--columns", "key,Size"}},
+		{args: []string{"--columns", "key,tags"}, metadata: true},
+		{args: []string{"--columns", "key,owner"}, fail: true},
+		{args: []string{"--format", "{{.Key}}"}},
+		{args: []string{"--format", "{{.Key}} {{.ReplicationStatus}}"}, metadata: true},
+		{args: []string{"--format", "{{.Key"}, fail: true},
+		{args: []string{"--columns", "key", "--format", "{{.Key}}"}, fail: true},
+	}
+	for i, testCase := range testCases {
+		f, e := newTestListFormatter(t, testCase.args...)
+		if testCase.fail != (e != nil) {
+			t.Fatalf("Test %d: expected failure %t, got %v", i+1, testCase.fail, e)
+		}
+		if testCase.fail {
+			continue
+		}
+		if f.sortBy != testCase.sortBy {
+			t.Errorf("Test %d: expected sort by %q, got %q", i+1, testCase.sortBy, f.sortBy)
+		}
+		if f.withMetadata() != testCase.metadata {
+			t.Errorf("Test %d: expected metadata %t, got %t", i+1, testCase.metadata, f.withMetadata())
+		}
+	}
+
+	var f *listFormatter
+	if f.withMetadata() {
+		t.Fatal("Expected no metadata without formatter")
+	}
+}
+
+func TestListFormatterSort(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	entries := []listEntry{
+		{Key: "b", Size: 10, LastModified: now, VersionOrdinal: 2},
+		{Key: "a", Size: 30, LastModified: now.Add(-time.Hour)},
+		{Key: "c", Size: 10, LastModified: now.Add(time.Hour)},
+		{Key: "b", Size: 20, LastModified: now.Add(-2 * time.Hour), VersionOrdinal: 1},
+	}
+	testCases := []struct {
+		args     []string
+		expected []int
+	}{
+		{[]string{"--sort", "name"}, []int{1, 0, 3, 2}},
+		{[]string{"--sort", "size"}, []int{0, 2, 3, 1}},
+		{[]string{"--sort", "size", "--reverse"}, []int{1, 3, 2, 0}},
+		{[]string{"--sort", "time"}, []int{3, 1, 0, 2}},
+		{[]string{"--sort", "version"}, []int{1, 3, 0, 2}},
+		{[]string{"--reverse"}, []int{2, 0, 3, 1}},
+	}
+	for i, testCase := range testCases {
+		f, e := newTestListFormatter(t, testCase.args...)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for _, entry := range entries {
+			f.print(entry)
+		}
+		f.sort()
+		for j, n := range testCase.expected {
+			if got := f.entries[j]; got.Key != entries[n].Key || got.Size != entries[n].Size {
+				t.Errorf("Test %d: expected %s of size %d at %d, got %s of size %d", i+1, entries[n].Key, entries[n].Size, j, got.Key, got.Size)
+			}
+		}
+	}
+}
+
+func TestListFormatterMessage(t *testing.T) {
+	entry := listEntry{
+		Key:          "a.csv",
+		Size:         2048,
+		LastModified: time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC),
+		StorageClass: "STANDARD",
+		Tags:         map[string]string{"tier": "hot"},
+	}
+
+	f, e := newTestListFormatter(t, "--columns", "key,size,storageClass,tags")
+	if e != nil {
+		t.Fatal(e)
+	}
+	msg := f.message(entry)
+	if s := msg.String(); s != "a.csv\t2048\tSTANDARD\t{\"tier\":\"hot\"}" {
+		t.Errorf("Unexpected columns %q", s)
+	}
+	expected := `{"status":"success","key":"a.csv","size":2048,"storageClass":"STANDARD","tags":{"tier":"hot"}}`
+	if s := msg.JSON(); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+
+	f, e = newTestListFormatter(t, "--format", `{{.Key}}\t{{human .Size}}\t{{json .Tags}}`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s := f.message(entry).String(); s != "a.csv\t2.0 KiB\t{\"tier\":\"hot\"}" {
+		t.Errorf("Unexpected template output %q", s)
+	}
+}
@@ -70,7 +70,7 @@ var lsCmd = cli.Command{
 	Action:       mainList,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(lsFlags, globalFlags...),
+	Flags:        append(append(lsFlags, listFormatFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -80,6 +80,7 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
+` + listFormatHelp + `
 EXAMPLES:
   1. List buckets on Amazon S3 cloud storage.
      {{.Prompt}} {{.HelpName}} s3
@@ -112,6 +113,15 @@ EXAMPLES:
   
   10. List all objects on mybucket, for the GLACIER storage class
      {{.Prompt}} {{.HelpName}} --storage-class 'GLACIER' s3/mybucket 
+
+  11. List the largest objects of mybucket first.
+     {{.Prompt}} {{.HelpName}} --recursive --sort size --reverse s3/mybucket
+
+  12. List the key, size and replication status of all objects of mybucket.
+     {{.Prompt}} {{.HelpName}} --recursive --columns key,size,replicationStatus s3/mybucket
+
+  13. List the objects of mybucket with a custom format.
+     {{.Prompt}} {{.HelpName}} --recursive --format '{{"{{"}}.Key{{"}}"}}\t{{"{{"}}.Size | human{{"}}"}}' s3/mybucket
 `,
 }
 
@@ -180,6 +190,8 @@ func checkListSyntax(cliCtx *cli.Context) ([]string, doListOptions) {
 		fatalIf(errInvalidArgument().Trace(args...), "Zip file listing can only be performed on the latest version")
 	}
 	storageClasss := cliCtx.String("storage-class")
+	formatter, err := newListFormatter(cliCtx)
+	fatalIf(err, "Unable to parse --sort, --columns or --format.")
 	opts := doListOptions{
 		timeRef:           timeRef,
 		isRecursive:       isRecursive,
@@ -188,6 +200,7 @@ func checkListSyntax(cliCtx *cli.Context) ([]string, doListOptions) {
 		withOlderVersions: withOlderVersions,
 		listZip:           listZip,
 		filter:            storageClasss,
+		formatter:         formatter,
 	}
 	return args, opts
 }
@@ -52,8 +52,9 @@ type contentMessage struct {
 	IsDeleteMarker bool   `json:"isDeleteMarker,omitempty"`
 	StorageClass   string `json:"storageClass,omitempty"`
 
-	Metadata map[string]string `json:"metadata,omitempty"`
-	Tags     map[string]string `json:"tags,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	Tags              map[string]string `json:"tags,omitempty"`
+	ReplicationStatus string            `json:"replicationStatus,omitempty"`
 }
 
 // String colorized string message.
@@ -142,6 +143,7 @@ func generateContentMessages(clntURL ClientURL, ctnts []*ClientContent, printAll
 		contentMsg.StorageClass = c.StorageClass
 		contentMsg.Metadata = c.Metadata
 		contentMsg.Tags = c.Tags
+		contentMsg.ReplicationStatus = c.ReplicationStatus
 
 		md5sum := strings.TrimPrefix(c.ETag, "\"")
 		md5sum = strings.TrimSuffix(md5sum, "\"")
@@ -198,11 +200,11 @@ func (s summaryMessage) JSON() string {
 }
 
 // Pretty print the list of versions belonging to one object
-func printObjectVersions(clntURL ClientURL, ctntVersions []*ClientContent, printAllVersions bool) {
+func printObjectVersions(clntURL ClientURL, ctntVersions []*ClientContent, printAllVersions bool, formatter *listFormatter) {
 	sortObjectVersions(ctntVersions)
 	msgs := generateContentMessages(clntURL, ctntVersions, printAllVersions)
 	for _, msg := range msgs {
-		printMsg(msg)
+		formatter.print(contentListEntry(msg, msg))
 	}
 }
 
@@ -214,6 +216,7 @@ type doListOptions struct {
 	withOlderVersions bool
 	listZip           bool
 	filter            string
+	formatter         *listFormatter
 }
 
 // doList - list all entities inside a folder.
@@ -234,6 +237,7 @@ func doList(ctx context.Context, clnt Client, o doListOptions) error {
 		WithDeleteMarkers: true,
 		ShowDir:           DirNone,
 		ListZip:           o.listZip,
+		WithMetadata:      o.formatter.withMetadata(),
 	}) {
 		if content.Err != nil {
 			errorIf(content.Err.Trace(clnt.GetURL().String()), "Unable to list folder.")
@@ -247,7 +251,7 @@ func doList(ctx context.Context, clnt Client, o doListOptions) error {
 
 		if lastPath != content.URL.Path {
 			// Print any object in the current list before reinitializing it
-			printObjectVersions(clnt.GetURL(), perObjectVersions, o.withOlderVersions)
+			printObjectVersions(clnt.GetURL(), perObjectVersions, o.withOlderVersions, o.formatter)
 			lastPath = content.URL.Path
 			perObjectVersions = []*ClientContent{}
 		}
@@ -257,7 +261,8 @@ func doList(ctx context.Context, clnt Client, o doListOptions) error {
 		totalObjects++
 	}
 
-	printObjectVersions(clnt.GetURL(), perObjectVersions, o.withOlderVersions)
+	printObjectVersions(clnt.GetURL(), perObjectVersions, o.withOlderVersions, o.formatter)
+	o.formatter.flush()
 
 	if o.isSummary {
 		printMsg(summaryMessage{
@@ -430,6 +430,7 @@ var appCmds = []cli.Command{
 	headCmd,
 	ilmCmd,
 	idpCmd,
+	inventoryCmd,
 	licenseCmd,
 	legalHoldCmd,
 	lsCmd,
@@ -445,11 +446,15 @@ var appCmds = []cli.Command{
 	rmCmd,
 	retentionCmd,
 	rbCmd,
+	replayCmd,
 	replicateCmd,
 	readyCmd,
+	scheduleCmd,
+	sessionCmd,
 	sqlCmd,
 	statCmd,
 	supportCmd,
+	syncCmd,
 	shareCmd,
 	treeCmd,
 	tagCmd,
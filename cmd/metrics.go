@@ -0,0 +1,337 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics of the running command, labeled with its name, served by
+// --metrics-address.
+var (
+	metricsObjects = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_objects_total",
+		Help: "The total number of objects processed",
+	}, []string{"command"})
+	metricsBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_bytes_total",
+		Help: "The total number of bytes processed",
+	}, []string{"command"})
+	metricsErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_errors_total",
+		Help: "The total number of errors by error code",
+	}, []string{"command", "code"})
+	metricsRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "mc_retries_total",
+		Help: "The total number of retried operations",
+	}, []string{"command"})
+	metricsWorkers = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mc_workers",
+		Help: "The current number of parallel workers",
+	}, []string{"command"})
+	metricsLastActivity = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mc_last_activity_timestamp_seconds",
+		Help: "The time an object was last processed, in seconds since epoch",
+	}, []string{"command"})
+	metricsRequestDurations = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mc_s3_request_duration_seconds",
+		Help:    "Histogram of the S3 request latencies by API",
+		Buckets: prometheus.ExponentialBuckets(0.005, 4, 8),
+	}, []string{"command", "api"})
+)
+
+// Name of the running command, 'batch status' for subcommands.
+var globalMetricsCommand string
+
+// Time the metrics server was started.
+var metricsStartTime time.Time
+
+// metricsObjectDone - accounts an object processed by the command.
+func metricsObjectDone(size int64) {
+	metricsObjectsDone(1, size)
+}
+
+// metricsObjectsDone - accounts objects processed by the command.
+func metricsObjectsDone(objects, size int64) {
+	metricsObjects.WithLabelValues(globalMetricsCommand).Add(float64(objects))
+	if size > 0 {
+		metricsBytes.WithLabelValues(globalMetricsCommand).Add(float64(size))
+	}
+	if objects <= 0 {
+		return
+	}
+	now := time.Now()
+	metricsLastActivity.WithLabelValues(globalMetricsCommand).Set(float64(now.UnixNano()) / 1e9)
+	metricsActivityMu.Lock()
+	metricsActivity = now
+	metricsActivityMu.Unlock()
+}
+
+// metricsError - accounts an error reported by the command by its code.
+func metricsError(err *probe.Error) {
+	if err == nil {
+		return
+	}
+	metricsErrors.WithLabelValues(globalMetricsCommand, metricsErrorCode(err.ToGoError())).Inc()
+}
+
+// metricsErrorCode - returns the S3 error code of an error, or the name
+// of its type for errors of mc.
+func metricsErrorCode(e error) string {
+	if code := minio.ToErrorResponse(e).Code; code != "" {
+		return code
+	}
+	if errors.Is(e, context.Canceled) {
+		return "Canceled"
+	}
+	// Errors of mc like PathNotFound are structs.
+	if t := reflect.TypeOf(e); t != nil && t.Kind() == reflect.Struct && t.Name() != "" {
+		return t.Name()
+	}
+	return "InternalError"
+}
+
+// metricsErrorsCount - accounts errors of the given code.
+func metricsErrorsCount(code string, n int64) {
+	metricsErrors.WithLabelValues(globalMetricsCommand, code).Add(float64(n))
+}
+
+// metricsRetry - accounts a retried operation.
+func metricsRetry() {
+	metricsRetriesCount(1)
+}
+
+// metricsRetriesCount - accounts retried operations.
+func metricsRetriesCount(n int64) {
+	metricsRetries.WithLabelValues(globalMetricsCommand).Add(float64(n))
+}
+
+// metricsAddWorkers - accounts parallel workers started, or stopped
+// when negative.
+func metricsAddWorkers(n int) {
+	metricsWorkers.WithLabelValues(globalMetricsCommand).Add(float64(n))
+}
+
+// metricsTransport observes the latency of every request by S3 API.
+type metricsTransport struct {
+	transport http.RoundTripper
+}
+
+func (t metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, e := t.transport.RoundTrip(req)
+	metricsRequestDurations.WithLabelValues(globalMetricsCommand, s3APIName(req)).Observe(time.Since(start).Seconds())
+	return resp, e
+}
+
+// Sub-resources of buckets and objects, the API is named after the
+// method, like GetObjectTagging.
+var s3SubResources = []struct {
+	query, name string
+}{
+	{"tagging", "Tagging"},
+	{"retention", "Retention"},
+	{"legal-hold", "LegalHold"},
+	{"versioning", "Versioning"},
+	{"policy", "Policy"},
+	{"lifecycle", "Lifecycle"},
+	{"replication", "Replication"},
+	{"encryption", "Encryption"},
+	{"object-lock", "ObjectLockConfiguration"},
+	{"notification", "Notification"},
+	{"acl", "Acl"},
+	{"cors", "Cors"},
+	{"location", "Location"},
+}
+
+// APIs of sub-resources with a single method.
+var s3SubResourceAPIs = map[string]string{
+	"select":     "SelectObjectContent",
+	"restore":    "RestoreObject",
+	"attributes": "GetObjectAttributes",
+}
+
+// s3APIName - returns the S3 API of a request, path style requests are
+// told apart between bucket and object requests.
+func s3APIName(req *http.Request) string {
+	path := strings.TrimPrefix(req.URL.Path, "/")
+	if strings.HasPrefix(path, "minio/admin/") {
+		return "Admin"
+	}
+	if strings.HasPrefix(path, "minio/") {
+		return "MinIO"
+	}
+	q := req.URL.Query()
+	_, object, _ := strings.Cut(path, "/")
+	isObject := object != ""
+	method := req.Method
+
+	switch {
+	case q.Has("uploadId"):
+		switch method {
+		case http.MethodPut:
+			if req.Header.Get("X-Amz-Copy-Source") != "" {
+				return "UploadPartCopy"
+			}
+			return "UploadPart"
+		case http.MethodPost:
+			return "CompleteMultipartUpload"
+		case http.MethodDelete:
+			return "AbortMultipartUpload"
+		}
+		return "ListParts"
+	case q.Has("uploads"):
+		if method == http.MethodPost {
+			return "CreateMultipartUpload"
+		}
+		return "ListMultipartUploads"
+	case q.Has("delete") && method == http.MethodPost:
+		return "DeleteObjects"
+	case q.Has("versions"):
+		return "ListObjectVersions"
+	case q.Get("list-type") == "2":
+		return "ListObjectsV2"
+	}
+	for query, api := range s3SubResourceAPIs {
+		if q.Has(query) {
+			return api
+		}
+	}
+	for _, sub := range s3SubResources {
+		if !q.Has(sub.query) {
+			continue
+		}
+		kind := "Bucket"
+		if isObject {
+			kind = "Object"
+		}
+		switch method {
+		case http.MethodPut:
+			return "Put" + kind + sub.name
+		case http.MethodDelete:
+			return "Delete" + kind + sub.name
+		}
+		return "Get" + kind + sub.name
+	}
+
+	if !isObject {
+		switch method {
+		case http.MethodHead:
+			return "HeadBucket"
+		case http.MethodPut:
+			return "CreateBucket"
+		case http.MethodDelete:
+			return "DeleteBucket"
+		case http.MethodGet:
+			if path == "" {
+				return "ListBuckets"
+			}
+			return "ListObjects"
+		}
+		return method
+	}
+	switch method {
+	case http.MethodHead:
+		return "HeadObject"
+	case http.MethodGet:
+		return "GetObject"
+	case http.MethodPut:
+		if req.Header.Get("X-Amz-Copy-Source") != "" {
+			return "CopyObject"
+		}
+		return "PutObject"
+	case http.MethodDelete:
+		return "DeleteObject"
+	}
+	return method
+}
+
+// metricsHealth - the status of the command reported by /healthz.
+type metricsHealth struct {
+	Status       string     `json:"status"`
+	Command      string     `json:"command"`
+	Uptime       string     `json:"uptime"`
+	LastActivity *time.Time `json:"lastActivity,omitempty"`
+}
+
+// Last activity of the command, for /healthz.
+var (
+	metricsActivityMu sync.Mutex
+	metricsActivity   time.Time
+)
+
+// healthzHandler - answers 'ok' with the time the command last
+// processed an object, to alert on stalled commands.
+func healthzHandler(w http.ResponseWriter, _ *http.Request) {
+	health := metricsHealth{
+		Status:  "ok",
+		Command: globalMetricsCommand,
+		Uptime:  time.Since(metricsStartTime).Round(time.Second).String(),
+	}
+	metricsActivityMu.Lock()
+	if !metricsActivity.IsZero() {
+		lastActivity := metricsActivity
+		health.LastActivity = &lastActivity
+	}
+	metricsActivityMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(health)
+}
+
+// Addresses the metrics server listens on.
+var (
+	metricsServersMu sync.Mutex
+	metricsServers   = make(map[string]bool)
+)
+
+// startMetricsServer - serves the Prometheus metrics on /metrics and the
+// status of the command on /healthz, once per address.
+func startMetricsServer(address string) {
+	metricsServersMu.Lock()
+	defer metricsServersMu.Unlock()
+	if metricsServers[address] {
+		return
+	}
+	metricsServers[address] = true
+	if metricsStartTime.IsZero() {
+		metricsStartTime = time.Now()
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	mux.HandleFunc("/healthz", healthzHandler)
+	go func() {
+		if e := http.ListenAndServe(address, mux); e != nil {
+			fatalIf(probe.NewError(e), "Unable to setup the metrics endpoint.")
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestS3APIName(t *testing.T) {
+	testCases := []struct {
+		method, url string
+		copySource  bool
+		api         string
+	}{
+		{http.MethodGet, "/", false, "ListBuckets"},
+		{http.MethodHead, "/bucket", false, "HeadBucket"},
+		{http.MethodPut, "/bucket/", false, "CreateBucket"},
+		{http.MethodGet, "/bucket/?list-type=2&prefix=a", false, "ListObjectsV2"},
+		{http.MethodGet, "/bucket?versions", false, "ListObjectVersions"},
+		{http.MethodGet, "/bucket?location", false, "GetBucketLocation"},
+		{http.MethodPost, "/bucket?delete", false, "DeleteObjects"},
+		{http.MethodGet, "/bucket/a/b.txt", false, "GetObject"},
+		{http.MethodHead, "/bucket/a", false, "HeadObject"},
+		{http.MethodPut, "/bucket/a", false, "PutObject"},
+		{http.MethodPut, "/bucket/a", true, "CopyObject"},
+		{http.MethodDelete, "/bucket/a?versionId=1", false, "DeleteObject"},
+		{http.MethodPut, "/bucket/a?tagging", false, "PutObjectTagging"},
+		{http.MethodGet, "/bucket?tagging", false, "GetBucketTagging"},
+		{http.MethodPost, "/bucket/a?uploads", false, "CreateMultipartUpload"},
+		{http.MethodPut, "/bucket/a?partNumber=1&uploadId=x", false, "UploadPart"},
+		{http.MethodPut, "/bucket/a?partNumber=1&uploadId=x", true, "UploadPartCopy"},
+		{http.MethodPost, "/bucket/a?uploadId=x", false, "CompleteMultipartUpload"},
+		{http.MethodPost, "/bucket/a?select&select-type=2", false, "SelectObjectContent"},
+		{http.MethodGet, "/minio/admin/v3/info", false, "Admin"},
+	}
+	for _, testCase := range testCases {
+		req := httptest.NewRequest(testCase.method, testCase.url, nil)
+		if testCase.copySource {
+			req.Header.Set("X-Amz-Copy-Source", "/src/a")
+		}
+		if api := s3APIName(req); api != testCase.api {
+			t.Errorf("%s %s: expected %s, got %s", testCase.method, testCase.url, testCase.api, api)
+		}
+	}
+}
+
+func TestMetricsErrorCode(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code string
+	}{
+		{minio.ErrorResponse{Code: "NoSuchKey"}, "NoSuchKey"},
+		{PathNotFound{Path: "a"}, "PathNotFound"},
+		{context.Canceled, "Canceled"},
+		{errInvalidArgument().ToGoError(), "InternalError"},
+	}
+	for _, testCase := range testCases {
+		if code := metricsErrorCode(testCase.err); code != testCase.code {
+			t.Errorf("%v: expected %s, got %s", testCase.err, testCase.code, code)
+		}
+	}
+}
+
+func TestCommandMetrics(t *testing.T) {
+	defer func(command string) {
+		globalMetricsCommand = command
+	}(globalMetricsCommand)
+	globalMetricsCommand = "metrics-test"
+
+	metricsObjectDone(10)
+	metricsObjectDone(0)
+	metricsError(probe.NewError(minio.ErrorResponse{Code: "SlowDown"}))
+	metricsRetry()
+	rec := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range []string{
+		`mc_objects_total{command="metrics-test"} 2`,
+		`mc_bytes_total{command="metrics-test"} 10`,
+		`mc_errors_total{code="SlowDown",command="metrics-test"} 1`,
+		`mc_retries_total{command="metrics-test"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), line+"\n") {
+			t.Errorf("Expected the metric %s", line)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	healthzHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var health metricsHealth
+	if e := json.Unmarshal(rec.Body.Bytes(), &health); e != nil {
+		t.Fatal(e)
+	}
+	if rec.Code != http.StatusOK || health.Status != "ok" || health.Command != "metrics-test" || health.LastActivity == nil {
+		t.Fatalf("Unexpected health %d %+v", rec.Code, health)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// Format of the dated prefix of --backup-dir, one per mirror run.
+const mirrorBackupTimeFormat = "20060102T150405Z"
+
+// deleteThreshold - the maximum number of target objects a mirror may
+// remove, either a count or a percentage of the target objects.
+type deleteThreshold struct {
+	count   int64
+	percent float64
+	value   string
+}
+
+// parseDeleteThreshold - parses the value of --max-delete, 'N' or 'P%'.
+func parseDeleteThreshold(value string) (*deleteThreshold, *probe.Error) {
+	t := &deleteThreshold{value: value}
+	if p, ok := strings.CutSuffix(value, "%"); ok {
+		percent, e := strconv.ParseFloat(p, 64)
+		if e != nil || percent < 0 || percent > 100 {
+			return nil, errInvalidArgument().Trace(value)
+		}
+		t.percent = percent
+		return t, nil
+	}
+	count, e := strconv.ParseInt(value, 10, 64)
+	if e != nil || count < 0 {
+		return nil, errInvalidArgument().Trace(value)
+	}
+	t.count = count
+	t.percent = -1
+	return t, nil
+}
+
+// exceeded - returns true if removing objects out of the target objects
+// goes over the threshold.
+func (t *deleteThreshold) exceeded(removals, targets int64) bool {
+	if t.percent < 0 {
+		return removals > t.count
+	}
+	return float64(removals)*100 > t.percent*float64(targets)
+}
+
+// expandFolderURL - returns the expanded URL of a folder with a trailing
+// separator, local folders are made absolute.
+func expandFolderURL(aliasedURL string) string {
+	_, urlStr, _ := mustExpandAlias(aliasedURL)
+	u := newClientURL(urlStr)
+	if u.Type == fileSystem {
+		if abs, e := filepath.Abs(urlStr); e == nil {
+			urlStr = abs
+		}
+	}
+	if separator := string(u.Separator); !strings.HasSuffix(urlStr, separator) {
+		urlStr += separator
+	}
+	return urlStr
+}
+
+// mirrorBackupPrefix - returns the dated prefix of --backup-dir the
+// objects of a mirror run are moved under.
+func mirrorBackupPrefix(backupDir string, t time.Time) string {
+	return urlJoinPath(backupDir, t.UTC().Format(mirrorBackupTimeFormat)) + "/"
+}
+
+// targetKey - returns the name of a target object relative to the
+// target folder.
+func (mj *mirrorJob) targetKey(targetURL ClientURL) string {
+	urlStr := targetURL.String()
+	if targetURL.Type == fileSystem {
+		if abs, e := filepath.Abs(urlStr); e == nil {
+			urlStr = abs
+		}
+	}
+	return filepath.ToSlash(strings.TrimPrefix(urlStr, expandFolderURL(mj.targetURL)))
+}
+
+// backupTarget - copies a target object about to be removed or
+// overwritten under the dated prefix of --backup-dir. Missing objects
+// have nothing to back up.
+func (mj *mirrorJob) backupTarget(ctx context.Context, targetAlias string, targetURL ClientURL) *probe.Error {
+	if mj.opts.backupPrefix == "" {
+		return nil
+	}
+	targetPath := filepath.ToSlash(filepath.Join(targetAlias, targetURL.Path))
+	clnt, err := newClientFromAlias(targetAlias, targetURL.String())
+	if err != nil {
+		return err.Trace(targetPath)
+	}
+	content, err := clnt.Stat(ctx, StatOptions{sse: getSSE(targetPath, mj.opts.encKeyDB[targetAlias])})
+	if err != nil {
+		switch err.ToGoError().(type) {
+		case ObjectMissing, PathNotFound:
+			return nil
+		}
+		return err.Trace(targetPath)
+	}
+	if content.Type.IsDir() {
+		return nil
+	}
+
+	backupPath := urlJoinPath(mj.opts.backupPrefix, mj.targetKey(targetURL))
+	backupAlias, backupURL, _ := mustExpandAlias(backupPath)
+	urls := URLs{
+		SourceAlias:   targetAlias,
+		SourceContent: content,
+		TargetAlias:   backupAlias,
+		TargetContent: &ClientContent{URL: *newClientURL(backupURL)},
+	}
+	return retryWithMessage(ctx, mj.opts.retry, targetPath, backupPath, func() *probe.Error {
+		return uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{urls: urls, encKeyDB: mj.opts.encKeyDB}).Error
+	})
+}
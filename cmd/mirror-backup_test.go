@@ -0,0 +1,128 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestDeleteThreshold(t *testing.T) {
+	testCases := []struct {
+		value             string
+		removals, targets int64
+		exceeded          bool
+	}{
+		{"10", 10, 20, false},
+		{"10", 11, 1000, true},
+		{"0", 1, 1000, true},
+		{"5%", 5, 100, false},
+		{"5%", 6, 100, true},
+		{"0.5%", 1, 100, true},
+		{"100%", 10, 10, false},
+	}
+	for _, testCase := range testCases {
+		threshold, err := parseDeleteThreshold(testCase.value)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.value, err)
+		}
+		if exceeded := threshold.exceeded(testCase.removals, testCase.targets); exceeded != testCase.exceeded {
+			t.Errorf("%s: expected %v for %d of %d, got %v", testCase.value, testCase.exceeded, testCase.removals, testCase.targets, exceeded)
+		}
+	}
+
+	for _, value := range []string{"", "-1", "ten", "x%", "101%", "-5%"} {
+		if _, err := parseDeleteThreshold(value); err == nil {
+			t.Errorf("%s: expected an invalid threshold", value)
+		}
+	}
+}
+
+func TestMirrorRemovalSafety(t *testing.T) {
+	root, srcDir := newTestFolder(t, map[string]string{"a": "a", "f": "f"})
+	srcDir += string(filepath.Separator)
+	tgtDir := filepath.Join(root, "tgt") + string(filepath.Separator)
+	backupDir := filepath.Join(tgtDir, ".trash")
+	for _, name := range []string{"a", "b", "c", "d"} {
+		writeTestFile(t, filepath.Join(tgtDir, name), name)
+	}
+	writeTestFile(t, filepath.Join(backupDir, "old", "e"), "e")
+
+	removals := func(maxDelete string) (names []string, err *probe.Error) {
+		t.Helper()
+		threshold, err := parseDeleteThreshold(maxDelete)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := mirrorOptions{isRemove: true, maxDelete: threshold, backupDir: backupDir}
+		for urls := range prepareMirrorURLs(context.Background(), srcDir, tgtDir, opts) {
+			if urls.Error != nil {
+				err = urls.Error
+				continue
+			}
+			if urls.SourceContent == nil {
+				names = append(names, filepath.Base(urls.TargetContent.URL.Path))
+			} else if !urls.targetMissing {
+				// Objects only in the source are copied without a backup.
+				t.Fatalf("Expected %s to be missing from the target", urls.SourceContent.URL.Path)
+			}
+		}
+		sort.Strings(names)
+		return names, err
+	}
+
+	// 3 of the 4 target objects, the backup folder is not part of the target.
+	if names, err := removals("50%"); err == nil || len(names) != 0 {
+		t.Fatalf("Expected the removals to be aborted, got %v", names)
+	}
+	if names, err := removals("2"); err == nil || len(names) != 0 {
+		t.Fatalf("Expected the removals to be aborted, got %v", names)
+	}
+	names, err := removals("75%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || names[0] != "b" || names[2] != "d" {
+		t.Fatalf("Expected b, c and d to be removed, got %v", names)
+	}
+
+	// The removed object is moved under the dated prefix.
+	backupTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	mj := &mirrorJob{
+		targetURL: tgtDir,
+		opts:      mirrorOptions{backupPrefix: mirrorBackupPrefix(backupDir, backupTime)},
+	}
+	if err = mj.backupTarget(context.Background(), "", *newClientURL(filepath.Join(tgtDir, "b"))); err != nil {
+		t.Fatal(err)
+	}
+	data, e := os.ReadFile(filepath.Join(backupDir, "20240506T070809Z", "b"))
+	if e != nil || string(data) != "b" {
+		t.Fatalf("Expected b to be backed up: %v", e)
+	}
+
+	// Missing objects have nothing to back up.
+	if err = mj.backupTarget(context.Background(), "", *newClientURL(filepath.Join(tgtDir, "missing"))); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
-	"net/http"
 	"path"
 	"path/filepath"
 	"runtime"
@@ -39,7 +38,6 @@ import (
 	"github.com/minio/pkg/v2/console"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // mirror specific flags.
@@ -84,6 +82,8 @@ var (
 			Name:  "md5",
 			Usage: "force all upload(s) to calculate md5sum checksum",
 		},
+		checksumFlag,
+		compareFlag,
 		cli.BoolFlag{
 			Name:   "multi-master",
 			Usage:  "enable multi-master multi-site setup",
@@ -121,17 +121,25 @@ var (
 			Name:  "storage-class, sc",
 			Usage: "specify storage class for new object(s) on target",
 		},
+		cli.StringFlag{
+			Name:  "max-delete",
+			Usage: "do not remove any object when the initial comparison would remove more than N objects or P% of the target objects, removals of --watch are not limited",
+		},
+		cli.StringFlag{
+			Name:  "backup-dir",
+			Usage: "move removed or overwritten target objects under a dated prefix of this folder instead of deleting them",
+		},
 		cli.StringFlag{
 			Name:  "attr",
 			Usage: "add custom metadata for all objects",
 		},
 		cli.StringFlag{
 			Name:  "monitoring-address",
-			Usage: "if specified, a new prometheus endpoint will be created to report mirroring activity. (eg: localhost:8081)",
+			Usage: "if specified, a new prometheus endpoint will be created to report mirroring activity, same as '--metrics-address'. (eg: localhost:8081)",
 		},
 		cli.BoolFlag{
 			Name:  "retry",
-			Usage: "if specified, will enable retrying on a per object basis if errors occur",
+			Usage: "retry an object up to 3 times on transient errors, same as '--max-retries 3'",
 		},
 		cli.BoolFlag{
 			Name:  "summary",
@@ -141,6 +149,15 @@ var (
 			Name:  "skip-errors",
 			Usage: "skip any errors when mirroring",
 		},
+		cli.StringFlag{
+			Name:  "state-db",
+			Usage: "remember the mirrored objects in a local file seeded by a first full comparison, later runs only list the source and compare it to the file",
+		},
+		cli.Float64Flag{
+			Name:  "state-sample",
+			Usage: "fraction of the unchanged objects verified on the target when '--state-db' is set",
+			Value: mirrorStateDefaultSample,
+		},
 	}
 )
 
@@ -151,7 +168,7 @@ var mirrorCmd = cli.Command{
 	Action:       mainMirror,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(mirrorFlags, encFlags...), globalFlags...),
+	Flags:        append(append(append(append(append(append(mirrorFlags, objectFilterFlags...), encFlags...), concurrencyFlags...), retryFlags...), failedReportFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -166,6 +183,17 @@ ENVIRONMENT VARIABLES:
   MC_ENC_KMS: KMS encryption key in the form of (alias/prefix=key).
   MC_ENC_S3: S3 encryption key in the form of (alias/prefix=key).
 
+FILTERS:
+  --include and --exclude patterns are evaluated in the order of the command line, the first matching
+  pattern decides. Objects matching no pattern are mirrored unless an --include pattern is set.
+  --larger, --smaller, --tags and --metadata select the source objects, objects only on the target are
+  only filtered by name. A --filter-file holds one rule per line named like the flags, such as
+  'include *.parquet' or 'tags tier=hot', its patterns are evaluated after the command line.
+
+  --smaller, --larger flags accept human-readable case-insensitive number suffixes such as "k", "m",
+  "g" and "t" referring to the metric units KB, MB, GB and TB respectively. Adding an "i" to these
+  prefixes uses the IEC units, so that "gi" refers to "gibibyte" or "GiB".
+
 EXAMPLES:
   01. Mirror a bucket recursively from MinIO cloud storage to a bucket on Amazon S3 cloud storage.
       {{.Prompt}} {{.HelpName}} play/photos/2014 s3/backup-photos
@@ -219,6 +247,41 @@ EXAMPLES:
   16. Cross mirror between sites in a active-active deployment.
       Site-A: {{.Prompt}} {{.HelpName}} --active-active siteA siteB
       Site-B: {{.Prompt}} {{.HelpName}} --active-active siteB siteA
+
+  17. Mirror a local folder to MinIO cloud storage verifying every object with a CRC32C checksum.
+      {{.Prompt}} {{.HelpName}} --checksum crc32c backup/ myminio/backup
+
+  18. Mirror a local folder to MinIO cloud storage, only re-uploading files whose MD5 sum differs from the object ETag.
+      {{.Prompt}} {{.HelpName}} --overwrite --compare etag backup/ myminio/backup
+
+  19. Watch and mirror a local folder, throttling uploads to 10MiB/s during office hours only.
+      {{.Prompt}} {{.HelpName}} --watch --limit-upload "09:00-18:00=10MiB,18:00-09:00=0" backup/ myminio/backup
+
+  20. Mirror a local folder to MinIO cloud storage, encrypting the objects on the client.
+      {{.Prompt}} {{.HelpName}} --enc-client "myminio/backup=/etc/mc/backup.key" backup/ myminio/backup
+
+  21. Mirror a bucket to a shared cluster with at most 16 parallel workers.
+      {{.Prompt}} {{.HelpName}} --max-workers 16 s3/archive/ myminio/archive/
+
+  22. Mirror a bucket, writing the objects which failed to a report, retry them with 'mc replay'.
+      {{.Prompt}} {{.HelpName}} --skip-errors --failed-report failed.json s3/archive/ myminio/archive/
+
+  23. Mirror a large bucket daily, only listing the source and verifying 5% of the unchanged objects on the target.
+      {{.Prompt}} {{.HelpName}} --state-db ~/.mc/archive.state --state-sample 0.05 s3/archive/ myminio/archive/
+
+  24. Mirror only the parquet files tagged with tier=hot.
+      {{.Prompt}} {{.HelpName}} --include "*.parquet" --tags "tier=hot" s3/datalake/ myminio/datalake/
+
+  25. Mirror the logs larger than 1MiB, except the temporary ones, with the rules of a filter file.
+      {{.Prompt}} {{.HelpName}} --exclude "tmp/*" --include "*.log" --larger 1MiB s3/logs/ myminio/logs/
+      {{.Prompt}} {{.HelpName}} --filter-file ~/logs.filter s3/logs/ myminio/logs/
+
+  26. Mirror a local folder removing extraneous objects, unless more than 5% of the target would be removed.
+      The threshold applies to the comparison of the folders, not to the objects removed while watching.
+      {{.Prompt}} {{.HelpName}} --remove --max-delete 5% /mnt/data/ myminio/data/
+
+  27. Mirror a bucket, moving the removed and overwritten objects under a dated prefix of a trash bucket.
+      {{.Prompt}} {{.HelpName}} --remove --overwrite --backup-dir myminio/trash s3/archive/ myminio/archive/
 `,
 }
 
@@ -341,6 +404,7 @@ func (mj *mirrorJob) doDeleteBucket(ctx context.Context, sURLs URLs) URLs {
 		}
 	}
 
+	mj.opts.state.remove(sURLs.TargetContent)
 	return sURLs.WithError(nil)
 }
 
@@ -361,6 +425,9 @@ func (mj *mirrorJob) doRemove(ctx context.Context, sURLs URLs) URLs {
 	} else {
 		clnt.AddUserAgent(uaMirrorAppName, ReleaseTag)
 	}
+	if pErr = mj.backupTarget(ctx, sURLs.TargetAlias, sURLs.TargetContent.URL); pErr != nil {
+		return sURLs.WithError(pErr)
+	}
 	contentCh := make(chan *ClientContent, 1)
 	contentCh <- &ClientContent{URL: *newClientURL(sURLs.TargetContent.URL.Path)}
 	close(contentCh)
@@ -484,30 +551,19 @@ func (mj *mirrorJob) doMirror(ctx context.Context, sURLs URLs) URLs {
 		})
 	}
 	sURLs.MD5 = mj.opts.md5
+	sURLs.Checksum = mj.opts.checksum
 	sURLs.DisableMultipart = mj.opts.disableMultipart
 
-	var ret URLs
-
-	if !mj.opts.isRetriable {
-		now := time.Now()
-		ret = uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{urls: sURLs, progress: mj.status, encKeyDB: mj.opts.encKeyDB, preserve: mj.opts.isMetadata, isZip: false})
-		if ret.Error == nil {
-			durationMs := time.Since(now).Milliseconds()
-			mirrorReplicationDurations.With(prometheus.Labels{"object_size": convertSizeToTag(sURLs.SourceContent.Size)}).Observe(float64(durationMs))
+	// Objects only in the source have no target to back up.
+	if !sURLs.targetMissing {
+		if err := mj.backupTarget(ctx, targetAlias, targetURL); err != nil {
+			return sURLs.WithError(err)
 		}
-
-		return ret
 	}
 
-	newRetryManager(ctx, time.Second, 3).retry(func(rm *retryManager) *probe.Error {
-		if rm.retries > 0 {
-			printMsg(retryMessage{
-				SourceURL: sURLs.SourceContent.URL.String(),
-				TargetURL: sURLs.TargetContent.URL.String(),
-				Retries:   rm.retries,
-			})
-		}
+	var ret URLs
 
+	retryWithMessage(ctx, mj.opts.retry, sourcePath, targetPath, func() *probe.Error {
 		now := time.Now()
 		ret = uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{urls: sURLs, progress: mj.status, encKeyDB: mj.opts.encKeyDB, preserve: mj.opts.isMetadata, isZip: false})
 		if ret.Error == nil {
@@ -517,6 +573,9 @@ func (mj *mirrorJob) doMirror(ctx context.Context, sURLs URLs) URLs {
 
 		return ret.Error
 	})
+	if ret.Error == nil {
+		mj.opts.state.put(sURLs.TargetContent, sURLs.SourceContent)
+	}
 
 	return ret
 }
@@ -579,6 +638,7 @@ func (mj *mirrorJob) monitorMirrorStatus(cancel context.CancelFunc) (errDuringMi
 			}
 
 			if !ignoreErr {
+				mj.opts.report.addURLs(sURLs)
 				mirrorFailedOps.Inc()
 				errDuringMirror = true
 				// Quit mirroring if --watch and --active-active are not passed
@@ -603,6 +663,36 @@ func (mj *mirrorJob) monitorMirrorStatus(cancel context.CancelFunc) (errDuringMi
 	return
 }
 
+// matchWatchedObject - returns true if a created object is selected by
+// the size, metadata and tags filters. The metadata of the events is not
+// the one of the listings, the metadata and tags are fetched from the
+// source object when needed, like the listings do.
+func (mj *mirrorJob) matchWatchedObject(ctx context.Context, sURLs URLs) bool {
+	content := sURLs.SourceContent
+	filter := mj.opts.filter
+	if !filter.withMetadata() {
+		return filter.matchAttrs(content.Size, nil, nil)
+	}
+	clnt, err := newClientFromAlias(sURLs.SourceAlias, content.URL.String())
+	if err != nil {
+		return false
+	}
+	var metadata, tags map[string]string
+	if len(filter.matchMeta) > 0 {
+		stat, err := clnt.Stat(ctx, StatOptions{})
+		if err != nil {
+			return false
+		}
+		metadata = stat.UserMetadata
+	}
+	if len(filter.matchTags) > 0 {
+		if tags, err = clnt.GetTags(ctx, ""); err != nil {
+			return false
+		}
+	}
+	return filter.matchAttrs(content.Size, metadata, tags)
+}
+
 func (mj *mirrorJob) watchMirrorEvents(ctx context.Context, events []EventInfo) {
 	for _, event := range events {
 		// It will change the expanded alias back to the alias
@@ -632,8 +722,8 @@ func (mj *mirrorJob) watchMirrorEvents(ctx context.Context, events []EventInfo)
 		// build target path, it is the relative of the eventPath with the sourceUrl
 		// joined to the targetURL.
 		sourceSuffix := strings.TrimPrefix(eventPath, sourceURLFull)
-		// Skip the object, if it matches the Exclude options provided
-		if matchExcludeOptions(mj.opts.excludeOptions, sourceSuffix, sourceURL.Type) {
+		// Skip the object, if its name is not selected by the filters provided
+		if !mj.opts.filter.matchName(sourceSuffix, sourceURL.Type) {
 			continue
 		}
 		// Skip the bucket, if it matches the Exclude options provided
@@ -677,9 +767,13 @@ func (mj *mirrorJob) watchMirrorEvents(ctx context.Context, events []EventInfo)
 				TargetAlias:      targetAlias,
 				TargetContent:    &ClientContent{URL: *targetURL},
 				MD5:              mj.opts.md5,
+				Checksum:         mj.opts.checksum,
 				DisableMultipart: mj.opts.disableMultipart,
 				encKeyDB:         mj.opts.encKeyDB,
 			}
+			if !mj.matchWatchedObject(ctx, mirrorURL) {
+				continue
+			}
 			if mj.opts.activeActive &&
 				event.Type != notification.ObjectCreatedCopy &&
 				event.Type != notification.ObjectCreatedCompleteMultipartUpload &&
@@ -704,6 +798,7 @@ func (mj *mirrorJob) watchMirrorEvents(ctx context.Context, events []EventInfo)
 				TargetAlias:      targetAlias,
 				TargetContent:    &ClientContent{URL: *targetURL},
 				MD5:              mj.opts.md5,
+				Checksum:         mj.opts.checksum,
 				DisableMultipart: mj.opts.disableMultipart,
 				encKeyDB:         mj.opts.encKeyDB,
 			}
@@ -869,7 +964,7 @@ func newMirrorJob(srcURL, dstURL string, opts mirrorOptions) *mirrorJob {
 		watcher:   NewWatcher(UTCNow()),
 	}
 
-	mj.parallel = newParallelManager(mj.statusCh)
+	mj.parallel = newParallelManager(mj.statusCh, mj.opts.parallelOpts)
 
 	// we'll define the status to use here,
 	// do we want the quiet status? or the progressbar
@@ -936,7 +1031,7 @@ func getEventPathURLWin(srcURL, eventPath string) string {
 }
 
 // runMirror - mirrors all buckets to another S3 server
-func runMirror(ctx context.Context, srcURL, dstURL string, cli *cli.Context, encKeyDB map[string][]prefixSSEPair) bool {
+func runMirror(ctx context.Context, srcURL, dstURL string, cli *cli.Context, encKeyDB map[string][]prefixSSEPair, report *failedReport) bool {
 	// Parse metadata.
 	userMetadata := make(map[string]string)
 	if cli.String("attr") != "" {
@@ -957,6 +1052,43 @@ func runMirror(ctx context.Context, srcURL, dstURL string, cli *cli.Context, enc
 		isOverwrite = cli.Bool("overwrite")
 	}
 
+	// --retry is kept for backward compatibility, it retries 3 times.
+	retryPolicy := retryPolicyFromContext(cli)
+	if cli.Bool("retry") && !cli.IsSet("max-retries") {
+		retryPolicy.maxRetries = 3
+	}
+
+	parallelOpts, err := parallelOptionsFromContext(cli)
+	fatalIf(err, "Unable to parse concurrency flags.")
+
+	filter, err := newObjectFilter(cli)
+	fatalIf(err, "Unable to parse the object filters.")
+
+	var maxDelete *deleteThreshold
+	if cli.IsSet("max-delete") {
+		maxDelete, err = parseDeleteThreshold(cli.String("max-delete"))
+		fatalIf(err, "--max-delete should be a number of objects or a percentage such as 10%.")
+	}
+
+	var backupPrefix string
+	backupDir := cli.String("backup-dir")
+	if backupDir != "" {
+		backupPrefix = mirrorBackupPrefix(backupDir, UTCNow())
+	}
+
+	var state *mirrorState
+	if path := cli.String("state-db"); path != "" {
+		sample := cli.Float64("state-sample")
+		if sample < 0 || sample > 1 {
+			fatalIf(errInvalidArgument().Trace(cli.String("state-sample")), "--state-sample should be between 0 and 1.")
+		}
+		state, err = openMirrorState(path, srcURL, dstURL, sample)
+		fatalIf(err, "Unable to open the mirror state `"+path+"`.")
+		defer func() {
+			errorIf(state.Close(), "Unable to close the mirror state `"+path+"`.")
+		}()
+	}
+
 	isWatch := cli.Bool("watch") || cli.Bool("multi-master") || cli.Bool("active-active")
 	isRemove := cli.Bool("remove")
 
@@ -971,11 +1103,16 @@ func runMirror(ctx context.Context, srcURL, dstURL string, cli *cli.Context, enc
 		isWatch:               isWatch,
 		isMetadata:            isMetadata,
 		isSummary:             cli.Bool("summary"),
-		isRetriable:           cli.Bool("retry"),
+		retry:                 retryPolicy,
 		md5:                   cli.Bool("md5"),
+		checksum:              mustParseChecksumFlag(cli),
+		compare:               mustParseCompareFlag(cli),
 		disableMultipart:      cli.Bool("disable-multipart"),
 		skipErrors:            cli.Bool("skip-errors"),
-		excludeOptions:        cli.StringSlice("exclude"),
+		filter:                filter,
+		maxDelete:             maxDelete,
+		backupDir:             backupDir,
+		backupPrefix:          backupPrefix,
 		excludeBuckets:        cli.StringSlice("exclude-bucket"),
 		excludeStorageClasses: cli.StringSlice("exclude-storageclass"),
 		olderThan:             cli.String("older-than"),
@@ -984,6 +1121,9 @@ func runMirror(ctx context.Context, srcURL, dstURL string, cli *cli.Context, enc
 		userMetadata:          userMetadata,
 		encKeyDB:              encKeyDB,
 		activeActive:          isWatch,
+		parallelOpts:          parallelOpts,
+		report:                report,
+		state:                 state,
 	}
 
 	// Create a new mirror job and execute it
@@ -1106,13 +1246,14 @@ func mainMirror(cliCtx *cli.Context) error {
 	// check 'mirror' cli arguments.
 	srcURL, tgtURL := checkMirrorSyntax(ctx, cliCtx, encKeyDB)
 
+	report, err := newFailedReport(cliCtx)
+	fatalIf(err, "Unable to create the failed objects report.")
+	defer func() {
+		errorIf(report.Close(), "Unable to close the failed objects report.")
+	}()
+
 	if prometheusAddress := cliCtx.String("monitoring-address"); prometheusAddress != "" {
-		http.Handle("/metrics", promhttp.Handler())
-		go func() {
-			if e := http.ListenAndServe(prometheusAddress, nil); e != nil {
-				fatalIf(probe.NewError(e), "Unable to setup monitoring endpoint.")
-			}
-		}()
+		startMetricsServer(prometheusAddress)
 	}
 
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -1121,7 +1262,7 @@ func mainMirror(cliCtx *cli.Context) error {
 		case <-ctx.Done():
 			return exitStatus(globalErrorExitStatus)
 		default:
-			errorDetected := runMirror(ctx, srcURL, tgtURL, cliCtx, encKeyDB)
+			errorDetected := runMirror(ctx, srcURL, tgtURL, cliCtx, encKeyDB, report)
 			if cliCtx.Bool("watch") || cliCtx.Bool("multi-master") || cliCtx.Bool("active-active") {
 				mirrorRestarts.Inc()
 				time.Sleep(time.Duration(r.Float64() * float64(2*time.Second)))
@@ -0,0 +1,635 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+// The mirror state remembers the objects known to be on the target, so
+// that a mirror only lists the source and compares it to the state.
+//
+// The state is made of two files:
+//   - PATH holds a header line followed by one line per object, sorted
+//     in the listing order. It is rewritten by every complete listing.
+//   - PATH.journal holds the objects copied or removed since, one line
+//     per object, appended as soon as the copy or removal is done. Watch
+//     mode appends every event, a restart resumes from the journal.
+const (
+	mirrorStateVersion    = "1"
+	mirrorStateJournalExt = ".journal"
+	mirrorStateTmpExt     = ".tmp"
+
+	// Fraction of the unchanged objects verified on the target by default.
+	mirrorStateDefaultSample = 0.01
+)
+
+// mirrorStateHeader - first line of the state file.
+type mirrorStateHeader struct {
+	Version string    `json:"version"`
+	Source  string    `json:"source"`
+	Target  string    `json:"target"`
+	Time    time.Time `json:"time"`
+}
+
+// mirrorStateEntry - an object known to be on the target.
+type mirrorStateEntry struct {
+	Key     string    `json:"key"`
+	Size    int64     `json:"size"`
+	ETag    string    `json:"etag,omitempty"`
+	ModTime time.Time `json:"mtime"`
+	Deleted bool      `json:"deleted,omitempty"`
+}
+
+type mirrorState struct {
+	path   string
+	header mirrorStateHeader
+	sample float64
+
+	// Path of the target root, the keys are relative to it.
+	targetRoot string
+
+	// Journal entries of earlier runs, applied over the state file.
+	overlay map[string]mirrorStateEntry
+
+	mu            sync.Mutex
+	journal       *os.File
+	journalOffset int64
+
+	// The state file being rewritten by the current listing.
+	tmp       *os.File
+	tmpWriter *bufio.Writer
+	tmpErr    error
+}
+
+// openMirrorState - opens or creates the state of a mirror, the sample is
+// the fraction of unchanged objects verified on the target.
+func openMirrorState(path, sourceURL, targetURL string, sample float64) (*mirrorState, *probe.Error) {
+	s := &mirrorState{
+		path: path,
+		header: mirrorStateHeader{
+			Version: mirrorStateVersion,
+			Source:  sourceURL,
+			Target:  targetURL,
+		},
+		sample:  sample,
+		overlay: make(map[string]mirrorStateEntry),
+	}
+
+	targetRoot := targetURL
+	if separator := string(newClientURL(targetURL).Separator); !strings.HasSuffix(targetRoot, separator) {
+		targetRoot += separator
+	}
+	_, expandedTargetRoot, _ := mustExpandAlias(targetRoot)
+	s.targetRoot = newClientURL(expandedTargetRoot).Path
+
+	file, e := os.Open(path)
+	if e == nil {
+		var header mirrorStateHeader
+		e = json.NewDecoder(bufio.NewReader(file)).Decode(&header)
+		file.Close()
+		if e != nil {
+			return nil, probe.NewError(e).Trace(path)
+		}
+		if header.Version != mirrorStateVersion || header.Source != sourceURL || header.Target != targetURL {
+			return nil, errMirrorStateMismatch(path).Trace(sourceURL, targetURL)
+		}
+	} else if !os.IsNotExist(e) {
+		return nil, probe.NewError(e).Trace(path)
+	}
+
+	journal, e := os.OpenFile(path+mirrorStateJournalExt, os.O_CREATE|os.O_RDWR, 0o600)
+	if e != nil {
+		return nil, probe.NewError(e).Trace(path)
+	}
+	err := readMirrorStateEntries(journal, func(entry mirrorStateEntry) {
+		s.overlay[entry.Key] = entry
+	})
+	if err != nil {
+		journal.Close()
+		return nil, err.Trace(path + mirrorStateJournalExt)
+	}
+	if s.journalOffset, e = journal.Seek(0, io.SeekEnd); e != nil {
+		journal.Close()
+		return nil, probe.NewError(e).Trace(path)
+	}
+	s.journal = journal
+	return s, nil
+}
+
+// readMirrorStateEntries - calls fn for every entry of a state or journal file.
+func readMirrorStateEntries(r io.Reader, fn func(mirrorStateEntry)) *probe.Error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry mirrorStateEntry
+		if e := json.Unmarshal(line, &entry); e != nil {
+			return probe.NewError(e)
+		}
+		fn(entry)
+	}
+	if e := scanner.Err(); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// list - returns the known objects in the listing order, the journal of
+// earlier runs is merged into the state file.
+func (s *mirrorState) list(ctx context.Context) (<-chan mirrorStateEntry, <-chan *probe.Error) {
+	entryCh := make(chan mirrorStateEntry)
+	errCh := make(chan *probe.Error, 1)
+
+	overlay := make([]mirrorStateEntry, 0, len(s.overlay))
+	for _, entry := range s.overlay {
+		overlay = append(overlay, entry)
+	}
+	sort.Slice(overlay, func(i, j int) bool { return overlay[i].Key < overlay[j].Key })
+
+	send := func(entry mirrorStateEntry) bool {
+		if entry.Deleted {
+			return true
+		}
+		select {
+		case entryCh <- entry:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(entryCh)
+		defer close(errCh)
+
+		file, e := os.Open(s.path)
+		if e != nil && !os.IsNotExist(e) {
+			errCh <- probe.NewError(e).Trace(s.path)
+			return
+		}
+
+		stateCh := make(chan mirrorStateEntry)
+		var readErr *probe.Error
+		go func() {
+			defer close(stateCh)
+			if file == nil {
+				return
+			}
+			defer file.Close()
+
+			first := true
+			readErr = readMirrorStateEntries(file, func(entry mirrorStateEntry) {
+				if first {
+					// Skip the header.
+					first = false
+					return
+				}
+				select {
+				case stateCh <- entry:
+				case <-ctx.Done():
+				}
+			})
+		}()
+
+		entry, ok := <-stateCh
+		for ok || len(overlay) > 0 {
+			switch {
+			case !ok || len(overlay) > 0 && overlay[0].Key < entry.Key:
+				if !send(overlay[0]) {
+					return
+				}
+				overlay = overlay[1:]
+			case len(overlay) > 0 && overlay[0].Key == entry.Key:
+				if !send(overlay[0]) {
+					return
+				}
+				overlay = overlay[1:]
+				entry, ok = <-stateCh
+			default:
+				if !send(entry) {
+					return
+				}
+				entry, ok = <-stateCh
+			}
+		}
+		if readErr != nil {
+			errCh <- readErr.Trace(s.path)
+		}
+	}()
+	return entryCh, errCh
+}
+
+// empty - returns true if the state knows no objects, the state file is
+// missing or has no entries and nothing was journaled.
+func (s *mirrorState) empty() bool {
+	if len(s.overlay) > 0 {
+		return false
+	}
+	file, e := os.Open(s.path)
+	if e != nil {
+		return os.IsNotExist(e)
+	}
+	defer file.Close()
+
+	// The first line is the header.
+	var lines int
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) > 0 {
+			if lines++; lines > 1 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// verify - returns true if an unchanged object should be verified on the target.
+func (s *mirrorState) verify() bool {
+	return s.sample > 0 && rand.Float64() < s.sample
+}
+
+// begin - starts rewriting the state file.
+func (s *mirrorState) begin() *probe.Error {
+	tmp, e := os.Create(s.path + mirrorStateTmpExt)
+	if e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	s.tmp = tmp
+	s.tmpWriter = bufio.NewWriter(tmp)
+
+	header := s.header
+	header.Time = UTCNow()
+	s.write(header)
+	return nil
+}
+
+// keep - adds an object to the state file being rewritten.
+func (s *mirrorState) keep(entry mirrorStateEntry) {
+	s.write(entry)
+}
+
+func (s *mirrorState) write(v interface{}) {
+	if s.tmpErr != nil {
+		return
+	}
+	data, e := json.Marshal(v)
+	if e == nil {
+		_, e = s.tmpWriter.Write(append(data, '\n'))
+	}
+	s.tmpErr = e
+}
+
+// commit - replaces the state file by the rewritten one. The journal
+// entries appended by earlier runs are now part of the state file, the
+// ones appended since this run started are kept.
+func (s *mirrorState) commit() *probe.Error {
+	if s.tmp == nil {
+		return nil
+	}
+	tmp := s.tmp
+	s.tmp = nil
+
+	e := s.tmpErr
+	if e == nil {
+		e = s.tmpWriter.Flush()
+	}
+	if e == nil {
+		e = tmp.Sync()
+	}
+	if ce := tmp.Close(); e == nil {
+		e = ce
+	}
+	if e == nil {
+		e = os.Rename(tmp.Name(), s.path)
+	}
+	if e != nil {
+		os.Remove(tmp.Name())
+		return probe.NewError(e).Trace(s.path)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, e = s.journal.Seek(s.journalOffset, io.SeekStart); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	journalTmp := s.path + mirrorStateJournalExt + mirrorStateTmpExt
+	file, e := os.Create(journalTmp)
+	if e != nil {
+		return probe.NewError(e).Trace(journalTmp)
+	}
+	if _, e = io.Copy(file, s.journal); e == nil {
+		e = file.Sync()
+	}
+	if ce := file.Close(); e == nil {
+		e = ce
+	}
+	if e == nil {
+		e = os.Rename(journalTmp, s.path+mirrorStateJournalExt)
+	}
+	if e != nil {
+		os.Remove(journalTmp)
+		return probe.NewError(e).Trace(s.path)
+	}
+
+	s.journal.Close()
+	if s.journal, e = os.OpenFile(s.path+mirrorStateJournalExt, os.O_RDWR, 0o600); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	s.journalOffset, e = s.journal.Seek(0, io.SeekEnd)
+	s.overlay = make(map[string]mirrorStateEntry)
+	return probe.NewError(e)
+}
+
+// abort - drops the state file being rewritten.
+func (s *mirrorState) abort() {
+	if s.tmp == nil {
+		return
+	}
+	s.tmp.Close()
+	os.Remove(s.tmp.Name())
+	s.tmp = nil
+}
+
+// put - journals an object copied from the source to the target.
+func (s *mirrorState) put(target, source *ClientContent) {
+	if s == nil {
+		return
+	}
+	s.append(mirrorStateEntry{
+		Key:     strings.TrimPrefix(target.URL.Path, s.targetRoot),
+		Size:    source.Size,
+		ETag:    source.ETag,
+		ModTime: source.Time,
+	})
+}
+
+// remove - journals an object removed from the target.
+func (s *mirrorState) remove(target *ClientContent) {
+	if s == nil {
+		return
+	}
+	s.append(mirrorStateEntry{Key: strings.TrimPrefix(target.URL.Path, s.targetRoot), Deleted: true})
+}
+
+func (s *mirrorState) append(entry mirrorStateEntry) {
+	if entry.Key == "" {
+		return
+	}
+	data, e := json.Marshal(entry)
+	if e != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, e = s.journal.Write(append(data, '\n'))
+	errorIf(probe.NewError(e).Trace(s.path), "Unable to save the mirror state.")
+}
+
+// Close - closes the state.
+func (s *mirrorState) Close() *probe.Error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e := s.journal.Close(); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	return nil
+}
+
+// stateDifference - compares the source objects to the objects known by
+// the state. Changed objects are detected with their size, ETag or,
+// without ETag, modification time. A sample of the unchanged objects is
+// verified on the target. The state file is rewritten along the way.
+func stateDifference(ctx context.Context, sourceClnt Client, sourceURL, targetAlias, targetURL string, state *mirrorState, withMetadata bool, encKeyDB map[string][]prefixSSEPair) (diffCh chan diffMessage) {
+	diffCh = make(chan diffMessage, 10000)
+
+	send := func(msg diffMessage) bool {
+		select {
+		case diffCh <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(diffCh)
+
+		// A listing which did not complete is dropped.
+		defer state.abort()
+		if err := state.begin(); err != nil {
+			send(diffMessage{Error: err})
+			return
+		}
+
+		srcCh := sourceClnt.List(ctx, ListOptions{Recursive: true, WithMetadata: withMetadata, ShowDir: DirNone})
+		stateCh, stateErrCh := state.list(ctx)
+
+		src, srcOk := <-srcCh
+		entry, entryOk := <-stateCh
+		for srcOk || entryOk {
+			if srcOk && src.Err != nil {
+				send(diffMessage{Error: src.Err.Trace(sourceURL, targetURL)})
+				return
+			}
+
+			var key string
+			if srcOk {
+				key = strings.TrimPrefix(src.URL.String(), sourceURL)
+			}
+			targetContent := func(key string) *ClientContent {
+				return &ClientContent{URL: *newClientURL(urlJoinPath(targetURL, key))}
+			}
+
+			switch {
+			case !entryOk || srcOk && key < entry.Key:
+				// Not on the target yet.
+				if !send(diffMessage{FirstURL: src.URL.String(), Diff: differInFirst, firstContent: src}) {
+					return
+				}
+				src, srcOk = <-srcCh
+			case !srcOk || entry.Key < key:
+				// Not on the source anymore, kept until removed.
+				state.keep(entry)
+				tgt := targetContent(entry.Key)
+				tgt.Size, tgt.ETag, tgt.Time = entry.Size, entry.ETag, entry.ModTime
+				if !send(diffMessage{SecondURL: tgt.URL.String(), Diff: differInSecond, secondContent: tgt}) {
+					return
+				}
+				entry, entryOk = <-stateCh
+			default:
+				tgt := targetContent(key)
+				tgt.Size, tgt.ETag, tgt.Time = entry.Size, entry.ETag, entry.ModTime
+				msg := diffMessage{
+					FirstURL:      src.URL.String(),
+					SecondURL:     tgt.URL.String(),
+					Diff:          differInNone,
+					firstContent:  src,
+					secondContent: tgt,
+				}
+				switch {
+				case src.Size != entry.Size:
+					msg.Diff = differInSize
+				case src.ETag != "" && entry.ETag != "" && strings.Trim(src.ETag, "\"") != strings.Trim(entry.ETag, "\""):
+					msg.Diff = differInContent
+				case src.ETag == "" && !src.Time.Equal(entry.ModTime):
+					msg.Diff = differInContent
+				case state.verify():
+					msg.Diff = verifyStateTarget(ctx, targetAlias, tgt, src, encKeyDB)
+				}
+
+				switch msg.Diff {
+				case differInNone:
+					state.keep(mirrorStateEntry{Key: key, Size: src.Size, ETag: src.ETag, ModTime: src.Time})
+				case differInFirst:
+					// Not on the target anymore.
+				default:
+					state.keep(entry)
+				}
+				if !send(msg) {
+					return
+				}
+				src, srcOk = <-srcCh
+				entry, entryOk = <-stateCh
+			}
+		}
+
+		if err := <-stateErrCh; err != nil {
+			send(diffMessage{Error: err})
+			return
+		}
+		if ctx.Err() == nil {
+			if err := state.commit(); err != nil {
+				send(diffMessage{Error: err})
+			}
+		}
+	}()
+
+	return diffCh
+}
+
+// seedMirrorState - seeds a state which knows no objects from the
+// difference of the source and target listings, the messages are passed
+// through. The state is only saved if the listings complete.
+func seedMirrorState(ctx context.Context, diffCh chan diffMessage, state *mirrorState, sourceAlias, targetAlias string, encKeyDB map[string][]prefixSSEPair) chan diffMessage {
+	seedCh := make(chan diffMessage, 10000)
+
+	go func() {
+		defer close(seedCh)
+
+		// A listing which did not complete is dropped.
+		defer state.abort()
+		err := state.begin()
+		if err != nil {
+			seedCh <- diffMessage{Error: err}
+		}
+		seeding := err == nil
+
+		for msg := range diffCh {
+			switch {
+			case !seeding:
+			case msg.Error != nil:
+				seeding = false
+			case msg.secondContent == nil || msg.Diff == differInType:
+				// Not on the target yet.
+			case msg.Diff == differInNone, msg.Diff == differInSize &&
+				clientSidePlainSize(sourceAlias, msg.firstContent, encKeyDB) == clientSidePlainSize(targetAlias, msg.secondContent, encKeyDB):
+				src := msg.firstContent
+				state.keep(mirrorStateEntry{
+					Key:     strings.TrimPrefix(msg.secondContent.URL.Path, state.targetRoot),
+					Size:    src.Size,
+					ETag:    src.ETag,
+					ModTime: src.Time,
+				})
+			default:
+				// Changed objects are journaled once copied.
+				tgt := msg.secondContent
+				state.keep(mirrorStateEntry{
+					Key:     strings.TrimPrefix(tgt.URL.Path, state.targetRoot),
+					Size:    tgt.Size,
+					ETag:    tgt.ETag,
+					ModTime: tgt.Time,
+				})
+			}
+			seedCh <- msg
+		}
+
+		if seeding && ctx.Err() == nil {
+			if err := state.commit(); err != nil {
+				seedCh <- diffMessage{Error: err}
+			}
+		}
+	}()
+
+	return seedCh
+}
+
+// verifyStateTarget - verifies an object the state considers unchanged
+// is still on the target with the same size and, when they can be
+// compared, the same ETag.
+func verifyStateTarget(ctx context.Context, targetAlias string, tgt, src *ClientContent, encKeyDB map[string][]prefixSSEPair) differType {
+	targetPath := urlJoinPath(targetAlias, tgt.URL.Path)
+	clnt, err := newClientFromAlias(targetAlias, tgt.URL.String())
+	if err != nil {
+		return differInNone
+	}
+	content, err := clnt.Stat(ctx, StatOptions{sse: getSSE(targetPath, encKeyDB[targetAlias])})
+	if err != nil {
+		switch err.ToGoError().(type) {
+		case ObjectMissing, PathNotFound:
+			return differInFirst
+		}
+		return differInNone
+	}
+	// Client side encrypted objects are listed with their encrypted size.
+	if content.Size != src.Size && clientSidePlainSize(targetAlias, content, encKeyDB) != src.Size {
+		return differInSize
+	}
+	// Multipart and encrypted objects have ETags of their own.
+	if clientSidePlainSize(targetAlias, content, encKeyDB) != content.Size || getSSE(targetPath, encKeyDB[targetAlias]) != nil {
+		return differInNone
+	}
+	srcETag, tgtETag := strings.Trim(src.ETag, "\""), strings.Trim(content.ETag, "\"")
+	if srcETag != "" && tgtETag != "" && !strings.Contains(srcETag, "-") && !strings.Contains(tgtETag, "-") && srcETag != tgtETag {
+		return differInContent
+	}
+	return differInNone
+}
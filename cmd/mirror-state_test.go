@@ -0,0 +1,163 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMirrorState(t *testing.T) {
+	root, srcDir := newTestFolder(t, map[string]string{"a": "a", "b": "b", "c": "c"})
+	srcDir += string(filepath.Separator)
+	tgtDir := filepath.Join(root, "tgt") + string(filepath.Separator)
+	statePath := filepath.Join(root, "state.json")
+	if e := os.MkdirAll(tgtDir, 0o755); e != nil {
+		t.Fatal(e)
+	}
+
+	// sync runs a state difference, the copies and removals are journaled
+	// unless the key is failing, the differences are returned by key.
+	sync := func(sample float64, failing string) map[string]differType {
+		t.Helper()
+		state, err := openMirrorState(statePath, srcDir, tgtDir, sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer state.Close()
+
+		sourceClnt, err := newClient(srcDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		diffs := make(map[string]differType)
+		for msg := range stateDifference(context.Background(), sourceClnt, srcDir, "", tgtDir, state, false, nil) {
+			if msg.Error != nil {
+				t.Fatal(msg.Error)
+			}
+			var key string
+			if msg.firstContent != nil {
+				key = strings.TrimPrefix(msg.FirstURL, srcDir)
+			} else {
+				key = strings.TrimPrefix(msg.SecondURL, tgtDir)
+			}
+			diffs[key] = msg.Diff
+			if key == failing {
+				continue
+			}
+			switch msg.Diff {
+			case differInFirst, differInSize, differInContent:
+				state.put(&ClientContent{URL: *newClientURL(tgtDir + key)}, msg.firstContent)
+			case differInSecond:
+				state.remove(msg.secondContent)
+			}
+		}
+		return diffs
+	}
+
+	expected := map[string]differType{"a": differInFirst, "b": differInFirst, "c": differInFirst}
+	if diffs := sync(0, "c"); !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("First run: expected %v, got %v", expected, diffs)
+	}
+
+	// The failed copy of c is retried, the others are unchanged.
+	expected = map[string]differType{"a": differInNone, "b": differInNone, "c": differInFirst}
+	if diffs := sync(0, ""); !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("Second run: expected %v, got %v", expected, diffs)
+	}
+
+	writeTestFile(t, srcDir+"b", "bb")
+	if e := os.Remove(filepath.Join(srcDir, "a")); e != nil {
+		t.Fatal(e)
+	}
+	expected = map[string]differType{"a": differInSecond, "b": differInSize, "c": differInNone}
+	if diffs := sync(0, ""); !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("Third run: expected %v, got %v", expected, diffs)
+	}
+
+	// Nothing was copied to the target, verifying every object finds out.
+	expected = map[string]differType{"b": differInFirst, "c": differInFirst}
+	if diffs := sync(1, ""); !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("Verified run: expected %v, got %v", expected, diffs)
+	}
+
+	if _, err := openMirrorState(statePath, srcDir, filepath.Join(root, "other"), 0); err == nil {
+		t.Fatal("Expected the state of another mirror to fail")
+	}
+}
+
+func TestSeedMirrorState(t *testing.T) {
+	root, srcDir := newTestFolder(t, map[string]string{"a": "a", "b": "bb", "c": "c"})
+	srcDir += string(filepath.Separator)
+	tgtDir := filepath.Join(root, "tgt") + string(filepath.Separator)
+	statePath := filepath.Join(root, "state.json")
+	writeTestFile(t, tgtDir+"a", "a")
+	writeTestFile(t, tgtDir+"b", "b")
+	writeTestFile(t, tgtDir+"d", "d")
+
+	state, err := openMirrorState(statePath, srcDir, tgtDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer state.Close()
+	if !state.empty() {
+		t.Fatal("Expected a new state to be empty")
+	}
+
+	sourceClnt, err := newClient(srcDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	targetClnt, err := newClient(tgtDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sourceCh := sourceClnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone})
+	targetCh := targetClnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone})
+	diffCh := difference(ctx, srcDir, sourceCh, tgtDir, targetCh, false, true, nil)
+	for msg := range seedMirrorState(ctx, diffCh, state, "", "", nil) {
+		if msg.Error != nil {
+			t.Fatal(msg.Error)
+		}
+	}
+	if state.empty() {
+		t.Fatal("Expected the state to be seeded")
+	}
+
+	// The seeded state knows the target without listing it.
+	diffs := make(map[string]differType)
+	for msg := range stateDifference(ctx, sourceClnt, srcDir, "", tgtDir, state, false, nil) {
+		if msg.Error != nil {
+			t.Fatal(msg.Error)
+		}
+		if msg.firstContent != nil {
+			diffs[strings.TrimPrefix(msg.FirstURL, srcDir)] = msg.Diff
+		} else {
+			diffs[strings.TrimPrefix(msg.SecondURL, tgtDir)] = msg.Diff
+		}
+	}
+	expected := map[string]differType{"a": differInNone, "b": differInSize, "c": differInFirst, "d": differInSecond}
+	if !reflect.DeepEqual(diffs, expected) {
+		t.Fatalf("Expected %v, got %v", expected, diffs)
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/minio/cli"
+	"github.com/minio/minio-go/v7"
 	"github.com/minio/pkg/v2/wildcard"
 )
 
@@ -90,20 +91,8 @@ func checkMirrorSyntax(ctx context.Context, cliCtx *cli.Context, encKeyDB map[st
 }
 
 func matchExcludeOptions(excludeOptions []string, srcSuffix string, typ ClientURLType) bool {
-	// if type is file system, remove leading slash
-	if typ == fileSystem {
-		if strings.HasPrefix(srcSuffix, "/") {
-			srcSuffix = srcSuffix[1:]
-		} else if runtime.GOOS == "windows" && strings.HasPrefix(srcSuffix, `\`) {
-			srcSuffix = srcSuffix[1:]
-		}
-	}
-	for _, pattern := range excludeOptions {
-		if wildcard.Match(pattern, srcSuffix) {
-			return true
-		}
-	}
-	return false
+	f := objectFilter{rules: orderedFilterRules(nil, nil, excludeOptions)}
+	return !f.matchName(srcSuffix, typ)
 }
 
 func matchExcludeBucketOptions(excludeBuckets []string, srcSuffix string) bool {
@@ -165,16 +154,42 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 	}
 
 	// List both source and target, compare and return values through channel.
-	for diffMsg := range objectDifference(ctx, sourceClnt, targetClnt, opts.isMetadata) {
+	var diffCh chan diffMessage
+	if opts.state != nil && !opts.state.empty() {
+		// Only list the source, the target is known by the state.
+		diffCh = stateDifference(ctx, sourceClnt, sourceURL, targetAlias, targetURL, opts.state, opts.filter.withMetadata(), opts.encKeyDB)
+	} else {
+		// The source is also listed with the metadata and tags to filter,
+		// similar objects are needed to count the target objects.
+		cmp := newContentComparer(opts.compare, sourceAlias, targetAlias, opts.encKeyDB)
+		sourceCh := sourceClnt.List(ctx, ListOptions{Recursive: true, WithMetadata: opts.isMetadata || opts.filter.withMetadata(), ShowDir: DirNone})
+		targetCh := targetClnt.List(ctx, ListOptions{Recursive: true, WithMetadata: opts.isMetadata, ShowDir: DirNone})
+		diffCh = difference(ctx, sourceClnt.GetURL().String(), sourceCh, targetClnt.GetURL().String(), targetCh, opts.isMetadata, opts.maxDelete != nil || opts.state != nil, cmp)
+		if opts.state != nil {
+			// A missing or empty state is seeded from both listings.
+			diffCh = seedMirrorState(ctx, diffCh, opts.state, sourceAlias, targetAlias, opts.encKeyDB)
+		}
+	}
+
+	var backupURL string
+	if opts.backupDir != "" {
+		backupURL = expandFolderURL(opts.backupDir)
+	}
+
+	var removals []URLs
+	var targetObjects int64
+	var listErr bool
+	for diffMsg := range diffCh {
 		if diffMsg.Error != nil {
 			// Send all errors through the channel
 			URLsCh <- URLs{Error: diffMsg.Error, ErrorCond: differInUnknown}
+			listErr = true
 			continue
 		}
 
 		srcSuffix := strings.TrimPrefix(diffMsg.FirstURL, sourceURL)
-		// Skip the source object if it matches the Exclude options provided
-		if matchExcludeOptions(opts.excludeOptions, srcSuffix, newClientURL(sourceURL).Type) {
+		// Skip the source object if it is not selected by the filters provided
+		if diffMsg.firstContent != nil && !opts.filter.match(srcSuffix, diffMsg.firstContent) {
 			continue
 		}
 
@@ -184,8 +199,8 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 		}
 
 		tgtSuffix := strings.TrimPrefix(diffMsg.SecondURL, targetURL)
-		// Skip the target object if it matches the Exclude options provided
-		if matchExcludeOptions(opts.excludeOptions, tgtSuffix, newClientURL(targetURL).Type) {
+		// Skip the target object if its name is not selected by the filters provided
+		if diffMsg.secondContent != nil && !opts.filter.matchName(tgtSuffix, newClientURL(targetURL).Type) {
 			continue
 		}
 
@@ -194,6 +209,15 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 			continue
 		}
 
+		// Skip the objects moved to a backup folder inside the target
+		if backupURL != "" && diffMsg.secondContent != nil && strings.HasPrefix(diffMsg.secondContent.URL.String(), backupURL) {
+			continue
+		}
+
+		if diffMsg.Diff != differInFirst {
+			targetObjects++
+		}
+
 		if diffMsg.firstContent != nil {
 			var found bool
 			for _, esc := range opts.excludeStorageClasses {
@@ -207,12 +231,18 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 			}
 		}
 
+		if diffMsg.Diff == differInSize &&
+			clientSidePlainSize(sourceAlias, diffMsg.firstContent, opts.encKeyDB) == clientSidePlainSize(targetAlias, diffMsg.secondContent, opts.encKeyDB) {
+			// Client side encrypted objects are listed with their encrypted size.
+			continue
+		}
+
 		switch diffMsg.Diff {
 		case differInNone:
 			// No difference, continue.
 		case differInType:
 			URLsCh <- URLs{Error: errInvalidTarget(diffMsg.SecondURL)}
-		case differInSize, differInMetadata, differInAASourceMTime:
+		case differInSize, differInMetadata, differInAASourceMTime, differInContent:
 			if !opts.isOverwrite && !opts.isFake && !opts.activeActive {
 				// Size or time or etag differs but --overwrite not set.
 				URLsCh <- URLs{
@@ -244,15 +274,22 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 				SourceContent: sourceContent,
 				TargetAlias:   targetAlias,
 				TargetContent: targetContent,
+				targetMissing: true,
 			}
 		case differInSecond:
 			if !opts.isRemove && !opts.isFake {
 				continue
 			}
-			URLsCh <- URLs{
+			removeURLs := URLs{
 				TargetAlias:   targetAlias,
 				TargetContent: diffMsg.secondContent,
 			}
+			if opts.maxDelete != nil {
+				// Removals wait for the whole comparison to be checked.
+				removals = append(removals, removeURLs)
+				continue
+			}
+			URLsCh <- removeURLs
 		default:
 			URLsCh <- URLs{
 				Error:     errUnrecognizedDiffType(diffMsg.Diff).Trace(diffMsg.FirstURL, diffMsg.SecondURL),
@@ -260,20 +297,40 @@ func deltaSourceTarget(ctx context.Context, sourceURL, targetURL string, opts mi
 			}
 		}
 	}
+
+	// An incomplete comparison cannot be checked.
+	if len(removals) == 0 || listErr || ctx.Err() != nil {
+		return
+	}
+	if opts.maxDelete.exceeded(int64(len(removals)), targetObjects) {
+		URLsCh <- URLs{Error: errMirrorMaxDelete(len(removals), targetObjects, opts.maxDelete.value)}
+		return
+	}
+	for _, removeURLs := range removals {
+		URLsCh <- removeURLs
+	}
 }
 
 type mirrorOptions struct {
-	isFake, isOverwrite, activeActive                     bool
-	isWatch, isRemove, isMetadata                         bool
-	isRetriable                                           bool
-	isSummary                                             bool
-	skipErrors                                            bool
-	excludeOptions, excludeStorageClasses, excludeBuckets []string
-	encKeyDB                                              map[string][]prefixSSEPair
-	md5, disableMultipart                                 bool
-	olderThan, newerThan                                  string
-	storageClass                                          string
-	userMetadata                                          map[string]string
+	isFake, isOverwrite, activeActive     bool
+	isWatch, isRemove, isMetadata         bool
+	retry                                 retryPolicy
+	isSummary                             bool
+	skipErrors                            bool
+	excludeStorageClasses, excludeBuckets []string
+	filter                                objectFilter
+	maxDelete                             *deleteThreshold
+	backupDir, backupPrefix               string
+	encKeyDB                              map[string][]prefixSSEPair
+	md5, disableMultipart                 bool
+	checksum                              minio.ChecksumType
+	compare                               compareMode
+	olderThan, newerThan                  string
+	storageClass                          string
+	userMetadata                          map[string]string
+	parallelOpts                          parallelOptions
+	report                                *failedReport
+	state                                 *mirrorState
 }
 
 // Prepares urls that need to be copied or removed based on requested options.
@@ -69,7 +69,7 @@ var mvCmd = cli.Command{
 	Action:       mainMove,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(mvFlags, encFlags...), globalFlags...),
+	Flags:        append(append(append(append(mvFlags, encFlags...), retryFlags...), failedReportFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -186,9 +186,13 @@ func (rm *removeManager) add(ctx context.Context, targetAlias, targetURL string)
 }
 
 func (rm *removeManager) close() {
+	rm.removeMapMutex.Lock()
 	for _, clientInfo := range rm.removeMap {
 		close(clientInfo.contentCh)
 	}
+	// The manager can be used again, as by 'mc replay'.
+	rm.removeMap = make(map[string]*removeClientInfo)
+	rm.removeMapMutex.Unlock()
 
 	// Wait until all on-going client.Remove() operations to finish
 	rm.wg.Wait()
@@ -220,7 +224,7 @@ func mainMove(cliCtx *cli.Context) error {
 	encKeyDB, err := validateAndCreateEncryptionKeys(cliCtx)
 	fatalIf(err, "Unable to parse encryption keys.")
 
-	e := doCopySession(ctx, cancelMove, cliCtx, encKeyDB, true)
+	e := doCopySession(ctx, cancelMove, cliCtx, encKeyDB, true, nil, nil)
 
 	console.Colorize("Copy", "Waiting for move operations to complete")
 	rmManager.close()
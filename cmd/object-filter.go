@@ -0,0 +1,348 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/wildcard"
+)
+
+// objectFilterFlags - select objects by name, size, tags and metadata.
+var objectFilterFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "include",
+		Usage: "include object(s) that match specified object name pattern, evaluated in order with --exclude",
+	},
+	cli.StringFlag{
+		Name:  "larger",
+		Usage: "include objects larger than specified size in units (see UNITS)",
+	},
+	cli.StringFlag{
+		Name:  "smaller",
+		Usage: "include objects smaller than specified size in units (see UNITS)",
+	},
+	cli.StringSliceFlag{
+		Name:  "tags",
+		Usage: "include objects with tags matching RE2 regex pattern. Specify each with key=regex. MinIO server only.",
+	},
+	cli.StringSliceFlag{
+		Name:  "metadata",
+		Usage: "include objects with metadata matching RE2 regex pattern. Specify each with key=regex. MinIO server only.",
+	},
+	cli.StringFlag{
+		Name:  "filter-file",
+		Usage: "read include, exclude, larger, smaller, tags and metadata rules from a file, one per line",
+	},
+}
+
+// objectAttrFilter - matches objects by their size, metadata and tags.
+type objectAttrFilter struct {
+	largerSize  uint64
+	smallerSize uint64
+	matchMeta   map[string]*regexp.Regexp
+	matchTags   map[string]*regexp.Regexp
+}
+
+// parseObjectAttrFilter - parses the sizes in units and the key=regex
+// values of metadata and tags.
+func parseObjectAttrFilter(larger, smaller string, metadata, tags []string) (f objectAttrFilter, err *probe.Error) {
+	var e error
+	if larger != "" {
+		if f.largerSize, e = humanize.ParseBytes(larger); e != nil {
+			return f, probe.NewError(e).Trace(larger)
+		}
+	}
+	if smaller != "" {
+		if f.smallerSize, e = humanize.ParseBytes(smaller); e != nil {
+			return f, probe.NewError(e).Trace(smaller)
+		}
+	}
+	if f.matchMeta, err = parseRegexMap(metadata); err != nil {
+		return f, err
+	}
+	if f.matchTags, err = parseRegexMap(tags); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// withMetadata - returns true if objects must be listed with their
+// metadata and tags to be matched.
+func (f objectAttrFilter) withMetadata() bool {
+	return len(f.matchMeta) > 0 || len(f.matchTags) > 0
+}
+
+// matchAttrs - returns true if the object matches all the size, metadata
+// and tags conditions.
+func (f objectAttrFilter) matchAttrs(size int64, metadata, tags map[string]string) bool {
+	if f.largerSize > 0 && int64(f.largerSize) >= size {
+		return false
+	}
+	if f.smallerSize > 0 && int64(f.smallerSize) <= size {
+		return false
+	}
+	if len(f.matchMeta) > 0 && !matchRegexMaps(f.matchMeta, metadata) {
+		return false
+	}
+	if len(f.matchTags) > 0 && !matchRegexMaps(f.matchTags, tags) {
+		return false
+	}
+	return true
+}
+
+// parseRegexMap returns a map from key=regex values, a nil map is
+// returned when there are no values.
+func parseRegexMap(values []string) (map[string]*regexp.Regexp, *probe.Error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	reMap := make(map[string]*regexp.Regexp, len(values))
+	for _, v := range values {
+		split := strings.SplitN(v, "=", 2)
+		if len(split) < 2 {
+			return nil, probe.NewError(fmt.Errorf("want one = separator, got none")).Trace(v)
+		}
+		// No value means it should not exist or be empty.
+		if len(split[1]) == 0 {
+			reMap[split[0]] = nil
+			continue
+		}
+		re, e := regexp.Compile(split[1])
+		if e != nil {
+			return nil, probe.NewError(e).Trace(v)
+		}
+		reMap[split[0]] = re
+	}
+	return reMap, nil
+}
+
+// matchRegexMaps will check if all regexes in 'm' match values in 'v' with the same key.
+// If a regex is nil, it must either not exist in v or have a 0 length value.
+func matchRegexMaps(m map[string]*regexp.Regexp, v map[string]string) bool {
+	for k, reg := range m {
+		if reg == nil {
+			if v[k] != "" {
+				return false
+			}
+			// Does not exist or empty, that is fine.
+			continue
+		}
+		val, ok := v[k]
+		if !ok || !reg.MatchString(val) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterRule - an include or exclude object name pattern.
+type filterRule struct {
+	include bool
+	pattern string
+}
+
+// objectFilter - selects objects by include and exclude name patterns,
+// the first matching pattern decides. Objects matching no pattern are
+// selected unless there are include patterns. Selected objects must
+// also match the size, metadata and tags conditions.
+type objectFilter struct {
+	rules []filterRule
+	objectAttrFilter
+}
+
+// filterName - returns the name patterns are matched against, without
+// the leading separator of local paths.
+func filterName(srcSuffix string, typ ClientURLType) string {
+	if typ == fileSystem {
+		if strings.HasPrefix(srcSuffix, "/") {
+			srcSuffix = srcSuffix[1:]
+		} else if runtime.GOOS == "windows" && strings.HasPrefix(srcSuffix, `\`) {
+			srcSuffix = srcSuffix[1:]
+		}
+	}
+	return srcSuffix
+}
+
+// matchName - returns true if the name is selected by the patterns.
+func (f objectFilter) matchName(srcSuffix string, typ ClientURLType) bool {
+	name := filterName(srcSuffix, typ)
+	hasInclude := false
+	for _, rule := range f.rules {
+		if wildcard.Match(rule.pattern, name) {
+			return rule.include
+		}
+		hasInclude = hasInclude || rule.include
+	}
+	return !hasInclude
+}
+
+// match - returns true if the object is selected by its name and its
+// attributes.
+func (f objectFilter) match(srcSuffix string, content *ClientContent) bool {
+	return f.matchName(srcSuffix, content.URL.Type) &&
+		f.matchAttrs(content.Size, content.UserMetadata, content.Tags)
+}
+
+// orderedFilterRules - interleaves the --include and --exclude patterns
+// in the order of the command line arguments, the flags keep them
+// apart. Patterns missing from the arguments are appended, excludes
+// first.
+func orderedFilterRules(args, includes, excludes []string) []filterRule {
+	var rules []filterRule
+	var i, x int
+	for n := 0; n < len(args); n++ {
+		arg := args[n]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		switch {
+		case name == "include" && i < len(includes):
+			rules = append(rules, filterRule{include: true, pattern: includes[i]})
+			i++
+		case name == "exclude" && x < len(excludes):
+			rules = append(rules, filterRule{pattern: excludes[x]})
+			x++
+		default:
+			continue
+		}
+		if !hasValue {
+			// Skip the pattern.
+			n++
+		}
+	}
+	for ; x < len(excludes); x++ {
+		rules = append(rules, filterRule{pattern: excludes[x]})
+	}
+	for ; i < len(includes); i++ {
+		rules = append(rules, filterRule{include: true, pattern: includes[i]})
+	}
+	return rules
+}
+
+// commandLineArgs - returns the arguments of the command of a cli context
+// as they were given, flags included and in order.
+func commandLineArgs(ctx *cli.Context) []string {
+	if parent := ctx.Parent(); parent != nil {
+		// The parent context holds the command name and its arguments.
+		return parent.Args().Tail()
+	}
+	return ctx.Args()
+}
+
+// newObjectFilter - parses the filter flags, the arguments of the command
+// give the order of the patterns. The rules of the filter file are
+// evaluated after the patterns of the command line, the flags take
+// precedence over the sizes, tags and metadata of the file.
+func newObjectFilter(ctx *cli.Context) (objectFilter, *probe.Error) {
+	var f objectFilter
+	rules := orderedFilterRules(commandLineArgs(ctx), ctx.StringSlice("include"), ctx.StringSlice("exclude"))
+	larger, smaller := ctx.String("larger"), ctx.String("smaller")
+	metadata, tags := ctx.StringSlice("metadata"), ctx.StringSlice("tags")
+
+	if name := ctx.String("filter-file"); name != "" {
+		file, err := readFilterFile(name)
+		if err != nil {
+			return f, err
+		}
+		rules = append(rules, file.rules...)
+		if larger == "" {
+			larger = file.larger
+		}
+		if smaller == "" {
+			smaller = file.smaller
+		}
+		// Values of the flags come last to override the same keys.
+		metadata = append(file.metadata, metadata...)
+		tags = append(file.tags, tags...)
+	}
+
+	attrs, err := parseObjectAttrFilter(larger, smaller, metadata, tags)
+	if err != nil {
+		return f, err
+	}
+	return objectFilter{rules: rules, objectAttrFilter: attrs}, nil
+}
+
+// filterFile - the rules of a filter file.
+type filterFile struct {
+	rules           []filterRule
+	larger, smaller string
+	metadata, tags  []string
+}
+
+// readFilterFile - reads a filter file, every line holds a rule named
+// like the flags, '#' starts a comment:
+//
+//	include *.parquet
+//	exclude tmp/*
+//	larger 1MiB
+//	tags tier=hot
+func readFilterFile(name string) (filterFile, *probe.Error) {
+	var f filterFile
+	file, e := os.Open(name)
+	if e != nil {
+		return f, probe.NewError(e).Trace(name)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return f, errInvalidArgument().Trace(name, fmt.Sprint(lineNum), line)
+		}
+		switch rule {
+		case "include":
+			f.rules = append(f.rules, filterRule{include: true, pattern: value})
+		case "exclude":
+			f.rules = append(f.rules, filterRule{pattern: value})
+		case "larger":
+			f.larger = value
+		case "smaller":
+			f.smaller = value
+		case "metadata":
+			f.metadata = append(f.metadata, value)
+		case "tags":
+			f.tags = append(f.tags, value)
+		default:
+			return f, errInvalidArgument().Trace(name, fmt.Sprint(lineNum), line)
+		}
+	}
+	if e = scanner.Err(); e != nil {
+		return f, probe.NewError(e).Trace(name)
+	}
+	return f, nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/minio/cli"
+)
+
+func TestOrderedFilterRules(t *testing.T) {
+	args := []string{"mirror", "--exclude", "tmp/*", "--overwrite", "--include=*.parquet", "--exclude=*", "src", "dst"}
+	expected := []filterRule{
+		{pattern: "tmp/*"},
+		{include: true, pattern: "*.parquet"},
+		{pattern: "*"},
+	}
+	rules := orderedFilterRules(args, []string{"*.parquet"}, []string{"tmp/*", "*"})
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Expected %v, got %v", expected, rules)
+	}
+
+	// Patterns missing from the arguments, excludes first.
+	expected = []filterRule{
+		{pattern: "tmp/*"},
+		{include: true, pattern: "*.parquet"},
+	}
+	rules = orderedFilterRules(nil, []string{"*.parquet"}, []string{"tmp/*"})
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Expected %v, got %v", expected, rules)
+	}
+}
+
+func TestNewObjectFilterArgs(t *testing.T) {
+	var rules []filterRule
+	app := cli.NewApp()
+	app.Commands = []cli.Command{{
+		Name: "mirror",
+		Flags: []cli.Flag{
+			cli.StringSliceFlag{Name: "include"},
+			cli.StringSliceFlag{Name: "exclude"},
+			cli.StringFlag{Name: "larger"},
+			cli.StringFlag{Name: "smaller"},
+			cli.StringSliceFlag{Name: "metadata"},
+			cli.StringSliceFlag{Name: "tags"},
+			cli.StringFlag{Name: "filter-file"},
+		},
+		Action: func(ctx *cli.Context) error {
+			f, err := newObjectFilter(ctx)
+			if err != nil {
+				return err.ToGoError()
+			}
+			rules = f.rules
+			return nil
+		},
+	}}
+	if err := app.Run([]string{"mc", "mirror", "--include", "*.parquet", "--exclude=*", "src", "dst"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []filterRule{
+		{include: true, pattern: "*.parquet"},
+		{pattern: "*"},
+	}
+	if !reflect.DeepEqual(rules, expected) {
+		t.Fatalf("Expected %v, got %v", expected, rules)
+	}
+}
+
+func TestObjectFilter(t *testing.T) {
+	attrs, err := parseObjectAttrFilter("1KiB", "1MiB", nil, []string{"tier=^hot$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := objectFilter{
+		rules: []filterRule{
+			{pattern: "tmp/*"},
+			{include: true, pattern: "*.parquet"},
+		},
+		objectAttrFilter: attrs,
+	}
+	hot := map[string]string{"tier": "hot"}
+	testCases := []struct {
+		name  string
+		size  int64
+		tags  map[string]string
+		match bool
+	}{
+		{"data/a.parquet", 4096, hot, true},
+		{"/data/a.parquet", 4096, hot, true},
+		{"tmp/a.parquet", 4096, hot, false},
+		{"data/a.csv", 4096, hot, false},
+		{"data/a.parquet", 4096, map[string]string{"tier": "cold"}, false},
+		{"data/a.parquet", 4096, nil, false},
+		{"data/a.parquet", 512, hot, false},
+		{"data/a.parquet", 2 << 20, hot, false},
+	}
+	for _, testCase := range testCases {
+		content := &ClientContent{URL: ClientURL{Type: fileSystem}, Size: testCase.size, Tags: testCase.tags}
+		if match := f.match(testCase.name, content); match != testCase.match {
+			t.Errorf("%s: expected %v, got %v", testCase.name, testCase.match, match)
+		}
+	}
+
+	// Without include patterns, names matching no pattern are selected.
+	f = objectFilter{rules: []filterRule{{pattern: "*.temp"}}}
+	if !f.matchName("a.csv", objectStorage) || f.matchName("a.temp", objectStorage) {
+		t.Fatal("Expected only the excluded names to be filtered out")
+	}
+
+	if _, err = parseObjectAttrFilter("", "", []string{"no-separator"}, nil); err == nil {
+		t.Fatal("Expected a metadata value without key=regex to fail")
+	}
+}
+
+func TestReadFilterFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "filter")
+	data := `# hot parquet files
+include *.parquet
+exclude *
+
+larger 1MiB
+tags tier=hot
+metadata content-type=parquet
+`
+	if e := os.WriteFile(name, []byte(data), 0o644); e != nil {
+		t.Fatal(e)
+	}
+	f, err := readFilterFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := filterFile{
+		rules: []filterRule{
+			{include: true, pattern: "*.parquet"},
+			{pattern: "*"},
+		},
+		larger:   "1MiB",
+		metadata: []string{"content-type=parquet"},
+		tags:     []string{"tier=hot"},
+	}
+	if !reflect.DeepEqual(f, expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, f)
+	}
+
+	if e := os.WriteFile(name, []byte("older 1d\n"), 0o644); e != nil {
+		t.Fatal(e)
+	}
+	if _, err = readFilterFile(name); err == nil {
+		t.Fatal("Expected an unknown rule to fail")
+	}
+}
@@ -159,6 +159,9 @@ func mainOD(cliCtx *cli.Context) error {
 
 	message, e := odCheckType(ctx, odURLs, kvsArgs)
 	fatalIf(probe.NewError(e), "Unable to transfer object")
+	if msg, ok := message.(odMessage); ok {
+		metricsObjectDone(msg.TotalSize)
+	}
 
 	// Print message.
 	printMsg(message)
@@ -18,13 +18,20 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
 	"os"
 	"runtime"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/minio-go/v7"
 	"github.com/shirou/gopsutil/v3/mem"
 )
@@ -35,8 +42,140 @@ const (
 
 	// Monitor tick to decide to add new workers
 	monitorPeriod = 4 * time.Second
+
+	// Workers are removed when more tasks than this
+	// ratio fail during a monitor tick.
+	maxTaskErrorRate = 0.1
+)
+
+const (
+	// Workers are added while the throughput grows and removed
+	// when the endpoint slows down or tasks start failing.
+	concurrencyAdaptive = "adaptive"
+	// The number of workers never changes.
+	concurrencyFixed = "fixed"
 )
 
+var concurrencyFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "max-workers",
+		Usage: "maximum number of parallel workers (default: 128)",
+	},
+	cli.IntFlag{
+		Name:  "min-workers",
+		Usage: "minimum number of parallel workers (default: number of CPUs)",
+	},
+	cli.StringFlag{
+		Name:  "concurrency-mode",
+		Value: concurrencyAdaptive,
+		Usage: "tune the number of workers to the throughput with 'adaptive', or run '--max-workers' workers with 'fixed'",
+	},
+}
+
+// Number of 503 Slow Down responses received from all endpoints, the
+// adaptive controller backs off when it grows.
+var globalSlowDownResponses int64
+
+// slowDownTransport counts 503 Slow Down responses, including the ones
+// retried by minio-go which are never returned to mc. Other 503 errors,
+// e.g. a server which is not initialized yet, are not counted.
+type slowDownTransport struct {
+	transport http.RoundTripper
+}
+
+func (t slowDownTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, e := t.transport.RoundTrip(req)
+	if e == nil && resp.StatusCode == http.StatusServiceUnavailable && isSlowDownResponse(resp) {
+		atomic.AddInt64(&globalSlowDownResponses, 1)
+	}
+	return resp, e
+}
+
+// isSlowDownResponse - returns true if the error code of a 503 response
+// is a Slow Down, the code is read from the MinIO header or else from
+// the XML body which is restored for minio-go.
+func isSlowDownResponse(resp *http.Response) bool {
+	code := resp.Header.Get("x-minio-error-code")
+	if code == "" && resp.Body != nil && resp.Body != http.NoBody {
+		body, e := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		if e != nil {
+			return false
+		}
+		var errResp struct {
+			Code string
+		}
+		if xml.Unmarshal(body, &errResp) == nil {
+			code = errResp.Code
+		}
+	}
+	return strings.HasPrefix(code, "SlowDown")
+}
+
+// isSlowDown - returns true if the error is a 503 Slow Down.
+func isSlowDown(err *probe.Error) bool {
+	if err == nil {
+		return false
+	}
+	errResp := minio.ToErrorResponse(err.ToGoError())
+	return errResp.StatusCode == http.StatusServiceUnavailable || strings.HasPrefix(errResp.Code, "SlowDown")
+}
+
+// parallelOptions - bounds of the number of workers.
+type parallelOptions struct {
+	minWorkers int
+	maxWorkers int
+	adaptive   bool
+}
+
+// parallelOptionsFromContext - parses --min-workers, --max-workers and
+// --concurrency-mode, commands without these flags get the defaults.
+func parallelOptionsFromContext(ctx *cli.Context) (parallelOptions, *probe.Error) {
+	opts := parallelOptions{
+		minWorkers: runtime.NumCPU(),
+		maxWorkers: maxParallelWorkers,
+		adaptive:   true,
+	}
+
+	minWorkers, maxWorkers := ctx.Int("min-workers"), ctx.Int("max-workers")
+	if minWorkers < 0 || maxWorkers < 0 ||
+		(ctx.IsSet("min-workers") && minWorkers == 0) || (ctx.IsSet("max-workers") && maxWorkers == 0) {
+		return opts, errInvalidArgument().Trace(strconv.Itoa(minWorkers), strconv.Itoa(maxWorkers))
+	}
+	if minWorkers > 0 {
+		opts.minWorkers = minWorkers
+		if maxWorkers == 0 && minWorkers > opts.maxWorkers {
+			opts.maxWorkers = minWorkers
+		}
+	}
+	if maxWorkers > 0 {
+		opts.maxWorkers = maxWorkers
+		if minWorkers == 0 && maxWorkers < opts.minWorkers {
+			opts.minWorkers = maxWorkers
+		}
+	}
+	if opts.minWorkers > opts.maxWorkers {
+		return opts, errInvalidArgument().Trace(strconv.Itoa(minWorkers), strconv.Itoa(maxWorkers))
+	}
+
+	switch mode := ctx.String("concurrency-mode"); mode {
+	case "", concurrencyAdaptive:
+	case concurrencyFixed:
+		// Without --max-workers, pin to the minimum.
+		opts.adaptive = false
+		if maxWorkers == 0 {
+			opts.maxWorkers = opts.minWorkers
+		}
+		opts.minWorkers = opts.maxWorkers
+	default:
+		return opts, errInvalidArgument().Trace(mode)
+	}
+	return opts, nil
+}
+
 // Number of workers added per bandwidth monitoring.
 var defaultWorkerFactor = runtime.GOMAXPROCS(0)
 
@@ -59,6 +198,14 @@ type ParallelManager struct {
 	// aligned at 64bit. See https://github.com/golang/go/issues/599
 	sentBytes int64
 
+	// Bytes of the tasks done successfully, for tasks
+	// which do not report their progress to the manager.
+	doneBytes int64
+
+	// Tasks done and failed, failures due to the endpoint
+	// slowing down are also counted apart.
+	doneTasks, failedTasks, slowDownTasks int64
+
 	// Synchronize workers
 	wg          *sync.WaitGroup
 	barrierSync sync.RWMutex
@@ -66,6 +213,12 @@ type ParallelManager struct {
 	// Current threads number
 	workersNum uint32
 
+	// Bounds of the number of workers
+	opts parallelOptions
+
+	// Workers receiving from this channel stop
+	removeWorkerCh chan struct{}
+
 	// Channel to receive tasks to run
 	queueCh chan task
 
@@ -80,7 +233,7 @@ type ParallelManager struct {
 
 // addWorker creates a new worker to process tasks
 func (p *ParallelManager) addWorker() {
-	if atomic.LoadUint32(&p.workersNum) >= maxParallelWorkers {
+	if atomic.LoadUint32(&p.workersNum) >= uint32(p.opts.maxWorkers) {
 		// Number of maximum workers is reached, no need to
 		// to create a new one.
 		return
@@ -88,21 +241,35 @@ func (p *ParallelManager) addWorker() {
 
 	// Update number of threads
 	atomic.AddUint32(&p.workersNum, 1)
+	metricsAddWorkers(1)
 
 	// Start a new worker
 	p.wg.Add(1)
 	go func() {
 		for {
 			// Wait for jobs
-			t, ok := <-p.queueCh
+			var t task
+			var ok bool
+			select {
+			case t, ok = <-p.queueCh:
+			case <-p.removeWorkerCh:
+				// Asked to stop by the monitor.
+				atomic.AddUint32(&p.workersNum, ^uint32(0))
+				metricsAddWorkers(-1)
+				p.wg.Done()
+				return
+			}
 			if !ok {
 				// No more tasks, quit
+				metricsAddWorkers(-1)
 				p.wg.Done()
 				return
 			}
 
 			// Execute the task and send the result to channel.
-			p.resultCh <- t.fn()
+			urls := t.fn()
+			p.accountTask(t, urls)
+			p.resultCh <- urls
 
 			if t.barrier {
 				p.barrierSync.Unlock()
@@ -113,6 +280,36 @@ func (p *ParallelManager) addWorker() {
 	}()
 }
 
+// removeWorker asks one worker to stop, without going
+// below the minimum number of workers.
+func (p *ParallelManager) removeWorker() {
+	// Workers only decrement the count once they stop,
+	// pending removals are accounted for.
+	n := atomic.LoadUint32(&p.workersNum)
+	if n <= uint32(p.opts.minWorkers)+uint32(len(p.removeWorkerCh)) {
+		return
+	}
+	select {
+	case p.removeWorkerCh <- struct{}{}:
+	default:
+	}
+}
+
+// accountTask records the outcome of a task for the adaptive controller.
+func (p *ParallelManager) accountTask(t task, urls URLs) {
+	atomic.AddInt64(&p.doneTasks, 1)
+	switch {
+	case urls.Error == nil:
+		atomic.AddInt64(&p.doneBytes, t.uploadSize)
+		metricsObjectDone(t.uploadSize)
+	case isSlowDown(urls.Error):
+		atomic.AddInt64(&p.slowDownTasks, 1)
+		atomic.AddInt64(&p.failedTasks, 1)
+	default:
+		atomic.AddInt64(&p.failedTasks, 1)
+	}
+}
+
 func (p *ParallelManager) Read(b []byte) (n int, err error) {
 	atomic.AddInt64(&p.sentBytes, int64(len(b)))
 	return len(b), nil
@@ -121,14 +318,18 @@ func (p *ParallelManager) Read(b []byte) (n int, err error) {
 // monitorProgress monitors realtime transfer speed of data
 // and increases threads until it reaches a maximum number of
 // threads or notice there is no apparent enhancement of
-// transfer speed.
+// transfer speed. Threads are removed whenever the endpoint
+// answers with 503 Slow Down or the error rate grows.
 func (p *ParallelManager) monitorProgress() {
 	go func() {
 		ticker := time.NewTicker(monitorPeriod)
 		defer ticker.Stop()
 
-		var prevSentBytes, maxBandwidth int64
+		var prevSentBytes, prevDoneBytes, maxBandwidth int64
+		var prevErrorRate float64
+		prevSlowDowns := atomic.LoadInt64(&globalSlowDownResponses)
 		var retry int
+		scaleUp := true
 
 		for {
 			select {
@@ -136,10 +337,39 @@ func (p *ParallelManager) monitorProgress() {
 				// Ordered to quit immediately
 				return
 			case <-ticker.C:
-				// Compute new bandwidth from counted sent bytes
+				// Compute new bandwidth from counted sent bytes, or
+				// from the bytes of done tasks if none were counted.
 				sentBytes := atomic.LoadInt64(&p.sentBytes)
+				doneBytes := atomic.LoadInt64(&p.doneBytes)
 				bandwidth := sentBytes - prevSentBytes
-				prevSentBytes = sentBytes
+				if bandwidth == 0 {
+					bandwidth = doneBytes - prevDoneBytes
+				}
+				prevSentBytes, prevDoneBytes = sentBytes, doneBytes
+
+				slowDowns := atomic.LoadInt64(&globalSlowDownResponses)
+				slowDown := atomic.SwapInt64(&p.slowDownTasks, 0) > 0 || slowDowns > prevSlowDowns
+				prevSlowDowns = slowDowns
+
+				var errorRate float64
+				doneTasks := atomic.SwapInt64(&p.doneTasks, 0)
+				failedTasks := atomic.SwapInt64(&p.failedTasks, 0)
+				if doneTasks > 0 {
+					errorRate = float64(failedTasks) / float64(doneTasks)
+				}
+				errorsRising := errorRate > maxTaskErrorRate && errorRate > prevErrorRate
+				prevErrorRate = errorRate
+
+				if slowDown || errorsRising {
+					// Back off, workers are added again only
+					// once the bandwidth beats the throttled one,
+					// the maximum before the backoff is forgotten.
+					for i := 0; i < defaultWorkerFactor; i++ {
+						p.removeWorker()
+					}
+					retry, scaleUp, maxBandwidth = 0, false, bandwidth
+					continue
+				}
 
 				if bandwidth <= maxBandwidth {
 					retry++
@@ -147,15 +377,18 @@ func (p *ParallelManager) monitorProgress() {
 					// until we are sure that it is not
 					// useful to add more of them.
 					if retry > 2 {
-						return
+						scaleUp = false
 					}
 				} else {
 					retry = 0
 					maxBandwidth = bandwidth
+					scaleUp = true
 				}
 
-				for i := 0; i < defaultWorkerFactor; i++ {
-					p.addWorker()
+				if scaleUp {
+					for i := 0; i < defaultWorkerFactor; i++ {
+						p.addWorker()
+					}
 				}
 			}
 		}
@@ -266,23 +499,27 @@ func availableMemory() (available uint64) {
 }
 
 // newParallelManager starts new workers waiting for executing tasks
-func newParallelManager(resultCh chan URLs) *ParallelManager {
+func newParallelManager(resultCh chan URLs, opts parallelOptions) *ParallelManager {
 	p := &ParallelManager{
-		wg:            &sync.WaitGroup{},
-		workersNum:    0,
-		stopMonitorCh: make(chan struct{}),
-		queueCh:       make(chan task),
-		resultCh:      resultCh,
-		maxMem:        availableMemory(),
+		wg:             &sync.WaitGroup{},
+		workersNum:     0,
+		opts:           opts,
+		removeWorkerCh: make(chan struct{}, opts.maxWorkers),
+		stopMonitorCh:  make(chan struct{}),
+		queueCh:        make(chan task),
+		resultCh:       resultCh,
+		maxMem:         availableMemory(),
 	}
 
-	// Start with runtime.NumCPU().
-	for i := 0; i < runtime.NumCPU(); i++ {
+	// Start with the minimum number of workers.
+	for i := 0; i < opts.minWorkers; i++ {
 		p.addWorker()
 	}
 
 	// Start monitoring tasks progress
-	p.monitorProgress()
+	if opts.adaptive && opts.minWorkers < opts.maxWorkers {
+		p.monitorProgress()
+	}
 
 	return p
 }
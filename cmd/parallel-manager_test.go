@@ -0,0 +1,147 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestParallelOptionsFromContext(t *testing.T) {
+	cpus := runtime.NumCPU()
+	testCases := []struct {
+		args     []string
+		expected parallelOptions
+		fail     bool
+	}{
+		{nil, parallelOptions{minWorkers: cpus, maxWorkers: maxParallelWorkers, adaptive: true}, false},
+		{[]string{"--max-workers=4", "--min-workers=2"}, parallelOptions{minWorkers: 2, maxWorkers: 4, adaptive: true}, false},
+		{[]string{"--min-workers=200"}, parallelOptions{minWorkers: 200, maxWorkers: 200, adaptive: true}, false},
+		{[]string{"--max-workers=8", "--concurrency-mode=fixed"}, parallelOptions{minWorkers: 8, maxWorkers: 8}, false},
+		{[]string{"--min-workers=3", "--concurrency-mode=fixed"}, parallelOptions{minWorkers: 3, maxWorkers: 3}, false},
+		{[]string{"--min-workers=8", "--max-workers=4"}, parallelOptions{}, true},
+		{[]string{"--max-workers=0"}, parallelOptions{}, true},
+		{[]string{"--concurrency-mode=turbo"}, parallelOptions{}, true},
+	}
+
+	for i, testCase := range testCases {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.Int("max-workers", 0, "")
+		set.Int("min-workers", 0, "")
+		set.String("concurrency-mode", concurrencyAdaptive, "")
+		if e := set.Parse(testCase.args); e != nil {
+			t.Fatal(e)
+		}
+
+		opts, err := parallelOptionsFromContext(cli.NewContext(nil, set, nil))
+		if testCase.fail != (err != nil) {
+			t.Fatalf("Test %d: expected failure %t, got %v", i+1, testCase.fail, err)
+		}
+		if !testCase.fail && opts != testCase.expected {
+			t.Fatalf("Test %d: expected %+v, got %+v", i+1, testCase.expected, opts)
+		}
+	}
+}
+
+func TestParallelManagerRemoveWorker(t *testing.T) {
+	resultCh := make(chan URLs)
+	p := newParallelManager(resultCh, parallelOptions{minWorkers: 2, maxWorkers: 4})
+	p.addWorker()
+	p.addWorker()
+	p.addWorker()
+	if n := atomic.LoadUint32(&p.workersNum); n != 4 {
+		t.Fatalf("Expected 4 workers, got %d", n)
+	}
+
+	for i := 0; i < 4; i++ {
+		p.removeWorker()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint32(&p.workersNum) != 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := atomic.LoadUint32(&p.workersNum); n != 2 {
+		t.Fatalf("Expected the minimum of 2 workers, got %d", n)
+	}
+
+	go func() {
+		for range resultCh {
+		}
+	}()
+	p.queueTask(func() URLs {
+		return URLs{Error: probe.NewError(minio.ErrorResponse{StatusCode: 503, Code: "SlowDown"})}
+	}, 0)
+	p.queueTask(func() URLs {
+		return URLs{Error: probe.NewError(errors.New("failed"))}
+	}, 0)
+	p.stopAndWait()
+	close(resultCh)
+
+	if failed, slowDown := atomic.LoadInt64(&p.failedTasks), atomic.LoadInt64(&p.slowDownTasks); failed != 2 || slowDown != 1 {
+		t.Fatalf("Expected 2 failed tasks of which 1 slow down, got %d and %d", failed, slowDown)
+	}
+}
+
+func TestSlowDownTransport(t *testing.T) {
+	testCases := []struct {
+		header   string
+		body     string
+		slowDown bool
+	}{
+		{body: "<Error><Code>SlowDown</Code></Error>", slowDown: true},
+		{body: "<Error><Code>SlowDownWrite</Code></Error>", slowDown: true},
+		{header: "SlowDownRead", slowDown: true},
+		{body: "<Error><Code>XMinioServerNotInitialized</Code></Error>"},
+		{},
+	}
+	for i, testCase := range testCases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if testCase.header != "" {
+				w.Header().Set("x-minio-error-code", testCase.header)
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			io.WriteString(w, testCase.body)
+		}))
+		client := http.Client{Transport: slowDownTransport{transport: http.DefaultTransport}}
+		before := atomic.LoadInt64(&globalSlowDownResponses)
+		resp, e := client.Get(server.URL)
+		if e != nil {
+			t.Fatalf("Test %d: %v", i+1, e)
+		}
+		body, e := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		server.Close()
+		if e != nil || string(body) != testCase.body {
+			t.Fatalf("Test %d: expected the body %q to be kept, got %q (%v)", i+1, testCase.body, body, e)
+		}
+		if counted := atomic.LoadInt64(&globalSlowDownResponses) > before; counted != testCase.slowDown {
+			t.Fatalf("Test %d: expected slow down %v, got %v", i+1, testCase.slowDown, counted)
+		}
+	}
+}
@@ -62,6 +62,7 @@ var pipeFlags = []cli.Flag{
 		Usage:  "increase the pipe buffer size to a custom value",
 		Hidden: true,
 	},
+	checksumFlag,
 }
 
 // Display contents of a file.
@@ -110,6 +111,12 @@ EXAMPLES:
 
   8. Set tags to the uploaded objects
       {{.Prompt}} tar cvf - . | {{.HelpName}} --tags "category=prod&type=backup" play/mybucket/backup.tar
+
+  9. Stream a backup to an object verifying the uploaded data with a SHA256 checksum.
+      {{.Prompt}} tar cvf - . | {{.HelpName}} --checksum sha256 play/mybucket/backup.tar
+
+  10. Stream a backup to an object encrypted on the client.
+      {{.Prompt}} tar cvf - . | {{.HelpName}} --enc-client "play/mybucket/=/etc/mc/backup.key" play/mybucket/backup.tar
 `,
 }
 
@@ -148,11 +155,13 @@ func pipe(ctx *cli.Context, targetURL string, encKeyDB map[string][]prefixSSEPai
 	// for local filesystem for example /proc files.
 	opts := PutOptions{
 		sse:              sseKey,
+		cse:              getCSE(targetURL, encKeyDB[alias]),
 		storageClass:     storageClass,
 		metadata:         meta,
 		multipartSize:    multipartSize,
 		multipartThreads: uint(multipartThreads),
 		concurrentStream: ctx.IsSet("concurrent"),
+		checksum:         mustParseChecksumFlag(ctx),
 	}
 
 	var reader io.Reader
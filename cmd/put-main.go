@@ -41,6 +41,7 @@ var (
 			Usage: "each part size",
 			Value: "16MiB",
 		},
+		checksumFlag,
 	}
 )
 
@@ -51,7 +52,7 @@ var putCmd = cli.Command{
 	Action:       mainPut,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(encFlags, globalFlags...), putFlags...),
+	Flags:        append(append(append(encFlags, globalFlags...), retryFlags...), putFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -81,6 +82,12 @@ EXAMPLES:
 
 	5. Put an object to MinIO storage using sse-kms encryption
 		{{.Prompt}} {{.HelpName}} --enc-kms path-to/object play/mybucket/object 
+
+  6. Put an object to S3 storage verifying the upload with a CRC32C checksum
+		{{.Prompt}} {{.HelpName}} --checksum crc32c path-to/object play/mybucket/object
+
+  7. Put an object to S3 storage encrypting it on the client
+		{{.Prompt}} {{.HelpName}} --enc-client "play/mybucket/object=/etc/mc/object.key" path-to/object play/mybucket/object
 `,
 }
 
@@ -108,6 +115,8 @@ func mainPut(cliCtx *cli.Context) (e error) {
 		fatalIf(errInvalidArgument().Trace(strconv.Itoa(threads)), "Invalid number of threads")
 	}
 
+	checksum := mustParseChecksumFlag(cliCtx)
+
 	// Parse encryption keys per command.
 	encryptionKeys, err := validateAndCreateEncryptionKeys(cliCtx)
 	if err != nil {
@@ -147,6 +156,7 @@ func mainPut(cliCtx *cli.Context) (e error) {
 				putURLsCh <- putURLs
 				break
 			}
+			putURLs.Checksum = checksum
 			totalBytes += putURLs.SourceContent.Size
 			pg.SetTotal(totalBytes)
 			totalObjects++
@@ -175,6 +185,7 @@ func mainPut(cliCtx *cli.Context) (e error) {
 				encryptionKeys:   encryptionKeys,
 				multipartSize:    size,
 				multipartThreads: strconv.Itoa(threads),
+				retry:            retryPolicyFromContext(cliCtx),
 			})
 			if urls.Error != nil {
 				e = urls.Error.ToGoError()
@@ -0,0 +1,247 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var replayCmd = cli.Command{
+	Name:         "replay",
+	Usage:        "retry the objects of a failed objects report",
+	Action:       mainReplay,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        globalFlags,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] FILE
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Every object of a report written by 'cp', 'mv', 'mirror' or 'rm' with --failed-report
+  is copied or removed again with the options of the original command. The objects of
+  the same command line are replayed together, in parallel, an object which fails does
+  not stop the replay of the others.
+  Mirrored objects are copied again with the options 'cp' has in common with 'mirror'.
+  Local paths are relative to the folder the original command was run from.
+
+EXAMPLES:
+  1. Copy a folder, writing the objects which failed to a report, and retry them later.
+     {{.Prompt}} mc cp --recursive --failed-report failed.json backup/ myminio/backup/
+     {{.Prompt}} {{.HelpName}} failed.json
+
+  2. Retry the objects a mirror failed on, only listing the objects which failed again.
+     {{.Prompt}} {{.HelpName}} --json failed.json | jq 'select(.status == "error")'
+`,
+}
+
+// Flags which select many objects, they do not apply to the
+// single objects of a report.
+var replayDroppedFlags = []string{
+	"recursive", "r", "stdin", "versions", "non-current", "rewind",
+	"older-than", "newer-than", "version-id", "vid", "session",
+	"archive", "extract", "failed-report",
+}
+
+// replayMessage container for replay status messages, one per
+// replayed command line.
+type replayMessage struct {
+	Status  string   `json:"status"`
+	Command string   `json:"command"`
+	Flags   []string `json:"flags,omitempty"`
+	Objects int      `json:"objects"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// String colorized replay message.
+func (r replayMessage) String() string {
+	commandLine := strings.Join(append([]string{r.Command}, r.Flags...), " ")
+	if r.Status == "success" {
+		return console.Colorize("Replay", fmt.Sprintf("Replayed %d objects of `%s`.", r.Objects, commandLine))
+	}
+	return console.Colorize("ReplayFailed", fmt.Sprintf("Unable to replay all the %d objects of `%s`.", r.Objects, commandLine))
+}
+
+// JSON jsonified replay message.
+func (r replayMessage) JSON() string {
+	replayMessageBytes, e := json.MarshalIndent(r, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(replayMessageBytes)
+}
+
+// checkReplaySyntax - validate all the passed arguments
+func checkReplaySyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+}
+
+// replayFlagName - returns the name of a command line flag.
+func replayFlagName(arg string) string {
+	return strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+}
+
+// hasFlag - returns true if the command has a flag of the given name.
+func hasFlag(command cli.Command, name string) bool {
+	for _, f := range command.Flags {
+		for _, n := range strings.Split(f.GetName(), ",") {
+			if strings.TrimSpace(n) == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replayCommandFlags - returns the command replaying a report entry, the
+// copy or the removal of one object, and the flags it runs with.
+func replayCommandFlags(entry failedReportEntry) (cli.Command, []string) {
+	command := cpCmd
+	switch {
+	case entry.Source == "":
+		command = rmCmd
+	case entry.Command == "mv":
+		command = mvCmd
+	}
+
+	var flags []string
+	for _, arg := range entry.Flags {
+		name := replayFlagName(arg)
+		if !hasFlag(command, name) || slices.Contains(replayDroppedFlags, name) {
+			continue
+		}
+		flags = append(flags, arg)
+	}
+	return command, flags
+}
+
+// replayGroup - the report entries replayed by the same command line.
+type replayGroup struct {
+	command cli.Command
+	flags   []string
+	entries []failedReportEntry
+}
+
+// groupReplayEntries - groups the report entries by command line, in
+// the order of their first entry.
+func groupReplayEntries(entries []failedReportEntry) []*replayGroup {
+	var groups []*replayGroup
+	byCommandLine := make(map[string]*replayGroup)
+	for _, entry := range entries {
+		command, flags := replayCommandFlags(entry)
+		key := strings.Join(append([]string{command.Name}, flags...), "\x00")
+		group, ok := byCommandLine[key]
+		if !ok {
+			group = &replayGroup{command: command, flags: flags}
+			byCommandLine[key] = group
+			groups = append(groups, group)
+		}
+		group.entries = append(group.entries, entry)
+	}
+	return groups
+}
+
+// replayEntries - copies or removes again the entries of a group, in
+// parallel.
+func replayEntries(cliCtx *cli.Context, group *replayGroup) error {
+	ctx, err := sessionCommandContext(cliCtx, group.command, group.flags)
+	if err != nil {
+		return err.ToGoError()
+	}
+
+	if group.command.Name == rmCmd.Name {
+		targetCh := make(chan rmTarget)
+		go func() {
+			defer close(targetCh)
+			for _, entry := range group.entries {
+				targetCh <- rmTarget{url: entry.Target, versionID: entry.VersionID}
+			}
+		}()
+		return doRemove(ctx, targetCh, true)
+	}
+
+	encKeyDB, err := validateAndCreateEncryptionKeys(ctx)
+	if err != nil {
+		return err.ToGoError()
+	}
+	pairs := make([]copyPair, 0, len(group.entries))
+	for _, entry := range group.entries {
+		pairs = append(pairs, copyPair{source: entry.Source, target: entry.Target, versionID: entry.VersionID})
+	}
+	copyCtx, cancelCopy := context.WithCancel(globalContext)
+	defer cancelCopy()
+
+	isMvCmd := group.command.Name == mvCmd.Name
+	e := doCopySession(copyCtx, cancelCopy, ctx, encKeyDB, isMvCmd, nil, pairs)
+	if isMvCmd {
+		rmManager.close()
+	}
+	return e
+}
+
+// mainReplay is the handle for "mc replay" command.
+func mainReplay(cliCtx *cli.Context) error {
+	checkReplaySyntax(cliCtx)
+	console.SetColor("Copy", color.New(color.FgGreen, color.Bold))
+	console.SetColor("Removed", color.New(color.FgGreen, color.Bold))
+	console.SetColor("Replay", color.New(color.FgGreen, color.Bold))
+	console.SetColor("ReplayFailed", color.New(color.FgRed, color.Bold))
+
+	name := cliCtx.Args().Get(0)
+	entries, err := readFailedReport(name)
+	fatalIf(err, "Unable to read the failed objects report `"+name+"`.")
+
+	var failed bool
+	for _, group := range groupReplayEntries(entries) {
+		msg := replayMessage{
+			Status:  "success",
+			Command: group.command.Name,
+			Flags:   group.flags,
+			Objects: len(group.entries),
+		}
+		if e := replayEntries(cliCtx, group); e != nil {
+			failed = true
+			msg.Status = "failure"
+			// The exit status of a command carries no message, its
+			// errors were printed already.
+			if _, ok := e.(cli.ExitCoder); !ok {
+				msg.Error = e.Error()
+			}
+		}
+		printMsg(msg)
+	}
+
+	if failed {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
@@ -197,7 +197,7 @@ func extractCredentialURL(argURL string) (accessKey, secretKey string, u *url.UR
 		}
 	} else {
 		var alias string
-		var aliasCfg *aliasConfigV10
+		var aliasCfg *aliasConfigV11
 		// get alias config by alias url
 		alias, parsedURL, aliasCfg = mustExpandAlias(argURL)
 		if aliasCfg == nil {
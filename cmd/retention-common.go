@@ -124,7 +124,7 @@ func getRetainUntilDate(validity uint64, unit minio.ValidityUnit) (string, *prob
 	return timeStr, nil
 }
 
-func setRetentionSingle(ctx context.Context, op lockOpType, alias, url, versionID string, mode minio.RetentionMode, retainUntil time.Time, bypassGovernance bool) *probe.Error {
+func setRetentionSingle(ctx context.Context, op lockOpType, alias, url, versionID string, mode minio.RetentionMode, retainUntil time.Time, bypassGovernance bool, retry retryPolicy) *probe.Error {
 	newClnt, err := newClientFromAlias(alias, url)
 	if err != nil {
 		return err
@@ -137,7 +137,9 @@ func setRetentionSingle(ctx context.Context, op lockOpType, alias, url, versionI
 		VersionID: versionID,
 	}
 
-	err = newClnt.PutObjectRetention(ctx, versionID, mode, retainUntil, bypassGovernance)
+	err = retryWithMessage(ctx, retry, "", msg.URLPath, func() *probe.Error {
+		return newClnt.PutObjectRetention(ctx, versionID, mode, retainUntil, bypassGovernance)
+	})
 	if err != nil {
 		msg.Err = err.ToGoError()
 		msg.Status = "failure"
@@ -179,7 +181,7 @@ func fatalIfBucketLockNotSupported(ctx context.Context, aliasedURL string) {
 
 // Apply Retention for one object/version or many objects within a given prefix.
 func applyRetention(ctx context.Context, op lockOpType, target, versionID string, timeRef time.Time, withOlderVersions, isRecursive bool,
-	mode minio.RetentionMode, validity uint64, unit minio.ValidityUnit, bypassGovernance bool,
+	mode minio.RetentionMode, validity uint64, unit minio.ValidityUnit, bypassGovernance bool, retry retryPolicy,
 ) error {
 	clnt, err := newClient(target)
 	if err != nil {
@@ -208,7 +210,7 @@ func applyRetention(ctx context.Context, op lockOpType, target, versionID string
 
 	alias, urlStr, _ := mustExpandAlias(target)
 	if versionID != "" || !isRecursive && !withOlderVersions {
-		err := setRetentionSingle(ctx, op, alias, urlStr, versionID, mode, until, bypassGovernance)
+		err := setRetentionSingle(ctx, op, alias, urlStr, versionID, mode, until, bypassGovernance, retry)
 		fatalIf(err.Trace(), "Unable to set retention on `%s`", target)
 		return nil
 	}
@@ -239,7 +241,7 @@ func applyRetention(ctx context.Context, op lockOpType, target, versionID string
 			break
 		}
 
-		err := setRetentionSingle(ctx, op, alias, content.URL.String(), content.VersionID, mode, until, bypassGovernance)
+		err := setRetentionSingle(ctx, op, alias, content.URL.String(), content.VersionID, mode, until, bypassGovernance, retry)
 		if err != nil {
 			errorIf(err.Trace(clnt.GetURL().String()), "Invalid URL")
 			continue
@@ -62,7 +62,7 @@ var retentionSetCmd = cli.Command{
 	Action:       mainRetentionSet,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(retentionSetFlags, globalFlags...),
+	Flags:        append(append(retentionSetFlags, retryFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -129,9 +129,9 @@ func parseSetRetentionArgs(cliCtx *cli.Context) (target, versionID string, recur
 
 // Set Retention for one object/version or many objects within a given prefix.
 func setRetention(ctx context.Context, target, versionID string, timeRef time.Time, withOlderVersions, isRecursive bool,
-	mode minio.RetentionMode, validity uint64, unit minio.ValidityUnit, bypassGovernance bool,
+	mode minio.RetentionMode, validity uint64, unit minio.ValidityUnit, bypassGovernance bool, retry retryPolicy,
 ) error {
-	return applyRetention(ctx, lockOpSet, target, versionID, timeRef, withOlderVersions, isRecursive, mode, validity, unit, bypassGovernance)
+	return applyRetention(ctx, lockOpSet, target, versionID, timeRef, withOlderVersions, isRecursive, mode, validity, unit, bypassGovernance, retry)
 }
 
 func setBucketLock(urlStr string, mode minio.RetentionMode, validity uint64, unit minio.ValidityUnit) error {
@@ -158,5 +158,5 @@ func mainRetentionSet(cliCtx *cli.Context) error {
 		rewind = time.Now().UTC()
 	}
 
-	return setRetention(ctx, target, versionID, rewind, withVersions, recursive, mode, validity, unit, bypass)
+	return setRetention(ctx, target, versionID, rewind, withVersions, recursive, mode, validity, unit, bypass, retryPolicyFromContext(cliCtx))
 }
@@ -2,67 +2,191 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
 	"time"
 
+	"github.com/minio/cli"
 	json "github.com/minio/colorjson"
 	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
 )
 
+const (
+	// Delay before the first retry, doubled for every retry.
+	retryBaseDelay = time.Second
+
+	// Maximum delay between two retries.
+	retryMaxDelay = 30 * time.Second
+)
+
+var retryFlags = []cli.Flag{
+	cli.IntFlag{
+		Name:  "max-retries",
+		Usage: "retry an object up to this many times on network errors, 5xx and slow down responses",
+	},
+}
+
+// retryPolicy - how operations failing with transient errors are retried.
+type retryPolicy struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+}
+
+func newRetryPolicy(maxRetries int) retryPolicy {
+	return retryPolicy{
+		maxRetries: maxRetries,
+		baseDelay:  retryBaseDelay,
+		maxDelay:   retryMaxDelay,
+	}
+}
+
+// retryPolicyFromContext - returns the retry policy set by --max-retries.
+func retryPolicyFromContext(ctx *cli.Context) retryPolicy {
+	return newRetryPolicy(ctx.Int("max-retries"))
+}
+
+// backoff - returns the delay before the given retry, it doubles for
+// every retry up to the maximum delay and is jittered by half.
+func (p retryPolicy) backoff(retries int) time.Duration {
+	delay := p.maxDelay
+	if retries < 32 && p.baseDelay<<retries < p.maxDelay {
+		delay = p.baseDelay << retries
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// isRetryable - returns true for transient network errors, 5xx and slow
+// down responses. Other errors, such as AccessDenied or NoSuchKey, are
+// not going to go away by retrying.
+func isRetryable(err *probe.Error) bool {
+	if err == nil {
+		return false
+	}
+	e := err.ToGoError()
+	if errors.Is(e, context.Canceled) {
+		return false
+	}
+
+	switch e.(type) {
+	case UnexpectedEOF, UnexpectedShortWrite:
+		return true
+	}
+
+	errResp := minio.ToErrorResponse(e)
+	switch errResp.Code {
+	case "SlowDown", "SlowDownRead", "SlowDownWrite", "RequestTimeout", "InternalError", "ServiceUnavailable", "XMinioServerNotInitialized":
+		return true
+	}
+	if errResp.StatusCode != 0 {
+		return errResp.StatusCode >= http.StatusInternalServerError ||
+			errResp.StatusCode == http.StatusTooManyRequests ||
+			errResp.StatusCode == http.StatusRequestTimeout
+	}
+
+	if errors.Is(e, io.ErrUnexpectedEOF) || errors.Is(e, syscall.ECONNRESET) ||
+		errors.Is(e, syscall.ECONNREFUSED) || errors.Is(e, syscall.EPIPE) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(e, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(e, &netErr) && netErr.Timeout()
+}
+
 type retryManager struct {
-	retries       int
-	maxRetries    int
-	retryInterval time.Duration
-	commandCtx    context.Context
-	retryCtx      context.Context
-	cancelRetry   context.CancelFunc
+	retries    int
+	lastErr    *probe.Error
+	policy     retryPolicy
+	commandCtx context.Context
 }
 
-func newRetryManager(ctx context.Context, retryInterval time.Duration, maxRetries int) *retryManager {
-	retryCtx, cancelFunc := context.WithCancel(context.Background())
+func newRetryManager(ctx context.Context, policy retryPolicy) *retryManager {
 	return &retryManager{
-		retryInterval: retryInterval,
-		maxRetries:    maxRetries,
-		commandCtx:    ctx,
-		retryCtx:      retryCtx,
-		cancelRetry:   cancelFunc,
+		policy:     policy,
+		commandCtx: ctx,
 	}
 }
 
 type retryMessage struct {
-	SourceURL string `json:"sourceURL"`
+	Status    string `json:"status"`
+	SourceURL string `json:"sourceURL,omitempty"`
 	TargetURL string `json:"targetURL"`
 	Retries   int    `json:"retries"`
+	Error     string `json:"error,omitempty"`
 }
 
 func (r retryMessage) String() string {
+	if r.SourceURL == "" {
+		return fmt.Sprintf("<INFO> Retries %d: `%s`", r.Retries, r.TargetURL)
+	}
 	return fmt.Sprintf("<INFO> Retries %d: source `%s` >> target `%s`", r.Retries, r.SourceURL, r.TargetURL)
 }
 
 func (r retryMessage) JSON() string {
+	r.Status = "retry"
 	jsonMessageBytes, e := json.MarshalIndent(r, "", " ")
 	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
 	return string(jsonMessageBytes)
 }
 
-func (r *retryManager) retry(action func(rm *retryManager) *probe.Error) {
-	defer r.cancelRetry()
-	for r.retries <= r.maxRetries {
-
+// retry - runs the action until it succeeds, fails with an error which
+// is not retryable or the retries are exhausted, the last error is
+// returned. The number of retries and the last error are available to
+// the action in the retry manager.
+func (r *retryManager) retry(action func(rm *retryManager) *probe.Error) *probe.Error {
+	for {
 		err := action(r)
-		if err == nil {
-			return
+		if err == nil || r.retries >= r.policy.maxRetries || !isRetryable(err) {
+			return err
 		}
+		r.lastErr = err
 
 		select {
-		case <-r.retryCtx.Done():
-			return
 		case <-r.commandCtx.Done():
-			return
-		case <-time.After(r.retryInterval/2 + time.Duration(rand.Int63n(int64(r.retryInterval)))):
+			return err
+		case <-time.After(r.policy.backoff(r.retries)):
 			r.retries++
+			metricsRetry()
 		}
-
 	}
 }
+
+// retryWithMessage - retries the action following the policy, every
+// retry of the object is reported with a retryMessage.
+func retryWithMessage(ctx context.Context, policy retryPolicy, sourceURL, targetURL string, action func() *probe.Error) *probe.Error {
+	_, err := retryObjectWithMessage(ctx, policy, sourceURL, targetURL, func(int) *probe.Error {
+		return action()
+	})
+	return err
+}
+
+// retryObjectWithMessage - same as retryWithMessage, the action is given
+// the number of retries before its attempt and the number of retries of
+// the object is returned for its final message.
+func retryObjectWithMessage(ctx context.Context, policy retryPolicy, sourceURL, targetURL string, action func(retries int) *probe.Error) (int, *probe.Error) {
+	r := newRetryManager(ctx, policy)
+	err := r.retry(func(rm *retryManager) *probe.Error {
+		if rm.retries > 0 {
+			printMsg(retryMessage{
+				SourceURL: sourceURL,
+				TargetURL: targetURL,
+				Retries:   rm.retries,
+				Error:     rm.lastErr.ToGoError().Error(),
+			})
+		}
+		return action(rm.retries)
+	})
+	return r.retries, err
+}
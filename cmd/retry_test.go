@@ -0,0 +1,136 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+)
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		err       error
+		retryable bool
+	}{
+		{minio.ErrorResponse{StatusCode: 503, Code: "SlowDown"}, true},
+		{minio.ErrorResponse{StatusCode: 500, Code: "InternalError"}, true},
+		{minio.ErrorResponse{StatusCode: 429, Code: "TooManyRequests"}, true},
+		{minio.ErrorResponse{StatusCode: 403, Code: "AccessDenied"}, false},
+		{minio.ErrorResponse{StatusCode: 404, Code: "NoSuchKey"}, false},
+		{ObjectMissing{}, false},
+		{context.Canceled, false},
+		{errors.New("invalid argument"), false},
+		{UnexpectedEOF{}, true},
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, true},
+		{syscall.ECONNRESET, true},
+	}
+	for i, testCase := range testCases {
+		if got := isRetryable(probe.NewError(testCase.err)); got != testCase.retryable {
+			t.Fatalf("Test %d: expected %t for %v, got %t", i+1, testCase.retryable, testCase.err, got)
+		}
+	}
+	if isRetryable(nil) {
+		t.Fatal("Expected no error not to be retryable")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := retryPolicy{maxRetries: 10, baseDelay: time.Second, maxDelay: 10 * time.Second}
+	for retries := 0; retries < 64; retries++ {
+		expected := policy.maxDelay
+		if retries < 4 {
+			expected = policy.baseDelay << retries
+		}
+		if delay := policy.backoff(retries); delay < expected/2 || delay > expected {
+			t.Fatalf("Retry %d: expected a delay between %s and %s, got %s", retries, expected/2, expected, delay)
+		}
+	}
+}
+
+func TestRetryManager(t *testing.T) {
+	policy := retryPolicy{maxRetries: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	slowDown := probe.NewError(minio.ErrorResponse{StatusCode: 503, Code: "SlowDown"})
+	accessDenied := probe.NewError(minio.ErrorResponse{StatusCode: 403, Code: "AccessDenied"})
+
+	testCases := []struct {
+		errs     []*probe.Error
+		attempts int
+		fail     bool
+	}{
+		// Succeeds at once.
+		{nil, 1, false},
+		// Succeeds after two retries.
+		{[]*probe.Error{slowDown, slowDown}, 3, false},
+		// Fails fast on non retryable errors.
+		{[]*probe.Error{accessDenied, accessDenied}, 1, true},
+		// Gives up once the retries are exhausted.
+		{[]*probe.Error{slowDown, slowDown, slowDown, slowDown, slowDown}, 4, true},
+	}
+	for i, testCase := range testCases {
+		attempts := 0
+		rm := newRetryManager(context.Background(), policy)
+		err := rm.retry(func(rm *retryManager) *probe.Error {
+			attempts++
+			if rm.retries < len(testCase.errs) {
+				return testCase.errs[rm.retries]
+			}
+			return nil
+		})
+		if testCase.fail != (err != nil) {
+			t.Fatalf("Test %d: expected failure %t, got %v", i+1, testCase.fail, err)
+		}
+		if attempts != testCase.attempts {
+			t.Fatalf("Test %d: expected %d attempts, got %d", i+1, testCase.attempts, attempts)
+		}
+	}
+}
+
+func TestRetryProgressRewind(t *testing.T) {
+	policy := retryPolicy{maxRetries: 3, baseDelay: time.Millisecond, maxDelay: time.Millisecond}
+	slowDown := probe.NewError(minio.ErrorResponse{StatusCode: 503, Code: "SlowDown"})
+
+	pg := newAccounter(100)
+	progress := &attemptProgress{ProgressReader: pg}
+	retries, err := retryObjectWithMessage(context.Background(), policy, "src", "dst", func(retries int) *probe.Error {
+		if retries > 0 {
+			progress.rewind()
+		}
+		progress.Read(make([]byte, 40))
+		if retries < 2 {
+			return slowDown
+		}
+		progress.Read(make([]byte, 60))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retries != 2 {
+		t.Fatalf("Expected 2 retries, got %d", retries)
+	}
+	if n := pg.Get(); n != 100 {
+		t.Fatalf("Expected 100 bytes of progress, got %d", n)
+	}
+}
@@ -26,9 +26,11 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/juju/ratelimit"
 	"github.com/minio/cli"
 	json "github.com/minio/colorjson"
 	"github.com/minio/mc/pkg/probe"
@@ -101,6 +103,23 @@ var (
 			Usage:  "attempt a prefix purge, requires confirmation please use with caution - only works with '--force'",
 			Hidden: true,
 		},
+		cli.IntFlag{
+			Name:  "delete-workers",
+			Value: 1,
+			Usage: "number of parallel bulk-delete workers per target, objects are sharded across them by name",
+		},
+		cli.StringFlag{
+			Name:  "rate",
+			Usage: "limit delete calls, each removing up to 1000 objects of a bucket or one local file, to a maximum number per second 'N/s', minute 'N/m' or hour 'N/h' (default: unlimited)",
+		},
+		cli.BoolFlag{
+			Name:  "progress",
+			Usage: "show a progress bar with the removal rate instead of the removed objects",
+		},
+		cli.BoolFlag{
+			Name:  "summary",
+			Usage: "print the number of removed objects, versions, non-current versions and delete markers",
+		},
 	}
 )
 
@@ -111,7 +130,7 @@ var rmCmd = cli.Command{
 	Action:       mainRm,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(rmFlags, globalFlags...),
+	Flags:        append(append(append(append(rmFlags, concurrencyFlags...), retryFlags...), failedReportFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -162,6 +181,22 @@ EXAMPLES:
   14. Perform a fake removal of object(s) versions that are non-current and older than 10 days. If top-level version is a delete 
   marker, this will also be deleted when --non-current flag is specified.
       {{.Prompt}} {{.HelpName}} s3/docs/ --recursive --force --versions --non-current --older-than 10d --dry-run
+
+  15. Remove the prefixes listed in a file, four at a time. Without --max-workers, --min-workers or
+      --concurrency-mode the targets are removed one after the other.
+      {{.Prompt}} cat prefixes.txt | {{.HelpName}} --stdin --recursive --force --concurrency-mode fixed --max-workers 4
+
+  16. Remove a prefix recursively, retrying every object up to 3 times on transient errors.
+      {{.Prompt}} {{.HelpName}} --recursive --force --max-retries 3 s3/jazz-songs/louis/
+
+  17. Remove a prefix recursively, writing the objects which failed to a report, retry them with 'mc replay'.
+      {{.Prompt}} {{.HelpName}} --recursive --force --failed-report failed.json s3/jazz-songs/louis/
+
+  18. Purge a large prefix with 16 bulk-delete workers, showing the progress.
+      {{.Prompt}} {{.HelpName}} --recursive --force --delete-workers 16 --progress s3/jazz-songs/louis/
+
+  19. Remove all versions under a prefix with at most 5 delete calls per second, printing the counts per kind at the end.
+      {{.Prompt}} {{.HelpName}} --recursive --force --versions --rate 5/s --summary s3/jazz-songs/louis/
 `,
 }
 
@@ -173,6 +208,7 @@ type rmMessage struct {
 	VersionID    string     `json:"versionID"`
 	ModTime      *time.Time `json:"modTime"`
 	DryRun       bool       `json:"dryRun"`
+	Retries      int        `json:"retries"`
 }
 
 // Colorized message for console printing.
@@ -319,7 +355,9 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 				_, ok := pErr.ToGoError().(ObjectMissing)
 				ignoreStatError = (st == http.StatusServiceUnavailable || ok || st == http.StatusNotFound) && (opts.isForce && opts.isForceDel)
 				if !ignoreStatError {
-					errorIf(pErr.Trace(url), "Failed to remove `"+url+"`.")
+					opts.errorIf(pErr.Trace(url), "Failed to remove `"+url+"`.")
+					opts.report.add("", url, versionID, pErr)
+					atomic.AddInt64(&opts.stats.failed, 1)
 					return exitStatus(globalErrorExitStatus)
 				}
 			}
@@ -330,7 +368,7 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 
 		// We should not proceed
 		if ignoreStatError && opts.olderThan != "" || opts.newerThan != "" {
-			errorIf(pErr.Trace(url), "Unable to stat `"+url+"`.")
+			opts.errorIf(pErr.Trace(url), "Unable to stat `"+url+"`.")
 			return exitStatus(globalErrorExitStatus)
 		}
 
@@ -345,6 +383,9 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 		}
 
 		if opts.isFake {
+			if content != nil {
+				opts.stats.add(rmContentEntryType(content))
+			}
 			printDryRunMsg(targetAlias, content, opts.withVersions)
 			return nil
 		}
@@ -352,7 +393,7 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 
 	clnt, pErr := newClientFromAlias(targetAlias, targetURL)
 	if pErr != nil {
-		errorIf(pErr.Trace(url), "Invalid argument `"+url+"`.")
+		opts.errorIf(pErr.Trace(url), "Invalid argument `"+url+"`.")
 		return exitStatus(globalErrorExitStatus) // End of journey.
 	}
 
@@ -360,31 +401,50 @@ func removeSingle(url, versionID string, opts removeOpts) error {
 		targetURL = targetURL + string(clnt.GetURL().Separator)
 	}
 
-	contentCh := make(chan *ClientContent, 1)
+	if !opts.waitRate(ctx) {
+		return exitStatus(globalErrorExitStatus)
+	}
+	opts.listed()
+
 	contentURL := *newClientURL(targetURL)
-	contentCh <- &ClientContent{URL: contentURL, VersionID: versionID}
-	close(contentCh)
 	isRemoveBucket := false
-	resultCh := clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, opts.isForce && opts.isForceDel, contentCh)
-	for result := range resultCh {
-		if result.Err != nil {
-			errorIf(result.Err.Trace(url), "Failed to remove `"+url+"`.")
-			switch result.Err.ToGoError().(type) {
-			case PathInsufficientPermission:
-				// Ignore Permission error.
+	var results []RemoveResult
+	retries, pErr := retryObjectWithMessage(ctx, opts.retry, "", url, func(int) *probe.Error {
+		contentCh := make(chan *ClientContent, 1)
+		contentCh <- &ClientContent{URL: contentURL, VersionID: versionID}
+		close(contentCh)
+
+		var rerr *probe.Error
+		results = nil
+		for result := range clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, opts.isForce && opts.isForceDel, contentCh) {
+			if result.Err != nil {
+				rerr = result.Err
 				continue
 			}
+			results = append(results, result)
+		}
+		return rerr
+	})
+	if pErr != nil {
+		opts.errorIf(pErr.Trace(url), "Failed to remove `"+url+"`.")
+		opts.report.add("", url, versionID, pErr)
+		atomic.AddInt64(&opts.stats.failed, 1)
+		if _, ok := pErr.ToGoError().(PathInsufficientPermission); !ok {
 			return exitStatus(globalErrorExitStatus)
 		}
+	}
+	for _, result := range results {
 		msg := rmMessage{
 			Key:       path.Join(targetAlias, result.BucketName, result.ObjectName),
 			VersionID: result.ObjectVersionID,
+			Retries:   retries,
 		}
 		if result.DeleteMarker {
 			msg.DeleteMarker = true
 			msg.VersionID = result.DeleteMarkerVersionID
 		}
-		printMsg(msg)
+		opts.removed(rmResultEntryType(result, rmEntryObject, false))
+		opts.printMsg(msg)
 	}
 	return nil
 }
@@ -401,6 +461,53 @@ type removeOpts struct {
 	isForceDel        bool
 	olderThan         string
 	newerThan         string
+	retry             retryPolicy
+	report            *failedReport
+	workers           int
+	limiter           *ratelimit.Bucket
+	progress          *ProgressStatus
+	stats             *rmStats
+	// Keeps removing after any error, for find --continue-on-error.
+	continueOnError bool
+}
+
+// retryRemoveResult - removes again an object which failed to be removed
+// with a retryable error, the result of the last attempt is returned with
+// the number of retries.
+func retryRemoveResult(ctx context.Context, clnt Client, targetAlias string, result RemoveResult, opts removeOpts) (RemoveResult, int) {
+	if result.Err == nil || result.ObjectName == "" || opts.retry.maxRetries == 0 {
+		return result, 0
+	}
+
+	separator := string(clnt.GetURL().Separator)
+	contentURL := clnt.GetURL()
+	contentURL.Path = result.ObjectName
+	if result.BucketName != "" {
+		contentURL.Path = separator + result.BucketName + separator + result.ObjectName
+	}
+	content := &ClientContent{URL: contentURL, VersionID: result.ObjectVersionID}
+
+	retries, err := retryObjectWithMessage(ctx, opts.retry, "", path.Join(targetAlias, result.BucketName, result.ObjectName), func(retries int) *probe.Error {
+		// The first attempt is the one which already failed.
+		if retries == 0 {
+			return result.Err
+		}
+		contentCh := make(chan *ClientContent, 1)
+		contentCh <- content
+		close(contentCh)
+
+		var rerr *probe.Error
+		for res := range clnt.Remove(ctx, opts.isIncomplete, false, opts.isBypass, false, contentCh) {
+			if res.Err != nil {
+				rerr = res.Err
+				continue
+			}
+			result = res
+		}
+		return rerr
+	})
+	result.Err = err
+	return result, retries
 }
 
 func printDryRunMsg(targetAlias string, content *ClientContent, printModTime bool) {
@@ -420,6 +527,7 @@ func printDryRunMsg(targetAlias string, content *ClientContent, printModTime boo
 }
 
 // listAndRemove uses listing before removal, it can list recursively or not, with versions or not.
+// The listed entries are removed by parallel bulk-delete workers.
 //
 //	Use cases:
 //	   * Remove objects recursively
@@ -431,11 +539,9 @@ func listAndRemove(url string, opts removeOpts) error {
 	targetAlias, targetURL, _ := mustExpandAlias(url)
 	clnt, pErr := newClientFromAlias(targetAlias, targetURL)
 	if pErr != nil {
-		errorIf(pErr.Trace(url), "Failed to remove `"+url+"` recursively.")
+		opts.errorIf(pErr.Trace(url), "Failed to remove `"+url+"` recursively.")
 		return exitStatus(globalErrorExitStatus) // End of journey.
 	}
-	contentCh := make(chan *ClientContent)
-	isRemoveBucket := false
 
 	listOpts := ListOptions{Recursive: opts.isRecursive, Incomplete: opts.isIncomplete, ShowDir: DirLast}
 	if !opts.timeRef.IsZero() {
@@ -445,20 +551,68 @@ func listAndRemove(url string, opts removeOpts) error {
 	}
 	atLeastOneObjectFound := false
 
-	resultCh := clnt.Remove(ctx, opts.isIncomplete, isRemoveBucket, opts.isBypass, false, contentCh)
+	remover := newBulkRemover(ctx, cancelRemove, clnt, targetAlias, opts)
+
+	// isSkipped - returns true for prefix levels and for entries out of
+	// --older-than and --newer-than.
+	isSkipped := func(content *ClientContent) bool {
+		if content.Time.IsZero() {
+			// Skip prefix levels.
+			return true
+		}
+		// Skip objects older than --older-than parameter, if specified
+		if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
+			return true
+		}
+		// Skip objects newer than --newer-than parameter if specified
+		return opts.newerThan != "" && isNewer(content.Time, opts.newerThan)
+	}
+
+	// remove - removes an entry, returns false once the removal stopped.
+	remove := func(content *ClientContent, printModTime bool) bool {
+		if opts.isFake {
+			opts.stats.add(rmContentEntryType(content))
+			printDryRunMsg(targetAlias, content, printModTime)
+			return true
+		}
+		return remover.send(content)
+	}
+
+	// removeNonCurrent - removes the non-current versions of an object.
+	removeNonCurrent := func(perObjectVersions []*ClientContent) bool {
+		for _, content := range perObjectVersions {
+			if content.IsLatest && !content.IsDeleteMarker {
+				continue
+			}
+			if isSkipped(content) {
+				continue
+			}
+			if !remove(content, true) {
+				return false
+			}
+		}
+		return true
+	}
 
 	var lastPath string
 	var perObjectVersions []*ClientContent
+	listingErr := false
+	stopped := false
 	for content := range clnt.List(ctx, listOpts) {
 		if content.Err != nil {
-			errorIf(content.Err.Trace(url), "Failed to remove `"+url+"` recursively.")
+			if ctx.Err() != nil {
+				// Removal stopped by a failed removal.
+				stopped = true
+				break
+			}
+			opts.errorIf(content.Err.Trace(url), "Failed to remove `"+url+"` recursively.")
 			switch content.Err.ToGoError().(type) {
 			case PathInsufficientPermission:
 				// Ignore Permission error.
 				continue
 			}
-			close(contentCh)
-			return exitStatus(globalErrorExitStatus)
+			listingErr = true
+			break
 		}
 
 		urlString := content.URL.Path
@@ -479,59 +633,9 @@ func listAndRemove(url string, opts removeOpts) error {
 		if opts.nonCurrentVersion && opts.isRecursive && opts.withVersions {
 			if lastPath != content.URL.Path {
 				lastPath = content.URL.Path
-				for _, content := range perObjectVersions {
-					if content.IsLatest && !content.IsDeleteMarker {
-						continue
-					}
-					if !content.Time.IsZero() {
-						// Skip objects older than --older-than parameter, if specified
-						if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
-							continue
-						}
-
-						// Skip objects newer than --newer-than parameter if specified
-						if opts.newerThan != "" && isNewer(content.Time, opts.newerThan) {
-							continue
-						}
-					} else {
-						// Skip prefix levels.
-						continue
-					}
-
-					if opts.isFake {
-						printDryRunMsg(targetAlias, content, true)
-						continue
-					}
-
-					sent := false
-					for !sent {
-						select {
-						case contentCh <- content:
-							sent = true
-						case result := <-resultCh:
-							path := path.Join(targetAlias, result.BucketName, result.ObjectName)
-							if result.Err != nil {
-								errorIf(result.Err.Trace(path),
-									"Failed to remove `"+path+"`.")
-								switch result.Err.ToGoError().(type) {
-								case PathInsufficientPermission:
-									// Ignore Permission error.
-									continue
-								}
-								close(contentCh)
-								return exitStatus(globalErrorExitStatus)
-							}
-							msg := rmMessage{
-								Key:       path,
-								VersionID: result.ObjectVersionID,
-							}
-							if result.DeleteMarker {
-								msg.DeleteMarker = true
-								msg.VersionID = result.DeleteMarkerVersionID
-							}
-							printMsg(msg)
-						}
-					}
+				if !removeNonCurrent(perObjectVersions) {
+					stopped = true
+					break
 				}
 				perObjectVersions = []*ClientContent{}
 			}
@@ -545,141 +649,24 @@ func listAndRemove(url string, opts removeOpts) error {
 		// inform the user that he was searching in an empty area
 		atLeastOneObjectFound = true
 
-		if !content.Time.IsZero() {
-			// Skip objects older than --older-than parameter, if specified
-			if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
-				continue
-			}
-
-			// Skip objects newer than --newer-than parameter if specified
-			if opts.newerThan != "" && isNewer(content.Time, opts.newerThan) {
-				continue
-			}
-		} else {
-			// Skip prefix levels.
+		if isSkipped(content) {
 			continue
 		}
-
-		if !opts.isFake {
-			sent := false
-			for !sent {
-				select {
-				case contentCh <- content:
-					sent = true
-				case result := <-resultCh:
-					path := path.Join(targetAlias, result.BucketName, result.ObjectName)
-					if result.Err != nil {
-						errorIf(result.Err.Trace(path),
-							"Failed to remove `"+path+"`.")
-						switch e := result.Err.ToGoError().(type) {
-						case PathInsufficientPermission:
-							// Ignore Permission error.
-							continue
-						case minio.ErrorResponse:
-							if strings.Contains(e.Message, "Object is WORM protected and cannot be overwritten") {
-								continue
-							}
-						}
-						close(contentCh)
-						return exitStatus(globalErrorExitStatus)
-					}
-					msg := rmMessage{
-						Key:       path,
-						VersionID: result.ObjectVersionID,
-					}
-					if result.DeleteMarker {
-						msg.DeleteMarker = true
-						msg.VersionID = result.DeleteMarkerVersionID
-					}
-					printMsg(msg)
-				}
-			}
-		} else {
-			printDryRunMsg(targetAlias, content, opts.withVersions)
+		if !remove(content, opts.withVersions) {
+			stopped = true
+			break
 		}
 	}
 
-	if opts.nonCurrentVersion && opts.isRecursive && opts.withVersions {
-		for _, content := range perObjectVersions {
-			if content.IsLatest && !content.IsDeleteMarker {
-				continue
-			}
-			if !content.Time.IsZero() {
-				// Skip objects older than --older-than parameter, if specified
-				if opts.olderThan != "" && isOlder(content.Time, opts.olderThan) {
-					continue
-				}
-
-				// Skip objects newer than --newer-than parameter if specified
-				if opts.newerThan != "" && isNewer(content.Time, opts.newerThan) {
-					continue
-				}
-			} else {
-				// Skip prefix levels.
-				continue
-			}
-
-			if opts.isFake {
-				printDryRunMsg(targetAlias, content, true)
-				continue
-			}
-
-			sent := false
-			for !sent {
-				select {
-				case contentCh <- content:
-					sent = true
-				case result := <-resultCh:
-					path := path.Join(targetAlias, result.BucketName, result.ObjectName)
-					if result.Err != nil {
-						errorIf(result.Err.Trace(path),
-							"Failed to remove `"+path+"`.")
-						switch result.Err.ToGoError().(type) {
-						case PathInsufficientPermission:
-							// Ignore Permission error.
-							continue
-						}
-						close(contentCh)
-						return exitStatus(globalErrorExitStatus)
-					}
-					msg := rmMessage{
-						Key:       path,
-						VersionID: result.ObjectVersionID,
-					}
-					if result.DeleteMarker {
-						msg.DeleteMarker = true
-						msg.VersionID = result.DeleteMarkerVersionID
-					}
-					printMsg(msg)
-				}
-			}
-		}
+	if !stopped && !listingErr && opts.nonCurrentVersion && opts.isRecursive && opts.withVersions {
+		removeNonCurrent(perObjectVersions)
 	}
 
-	close(contentCh)
-	if opts.isFake {
-		return nil
+	if err := remover.wait(); err != nil {
+		return err
 	}
-	for result := range resultCh {
-		path := path.Join(targetAlias, result.BucketName, result.ObjectName)
-		if result.Err != nil {
-			errorIf(result.Err.Trace(path), "Failed to remove `"+path+"` recursively.")
-			switch result.Err.ToGoError().(type) {
-			case PathInsufficientPermission:
-				// Ignore Permission error.
-				continue
-			}
-			return exitStatus(globalErrorExitStatus)
-		}
-		msg := rmMessage{
-			Key:       path,
-			VersionID: result.ObjectVersionID,
-		}
-		if result.DeleteMarker {
-			msg.DeleteMarker = true
-			msg.VersionID = result.DeleteMarkerVersionID
-		}
-		printMsg(msg)
+	if stopped || listingErr {
+		return exitStatus(globalErrorExitStatus)
 	}
 
 	if !atLeastOneObjectFound {
@@ -688,7 +675,7 @@ func listAndRemove(url string, opts removeOpts) error {
 			// behavior and do not print an error as well.
 			return nil
 		}
-		errorIf(errDummy().Trace(url), "No object/version found to be removed in `"+url+"`.")
+		opts.errorIf(errDummy().Trace(url), "No object/version found to be removed in `"+url+"`.")
 		return exitStatus(globalErrorExitStatus)
 	}
 
@@ -702,10 +689,40 @@ func mainRm(cliCtx *cli.Context) error {
 
 	checkRmSyntax(ctx, cliCtx)
 
+	// Support multiple targets.
+	targetCh := make(chan rmTarget)
+	go func() {
+		defer close(targetCh)
+		for _, url := range cliCtx.Args() {
+			targetCh <- rmTarget{url: url}
+		}
+		if cliCtx.Bool("stdin") {
+			scanner := bufio.NewScanner(os.Stdin)
+			for scanner.Scan() {
+				targetCh <- rmTarget{url: scanner.Text()}
+			}
+		}
+	}()
+
+	// Targets are removed one after the other unless the concurrency
+	// flags are specified.
+	parallelize := cliCtx.IsSet("max-workers") || cliCtx.IsSet("min-workers") || cliCtx.IsSet("concurrency-mode")
+	return doRemove(cliCtx, targetCh, parallelize)
+}
+
+// rmTarget - an object or a prefix to remove, along with the version
+// to remove when it is not given by --version-id.
+type rmTarget struct {
+	url       string
+	versionID string
+}
+
+// doRemove - removes the targets with the flags of the rm command line,
+// one after the other or in parallel. The first error is returned.
+func doRemove(cliCtx *cli.Context, targetCh <-chan rmTarget, parallelize bool) error {
 	isIncomplete := cliCtx.Bool("incomplete")
 	isRecursive := cliCtx.Bool("recursive")
 	isFake := cliCtx.Bool("dry-run") || cliCtx.Bool("fake")
-	isStdin := cliCtx.Bool("stdin")
 	isBypass := cliCtx.Bool("bypass")
 	olderThan := cliCtx.String("older-than")
 	newerThan := cliCtx.String("newer-than")
@@ -723,48 +740,37 @@ func mainRm(cliCtx *cli.Context) error {
 	// Set color.
 	console.SetColor("Removed", color.New(color.FgGreen, color.Bold))
 
-	var rerr error
-	var e error
-	// Support multiple targets.
-	for _, url := range cliCtx.Args() {
-		if isRecursive || withVersions {
-			e = listAndRemove(url, removeOpts{
-				timeRef:           rewind,
-				withVersions:      withVersions,
-				nonCurrentVersion: withNoncurrentVersion,
-				isForce:           isForce,
-				isRecursive:       isRecursive,
-				isIncomplete:      isIncomplete,
-				isFake:            isFake,
-				isBypass:          isBypass,
-				olderThan:         olderThan,
-				newerThan:         newerThan,
-			})
-		} else {
-			e = removeSingle(url, versionID, removeOpts{
-				isIncomplete: isIncomplete,
-				isFake:       isFake,
-				isForce:      isForce,
-				isForceDel:   isForceDel,
-				isBypass:     isBypass,
-				olderThan:    olderThan,
-				newerThan:    newerThan,
-			})
-		}
-		if rerr == nil {
-			rerr = e
-		}
+	parallelOpts, err := parallelOptionsFromContext(cliCtx)
+	fatalIf(err, "Unable to parse concurrency flags.")
+	retryPolicy := retryPolicyFromContext(cliCtx)
+
+	report, err := newFailedReport(cliCtx)
+	fatalIf(err, "Unable to create the failed objects report.")
+	defer func() {
+		errorIf(report.Close(), "Unable to close the failed objects report.")
+	}()
+
+	var limiter *ratelimit.Bucket
+	if cliCtx.IsSet("rate") {
+		rate, err := parseRemoveRate(cliCtx.String("rate"))
+		fatalIf(err, "Unable to parse --rate.")
+		limiter = newRemoveLimiter(rate)
+	}
+	deleteWorkers := cliCtx.Int("delete-workers")
+	if deleteWorkers < 1 {
+		fatalIf(errInvalidArgument().Trace(cliCtx.String("delete-workers")), "--delete-workers must be at least 1.")
 	}
 
-	if !isStdin {
-		return rerr
+	// The progress bar replaces the removed objects messages.
+	var progress *ProgressStatus
+	if cliCtx.Bool("progress") && !globalQuiet && !globalJSON && !isFake {
+		progress = newRemoveProgress()
 	}
+	stats := &rmStats{}
 
-	scanner := bufio.NewScanner(os.Stdin)
-	for scanner.Scan() {
-		url := scanner.Text()
+	removeURL := func(url, versionID string) error {
 		if isRecursive || withVersions {
-			e = listAndRemove(url, removeOpts{
+			return listAndRemove(url, removeOpts{
 				timeRef:           rewind,
 				withVersions:      withVersions,
 				nonCurrentVersion: withNoncurrentVersion,
@@ -775,22 +781,79 @@ func mainRm(cliCtx *cli.Context) error {
 				isBypass:          isBypass,
 				olderThan:         olderThan,
 				newerThan:         newerThan,
-			})
-		} else {
-			e = removeSingle(url, versionID, removeOpts{
-				isIncomplete: isIncomplete,
-				isFake:       isFake,
-				isForce:      isForce,
-				isForceDel:   isForceDel,
-				isBypass:     isBypass,
-				olderThan:    olderThan,
-				newerThan:    newerThan,
+				retry:             retryPolicy,
+				report:            report,
+				workers:           deleteWorkers,
+				limiter:           limiter,
+				progress:          progress,
+				stats:             stats,
 			})
 		}
-		if rerr == nil {
+		return removeSingle(url, versionID, removeOpts{
+			isIncomplete: isIncomplete,
+			isFake:       isFake,
+			isForce:      isForce,
+			isForceDel:   isForceDel,
+			isBypass:     isBypass,
+			olderThan:    olderThan,
+			newerThan:    newerThan,
+			retry:        retryPolicy,
+			report:       report,
+			limiter:      limiter,
+			progress:     progress,
+			stats:        stats,
+		})
+	}
+
+	var rerr error
+	removeTarget := func(target rmTarget) {
+		if e := removeURL(target.url, target.versionID); rerr == nil {
 			rerr = e
 		}
 	}
+	var parallel *ParallelManager
+	var resultCh chan URLs
+	var doneCh chan error
+	if parallelize {
+		resultCh = make(chan URLs)
+		parallel = newParallelManager(resultCh, parallelOpts)
+		doneCh = make(chan error)
+		go func() {
+			var perr error
+			for result := range resultCh {
+				if perr == nil && result.Error != nil {
+					perr = result.Error.ToGoError()
+				}
+			}
+			doneCh <- perr
+		}()
+		removeTarget = func(target rmTarget) {
+			parallel.queueTask(func() URLs {
+				if e := removeURL(target.url, target.versionID); e != nil {
+					return URLs{Error: probe.NewError(e)}
+				}
+				return URLs{}
+			}, 0)
+		}
+	}
 
+	for target := range targetCh {
+		if target.versionID == "" {
+			target.versionID = versionID
+		}
+		removeTarget(target)
+	}
+
+	if parallel != nil {
+		parallel.stopAndWait()
+		close(resultCh)
+		rerr = <-doneCh
+	}
+	if progress != nil {
+		progress.Finish()
+	}
+	if cliCtx.Bool("summary") {
+		printMsg(stats.summary(isFake))
+	}
 	return rerr
 }
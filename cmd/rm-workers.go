@@ -0,0 +1,456 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cheggaaa/pb"
+	"github.com/dustin/go-humanize"
+	"github.com/juju/ratelimit"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+)
+
+// rmEntryType - the kind of a removed entry, counted by --summary.
+type rmEntryType int
+
+const (
+	rmEntryObject rmEntryType = iota
+	rmEntryVersion
+	rmEntryNonCurrent
+	rmEntryDeleteMarker
+	rmEntryDeleteMarkerCreated
+)
+
+// rmContentEntryType - returns the kind of a listed entry.
+func rmContentEntryType(content *ClientContent) rmEntryType {
+	switch {
+	case content.IsDeleteMarker:
+		return rmEntryDeleteMarker
+	case content.VersionID == "":
+		return rmEntryObject
+	case content.IsLatest:
+		return rmEntryVersion
+	default:
+		return rmEntryNonCurrent
+	}
+}
+
+// rmResultEntryType - returns the kind of a removed entry, listed
+// entries are classified when listed, the result only tells whether
+// a delete marker was created.
+func rmResultEntryType(result RemoveResult, listed rmEntryType, isListed bool) rmEntryType {
+	switch {
+	case result.DeleteMarker && result.ObjectVersionID == "":
+		return rmEntryDeleteMarkerCreated
+	case isListed:
+		return listed
+	case result.DeleteMarker:
+		return rmEntryDeleteMarker
+	case result.ObjectVersionID != "":
+		return rmEntryVersion
+	default:
+		return rmEntryObject
+	}
+}
+
+// rmStats - counts the entries listed and removed by all the targets.
+type rmStats struct {
+	listed               int64
+	objects              int64
+	versions             int64
+	nonCurrent           int64
+	deleteMarkers        int64
+	deleteMarkersCreated int64
+	failed               int64
+}
+
+// add - counts a removed entry.
+func (s *rmStats) add(t rmEntryType) {
+	switch t {
+	case rmEntryObject:
+		atomic.AddInt64(&s.objects, 1)
+	case rmEntryVersion:
+		atomic.AddInt64(&s.versions, 1)
+	case rmEntryNonCurrent:
+		atomic.AddInt64(&s.versions, 1)
+		atomic.AddInt64(&s.nonCurrent, 1)
+	case rmEntryDeleteMarker:
+		atomic.AddInt64(&s.deleteMarkers, 1)
+	case rmEntryDeleteMarkerCreated:
+		atomic.AddInt64(&s.deleteMarkersCreated, 1)
+	}
+}
+
+// summary - returns the --summary message of the counts.
+func (s *rmStats) summary(dryRun bool) rmSummaryMessage {
+	msg := rmSummaryMessage{
+		Objects:              atomic.LoadInt64(&s.objects),
+		Versions:             atomic.LoadInt64(&s.versions),
+		NonCurrent:           atomic.LoadInt64(&s.nonCurrent),
+		DeleteMarkers:        atomic.LoadInt64(&s.deleteMarkers),
+		DeleteMarkersCreated: atomic.LoadInt64(&s.deleteMarkersCreated),
+		Failed:               atomic.LoadInt64(&s.failed),
+		DryRun:               dryRun,
+	}
+	msg.Removed = msg.Objects + msg.Versions + msg.DeleteMarkers + msg.DeleteMarkersCreated
+	return msg
+}
+
+// rmSummaryMessage - the counts of the removed entries per kind.
+type rmSummaryMessage struct {
+	Status               string `json:"status"`
+	Removed              int64  `json:"removed"`
+	Objects              int64  `json:"objects"`
+	Versions             int64  `json:"versions"`
+	NonCurrent           int64  `json:"nonCurrent"`
+	DeleteMarkers        int64  `json:"deleteMarkers"`
+	DeleteMarkersCreated int64  `json:"deleteMarkersCreated"`
+	Failed               int64  `json:"failed"`
+	DryRun               bool   `json:"dryRun"`
+}
+
+// Colorized message for console printing.
+func (s rmSummaryMessage) String() string {
+	msg := "Removed "
+	if s.DryRun {
+		msg = "DRYRUN: Removing "
+	}
+	msg += console.Colorize("Removed", humanize.Comma(s.Removed)+" entries")
+	return msg + fmt.Sprintf(": %s objects, %s versions (%s non-current), %s delete markers, %s delete markers created, %s failed.",
+		humanize.Comma(s.Objects), humanize.Comma(s.Versions), humanize.Comma(s.NonCurrent),
+		humanize.Comma(s.DeleteMarkers), humanize.Comma(s.DeleteMarkersCreated), humanize.Comma(s.Failed))
+}
+
+// JSON'ified message for scripting.
+func (s rmSummaryMessage) JSON() string {
+	s.Status = "success"
+	msgBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(msgBytes)
+}
+
+// parseRemoveRate - parses the value of --rate, a number of delete
+// calls per second 'N/s', minute 'N/m' or hour 'N/h'.
+func parseRemoveRate(value string) (float64, *probe.Error) {
+	count, unit, found := strings.Cut(value, "/")
+	if !found {
+		unit = "s"
+	}
+	per := time.Second
+	switch unit {
+	case "s":
+	case "m":
+		per = time.Minute
+	case "h":
+		per = time.Hour
+	default:
+		return 0, errInvalidArgument().Trace(value)
+	}
+	n, e := strconv.ParseFloat(count, 64)
+	if e != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, errInvalidArgument().Trace(value)
+	}
+	return n / per.Seconds(), nil
+}
+
+// newRemoveLimiter - returns a token bucket allowing rate delete calls
+// per second, with a burst of one second of calls.
+func newRemoveLimiter(rate float64) *ratelimit.Bucket {
+	return ratelimit.NewBucketWithRate(rate, int64(math.Ceil(rate)))
+}
+
+// newRemoveProgress - returns a progress bar counting removed entries
+// out of the entries listed so far.
+func newRemoveProgress() *ProgressStatus {
+	progress := NewProgressStatus(nil).(*ProgressStatus)
+	progress.SetUnits(pb.U_NO)
+	progress.SetCaption("Removing")
+	return progress
+}
+
+// printMsg - prints a removal message unless the progress bar is shown.
+func (opts removeOpts) printMsg(msg message) {
+	if opts.progress != nil {
+		return
+	}
+	printMsg(msg)
+}
+
+// errorIf - prints an error above the progress bar when it is shown.
+func (opts removeOpts) errorIf(err *probe.Error, msg string) {
+	if opts.progress != nil {
+		opts.progress.errorIf(err, msg)
+		return
+	}
+	errorIf(err, msg)
+}
+
+// listed - accounts an entry about to be removed, the progress bar
+// total grows with the listing.
+func (opts removeOpts) listed() {
+	listed := atomic.AddInt64(&opts.stats.listed, 1)
+	if opts.progress != nil {
+		opts.progress.SetTotal(listed)
+	}
+}
+
+// removed - accounts a removed entry.
+func (opts removeOpts) removed(t rmEntryType) {
+	opts.stats.add(t)
+	if opts.progress != nil {
+		opts.progress.Add(1)
+	}
+	metricsObjectDone(0)
+}
+
+// waitRate - waits for the --rate limit to allow a removal, returns
+// false if the context is canceled first.
+func (opts removeOpts) waitRate(ctx context.Context) bool {
+	return waitRemoveLimiter(ctx, opts.limiter)
+}
+
+// waitRemoveLimiter - waits for a delete call to be allowed by limiter,
+// returns false if the context is canceled first.
+func waitRemoveLimiter(ctx context.Context, limiter *ratelimit.Bucket) bool {
+	if limiter == nil {
+		return true
+	}
+	if wait := limiter.Take(1); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// removeLimiterKey - the context key of the --rate limit of S3 delete
+// requests.
+type removeLimiterKey struct{}
+
+// removeRateTransport - waits for the --rate limit of the request context
+// before every S3 delete request, a DeleteObjects request removing up to
+// 1000 objects or the DELETE of a single object.
+type removeRateTransport struct {
+	transport http.RoundTripper
+}
+
+func (t removeRateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter, ok := req.Context().Value(removeLimiterKey{}).(*ratelimit.Bucket)
+	isDelete := req.Method == http.MethodDelete || req.Method == http.MethodPost && req.URL.Query().Has("delete")
+	if ok && isDelete {
+		if !waitRemoveLimiter(req.Context(), limiter) {
+			return nil, req.Context().Err()
+		}
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// isIgnoredRemoveError - returns true if the removal of the remaining
+// entries goes on after this error.
+func isIgnoredRemoveError(err *probe.Error) bool {
+	switch e := err.ToGoError().(type) {
+	case PathInsufficientPermission:
+		return true
+	case minio.ErrorResponse:
+		return strings.Contains(e.Message, "Object is WORM protected and cannot be overwritten")
+	}
+	return false
+}
+
+// rmEntryKey - identifies an entry across listing and removal results.
+func rmEntryKey(name, versionID string) string {
+	return strings.TrimPrefix(filepath.ToSlash(name), "/") + "\x00" + versionID
+}
+
+// bulkRemoveWorker - removes the entries of one shard with its own
+// bulk delete calls.
+type bulkRemoveWorker struct {
+	contentCh chan *ClientContent
+
+	mu      sync.Mutex
+	pending map[string]rmEntryType
+}
+
+// sent - remembers the kind of an entry until its result arrives.
+func (w *bulkRemoveWorker) sent(content *ClientContent) {
+	w.mu.Lock()
+	w.pending[rmEntryKey(content.URL.Path, content.VersionID)] = rmContentEntryType(content)
+	w.mu.Unlock()
+}
+
+// done - returns the kind of the entry of a result.
+func (w *bulkRemoveWorker) done(result RemoveResult) (rmEntryType, bool) {
+	key := rmEntryKey(path.Join(result.BucketName, result.ObjectName), result.ObjectVersionID)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	t, ok := w.pending[key]
+	delete(w.pending, key)
+	return t, ok
+}
+
+// bulkRemover - removes the listed entries of a target with parallel
+// bulk-delete workers. Entries are sharded across the workers by their
+// object name, so all the versions of an object are removed in order
+// by the same worker.
+type bulkRemover struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	clnt        Client
+	targetAlias string
+	opts        removeOpts
+	workers     []*bulkRemoveWorker
+	wg          sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// newBulkRemover - starts the workers, cancel stops the listing of the
+// target when a removal fails.
+func newBulkRemover(ctx context.Context, cancel context.CancelFunc, clnt Client, targetAlias string, opts removeOpts) *bulkRemover {
+	r := &bulkRemover{
+		ctx:         ctx,
+		cancel:      cancel,
+		clnt:        clnt,
+		targetAlias: targetAlias,
+		opts:        opts,
+	}
+	// S3 removals are limited per delete request, other removals per
+	// entry.
+	if _, ok := clnt.(*S3Client); ok && opts.limiter != nil {
+		r.ctx = context.WithValue(ctx, removeLimiterKey{}, opts.limiter)
+		r.opts.limiter = nil
+	}
+	workers := opts.workers
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		w := &bulkRemoveWorker{
+			contentCh: make(chan *ClientContent),
+			pending:   make(map[string]rmEntryType),
+		}
+		r.workers = append(r.workers, w)
+		r.wg.Add(1)
+		go r.run(w)
+	}
+	return r
+}
+
+// shard - returns the worker removing the versions of an object.
+func (r *bulkRemover) shard(content *ClientContent) *bulkRemoveWorker {
+	if len(r.workers) == 1 {
+		return r.workers[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(content.URL.Path))
+	return r.workers[h.Sum32()%uint32(len(r.workers))]
+}
+
+// send - hands an entry to its worker after waiting for the --rate
+// limit, returns false once the removal stopped.
+func (r *bulkRemover) send(content *ClientContent) bool {
+	if !r.opts.waitRate(r.ctx) {
+		return false
+	}
+	r.opts.listed()
+
+	w := r.shard(content)
+	w.sent(content)
+	select {
+	case w.contentCh <- content:
+		return true
+	case <-r.ctx.Done():
+		return false
+	}
+}
+
+// run - removes the entries of a worker and handles their results.
+func (r *bulkRemover) run(w *bulkRemoveWorker) {
+	defer r.wg.Done()
+	for result := range r.clnt.Remove(r.ctx, r.opts.isIncomplete, false, r.opts.isBypass, false, w.contentCh) {
+		t, isListed := w.done(result)
+		r.handle(result, t, isListed)
+	}
+}
+
+// handle - retries, reports and prints the result of a removal.
+func (r *bulkRemover) handle(result RemoveResult, t rmEntryType, isListed bool) {
+	result, retries := retryRemoveResult(r.ctx, r.clnt, r.targetAlias, result, r.opts)
+	path := path.Join(r.targetAlias, result.BucketName, result.ObjectName)
+	if result.Err != nil {
+		if r.ctx.Err() != nil {
+			// Removal stopped, either by a previous error or by the user.
+			r.fail(exitStatus(globalErrorExitStatus))
+			return
+		}
+		r.opts.errorIf(result.Err.Trace(path), "Failed to remove `"+path+"`.")
+		r.opts.report.add("", path, result.ObjectVersionID, result.Err)
+		atomic.AddInt64(&r.opts.stats.failed, 1)
+		if !isIgnoredRemoveError(result.Err) && !r.opts.continueOnError {
+			r.fail(exitStatus(globalErrorExitStatus))
+		}
+		return
+	}
+	msg := rmMessage{
+		Key:       path,
+		VersionID: result.ObjectVersionID,
+		Retries:   retries,
+	}
+	if result.DeleteMarker {
+		msg.DeleteMarker = true
+		msg.VersionID = result.DeleteMarkerVersionID
+	}
+	r.opts.removed(rmResultEntryType(result, t, isListed))
+	r.opts.printMsg(msg)
+}
+
+// fail - records the first error and stops the removal.
+func (r *bulkRemover) fail(err error) {
+	r.errOnce.Do(func() {
+		r.err = err
+		r.cancel()
+	})
+}
+
+// wait - closes the workers once all entries are sent, returns the
+// first error.
+func (r *bulkRemover) wait() error {
+	for _, w := range r.workers {
+		close(w.contentCh)
+	}
+	r.wg.Wait()
+	return r.err
+}
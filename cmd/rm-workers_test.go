@@ -0,0 +1,182 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/juju/ratelimit"
+)
+
+func TestParseRemoveRate(t *testing.T) {
+	testCases := []struct {
+		value string
+		rate  float64
+	}{
+		{"100", 100},
+		{"100/s", 100},
+		{"0.5/s", 0.5},
+		{"120/m", 2},
+		{"3600/h", 1},
+	}
+	for _, testCase := range testCases {
+		rate, err := parseRemoveRate(testCase.value)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.value, err)
+		}
+		if rate != testCase.rate {
+			t.Errorf("%s: expected %v, got %v", testCase.value, testCase.rate, rate)
+		}
+	}
+
+	for _, value := range []string{"", "0/s", "-1/s", "ten/s", "10/d", "10/"} {
+		if _, err := parseRemoveRate(value); err == nil {
+			t.Errorf("%s: expected an invalid rate", value)
+		}
+	}
+}
+
+func TestRmEntryType(t *testing.T) {
+	testCases := []struct {
+		content  ClientContent
+		result   RemoveResult
+		expected rmEntryType
+	}{
+		{ClientContent{}, RemoveResult{}, rmEntryObject},
+		{ClientContent{VersionID: "v1", IsLatest: true}, RemoveResult{}, rmEntryVersion},
+		{ClientContent{VersionID: "v1"}, RemoveResult{}, rmEntryNonCurrent},
+		{ClientContent{VersionID: "v1", IsDeleteMarker: true}, RemoveResult{}, rmEntryDeleteMarker},
+	}
+	for i, testCase := range testCases {
+		listed := rmContentEntryType(&testCase.content)
+		if got := rmResultEntryType(testCase.result, listed, true); got != testCase.expected {
+			t.Errorf("Test %d: expected %v, got %v", i+1, testCase.expected, got)
+		}
+	}
+
+	created := RemoveResult{}
+	created.DeleteMarker = true
+	if got := rmResultEntryType(created, rmEntryObject, true); got != rmEntryDeleteMarkerCreated {
+		t.Errorf("Expected a created delete marker, got %v", got)
+	}
+	removed := RemoveResult{}
+	removed.DeleteMarker = true
+	removed.ObjectVersionID = "v1"
+	if got := rmResultEntryType(removed, rmEntryObject, false); got != rmEntryDeleteMarker {
+		t.Errorf("Expected a removed delete marker, got %v", got)
+	}
+}
+
+func TestBulkRemoverShard(t *testing.T) {
+	r := &bulkRemover{}
+	for i := 0; i < 4; i++ {
+		r.workers = append(r.workers, &bulkRemoveWorker{})
+	}
+	object := &ClientContent{URL: *newClientURL("/bucket/prefix/object")}
+	version := &ClientContent{URL: *newClientURL("/bucket/prefix/object"), VersionID: "v1"}
+	if r.shard(object) != r.shard(version) {
+		t.Fatal("Expected all versions of an object on the same worker")
+	}
+	used := make(map[*bulkRemoveWorker]bool)
+	for i := 0; i < 100; i++ {
+		used[r.shard(&ClientContent{URL: *newClientURL(fmt.Sprintf("/bucket/prefix/%d", i))})] = true
+	}
+	if len(used) != len(r.workers) {
+		t.Fatalf("Expected objects on all %d workers, got %d", len(r.workers), len(used))
+	}
+}
+
+func TestListAndRemoveWorkers(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		files[fmt.Sprintf("dir%d/object%d", i%5, i)] = "data"
+	}
+	_, root := newTestFolder(t, files)
+
+	rate, err := parseRemoveRate("1000/s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := &rmStats{}
+	opts := removeOpts{
+		isRecursive: true,
+		isForce:     true,
+		workers:     4,
+		limiter:     newRemoveLimiter(rate),
+		stats:       stats,
+	}
+	start := time.Now()
+	if e := listAndRemove(root+string(filepath.Separator), opts); e != nil {
+		t.Fatal(e)
+	}
+	if time.Since(start) > 10*time.Second {
+		t.Fatal("Removal took too long")
+	}
+
+	entries, e := os.ReadDir(root)
+	if e != nil && !os.IsNotExist(e) {
+		t.Fatal(e)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("Expected all objects to be removed, found %d entries", len(entries))
+	}
+	summary := stats.summary(false)
+	if summary.Objects < 50 || summary.Failed != 0 || summary.Versions != 0 {
+		t.Fatalf("Unexpected summary %+v", summary)
+	}
+}
+
+func TestRemoveRateTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := http.Client{Transport: removeRateTransport{transport: http.DefaultTransport}}
+
+	// A single delete call is allowed per hour.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	ctx = context.WithValue(ctx, removeLimiterKey{}, ratelimit.NewBucketWithRate(1.0/3600, 1))
+	do := func(method, query string) error {
+		req, e := http.NewRequestWithContext(ctx, method, server.URL+"/bucket"+query, nil)
+		if e != nil {
+			t.Fatal(e)
+		}
+		resp, e := client.Do(req)
+		if e == nil {
+			resp.Body.Close()
+		}
+		return e
+	}
+	if e := do(http.MethodPost, "?delete"); e != nil {
+		t.Fatal(e)
+	}
+	for _, query := range []string{"", "?list-type=2", "?versions"} {
+		if e := do(http.MethodGet, query); e != nil {
+			t.Fatalf("Expected listings not to be limited, got %v", e)
+		}
+	}
+	if e := do(http.MethodDelete, "/object"); e == nil {
+		t.Fatal("Expected the second delete call to wait for the limit")
+	}
+}
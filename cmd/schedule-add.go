@@ -0,0 +1,193 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var scheduleAddCmd = cli.Command{
+	Name:            "add",
+	Usage:           "add an mc command run on a cron schedule",
+	Action:          mainScheduleAdd,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} NAME "CRON" -- COMMAND [ARGUMENTS...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+CRON:
+  Five fields 'MINUTE HOUR DAY-OF-MONTH MONTH DAY-OF-WEEK' in local time. Every field is '*', a value,
+  a range 'a-b' or a list of them separated by ',', with an optional '/step'. Days of the week go
+  from 0 (Sunday) to 7 (Sunday). @hourly, @daily, @weekly, @monthly and @yearly are accepted too.
+
+DESCRIPTION:
+  The mc command line after '--' is run in an mc process of its own by 'mc schedule daemon' or
+  'mc schedule run', it is validated when the job is added. Global flags of the job apply to the
+  job, global flags of the daemon, like --json or --limit-upload, apply to all the jobs.
+
+EXAMPLES:
+  1. Mirror a local folder to a bucket every night at 1:30.
+     {{.Prompt}} {{.HelpName}} nightly "30 1 * * *" -- mirror --remove /var/backups/ myminio/backups/
+
+  2. Remove objects older than 30 days every Sunday.
+     {{.Prompt}} {{.HelpName}} cleanup "0 3 * * 0" -- rm --recursive --force --older-than 30d myminio/backups/tmp/
+
+  3. Copy the logs every 15 minutes during working hours.
+     {{.Prompt}} {{.HelpName}} logs "*/15 8-18 * * 1-5" -- cp --recursive /var/log/app/ myminio/logs/
+`,
+}
+
+// scheduleMessage container for scheduled job messages.
+type scheduleMessage struct {
+	op      string
+	Status  string       `json:"status"`
+	Name    string       `json:"name"`
+	Cron    string       `json:"cron,omitempty"`
+	Command string       `json:"command,omitempty"`
+	Next    *time.Time   `json:"next,omitempty"`
+	LastRun *scheduleRun `json:"lastRun,omitempty"`
+}
+
+// String colorized scheduled job message.
+func (s scheduleMessage) String() string {
+	switch s.op {
+	case "add":
+		msg := console.Colorize("ScheduleName", "`"+s.Name+"`") + " runs " +
+			console.Colorize("ScheduleCmd", "`"+s.Command+"`") + " at " +
+			console.Colorize("ScheduleCron", "`"+s.Cron+"`")
+		if s.Next != nil {
+			msg += ", next run at " + console.Colorize("ScheduleTime", s.Next.Format(printDate))
+		}
+		return "Added " + msg + "."
+	case "remove":
+		return "Removed " + console.Colorize("ScheduleName", "`"+s.Name+"`") + "."
+	}
+
+	msg := console.Colorize("ScheduleName", s.Name) + " " +
+		console.Colorize("ScheduleCron", "["+s.Cron+"] ") +
+		console.Colorize("ScheduleCmd", s.Command)
+	if s.Next != nil {
+		msg += console.Colorize("ScheduleTime", " (next "+s.Next.Format(printDate)+")")
+	}
+	if s.LastRun != nil {
+		lastRun := " (last " + s.LastRun.Start.Local().Format(printDate) + " " + s.LastRun.Summary + ")"
+		if s.LastRun.ExitStatus == 0 {
+			msg += console.Colorize("ScheduleSuccess", lastRun)
+		} else {
+			msg += console.Colorize("ScheduleFailure", lastRun)
+		}
+	}
+	return msg
+}
+
+// JSON jsonified scheduled job message.
+func (s scheduleMessage) JSON() string {
+	s.Status = "success"
+	scheduleMessageBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(scheduleMessageBytes)
+}
+
+// scheduleCommandLine - returns the mc command line of a job, arguments
+// with spaces are quoted.
+func scheduleCommandLine(args []string) string {
+	quoted := make([]string, 0, len(args)+1)
+	quoted = append(quoted, "mc")
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'") {
+			arg = strconv.Quote(arg)
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// setScheduleColors - sets the colors of the scheduled job messages.
+func setScheduleColors() {
+	console.SetColor("ScheduleName", color.New(color.FgYellow, color.Bold))
+	console.SetColor("ScheduleCron", color.New(color.FgGreen))
+	console.SetColor("ScheduleCmd", color.New(color.FgWhite))
+	console.SetColor("ScheduleTime", color.New(color.FgCyan))
+	console.SetColor("ScheduleSuccess", color.New(color.FgGreen))
+	console.SetColor("ScheduleFailure", color.New(color.FgRed, color.Bold))
+}
+
+// checkScheduleAddSyntax - validate all the passed arguments
+func checkScheduleAddSyntax(ctx *cli.Context) (name, cron string, args []string) {
+	args = ctx.Args()
+	if len(args) < 3 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+	name, cron, args = args[0], args[1], args[2:]
+	if args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+	return name, cron, args
+}
+
+// mainScheduleAdd is the handle for "mc schedule add" command.
+func mainScheduleAdd(ctx *cli.Context) error {
+	name, cron, args := checkScheduleAddSyntax(ctx)
+	setScheduleColors()
+
+	fatalIf(checkScheduleName(name), "Invalid job name `"+name+"`.")
+	schedule, err := parseCronSchedule(cron)
+	fatalIf(err, "Unable to add `"+name+"`.")
+	fatalIf(checkScheduleCommand(ctx, args), "Unable to add `"+name+"`.")
+
+	jobs, err := loadScheduleJobs()
+	fatalIf(err, "Unable to load the scheduled jobs.")
+	fatalIf(jobs.add(scheduleJob{
+		Name:    name,
+		Cron:    cron,
+		Args:    args,
+		Created: UTCNow(),
+	}), "Unable to add `"+name+"`.")
+	fatalIf(jobs.save(), "Unable to save the scheduled jobs.")
+
+	msg := scheduleMessage{
+		op:      "add",
+		Name:    name,
+		Cron:    cron,
+		Command: scheduleCommandLine(args),
+	}
+	if next := schedule.next(time.Now()); !next.IsZero() {
+		msg.Next = &next
+	}
+	printMsg(msg)
+	return nil
+}
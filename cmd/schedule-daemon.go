@@ -0,0 +1,117 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+)
+
+var scheduleDaemonCmd = cli.Command{
+	Name:            "daemon",
+	Usage:           "run the scheduled jobs in the foreground",
+	Action:          mainScheduleDaemon,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Jobs run one at a time, a job due while another one runs starts after it. A job never runs
+  twice at the same time, a job still running from 'mc schedule run' or from the previous
+  schedule is skipped. Added and removed jobs are picked up without a restart.
+  Each job runs in an mc process of its own rather than in the daemon, so that a job exiting
+  on an error does not end the daemon, the lock of the job is held by the daemon while it runs.
+  The metrics address of the daemon is not passed on to the jobs.
+  Every run is added to the history of its job under the config folder.
+
+EXAMPLES:
+  1. Run the scheduled jobs, for instance from a systemd unit.
+     {{.Prompt}} {{.HelpName}}
+
+  2. Run the scheduled jobs, logging their runs as JSON.
+     {{.Prompt}} {{.HelpName}} --json >> /var/log/mc-schedule.log
+`,
+}
+
+// checkScheduleDaemonSyntax - validate all the passed arguments
+func checkScheduleDaemonSyntax(ctx *cli.Context) {
+	if ctx.Args().Present() {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+}
+
+// dueScheduleJobs - returns the jobs scheduled after 'from' until 'to'.
+func dueScheduleJobs(jobs []scheduleJob, from, to time.Time) []scheduleJob {
+	var due []scheduleJob
+	for _, job := range jobs {
+		schedule, err := parseCronSchedule(job.Cron)
+		if err != nil {
+			errorIf(err, "Unable to schedule `"+job.Name+"`.")
+			continue
+		}
+		if next := schedule.next(from); !next.IsZero() && !next.After(to) {
+			due = append(due, job)
+		}
+	}
+	return due
+}
+
+// mainScheduleDaemon is the handle for "mc schedule daemon" command.
+func mainScheduleDaemon(ctx *cli.Context) error {
+	checkScheduleDaemonSyntax(ctx)
+	setScheduleColors()
+
+	last := time.Now()
+	for {
+		// Wake up right after every minute.
+		tick := time.NewTimer(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+		select {
+		case <-globalContext.Done():
+			tick.Stop()
+			return nil
+		case <-tick.C:
+		}
+
+		now := time.Now()
+		jobs, err := loadScheduleJobs()
+		if err != nil {
+			errorIf(err, "Unable to load the scheduled jobs.")
+			continue
+		}
+		for _, job := range dueScheduleJobs(jobs.Jobs, last, now) {
+			run, err := runScheduleJob(ctx, job)
+			if run.Start.IsZero() {
+				errorIf(err, "Unable to run `"+job.Name+"`.")
+				continue
+			}
+			errorIf(err, "Unable to save the run of `"+job.Name+"`.")
+			printMsg(scheduleRunMessage{scheduleRun: run})
+		}
+		last = now
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"time"
+
+	"github.com/minio/cli"
+)
+
+var scheduleListCmd = cli.Command{
+	Name:            "list",
+	ShortName:       "ls",
+	Usage:           "list scheduled jobs or the runs of a job",
+	Action:          mainScheduleList,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [NAME]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all the scheduled jobs with their next and last run.
+     {{.Prompt}} {{.HelpName}}
+
+  2. List the run history of the job 'nightly'.
+     {{.Prompt}} {{.HelpName}} nightly
+
+  3. List the failed runs of the job 'nightly'.
+     {{.Prompt}} {{.HelpName}} --json nightly | jq 'select(.exitStatus != 0)'
+`,
+}
+
+// checkScheduleListSyntax - validate all the passed arguments
+func checkScheduleListSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) > 1 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+}
+
+// mainScheduleList is the handle for "mc schedule list" command.
+func mainScheduleList(ctx *cli.Context) error {
+	checkScheduleListSyntax(ctx)
+	setScheduleColors()
+
+	jobs, err := loadScheduleJobs()
+	fatalIf(err, "Unable to load the scheduled jobs.")
+
+	if name := ctx.Args().First(); name != "" {
+		_, err = jobs.get(name)
+		fatalIf(err, "Unable to list the runs of `"+name+"`.")
+		runs, err := readScheduleHistory(name)
+		fatalIf(err, "Unable to list the runs of `"+name+"`.")
+		for _, run := range runs {
+			printMsg(scheduleRunMessage{scheduleRun: run})
+		}
+		return nil
+	}
+
+	now := time.Now()
+	for _, job := range jobs.Jobs {
+		msg := scheduleMessage{
+			op:      "list",
+			Name:    job.Name,
+			Cron:    job.Cron,
+			Command: scheduleCommandLine(job.Args),
+		}
+		if schedule, err := parseCronSchedule(job.Cron); err == nil {
+			if next := schedule.next(now); !next.IsZero() {
+				msg.Next = &next
+			}
+		}
+		runs, err := readScheduleHistory(job.Name)
+		errorIf(err, "Unable to list the runs of `"+job.Name+"`.")
+		if len(runs) > 0 {
+			msg.LastRun = &runs[len(runs)-1]
+		}
+		printMsg(msg)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+)
+
+var scheduleSubcommands = []cli.Command{
+	scheduleAddCmd,
+	scheduleListCmd,
+	scheduleRemoveCmd,
+	scheduleRunCmd,
+	scheduleDaemonCmd,
+}
+
+var scheduleCmd = cli.Command{
+	Name:            "schedule",
+	Usage:           "run mc commands on cron schedules",
+	Action:          mainSchedule,
+	Before:          setGlobalsFromContext,
+	HideHelpCommand: true,
+	Flags:           globalFlags,
+	Subcommands:     scheduleSubcommands,
+}
+
+// mainSchedule is the handle for "mc schedule" command.
+func mainSchedule(ctx *cli.Context) error {
+	commandNotFound(ctx, scheduleSubcommands)
+	return nil
+	// Sub-commands like add, list, remove, run and daemon have their own main.
+}
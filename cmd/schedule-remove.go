@@ -0,0 +1,74 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"github.com/minio/cli"
+)
+
+var scheduleRemoveCmd = cli.Command{
+	Name:            "remove",
+	ShortName:       "rm",
+	Usage:           "remove a scheduled job",
+	Action:          mainScheduleRemove,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} NAME [NAME...]
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  The run history of removed jobs is kept under the config folder.
+
+EXAMPLES:
+  1. Remove the job 'nightly', a running daemon stops running it.
+     {{.Prompt}} {{.HelpName}} nightly
+`,
+}
+
+// checkScheduleRemoveSyntax - validate all the passed arguments
+func checkScheduleRemoveSyntax(ctx *cli.Context) {
+	if !ctx.Args().Present() {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+}
+
+// mainScheduleRemove is the handle for "mc schedule remove" command.
+func mainScheduleRemove(ctx *cli.Context) error {
+	checkScheduleRemoveSyntax(ctx)
+	setScheduleColors()
+
+	jobs, err := loadScheduleJobs()
+	fatalIf(err, "Unable to load the scheduled jobs.")
+	for _, name := range ctx.Args() {
+		fatalIf(jobs.remove(name), "Unable to remove `"+name+"`.")
+	}
+	fatalIf(jobs.save(), "Unable to save the scheduled jobs.")
+
+	for _, name := range ctx.Args() {
+		printMsg(scheduleMessage{op: "remove", Name: name})
+	}
+	return nil
+}
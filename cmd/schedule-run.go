@@ -0,0 +1,115 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var scheduleRunCmd = cli.Command{
+	Name:            "run",
+	Usage:           "run a scheduled job now",
+	Action:          mainScheduleRun,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} NAME
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  The job runs in the foreground, unless it is already running, and its run is added to its
+  history. mc exits with the exit status of the job.
+
+EXAMPLES:
+  1. Run the job 'nightly' now to check it works.
+     {{.Prompt}} {{.HelpName}} nightly
+`,
+}
+
+// scheduleRunMessage container for the run of a scheduled job.
+type scheduleRunMessage struct {
+	Status string `json:"status"`
+	scheduleRun
+}
+
+// String colorized scheduled job run message.
+func (s scheduleRunMessage) String() string {
+	msg := console.Colorize("ScheduleName", s.Name) + " " +
+		console.Colorize("ScheduleTime", "["+s.Start.Local().Format(printDate)+"] ")
+	if s.ExitStatus == 0 {
+		return msg + console.Colorize("ScheduleSuccess", s.Summary)
+	}
+	msg += console.Colorize("ScheduleFailure", s.Summary)
+	if s.Error != "" {
+		msg += console.Colorize("ScheduleFailure", fmt.Sprintf(": %s", s.Error))
+	}
+	return msg
+}
+
+// JSON jsonified scheduled job run message.
+func (s scheduleRunMessage) JSON() string {
+	s.Status = "success"
+	if s.ExitStatus != 0 {
+		s.Status = "failure"
+	}
+	scheduleRunMessageBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(scheduleRunMessageBytes)
+}
+
+// checkScheduleRunSyntax - validate all the passed arguments
+func checkScheduleRunSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+}
+
+// mainScheduleRun is the handle for "mc schedule run" command.
+func mainScheduleRun(ctx *cli.Context) error {
+	checkScheduleRunSyntax(ctx)
+	setScheduleColors()
+
+	name := ctx.Args().First()
+	jobs, err := loadScheduleJobs()
+	fatalIf(err, "Unable to load the scheduled jobs.")
+	job, err := jobs.get(name)
+	fatalIf(err, "Unable to run `"+name+"`.")
+
+	run, err := runScheduleJob(ctx, job)
+	if run.Start.IsZero() {
+		fatalIf(err, "Unable to run `"+name+"`.")
+	}
+	errorIf(err, "Unable to save the run of `"+name+"`.")
+	printMsg(scheduleRunMessage{scheduleRun: run})
+	if run.ExitStatus != 0 {
+		return exitStatus(run.ExitStatus)
+	}
+	return nil
+}
@@ -0,0 +1,762 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+const (
+	scheduleVersion    = "1"
+	scheduleDir        = "schedule"
+	scheduleJobsFile   = "jobs.json"
+	scheduleHistoryDir = "history"
+	scheduleLocksDir   = "locks"
+
+	// Number of runs kept in the history of a job.
+	scheduleHistoryMax = 1000
+)
+
+// Job names are used as file names of the history and the locks.
+var scheduleNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+var (
+	scheduleTemplateRegexp = regexp.MustCompile(`{{[^}]*}}`)
+	scheduleJSONErrRegexp  = regexp.MustCompile(`"status": ?"error"`)
+)
+
+// cronField - the set of values a cron field matches, bit i is value i.
+type cronField uint64
+
+func (f cronField) has(v int) bool {
+	return f&(1<<uint(v)) != 0
+}
+
+// cronSchedule - a five fields cron expression evaluated in local time.
+type cronSchedule struct {
+	minute, hour, dom, month, dow cronField
+	// When both day fields are restricted, a day matching
+	// either of them matches, like cron does.
+	domAny, dowAny bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseCronSchedule - parses 'MINUTE HOUR DAY-OF-MONTH MONTH DAY-OF-WEEK',
+// every field is '*', a value, a range 'a-b' or a list of them separated
+// by ',' with an optional '/step'. Days of the week go from 0 (Sunday) to
+// 7 (Sunday). The macros @hourly, @daily, @weekly, @monthly and @yearly
+// are supported too.
+func parseCronSchedule(spec string) (s cronSchedule, err *probe.Error) {
+	expr := strings.TrimSpace(spec)
+	if macro, ok := cronMacros[expr]; ok {
+		expr = macro
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return s, errInvalidCronSchedule(spec, "want 5 fields")
+	}
+	bounds := []struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	values := make([]cronField, len(fields))
+	for i, field := range fields {
+		v, e := parseCronField(field, bounds[i].min, bounds[i].max)
+		if e != nil {
+			return s, errInvalidCronSchedule(spec, e.Error())
+		}
+		values[i] = v
+	}
+	s = cronSchedule{
+		minute: values[0],
+		hour:   values[1],
+		dom:    values[2],
+		month:  values[3],
+		dow:    values[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}
+	if s.dow.has(7) {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseCronField - parses one field of a cron expression.
+func parseCronField(field string, min, max int) (cronField, error) {
+	var f cronField
+	for _, part := range strings.Split(field, ",") {
+		rangeStr, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var e error
+			if step, e = strconv.Atoi(stepStr); e != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step `%s`", part)
+			}
+		}
+		low, high := min, max
+		if rangeStr != "*" {
+			lowStr, highStr, isRange := strings.Cut(rangeStr, "-")
+			var e error
+			if low, e = strconv.Atoi(lowStr); e != nil {
+				return 0, fmt.Errorf("invalid value `%s`", part)
+			}
+			high = low
+			if isRange {
+				if high, e = strconv.Atoi(highStr); e != nil {
+					return 0, fmt.Errorf("invalid value `%s`", part)
+				}
+			} else if hasStep {
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf("`%s` is out of range %d-%d", part, min, max)
+		}
+		for v := low; v <= high; v += step {
+			f |= 1 << uint(v)
+		}
+	}
+	return f, nil
+}
+
+// matchDay - returns true if the day of t matches the day fields.
+func (s cronSchedule) matchDay(t time.Time) bool {
+	dom, dow := s.dom.has(t.Day()), s.dow.has(int(t.Weekday()))
+	switch {
+	case s.domAny && s.dowAny:
+		return true
+	case s.domAny:
+		return dow
+	case s.dowAny:
+		return dom
+	}
+	return dom || dow
+}
+
+// next - returns the first time after t matching the schedule, the zero
+// time if there is none within five years.
+func (s cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !s.month.has(int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !s.hour.has(t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !s.minute.has(t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// scheduleJob - an mc command line run on a cron schedule.
+type scheduleJob struct {
+	Name    string    `json:"name"`
+	Cron    string    `json:"cron"`
+	Args    []string  `json:"args"`
+	Created time.Time `json:"created"`
+}
+
+// scheduleJobs - the jobs saved under the config folder.
+type scheduleJobs struct {
+	Version string        `json:"version"`
+	Jobs    []scheduleJob `json:"jobs"`
+}
+
+// getScheduleDir - returns the folder of the jobs, their history and
+// their locks.
+func getScheduleDir() (string, *probe.Error) {
+	configDir, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	return filepath.Join(configDir, scheduleDir), nil
+}
+
+// checkScheduleName - validates the name of a job.
+func checkScheduleName(name string) *probe.Error {
+	if !scheduleNameRegexp.MatchString(name) {
+		return errInvalidArgument().Trace(name)
+	}
+	return nil
+}
+
+// loadScheduleJobs - loads the saved jobs sorted by name, no jobs are
+// returned if none were added yet.
+func loadScheduleJobs() (*scheduleJobs, *probe.Error) {
+	dir, err := getScheduleDir()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	jobs := &scheduleJobs{Version: scheduleVersion}
+	name := filepath.Join(dir, scheduleJobsFile)
+	data, e := os.ReadFile(name)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return jobs, nil
+		}
+		return nil, probe.NewError(e).Trace(name)
+	}
+	if e = json.Unmarshal(data, jobs); e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	sort.Slice(jobs.Jobs, func(i, j int) bool {
+		return jobs.Jobs[i].Name < jobs.Jobs[j].Name
+	})
+	return jobs, nil
+}
+
+// save - writes the jobs, replacing the previous ones at once.
+func (s *scheduleJobs) save() *probe.Error {
+	dir, err := getScheduleDir()
+	if err != nil {
+		return err.Trace()
+	}
+	if e := os.MkdirAll(dir, 0o700); e != nil {
+		return probe.NewError(e).Trace(dir)
+	}
+	data, e := json.MarshalIndent(s, "", " ")
+	if e != nil {
+		return probe.NewError(e)
+	}
+	name := filepath.Join(dir, scheduleJobsFile)
+	tmpName := name + ".tmp"
+	if e = os.WriteFile(tmpName, data, 0o600); e != nil {
+		return probe.NewError(e).Trace(tmpName)
+	}
+	if e = os.Rename(tmpName, name); e != nil {
+		return probe.NewError(e).Trace(name)
+	}
+	return nil
+}
+
+// get - returns the job of the given name.
+func (s *scheduleJobs) get(name string) (scheduleJob, *probe.Error) {
+	for _, job := range s.Jobs {
+		if job.Name == name {
+			return job, nil
+		}
+	}
+	return scheduleJob{}, errScheduleJobNotFound(name)
+}
+
+// add - adds a job, names are unique.
+func (s *scheduleJobs) add(job scheduleJob) *probe.Error {
+	if _, err := s.get(job.Name); err == nil {
+		return errScheduleJobExists(job.Name)
+	}
+	s.Jobs = append(s.Jobs, job)
+	return nil
+}
+
+// remove - removes the job of the given name.
+func (s *scheduleJobs) remove(name string) *probe.Error {
+	for i, job := range s.Jobs {
+		if job.Name == name {
+			s.Jobs = append(s.Jobs[:i], s.Jobs[i+1:]...)
+			return nil
+		}
+	}
+	return errScheduleJobNotFound(name)
+}
+
+// scheduleRun - the record of a run of a job in its history.
+type scheduleRun struct {
+	Name       string    `json:"name"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Duration   string    `json:"duration"`
+	ExitStatus int       `json:"exitStatus"`
+	Errors     int       `json:"errors"`
+	Summary    string    `json:"summary"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// scheduleHistoryPath - returns the history file of a job, one JSON
+// run record per line.
+func scheduleHistoryPath(name string) (string, *probe.Error) {
+	dir, err := getScheduleDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	return filepath.Join(dir, scheduleHistoryDir, name+".json"), nil
+}
+
+// appendScheduleHistory - appends a run record to the history of its
+// job, the oldest runs are dropped past scheduleHistoryMax runs.
+func appendScheduleHistory(run scheduleRun) *probe.Error {
+	name, err := scheduleHistoryPath(run.Name)
+	if err != nil {
+		return err.Trace(run.Name)
+	}
+	if e := os.MkdirAll(filepath.Dir(name), 0o700); e != nil {
+		return probe.NewError(e).Trace(name)
+	}
+	runs, err := readScheduleHistory(run.Name)
+	if err != nil {
+		return err.Trace(run.Name)
+	}
+	if len(runs) < scheduleHistoryMax {
+		data, e := json.Marshal(run)
+		if e != nil {
+			return probe.NewError(e)
+		}
+		f, e := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if e != nil {
+			return probe.NewError(e).Trace(name)
+		}
+		defer f.Close()
+		if _, e = f.Write(append(data, '\n')); e != nil {
+			return probe.NewError(e).Trace(name)
+		}
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for _, r := range append(runs[len(runs)-scheduleHistoryMax+1:], run) {
+		data, e := json.Marshal(r)
+		if e != nil {
+			return probe.NewError(e)
+		}
+		buf.Write(append(data, '\n'))
+	}
+	tmpName := name + ".tmp"
+	if e := os.WriteFile(tmpName, buf.Bytes(), 0o600); e != nil {
+		return probe.NewError(e).Trace(tmpName)
+	}
+	if e := os.Rename(tmpName, name); e != nil {
+		return probe.NewError(e).Trace(name)
+	}
+	return nil
+}
+
+// readScheduleHistory - reads the run records of a job, oldest first.
+func readScheduleHistory(jobName string) ([]scheduleRun, *probe.Error) {
+	name, err := scheduleHistoryPath(jobName)
+	if err != nil {
+		return nil, err.Trace(jobName)
+	}
+	f, e := os.Open(name)
+	if e != nil {
+		if os.IsNotExist(e) {
+			return nil, nil
+		}
+		return nil, probe.NewError(e).Trace(name)
+	}
+	defer f.Close()
+
+	var runs []scheduleRun
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var run scheduleRun
+		if e = json.Unmarshal(scanner.Bytes(), &run); e != nil {
+			return nil, probe.NewError(e).Trace(name)
+		}
+		runs = append(runs, run)
+	}
+	if e = scanner.Err(); e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	return runs, nil
+}
+
+// lockScheduleJob - takes the lock of a job so that a job never runs
+// twice at the same time, even from different mc processes. The lock
+// file holds the process id of its owner, locks of processes which
+// are gone are taken over.
+func lockScheduleJob(name string) (unlock func(), err *probe.Error) {
+	dir, err := getScheduleDir()
+	if err != nil {
+		return nil, err.Trace(name)
+	}
+	lockPath := filepath.Join(dir, scheduleLocksDir, name+".lock")
+	if e := os.MkdirAll(filepath.Dir(lockPath), 0o700); e != nil {
+		return nil, probe.NewError(e).Trace(lockPath)
+	}
+	for i := 0; i < 2; i++ {
+		f, e := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if e == nil {
+			_, e = f.WriteString(strconv.Itoa(os.Getpid()))
+			f.Close()
+			if e != nil {
+				os.Remove(lockPath)
+				return nil, probe.NewError(e).Trace(lockPath)
+			}
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(e) {
+			return nil, probe.NewError(e).Trace(lockPath)
+		}
+		data, e := os.ReadFile(lockPath)
+		if e != nil && !os.IsNotExist(e) {
+			return nil, probe.NewError(e).Trace(lockPath)
+		}
+		if pid, e := strconv.Atoi(strings.TrimSpace(string(data))); e == nil {
+			if alive, _ := process.PidExists(int32(pid)); alive {
+				return nil, errScheduleJobRunning(name, pid)
+			}
+		}
+		os.Remove(lockPath)
+	}
+	return nil, errScheduleJobRunning(name, 0)
+}
+
+// scheduleCommand - returns the mc command and its arguments from the
+// command line of a job, subcommands like 'ilm rule add' included.
+func scheduleCommand(commands []cli.Command, args []string) (cli.Command, []string, *probe.Error) {
+	var path []string
+	for len(args) > 0 {
+		var found bool
+		for _, command := range commands {
+			if !command.HasName(args[0]) || command.Name == "help" {
+				continue
+			}
+			path = append(path, command.Name)
+			if command.Name == "schedule" && len(path) == 1 {
+				return cli.Command{}, nil, errScheduleCommand(strings.Join(path, " "))
+			}
+			if len(command.Subcommands) == 0 {
+				return command, args[1:], nil
+			}
+			commands, args, found = command.Subcommands, args[1:], true
+			break
+		}
+		if !found {
+			break
+		}
+	}
+	if len(args) > 0 {
+		path = append(path, args[0])
+	}
+	return cli.Command{}, nil, errScheduleCommand(strings.Join(path, " "))
+}
+
+// scheduleCommandArgs - moves the flags of a command line before its
+// arguments, as mc accepts flags anywhere on its command line. Short
+// flag names are replaced by their long names.
+func scheduleCommandArgs(command cli.Command, args []string) []string {
+	type flagName struct {
+		name   string
+		isBool bool
+	}
+	flagNames := make(map[string]flagName)
+	for _, f := range command.Flags {
+		_, isBool := f.(cli.BoolFlag)
+		names := strings.Split(f.GetName(), ",")
+		for _, name := range names {
+			flagNames[strings.TrimSpace(name)] = flagName{name: strings.TrimSpace(names[0]), isBool: isBool}
+		}
+	}
+	var flagArgs, regularArgs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			regularArgs = append(regularArgs, args[i:]...)
+			i = len(args)
+		case arg == "-" || !strings.HasPrefix(arg, "-"):
+			regularArgs = append(regularArgs, arg)
+		default:
+			name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			f, ok := flagNames[name]
+			if !ok {
+				flagArgs = append(flagArgs, arg)
+				continue
+			}
+			if hasValue {
+				flagArgs = append(flagArgs, "--"+f.name+"="+value)
+				continue
+			}
+			flagArgs = append(flagArgs, "--"+f.name)
+			if !f.isBool && i+1 < len(args) {
+				i++
+				flagArgs = append(flagArgs, args[i])
+			}
+		}
+	}
+	return append(flagArgs, regularArgs...)
+}
+
+// scheduleAppCommands - returns the top level mc commands, subcommands
+// run in an app of their own.
+func scheduleAppCommands(ctx *cli.Context) []cli.Command {
+	for ctx.Parent() != nil {
+		ctx = ctx.Parent()
+	}
+	return ctx.App.Commands
+}
+
+// scheduleCommandMinArgs - returns the number of arguments a command
+// takes at least, from the USAGE section of its help. Optional
+// arguments and flags are in brackets.
+func scheduleCommandMinArgs(command cli.Command) int {
+	_, usage, ok := strings.Cut(command.CustomHelpTemplate, "USAGE:\n")
+	if !ok {
+		return 0
+	}
+	minArgs := -1
+	for _, line := range strings.Split(usage, "\n") {
+		line = strings.TrimSpace(scheduleTemplateRegexp.ReplaceAllString(line, ""))
+		if line == "" {
+			break
+		}
+		var required strings.Builder
+		var depth int
+		for _, r := range line {
+			switch {
+			case r == '[':
+				depth++
+			case r == ']':
+				if depth > 0 {
+					depth--
+				}
+			case depth == 0:
+				required.WriteRune(r)
+			}
+		}
+		if n := len(strings.Fields(required.String())); minArgs < 0 || n < minArgs {
+			minArgs = n
+		}
+	}
+	return max(minArgs, 0)
+}
+
+// checkScheduleCommand - validates the command line of a job: the mc
+// command, its flags, global flags included, and its number of
+// arguments.
+func checkScheduleCommand(parent *cli.Context, args []string) *probe.Error {
+	command, commandArgs, err := scheduleCommand(scheduleAppCommands(parent), args)
+	if err != nil {
+		return err
+	}
+	if _, ok := command.Action.(func(*cli.Context) error); !ok {
+		return errScheduleCommand(command.Name)
+	}
+	ctx, err := sessionCommandContext(parent, command, scheduleCommandArgs(command, commandArgs))
+	if err != nil {
+		return err.Trace(args...)
+	}
+	if minArgs := scheduleCommandMinArgs(command); len(ctx.Args()) < minArgs {
+		return errScheduleCommandArgs(scheduleCommandLine(args), minArgs)
+	}
+	return nil
+}
+
+// scheduleExecutable - returns the mc binary the jobs run with.
+var scheduleExecutable = os.Executable
+
+// scheduleJobEnv - returns the environment of the jobs, the global
+// flags of 'mc schedule' apply to the jobs on top of their own. The
+// metrics address is left out, its port is bound by the daemon.
+func scheduleJobEnv(ctx *cli.Context) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, envPrefix+"METRICS_ADDRESS=") {
+			env = append(env, kv)
+		}
+	}
+	if configDir, err := getMcConfigDir(); err == nil {
+		env = append(env, envPrefix+"CONFIG_DIR="+configDir)
+	}
+	for _, name := range []string{"quiet", "no-color", "json", "debug", "insecure"} {
+		if ctx.IsSet(name) || ctx.GlobalIsSet(name) {
+			env = append(env, scheduleEnvName(name)+"=true")
+		}
+	}
+	for _, name := range []string{"limit-upload", "limit-download", "limit-schedule"} {
+		value := ctx.String(name)
+		if value == "" {
+			value = ctx.GlobalString(name)
+		}
+		if value != "" {
+			env = append(env, scheduleEnvName(name)+"="+value)
+		}
+	}
+	return env
+}
+
+// scheduleEnvName - returns the environment variable of a global flag.
+func scheduleEnvName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// scheduleOutput - counts the errors printed by the command of a job,
+// as text or as JSON, and keeps the last of them.
+type scheduleOutput struct {
+	mu      sync.Mutex
+	errs    int
+	lastErr string
+}
+
+// copy - copies the output of a command line by line to w.
+func (o *scheduleOutput) copy(r io.Reader, w io.Writer) {
+	var inJSONErr bool
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fmt.Fprintln(w, line)
+
+		o.mu.Lock()
+		if _, msg, ok := strings.Cut(line, "<ERROR> "); ok {
+			o.errs++
+			o.lastErr = strings.TrimSpace(msg)
+		} else if scheduleJSONErrRegexp.MatchString(line) {
+			o.errs++
+			var jsonErr struct {
+				Error struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			// Errors are a single line in JSON lines mode.
+			inJSONErr = json.Unmarshal([]byte(line), &jsonErr) != nil
+			if !inJSONErr {
+				o.lastErr = jsonErr.Error.Message
+			}
+		} else if inJSONErr {
+			if _, value, ok := strings.Cut(strings.TrimSpace(line), `"message": `); ok {
+				if msg, e := strconv.Unquote(strings.TrimSuffix(value, ",")); e == nil {
+					o.lastErr = msg
+				}
+				inJSONErr = false
+			}
+		}
+		o.mu.Unlock()
+	}
+	// Drain the rest of an overlong line, the command must not block.
+	io.Copy(w, r)
+}
+
+// runScheduleCommand - runs the command line of a job in an mc process
+// of its own, so that exits and crashes of the command never end the
+// daemon. Returns the exit status of the command, the number of errors
+// it reported and the last of them.
+func runScheduleCommand(ctx *cli.Context, args []string) (status, errs int, lastErr string) {
+	exe, e := scheduleExecutable()
+	if e != nil {
+		return globalErrorExitStatus, 1, e.Error()
+	}
+	cmd := exec.CommandContext(globalContext, exe, args...)
+	cmd.Env = scheduleJobEnv(ctx)
+	stdout, e := cmd.StdoutPipe()
+	if e != nil {
+		return globalErrorExitStatus, 1, e.Error()
+	}
+	stderr, e := cmd.StderrPipe()
+	if e != nil {
+		return globalErrorExitStatus, 1, e.Error()
+	}
+	if e = cmd.Start(); e != nil {
+		return globalErrorExitStatus, 1, e.Error()
+	}
+
+	var output scheduleOutput
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		output.copy(stdout, os.Stdout)
+	}()
+	go func() {
+		defer wg.Done()
+		output.copy(stderr, os.Stderr)
+	}()
+	wg.Wait()
+
+	status, errs, lastErr = 0, output.errs, output.lastErr
+	if e = cmd.Wait(); e != nil {
+		status = globalErrorExitStatus
+		var exitErr *exec.ExitError
+		if errors.As(e, &exitErr) && exitErr.ExitCode() > 0 {
+			status = exitErr.ExitCode()
+		}
+		if lastErr == "" {
+			lastErr = e.Error()
+		}
+	}
+	return status, errs, lastErr
+}
+
+// runScheduleJob - runs the command line of a job while holding its
+// lock and appends the run to the history of the job.
+func runScheduleJob(parent *cli.Context, job scheduleJob) (scheduleRun, *probe.Error) {
+	unlock, err := lockScheduleJob(job.Name)
+	if err != nil {
+		return scheduleRun{}, err
+	}
+	defer unlock()
+
+	run := scheduleRun{Name: job.Name, Start: UTCNow()}
+	if err = checkScheduleCommand(parent, job.Args); err != nil {
+		run.ExitStatus, run.Errors = globalErrorExitStatus, 1
+		run.Error = err.ToGoError().Error()
+	} else {
+		run.ExitStatus, run.Errors, run.Error = runScheduleCommand(parent, job.Args)
+	}
+	run.End = UTCNow()
+	run.Duration = run.End.Sub(run.Start).Round(time.Millisecond).String()
+	run.Summary = scheduleRunSummary(run)
+
+	return run, appendScheduleHistory(run).Trace(job.Name)
+}
+
+// scheduleRunSummary - returns a one line summary of a run.
+func scheduleRunSummary(run scheduleRun) string {
+	summary := fmt.Sprintf("exited with status %d after %s", run.ExitStatus, run.Duration)
+	switch run.Errors {
+	case 0:
+	case 1:
+		summary += ", 1 error"
+	default:
+		summary += fmt.Sprintf(", %d errors", run.Errors)
+	}
+	return summary
+}
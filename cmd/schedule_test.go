@@ -0,0 +1,262 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 7, 30, 0, time.UTC) // A Wednesday.
+	testCases := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 15, 0, 0, time.UTC)},
+		{"30 1 * * *", time.Date(2024, 2, 1, 1, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"0 3 * * 0", time.Date(2024, 2, 4, 3, 0, 0, 0, time.UTC)},
+		{"0 3 * * 7", time.Date(2024, 2, 4, 3, 0, 0, 0, time.UTC)},
+		{"0 0 30 * *", time.Date(2024, 3, 30, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 15 * 5", time.Date(2024, 2, 2, 0, 0, 0, 0, time.UTC)},
+		{"5,10 10 * * *", time.Date(2024, 1, 31, 10, 10, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 2 *", time.Time{}},
+	}
+	for _, testCase := range testCases {
+		s, err := parseCronSchedule(testCase.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", testCase.spec, err)
+		}
+		if next := s.next(from); !next.Equal(testCase.next) {
+			t.Errorf("%s: expected %v, got %v", testCase.spec, testCase.next, next)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := parseCronSchedule(spec); err == nil {
+			t.Errorf("%q: expected an invalid schedule", spec)
+		}
+	}
+}
+
+func TestDueScheduleJobs(t *testing.T) {
+	jobs := []scheduleJob{
+		{Name: "hourly", Cron: "@hourly"},
+		{Name: "quarter", Cron: "*/15 * * * *"},
+		{Name: "night", Cron: "0 1 * * *"},
+	}
+	from := time.Date(2024, 1, 31, 10, 44, 0, 0, time.UTC)
+	var names []string
+	for _, job := range dueScheduleJobs(jobs, from, from.Add(20*time.Minute)) {
+		names = append(names, job.Name)
+	}
+	if expected := []string{"hourly", "quarter"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Expected %v, got %v", expected, names)
+	}
+}
+
+func TestScheduleCommandArgs(t *testing.T) {
+	command := cli.Command{
+		Name: "mirror",
+		Flags: []cli.Flag{
+			cli.BoolFlag{Name: "remove"},
+			cli.StringSliceFlag{Name: "exclude"},
+			cli.StringFlag{Name: "newer-than, n"},
+		},
+	}
+	args := []string{"src/", "--exclude", "*.tmp", "dst/", "-n=7d", "--remove", "--", "--not-a-flag"}
+	expected := []string{"--exclude", "*.tmp", "--newer-than=7d", "--remove", "src/", "dst/", "--", "--not-a-flag"}
+	if got := scheduleCommandArgs(command, args); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestScheduleCommandMinArgs(t *testing.T) {
+	testCases := []struct {
+		usage   string
+		minArgs int
+	}{
+		{"{{.HelpName}} [FLAGS] SOURCE [SOURCE...] TARGET", 2},
+		{"{{.HelpName}} [FLAGS] ALIAS/BUCKET[/PREFIX] [TARGET]", 1},
+		{"{{.HelpName}}{{if .VisibleFlags}} [FLAGS]{{end}}", 0},
+		{"{{.HelpName}} TARGET POLICY [POLICY...] [--user USER | --group GROUP]", 2},
+		{"{{.HelpName}} [FLAGS] TARGET\n  {{.HelpName}} [FLAGS] TARGET NAME", 1},
+	}
+	for _, testCase := range testCases {
+		command := cli.Command{CustomHelpTemplate: "NAME:\n  x\n\nUSAGE:\n  " + testCase.usage + "\n\nFLAGS:\n  y\n"}
+		if got := scheduleCommandMinArgs(command); got != testCase.minArgs {
+			t.Errorf("%q: expected %d, got %d", testCase.usage, testCase.minArgs, got)
+		}
+	}
+	if got := scheduleCommandMinArgs(cpCmd); got != 2 {
+		t.Errorf("cp: expected 2, got %d", got)
+	}
+}
+
+func TestRunScheduleJob(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the jobs run with a shell script")
+	}
+	defer setMcConfigDir(mcCustomConfigDir)
+	dir := t.TempDir()
+	setMcConfigDir(dir)
+
+	// The jobs run with a script standing in for mc, which records its
+	// command lines.
+	script := filepath.Join(dir, "mc")
+	if e := os.WriteFile(script, []byte(`#!/bin/sh
+echo "$MC_INSECURE$MC_METRICS_ADDRESS $*" >> "$(dirname "$0")/args"
+case "$1" in
+fail) echo "mc: <ERROR> Unable to fail. Invalid arguments." >&2; exit 1 ;;
+json) printf '{\n "status": "error",\n "error": {\n  "message": "Unable to fail.",\n  "cause": {\n   "message": "Invalid arguments."\n  }\n }\n}\n'; exit 1 ;;
+group) exit 3 ;;
+esac
+`), 0o700); e != nil {
+		t.Fatal(e)
+	}
+	defer func(executable func() (string, error)) { scheduleExecutable = executable }(scheduleExecutable)
+	scheduleExecutable = func() (string, error) { return script, nil }
+	// The daemon binds the metrics port, the jobs must not.
+	t.Setenv("MC_METRICS_ADDRESS", "localhost:0")
+
+	action := func(ctx *cli.Context) error { return nil }
+	app := cli.NewApp()
+	app.Commands = []cli.Command{
+		{
+			Name:               "copy",
+			Flags:              []cli.Flag{cli.BoolFlag{Name: "recursive, r"}},
+			Action:             action,
+			CustomHelpTemplate: "USAGE:\n  {{.HelpName}} [FLAGS] SOURCE TARGET\n",
+		},
+		{Name: "fail", Action: action},
+		{Name: "json", Action: action},
+		{
+			Name:        "group",
+			Subcommands: []cli.Command{{Name: "exit", Action: action}},
+		},
+	}
+	set := flag.NewFlagSet("mc", flag.ContinueOnError)
+	set.Bool("insecure", false, "")
+	if e := set.Parse([]string{"--insecure"}); e != nil {
+		t.Fatal(e)
+	}
+	parent := cli.NewContext(app, set, nil)
+
+	testCases := []struct {
+		args   []string
+		status int
+		errs   int
+		err    string
+	}{
+		{[]string{"copy", "a", "-r", "b"}, 0, 0, ""},
+		{[]string{"copy", "a"}, globalErrorExitStatus, 1, "`mc copy a` is missing arguments, the command takes at least 2."},
+		{[]string{"fail"}, globalErrorExitStatus, 1, "Unable to fail. Invalid arguments."},
+		{[]string{"json"}, globalErrorExitStatus, 1, "Unable to fail."},
+		{[]string{"group", "exit"}, 3, 0, "exit status 3"},
+		{[]string{"group"}, globalErrorExitStatus, 1, "`group` is not an mc command which can be scheduled."},
+		{[]string{"unknown"}, globalErrorExitStatus, 1, "`unknown` is not an mc command which can be scheduled."},
+	}
+	for i, testCase := range testCases {
+		job := scheduleJob{Name: "job", Args: testCase.args}
+		run, err := runScheduleJob(parent, job)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if run.ExitStatus != testCase.status || run.Errors != testCase.errs || run.Error != testCase.err {
+			t.Errorf("%v: expected %d, %d, %q, got %d, %d, %q", testCase.args, testCase.status, testCase.errs, testCase.err,
+				run.ExitStatus, run.Errors, run.Error)
+		}
+		runs, err := readScheduleHistory("job")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(runs) != i+1 || runs[i].Summary != run.Summary {
+			t.Fatalf("%v: expected the run in the history, got %v", testCase.args, runs)
+		}
+	}
+
+	// Invalid command lines never run, global flags of the daemon apply.
+	got, e := os.ReadFile(filepath.Join(dir, "args"))
+	if e != nil {
+		t.Fatal(e)
+	}
+	if expected := "true copy a -r b\ntrue fail\ntrue json\ntrue group exit\n"; string(got) != expected {
+		t.Fatalf("Expected %q, got %q", expected, got)
+	}
+
+	// A job never runs twice at the same time.
+	unlock, err := lockScheduleJob("job")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = runScheduleJob(parent, scheduleJob{Name: "job", Args: []string{"copy"}}); err == nil {
+		t.Fatal("Expected a locked job to fail to run")
+	}
+	unlock()
+}
+
+func TestScheduleJobs(t *testing.T) {
+	defer setMcConfigDir(mcCustomConfigDir)
+	setMcConfigDir(t.TempDir())
+
+	jobs, err := loadScheduleJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"b", "a"} {
+		if err = jobs.add(scheduleJob{Name: name, Cron: "@daily", Args: []string{"ls"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = jobs.add(scheduleJob{Name: "a"}); err == nil {
+		t.Fatal("Expected a duplicate job to fail")
+	}
+	if err = jobs.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if jobs, err = loadScheduleJobs(); err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Jobs) != 2 || jobs.Jobs[0].Name != "a" || jobs.Jobs[1].Name != "b" {
+		t.Fatalf("Expected jobs a and b, got %v", jobs.Jobs)
+	}
+	if err = jobs.remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = jobs.remove("a"); err == nil {
+		t.Fatal("Expected a missing job to fail")
+	}
+
+	for _, name := range []string{"", "../a", "a b", ".a"} {
+		if checkScheduleName(name) == nil {
+			t.Errorf("%q: expected an invalid name", name)
+		}
+	}
+}
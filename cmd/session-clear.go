@@ -0,0 +1,139 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/pkg/v2/console"
+)
+
+var sessionClearCmd = cli.Command{
+	Name:            "clear",
+	Usage:           "clear saved copy sessions",
+	Action:          mainSessionClear,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} NAME|all
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Clear the copy session named "backup", aborting its in-flight uploads.
+     {{.Prompt}} {{.HelpName}} backup
+
+  2. Clear all saved copy sessions.
+     {{.Prompt}} {{.HelpName}} all
+`,
+}
+
+// sessionClearMessage container for session clear message structure.
+type sessionClearMessage struct {
+	Status string `json:"status"`
+	Name   string `json:"name"`
+}
+
+// String colorized clear session message.
+func (c sessionClearMessage) String() string {
+	return console.Colorize("ClearSession", "Session `"+c.Name+"` cleared successfully.")
+}
+
+// JSON jsonified clear session message.
+func (c sessionClearMessage) JSON() string {
+	c.Status = "success"
+	clearMessageBytes, e := json.MarshalIndent(c, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(clearMessageBytes)
+}
+
+// checkSessionClearSyntax - validate all the passed arguments
+func checkSessionClearSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+	if name := ctx.Args().Get(0); name != "all" && !isValidSessionName(name) {
+		fatalIf(errInvalidArgument().Trace(name), "Invalid session name `"+name+"`.")
+	}
+}
+
+// abortSessionUploads - aborts multipart uploads left behind by a session.
+func abortSessionUploads(ctx context.Context, s *sessionV8) {
+	for target, uploadID := range s.Header.Uploads {
+		alias, urlStr, _ := mustExpandAlias(target)
+		clnt, err := newClientFromAlias(alias, urlStr)
+		if err != nil {
+			errorIf(err.Trace(target), "Unable to abort upload of `"+target+"`.")
+			continue
+		}
+		s3Clnt, ok := clnt.(*S3Client)
+		if !ok {
+			continue
+		}
+		bucket, object := s3Clnt.url2BucketAndObject()
+		core := minio.Core{Client: s3Clnt.api}
+		if e := core.AbortMultipartUpload(ctx, bucket, object, uploadID); e != nil {
+			errorIf(probe.NewError(e).Trace(target), "Unable to abort upload of `"+target+"`.")
+		}
+	}
+}
+
+// clearSession - removes a session and its in-flight uploads.
+func clearSession(ctx context.Context, name string) {
+	s, err := loadSessionV8(name)
+	fatalIf(err.Trace(name), "Unable to load session `"+name+"`.")
+
+	abortSessionUploads(ctx, s)
+
+	fatalIf(s.Delete().Trace(name), "Unable to clear session `"+name+"`.")
+	printMsg(sessionClearMessage{Name: name})
+}
+
+// mainSessionClear is the handle for "mc session clear" command.
+func mainSessionClear(cliCtx *cli.Context) error {
+	checkSessionClearSyntax(cliCtx)
+	console.SetColor("ClearSession", color.New(color.FgGreen, color.Bold))
+
+	ctx, cancelClear := context.WithCancel(globalContext)
+	defer cancelClear()
+
+	name := cliCtx.Args().Get(0)
+	if name != "all" {
+		clearSession(ctx, name)
+		return nil
+	}
+
+	names, err := getSessionNames()
+	fatalIf(err.Trace(), "Unable to list sessions.")
+	for _, name := range names {
+		clearSession(ctx, name)
+	}
+	return nil
+}
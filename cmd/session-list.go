@@ -0,0 +1,123 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+var sessionListCmd = cli.Command{
+	Name:            "list",
+	ShortName:       "ls",
+	Usage:           "list saved copy sessions",
+	Action:          mainSessionList,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}}
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. List all saved copy sessions.
+     {{.Prompt}} {{.HelpName}}
+`,
+}
+
+// sessionMessage container for session message structure.
+type sessionMessage struct {
+	Status       string    `json:"status"`
+	Name         string    `json:"name"`
+	Time         time.Time `json:"time"`
+	CommandType  string    `json:"commandType"`
+	CommandArgs  []string  `json:"commandArgs"`
+	Copied       int       `json:"copied"`
+	TotalObjects int64     `json:"totalObjects"`
+	TotalBytes   int64     `json:"totalBytes"`
+}
+
+// String colorized session message.
+func (s sessionMessage) String() string {
+	return console.Colorize("SessionName", s.Name) + " " +
+		console.Colorize("SessionTime", "["+s.Time.Local().Format(printDate)+"] ") +
+		console.Colorize("SessionCmd", s.CommandType+" "+strings.Join(s.CommandArgs, " ")) + " " +
+		console.Colorize("SessionStat", "("+humanize.Comma(int64(s.Copied))+"/"+humanize.Comma(s.TotalObjects)+" objects, "+humanize.IBytes(uint64(s.TotalBytes))+")")
+}
+
+// JSON jsonified session message.
+func (s sessionMessage) JSON() string {
+	s.Status = "success"
+	sessionMessageBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(sessionMessageBytes)
+}
+
+// checkSessionListSyntax - validate all the passed arguments
+func checkSessionListSyntax(ctx *cli.Context) {
+	if ctx.Args().Present() {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+}
+
+// mainSessionList is the handle for "mc session list" command.
+func mainSessionList(ctx *cli.Context) error {
+	checkSessionListSyntax(ctx)
+
+	console.SetColor("SessionName", color.New(color.FgYellow, color.Bold))
+	console.SetColor("SessionTime", color.New(color.FgGreen))
+	console.SetColor("SessionCmd", color.New(color.FgWhite))
+	console.SetColor("SessionStat", color.New(color.FgCyan))
+
+	names, err := getSessionNames()
+	fatalIf(err.Trace(), "Unable to list sessions.")
+
+	for _, name := range names {
+		s, err := loadSessionV8(name)
+		if err != nil {
+			errorIf(err.Trace(name), "Unable to load session `"+name+"`.")
+			continue
+		}
+		msg := sessionMessage{
+			Name:         s.Name,
+			Time:         s.Header.When,
+			CommandType:  s.Header.CommandType,
+			CommandArgs:  s.Header.CommandArgs,
+			Copied:       len(s.completed),
+			TotalObjects: s.Header.TotalObjects,
+			TotalBytes:   s.Header.TotalBytes,
+		}
+		s.Close()
+		printMsg(msg)
+	}
+	return nil
+}
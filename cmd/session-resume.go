@@ -0,0 +1,81 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/pkg/v2/console"
+)
+
+var sessionResumeCmd = cli.Command{
+	Name:            "resume",
+	Usage:           "resume an interrupted copy session",
+	Action:          mainSessionResume,
+	Before:          setGlobalsFromContext,
+	Flags:           globalFlags,
+	OnUsageError:    onUsageError,
+	HideHelpCommand: true,
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} NAME
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+EXAMPLES:
+  1. Resume the copy session named "backup".
+     {{.Prompt}} {{.HelpName}} backup
+`,
+}
+
+// checkSessionResumeSyntax - validate all the passed arguments
+func checkSessionResumeSyntax(ctx *cli.Context) {
+	if len(ctx.Args()) != 1 {
+		showCommandHelpAndExit(ctx, globalErrorExitStatus)
+	}
+	if name := ctx.Args().Get(0); !isValidSessionName(name) {
+		fatalIf(errInvalidArgument().Trace(name), "Invalid session name `"+name+"`.")
+	}
+}
+
+// mainSessionResume is the handle for "mc session resume" command.
+func mainSessionResume(cliCtx *cli.Context) error {
+	checkSessionResumeSyntax(cliCtx)
+	console.SetColor("Copy", color.New(color.FgGreen, color.Bold))
+
+	name := cliCtx.Args().Get(0)
+	session, err := loadSessionV8(name)
+	fatalIf(err.Trace(name), "Unable to load session `"+name+"`.")
+
+	copyCtx, err := sessionCommandContext(cliCtx, cpCmd, session.Header.CommandArgs)
+	fatalIf(err.Trace(name), "Unable to restore command line of session `"+name+"`.")
+
+	// Parse encryption keys per command.
+	encryptionKeyMap, err := validateAndCreateEncryptionKeys(copyCtx)
+	fatalIf(err, "SSE Error")
+
+	ctx, cancelCopy := context.WithCancel(globalContext)
+	defer cancelCopy()
+
+	return doCopySession(ctx, cancelCopy, copyCtx, encryptionKeyMap, false, session, nil)
+}
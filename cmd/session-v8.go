@@ -0,0 +1,303 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/quick"
+)
+
+// sessionV8Header - session header, persisted as <name>.json.
+type sessionV8Header struct {
+	Version      string    `json:"version"`
+	When         time.Time `json:"time"`
+	CommandType  string    `json:"commandType"`
+	CommandArgs  []string  `json:"commandArgs"`
+	PlanComplete bool      `json:"planComplete"`
+	TotalBytes   int64     `json:"totalBytes"`
+	TotalObjects int64     `json:"totalObjects"`
+
+	// In-flight multipart upload ids, keyed by target URL.
+	Uploads map[string]string `json:"uploads"`
+}
+
+// sessionV8 - a resumable copy session. Besides the header, a session
+// owns two append only journals, the planned URLs (<name>.plan) and the
+// source URLs already copied (<name>.done).
+type sessionV8 struct {
+	Header sessionV8Header
+	Name   string
+
+	mutex     *sync.Mutex
+	plan      *os.File
+	done      *os.File
+	completed map[string]struct{}
+}
+
+// newSessionV8 - creates a new session with the given name.
+func newSessionV8(name string) (*sessionV8, *probe.Error) {
+	if isSessionExists(name) {
+		return nil, errSessionExists(name).Trace(name)
+	}
+	if err := createSessionDir(); err != nil {
+		return nil, err.Trace(name)
+	}
+
+	s := &sessionV8{
+		Header: sessionV8Header{
+			Version: globalSessionConfigVersion,
+			When:    UTCNow(),
+			Uploads: make(map[string]string),
+		},
+		Name:      name,
+		mutex:     &sync.Mutex{},
+		completed: make(map[string]struct{}),
+	}
+	if err := s.open(); err != nil {
+		return nil, err.Trace(name)
+	}
+	if err := s.save(); err != nil {
+		s.Close()
+		return nil, err.Trace(name)
+	}
+	return s, nil
+}
+
+// loadSessionV8 - loads an existing session along with its journals.
+func loadSessionV8(name string) (*sessionV8, *probe.Error) {
+	if !isSessionExists(name) {
+		return nil, errSessionNotFound(name).Trace(name)
+	}
+
+	qs, e := quick.LoadConfig(getSessionFile(name, sessionHeaderExt), nil, &sessionV8Header{})
+	if e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	header := qs.Data().(*sessionV8Header)
+	if header.Version != globalSessionConfigVersion {
+		return nil, errSessionVersion(name, header.Version).Trace(name)
+	}
+	if header.Uploads == nil {
+		header.Uploads = make(map[string]string)
+	}
+
+	s := &sessionV8{
+		Header:    *header,
+		Name:      name,
+		mutex:     &sync.Mutex{},
+		completed: make(map[string]struct{}),
+	}
+	if err := s.open(); err != nil {
+		return nil, err.Trace(name)
+	}
+
+	// Replay the completed journal.
+	scanner := bufio.NewScanner(s.done)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.completed[scanner.Text()] = struct{}{}
+	}
+	if e := scanner.Err(); e != nil {
+		s.Close()
+		return nil, probe.NewError(e).Trace(name)
+	}
+	return s, nil
+}
+
+// open - opens the session journals for appending.
+func (s *sessionV8) open() *probe.Error {
+	plan, e := os.OpenFile(getSessionFile(s.Name, sessionPlanExt), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	done, e := os.OpenFile(getSessionFile(s.Name, sessionDoneExt), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if e != nil {
+		plan.Close()
+		return probe.NewError(e)
+	}
+	s.plan, s.done = plan, done
+	return nil
+}
+
+// save - persists the session header, caller must hold the lock or
+// own the session exclusively.
+func (s *sessionV8) save() *probe.Error {
+	qs, e := quick.NewConfig(&s.Header, nil)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e = qs.Save(getSessionFile(s.Name, sessionHeaderExt)); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// Save - persists the session header.
+func (s *sessionV8) Save() *probe.Error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.save()
+}
+
+// resetPlan - discards a partially written plan before planning again.
+func (s *sessionV8) resetPlan() *probe.Error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e := s.plan.Truncate(0); e != nil {
+		return probe.NewError(e)
+	}
+	s.Header.PlanComplete = false
+	s.Header.TotalBytes = 0
+	s.Header.TotalObjects = 0
+	return s.save()
+}
+
+// addPlanned - appends a planned copy to the plan journal.
+func (s *sessionV8) addPlanned(urls URLs) *probe.Error {
+	buf, e := json.Marshal(urls)
+	if e != nil {
+		return probe.NewError(e)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, e = s.plan.Write(append(buf, '\n')); e != nil {
+		return probe.NewError(e)
+	}
+	s.Header.TotalBytes += urls.SourceContent.Size
+	s.Header.TotalObjects++
+	return nil
+}
+
+// completePlan - marks the plan as fully written.
+func (s *sessionV8) completePlan() *probe.Error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e := s.plan.Sync(); e != nil {
+		return probe.NewError(e)
+	}
+	s.Header.PlanComplete = true
+	return s.save()
+}
+
+// planned - streams back the planned copies in the order they were written.
+func (s *sessionV8) planned() <-chan URLs {
+	urlsCh := make(chan URLs, 10000)
+	go func() {
+		defer close(urlsCh)
+
+		f, e := os.Open(getSessionFile(s.Name, sessionPlanExt))
+		if e != nil {
+			urlsCh <- URLs{Error: probe.NewError(e)}
+			return
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			var urls URLs
+			if e := json.Unmarshal(scanner.Bytes(), &urls); e != nil {
+				urlsCh <- URLs{Error: probe.NewError(e)}
+				return
+			}
+			urlsCh <- urls
+		}
+		if e := scanner.Err(); e != nil {
+			urlsCh <- URLs{Error: probe.NewError(e)}
+		}
+	}()
+	return urlsCh
+}
+
+// isCopied - returns true if the source was copied by this session.
+func (s *sessionV8) isCopied(sourceURL string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.completed[sourceURL]
+	return ok
+}
+
+// markCopied - records the source as copied in the done journal.
+func (s *sessionV8) markCopied(sourceURL string) *probe.Error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.completed[sourceURL]; ok {
+		return nil
+	}
+	if _, e := s.done.WriteString(sourceURL + "\n"); e != nil {
+		return probe.NewError(e)
+	}
+	s.completed[sourceURL] = struct{}{}
+	return nil
+}
+
+// uploadID - returns the in-flight multipart upload id of a target, if any.
+func (s *sessionV8) uploadID(target string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.Header.Uploads[target]
+}
+
+// setUploadID - records an in-flight multipart upload, an empty
+// upload id removes the entry.
+func (s *sessionV8) setUploadID(target, uploadID string) *probe.Error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if uploadID == "" {
+		delete(s.Header.Uploads, target)
+	} else {
+		s.Header.Uploads[target] = uploadID
+	}
+	return s.save()
+}
+
+// Close - persists the header and closes the journals.
+func (s *sessionV8) Close() *probe.Error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := s.save()
+	s.plan.Close()
+	s.done.Close()
+	return err
+}
+
+// Delete - closes and removes all session files.
+func (s *sessionV8) Delete() *probe.Error {
+	s.mutex.Lock()
+	s.plan.Close()
+	s.done.Close()
+	s.mutex.Unlock()
+
+	return removeSessionFiles(s.Name)
+}
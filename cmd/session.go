@@ -0,0 +1,162 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+)
+
+const (
+	sessionHeaderExt = ".json"
+	sessionPlanExt   = ".plan"
+	sessionDoneExt   = ".done"
+)
+
+// Session names are used as file names, keep them simple.
+var sessionNameRgx = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// isValidSessionName - validates a session name.
+func isValidSessionName(name string) bool {
+	return sessionNameRgx.MatchString(name) && name != "all"
+}
+
+// Get session dir name.
+func getSessionDir() (string, *probe.Error) {
+	configDir, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+
+	sessionDir := filepath.Join(configDir, globalSessionDir)
+	return sessionDir, nil
+}
+
+// Get session dir name or die. (NOTE: This `Die` approach is only OK for mc like tools.).
+func mustGetSessionDir() string {
+	sessionDir, err := getSessionDir()
+	fatalIf(err.Trace(), "Unable to determine session folder.")
+	return sessionDir
+}
+
+// Create session dir.
+func createSessionDir() *probe.Error {
+	if e := os.MkdirAll(mustGetSessionDir(), 0o700); e != nil {
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// Get the path of a session file.
+func getSessionFile(name, ext string) string {
+	return filepath.Join(mustGetSessionDir(), name+ext)
+}
+
+// Check if a session exists.
+func isSessionExists(name string) bool {
+	if _, e := os.Stat(getSessionFile(name, sessionHeaderExt)); e != nil {
+		return false
+	}
+	return true
+}
+
+// Remove all files of a session.
+func removeSessionFiles(name string) *probe.Error {
+	// quick keeps a backup of the previously saved header as '.old'.
+	for _, ext := range []string{sessionPlanExt, sessionDoneExt, sessionHeaderExt, sessionHeaderExt + ".old"} {
+		if e := os.Remove(getSessionFile(name, ext)); e != nil && !os.IsNotExist(e) {
+			return probe.NewError(e).Trace(name)
+		}
+	}
+	return nil
+}
+
+// List names of all saved sessions.
+func getSessionNames() ([]string, *probe.Error) {
+	files, e := os.ReadDir(mustGetSessionDir())
+	if e != nil {
+		if os.IsNotExist(e) {
+			return nil, nil
+		}
+		return nil, probe.NewError(e)
+	}
+
+	var names []string
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), sessionHeaderExt) {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(file.Name(), sessionHeaderExt))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// sessionCommandArgs - reconstructs the command line of a copy session
+// from the flags explicitly set by the user, so that it can be replayed
+// by `mc session resume`.
+func sessionCommandArgs(ctx *cli.Context, flags []cli.Flag) []string {
+	return append(commandFlagArgs(ctx, flags), ctx.Args()...)
+}
+
+// commandFlagArgs - returns the given flags explicitly set by the user
+// as command line arguments.
+func commandFlagArgs(ctx *cli.Context, flags []cli.Flag) []string {
+	var args []string
+	for _, f := range flags {
+		name := strings.TrimSpace(strings.Split(f.GetName(), ",")[0])
+		if !ctx.IsSet(name) {
+			continue
+		}
+		switch f.(type) {
+		case cli.BoolFlag:
+			args = append(args, "--"+name)
+		case cli.StringSliceFlag:
+			for _, v := range ctx.StringSlice(name) {
+				args = append(args, "--"+name+"="+v)
+			}
+		case cli.IntFlag:
+			args = append(args, fmt.Sprintf("--%s=%d", name, ctx.Int(name)))
+		default:
+			args = append(args, "--"+name+"="+ctx.String(name))
+		}
+	}
+	return args
+}
+
+// sessionCommandContext - rebuilds a cli context for a saved command line.
+func sessionCommandContext(parent *cli.Context, command cli.Command, args []string) (*cli.Context, *probe.Error) {
+	set := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	for _, f := range command.Flags {
+		f.Apply(set)
+	}
+	if e := set.Parse(args); e != nil {
+		return nil, probe.NewError(e)
+	}
+	ctx := cli.NewContext(parent.App, set, parent)
+	ctx.Command = command
+	return ctx, nil
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2015-2022 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSessionV8Journal(t *testing.T) {
+	defer setMcConfigDir(mcCustomConfigDir)
+	setMcConfigDir(t.TempDir())
+
+	s, err := newSessionV8("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newSessionV8("backup"); err == nil {
+		t.Fatal("expected an error creating a duplicate session")
+	}
+
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	for _, name := range []string{"a", "b", "c"} {
+		urls := URLs{
+			SourceContent: &ClientContent{URL: *newClientURL("/src/" + name), Size: 10, Time: modTime},
+			TargetContent: &ClientContent{URL: *newClientURL("/dst/" + name)},
+		}
+		if err = s.addPlanned(urls); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.completePlan(); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.markCopied("/src/a"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.setUploadID("dst/b", "upload-b"); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = loadSessionV8("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Header.PlanComplete || s.Header.TotalObjects != 3 || s.Header.TotalBytes != 30 {
+		t.Fatalf("unexpected header %+v", s.Header)
+	}
+	if !s.isCopied("/src/a") || s.isCopied("/src/b") {
+		t.Fatal("unexpected completed journal")
+	}
+	if id := s.uploadID("dst/b"); id != "upload-b" {
+		t.Fatalf("expected upload id `upload-b`, found `%s`", id)
+	}
+
+	var planned []string
+	for urls := range s.planned() {
+		if urls.Error != nil {
+			t.Fatal(urls.Error)
+		}
+		planned = append(planned, urls.SourceContent.URL.Path)
+		// The planned version of the source is checked on resume.
+		if urls.SourceContent.Size != 10 || !urls.SourceContent.Time.Equal(modTime) {
+			t.Fatalf("unexpected planned source %+v", urls.SourceContent)
+		}
+	}
+	if len(planned) != 3 || planned[0] != "/src/a" || planned[2] != "/src/c" {
+		t.Fatalf("unexpected plan %v", planned)
+	}
+
+	if err = s.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if isSessionExists("backup") {
+		t.Fatal("expected session to be removed")
+	}
+}
+
+func TestSourceChanged(t *testing.T) {
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.UTC)
+	planned := &ClientContent{Size: 10, Time: modTime}
+	testCases := []struct {
+		current  *ClientContent
+		withSize bool
+		changed  bool
+	}{
+		{&ClientContent{Size: 10, Time: modTime}, true, false},
+		// HEAD requests return times to the second.
+		{&ClientContent{Size: 10, Time: modTime.Truncate(time.Second)}, true, false},
+		{&ClientContent{Size: 11, Time: modTime}, true, true},
+		{&ClientContent{Size: 11, Time: modTime}, false, false},
+		{&ClientContent{Size: 10, Time: modTime.Add(time.Second)}, true, true},
+	}
+	for i, testCase := range testCases {
+		if changed := sourceChanged(planned, testCase.current, testCase.withSize); changed != testCase.changed {
+			t.Errorf("Test %d: expected %t, got %t", i+1, testCase.changed, changed)
+		}
+	}
+}
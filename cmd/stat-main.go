@@ -56,7 +56,7 @@ var statCmd = cli.Command{
 	Action:       mainStat,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(append(statFlags, encCFlag), globalFlags...),
+	Flags:        append(append(append(statFlags, encCFlag), listFormatFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -66,7 +66,7 @@ USAGE:
 FLAGS:
   {{range .VisibleFlags}}{{.}}
   {{end}}
-
+` + listFormatHelp + `
 EXAMPLES:
   1. Stat all contents of mybucket on Amazon S3 cloud storage.
      {{.Prompt}} {{.HelpName}} s3/mybucket/
@@ -86,6 +86,9 @@ EXAMPLES:
 
   6. Stat all objects versions recursively created before 1st January 2020.
      {{.Prompt}} {{.HelpName}} --versions --rewind 2020.01.01T00:00 s3/personal-docs/
+
+  7. Stat all objects recursively, largest first, showing the key, size and storage class.
+     {{.Prompt}} {{.HelpName}} --recursive --sort size --reverse --columns key,size,storageClass s3/personal-docs/
 `,
 }
 
@@ -154,13 +157,17 @@ func mainStat(cliCtx *cli.Context) error {
 
 	// check 'stat' cli arguments.
 	args, isRecursive, versionID, rewind, withVersions := parseAndCheckStatSyntax(ctx, cliCtx, encKeyDB)
+
+	formatter, err := newListFormatter(cliCtx)
+	fatalIf(err, "Unable to parse --sort, --columns or --format.")
+
 	// mimic operating system tool behavior.
 	if len(args) == 0 {
 		args = []string{"."}
 	}
 
 	for _, targetURL := range args {
-		fatalIf(statURL(ctx, targetURL, versionID, rewind, withVersions, false, isRecursive, encKeyDB), "Unable to stat `"+targetURL+"`.")
+		fatalIf(statURL(ctx, targetURL, versionID, rewind, withVersions, false, isRecursive, encKeyDB, formatter), "Unable to stat `"+targetURL+"`.")
 	}
 
 	return nil
@@ -175,6 +175,25 @@ func parseStat(c *ClientContent) statMessage {
 	return content
 }
 
+// statListEntry - returns the entry of a stat object for --sort,
+// --columns and --format.
+func statListEntry(c *ClientContent, msg statMessage) listEntry {
+	return listEntry{
+		Key:               msg.Key,
+		Size:              msg.Size,
+		LastModified:      msg.Date,
+		ETag:              msg.ETag,
+		VersionID:         msg.VersionID,
+		IsDeleteMarker:    msg.DeleteMarker,
+		StorageClass:      c.StorageClass,
+		Type:              msg.Type,
+		Tags:              c.Tags,
+		Metadata:          msg.Metadata,
+		ReplicationStatus: msg.ReplicationStatus,
+		msg:               msg,
+	}
+}
+
 // Return standardized URL to be used to compare later.
 func getStandardizedURL(targetURL string) string {
 	return filepath.FromSlash(targetURL)
@@ -183,11 +202,12 @@ func getStandardizedURL(targetURL string) string {
 // statURL - uses combination of GET listing and HEAD to fetch information of one or more objects
 // HEAD can fail with 400 with an SSE-C encrypted object but we still return information gathered
 // from GET listing.
-func statURL(ctx context.Context, targetURL, versionID string, timeRef time.Time, includeOlderVersions, isIncomplete, isRecursive bool, encKeyDB map[string][]prefixSSEPair) *probe.Error {
+func statURL(ctx context.Context, targetURL, versionID string, timeRef time.Time, includeOlderVersions, isIncomplete, isRecursive bool, encKeyDB map[string][]prefixSSEPair, formatter *listFormatter) *probe.Error {
 	clnt, err := newClient(targetURL)
 	if err != nil {
 		return err
 	}
+	defer formatter.flush()
 
 	targetAlias, _, _ := mustExpandAlias(targetURL)
 	prefixPath := clnt.GetURL().Path
@@ -301,7 +321,7 @@ func statURL(ctx context.Context, targetURL, versionID string, timeRef time.Time
 		contentURL = strings.TrimPrefix(contentURL, prefixPath)
 		stat.URL.Path = contentURL
 
-		printMsg(parseStat(stat))
+		formatter.print(statListEntry(stat, parseStat(stat)))
 	}
 
 	return probe.NewError(e)
@@ -361,7 +361,7 @@ func getSubnetLicenseFromConfig(alias string) string {
 	return mcConfig().Aliases[alias].License
 }
 
-func mcConfig() *configV10 {
+func mcConfig() *configV11 {
 	loadMcConfig = loadMcConfigFactory()
 	config, err := loadMcConfig()
 	fatalIf(err.Trace(mustGetMcConfigPath()), "Unable to access configuration file.")
@@ -0,0 +1,317 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+const (
+	syncBaselineVersion = "1"
+	syncBaselineDir     = "sync"
+)
+
+// syncConflictPolicy - how objects changed on both sides are resolved.
+type syncConflictPolicy string
+
+const (
+	syncConflictNewer    syncConflictPolicy = "newer"     // the last modified object wins
+	syncConflictSource   syncConflictPolicy = "source"    // the first folder wins
+	syncConflictTarget   syncConflictPolicy = "target"    // the second folder wins
+	syncConflictKeepBoth syncConflictPolicy = "keep-both" // the second object is kept under a new name
+)
+
+// parseSyncConflictPolicy - parses the value of '--conflict'.
+func parseSyncConflictPolicy(policy string) (syncConflictPolicy, *probe.Error) {
+	switch p := syncConflictPolicy(strings.ToLower(policy)); p {
+	case syncConflictNewer, syncConflictSource, syncConflictTarget, syncConflictKeepBoth:
+		return p, nil
+	}
+	return "", errInvalidArgument().Trace(policy)
+}
+
+// syncVersion - the version of an object on one side when both
+// sides were last in sync.
+type syncVersion struct {
+	Size    int64     `json:"size"`
+	ETag    string    `json:"etag,omitempty"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// newSyncVersion - returns the version of a listed object.
+func newSyncVersion(content *ClientContent) syncVersion {
+	return syncVersion{
+		Size:    content.Size,
+		ETag:    strings.Trim(content.ETag, "\""),
+		ModTime: content.Time.UTC(),
+	}
+}
+
+// matches - returns true if the object was not modified since this
+// version, objects without ETag are compared by modtime.
+func (v syncVersion) matches(content *ClientContent) bool {
+	if content == nil || content.Size != v.Size {
+		return false
+	}
+	if etag := strings.Trim(content.ETag, "\""); etag != "" && v.ETag != "" {
+		return etag == v.ETag
+	}
+	return content.Time.Equal(v.ModTime)
+}
+
+// syncBaselineEntry - an object present on both sides when they were
+// last in sync.
+type syncBaselineEntry struct {
+	A syncVersion `json:"a"`
+	B syncVersion `json:"b"`
+}
+
+// syncBaseline - the snapshot of both sides after the last sync, an
+// object missing from one side but present in the baseline was removed
+// from it, otherwise it was never seen there.
+type syncBaseline struct {
+	Version string                       `json:"version"`
+	A       string                       `json:"a"`
+	B       string                       `json:"b"`
+	Time    time.Time                    `json:"time"`
+	Entries map[string]syncBaselineEntry `json:"entries"`
+
+	mu   sync.Mutex
+	path string
+}
+
+// defaultSyncBaselinePath - returns the baseline file of a pair of
+// folders in the mc config folder.
+func defaultSyncBaselinePath(a, b string) (string, *probe.Error) {
+	configDir, err := getMcConfigDir()
+	if err != nil {
+		return "", err.Trace()
+	}
+	sum := sha256.Sum256([]byte(a + "\n" + b))
+	return filepath.Join(configDir, syncBaselineDir, hex.EncodeToString(sum[:16])+".json"), nil
+}
+
+// loadSyncBaseline - loads the baseline of the folders from a file, an
+// empty baseline is returned if the file does not exist yet.
+func loadSyncBaseline(name, a, b string) (*syncBaseline, *probe.Error) {
+	baseline := &syncBaseline{
+		Version: syncBaselineVersion,
+		A:       a,
+		B:       b,
+		Entries: make(map[string]syncBaselineEntry),
+		path:    name,
+	}
+	data, e := os.ReadFile(name)
+	if os.IsNotExist(e) {
+		return baseline, nil
+	}
+	if e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	if e = json.Unmarshal(data, baseline); e != nil {
+		return nil, probe.NewError(e).Trace(name)
+	}
+	if baseline.Version != syncBaselineVersion || baseline.A != a || baseline.B != b {
+		return nil, errSyncBaselineMismatch(name).Trace(a, b)
+	}
+	if baseline.Entries == nil {
+		baseline.Entries = make(map[string]syncBaselineEntry)
+	}
+	return baseline, nil
+}
+
+// set - records the object as in sync with the given versions.
+func (s *syncBaseline) set(key string, a, b *ClientContent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Entries[key] = syncBaselineEntry{A: newSyncVersion(a), B: newSyncVersion(b)}
+}
+
+// forget - removes an object removed from both sides.
+func (s *syncBaseline) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Entries, key)
+}
+
+// save - writes the baseline to a temporary file renamed over the
+// previous baseline, an interrupted save leaves it untouched.
+func (s *syncBaseline) save() *probe.Error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Time = UTCNow()
+	data, e := json.Marshal(s)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e = os.MkdirAll(filepath.Dir(s.path), 0o700); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	tmp := s.path + ".tmp"
+	if e = os.WriteFile(tmp, data, 0o600); e != nil {
+		return probe.NewError(e).Trace(tmp)
+	}
+	if e = os.Rename(tmp, s.path); e != nil {
+		return probe.NewError(e).Trace(s.path)
+	}
+	return nil
+}
+
+// syncOp - what is done to bring an object in sync.
+type syncOp string
+
+const (
+	syncOpNone     syncOp = "none"      // in sync, the baseline is refreshed
+	syncOpForget   syncOp = "forget"    // removed from both sides
+	syncOpCopyToB  syncOp = "copy-a-b"  // copied from A to B
+	syncOpCopyToA  syncOp = "copy-b-a"  // copied from B to A
+	syncOpRemoveA  syncOp = "remove-a"  // removed from A
+	syncOpRemoveB  syncOp = "remove-b"  // removed from B
+	syncOpKeepBoth syncOp = "keep-both" // B is kept under a new name on both sides, A overwrites B
+)
+
+// syncDecision - the operation planned for an object.
+type syncDecision struct {
+	key      string
+	op       syncOp
+	a, b     *ClientContent
+	conflict bool
+	err      *probe.Error
+}
+
+// syncConflictKey - returns the name an object of B is kept under by
+// the keep-both policy, 'dir/name.sync-conflict-20060102-150405.ext'.
+func syncConflictKey(key string, modTime time.Time) string {
+	dir, file := path.Split(key)
+	ext := path.Ext(file)
+	return dir + strings.TrimSuffix(file, ext) + ".sync-conflict-" + modTime.UTC().Format("20060102-150405") + ext
+}
+
+// planSync - compares the objects of both sides to the baseline, the
+// objects changed on one side only are propagated to the other side and
+// the objects changed on both sides are resolved by the conflict policy.
+// Objects new on both sides and objects modified on both sides to the
+// same content are not conflicting.
+func planSync(a, b map[string]*ClientContent, baseline *syncBaseline, policy syncConflictPolicy,
+	equal func(a, b *ClientContent) (bool, *probe.Error),
+) []syncDecision {
+	keys := make(map[string]struct{}, len(a)+len(b)+len(baseline.Entries))
+	for key := range a {
+		keys[key] = struct{}{}
+	}
+	for key := range b {
+		keys[key] = struct{}{}
+	}
+	for key := range baseline.Entries {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	decisions := make([]syncDecision, 0, len(sorted))
+	for _, key := range sorted {
+		d := syncDecision{key: key, a: a[key], b: b[key]}
+		entry, seen := baseline.Entries[key]
+
+		var changedA, changedB bool
+		if seen {
+			changedA = !entry.A.matches(d.a)
+			changedB = !entry.B.matches(d.b)
+		} else {
+			changedA, changedB = d.a != nil, d.b != nil
+		}
+
+		switch {
+		case !changedA && !changedB:
+			d.op = syncOpNone
+		case changedA && !changedB:
+			d.op = syncOpCopyToB
+			if d.a == nil {
+				d.op = syncOpRemoveB
+			}
+		case !changedA && changedB:
+			d.op = syncOpCopyToA
+			if d.b == nil {
+				d.op = syncOpRemoveA
+			}
+		case d.a == nil && d.b == nil:
+			d.op = syncOpForget
+		default:
+			if d.a != nil && d.b != nil {
+				same, err := equal(d.a, d.b)
+				if err != nil {
+					d.err = err.Trace(key)
+					break
+				}
+				if same {
+					d.op = syncOpNone
+					break
+				}
+			}
+			d.conflict = true
+			d.op = resolveSyncConflict(d.a, d.b, policy)
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions
+}
+
+// resolveSyncConflict - returns the operation resolving an object
+// changed on both sides, a removal is conflicting with a modification.
+func resolveSyncConflict(a, b *ClientContent, policy syncConflictPolicy) syncOp {
+	switch policy {
+	case syncConflictSource:
+		if a == nil {
+			return syncOpRemoveB
+		}
+		return syncOpCopyToB
+	case syncConflictTarget:
+		if b == nil {
+			return syncOpRemoveA
+		}
+		return syncOpCopyToA
+	}
+
+	// The modification wins over the removal.
+	switch {
+	case a == nil:
+		return syncOpCopyToA
+	case b == nil:
+		return syncOpCopyToB
+	case policy == syncConflictKeepBoth:
+		return syncOpKeepBoth
+	case b.Time.After(a.Time):
+		return syncOpCopyToA
+	}
+	return syncOpCopyToB
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/minio/mc/pkg/probe"
+)
+
+func TestPlanSync(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	object := func(size int64, etag string, modTime time.Time) *ClientContent {
+		return &ClientContent{Size: size, ETag: etag, Time: modTime}
+	}
+	synced := object(1, "s", t0)
+
+	baseline := &syncBaseline{Entries: map[string]syncBaselineEntry{}}
+	for _, key := range []string{"unchanged", "modified-a", "modified-b", "removed-a", "removed-b", "removed-both", "both-same", "both-differ", "removed-a-modified-b"} {
+		baseline.Entries[key] = syncBaselineEntry{A: newSyncVersion(synced), B: newSyncVersion(synced)}
+	}
+
+	a := map[string]*ClientContent{
+		"unchanged":       synced,
+		"modified-a":      object(2, "a", t0.Add(time.Hour)),
+		"modified-b":      synced,
+		"removed-b":       synced,
+		"both-same":       object(2, "x", t0.Add(time.Hour)),
+		"both-differ":     object(2, "a", t0.Add(2*time.Hour)),
+		"new-a":           object(1, "n", t0),
+		"new-both-same":   object(1, "n", t0),
+		"new-both-differ": object(1, "a", t0),
+	}
+	b := map[string]*ClientContent{
+		"unchanged":            synced,
+		"modified-a":           synced,
+		"modified-b":           object(2, "b", t0.Add(time.Hour)),
+		"removed-a":            synced,
+		"both-same":            object(2, "x", t0.Add(time.Hour)),
+		"both-differ":          object(2, "b", t0.Add(time.Hour)),
+		"new-b":                object(1, "n", t0),
+		"new-both-same":        object(1, "n", t0),
+		"new-both-differ":      object(1, "b", t0.Add(time.Hour)),
+		"removed-a-modified-b": object(2, "b", t0.Add(time.Hour)),
+	}
+	equal := func(a, b *ClientContent) (bool, *probe.Error) {
+		return a.ETag == b.ETag, nil
+	}
+
+	testCases := []struct {
+		policy   syncConflictPolicy
+		expected map[string]syncOp
+	}{
+		{syncConflictNewer, map[string]syncOp{
+			"both-differ":          syncOpCopyToB,
+			"new-both-differ":      syncOpCopyToA,
+			"removed-a-modified-b": syncOpCopyToA,
+		}},
+		{syncConflictSource, map[string]syncOp{
+			"both-differ":          syncOpCopyToB,
+			"new-both-differ":      syncOpCopyToB,
+			"removed-a-modified-b": syncOpRemoveB,
+		}},
+		{syncConflictTarget, map[string]syncOp{
+			"both-differ":          syncOpCopyToA,
+			"new-both-differ":      syncOpCopyToA,
+			"removed-a-modified-b": syncOpCopyToA,
+		}},
+		{syncConflictKeepBoth, map[string]syncOp{
+			"both-differ":          syncOpKeepBoth,
+			"new-both-differ":      syncOpKeepBoth,
+			"removed-a-modified-b": syncOpCopyToA,
+		}},
+	}
+	for _, testCase := range testCases {
+		expected := map[string]syncOp{
+			"unchanged":     syncOpNone,
+			"modified-a":    syncOpCopyToB,
+			"modified-b":    syncOpCopyToA,
+			"removed-a":     syncOpRemoveB,
+			"removed-b":     syncOpRemoveA,
+			"removed-both":  syncOpForget,
+			"both-same":     syncOpNone,
+			"new-a":         syncOpCopyToB,
+			"new-b":         syncOpCopyToA,
+			"new-both-same": syncOpNone,
+		}
+		for key, op := range testCase.expected {
+			expected[key] = op
+		}
+
+		ops := make(map[string]syncOp)
+		for _, d := range planSync(a, b, baseline, testCase.policy, equal) {
+			if d.err != nil {
+				t.Fatal(d.err)
+			}
+			ops[d.key] = d.op
+			if conflict := testCase.expected[d.key] != ""; d.conflict != conflict {
+				t.Errorf("%s: expected %s to be conflicting: %v", testCase.policy, d.key, conflict)
+			}
+		}
+		if !reflect.DeepEqual(ops, expected) {
+			t.Errorf("%s: expected %v, got %v", testCase.policy, expected, ops)
+		}
+	}
+}
+
+func TestSyncConflictKey(t *testing.T) {
+	modTime := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	testCases := map[string]string{
+		"a.txt":        "a.sync-conflict-20240304-050607.txt",
+		"dir/a":        "dir/a.sync-conflict-20240304-050607",
+		"dir/a.tar.gz": "dir/a.tar.sync-conflict-20240304-050607.gz",
+	}
+	for key, expected := range testCases {
+		if got := syncConflictKey(key, modTime); got != expected {
+			t.Errorf("%s: expected %s, got %s", key, expected, got)
+		}
+	}
+}
+
+func TestSyncJob(t *testing.T) {
+	root, dirA := newTestFolder(t, map[string]string{"a": "a", "same": "same"})
+	dirB := filepath.Join(root, "b")
+	baselinePath := filepath.Join(root, "baseline.json")
+	writeFile := func(dir, name, data string) {
+		t.Helper()
+		writeTestFile(t, filepath.Join(dir, name), data)
+	}
+	readDir := func(dir string) map[string]string {
+		t.Helper()
+		files := make(map[string]string)
+		entries, e := os.ReadDir(dir)
+		if e != nil {
+			t.Fatal(e)
+		}
+		for _, entry := range entries {
+			data, e := os.ReadFile(filepath.Join(dir, entry.Name()))
+			if e != nil {
+				t.Fatal(e)
+			}
+			files[entry.Name()] = string(data)
+		}
+		return files
+	}
+	sync := func(policy syncConflictPolicy) {
+		t.Helper()
+		a, err := newSyncSide(dirA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := newSyncSide(dirB)
+		if err != nil {
+			t.Fatal(err)
+		}
+		baseline, err := loadSyncBaseline(baselinePath, a.url, b.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j := &syncJob{
+			a:            a,
+			b:            b,
+			baseline:     baseline,
+			conflict:     policy,
+			comparer:     newContentComparer(compareETag, "", "", nil),
+			parallelOpts: parallelOptions{minWorkers: 2, maxWorkers: 2},
+		}
+		failed, err := j.run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if failed > 0 {
+			t.Fatalf("%d objects failed to sync", failed)
+		}
+	}
+
+	writeFile(dirB, "same", "same")
+	writeFile(dirB, "b", "b")
+	sync(syncConflictNewer)
+	expected := map[string]string{"a": "a", "b": "b", "same": "same"}
+	if files := readDir(dirA); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("First sync of A: expected %v, got %v", expected, files)
+	}
+	if files := readDir(dirB); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("First sync of B: expected %v, got %v", expected, files)
+	}
+
+	// A removal is propagated, a modification on the other side too.
+	if e := os.Remove(filepath.Join(dirB, "a")); e != nil {
+		t.Fatal(e)
+	}
+	writeFile(dirA, "b", "bb")
+	sync(syncConflictNewer)
+	expected = map[string]string{"b": "bb", "same": "same"}
+	if files := readDir(dirA); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Second sync of A: expected %v, got %v", expected, files)
+	}
+	if files := readDir(dirB); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Second sync of B: expected %v, got %v", expected, files)
+	}
+
+	// Both sides modify the same object.
+	writeFile(dirA, "same", "from a")
+	writeFile(dirB, "same", "from b")
+	modTime := time.Date(2024, 3, 4, 5, 6, 7, 0, time.UTC)
+	if e := os.Chtimes(filepath.Join(dirB, "same"), modTime, modTime); e != nil {
+		t.Fatal(e)
+	}
+	sync(syncConflictKeepBoth)
+	kept := syncConflictKey("same", modTime)
+	expected = map[string]string{"b": "bb", "same": "from a", kept: "from b"}
+	if files := readDir(dirA); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Conflicting sync of A: expected %v, got %v", expected, files)
+	}
+	if files := readDir(dirB); !reflect.DeepEqual(files, expected) {
+		t.Fatalf("Conflicting sync of B: expected %v, got %v", expected, files)
+	}
+
+	if _, err := loadSyncBaseline(baselinePath, dirB, dirA); err == nil {
+		t.Fatal("Expected the baseline of other folders to fail")
+	}
+}
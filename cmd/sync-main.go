@@ -0,0 +1,499 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	json "github.com/minio/colorjson"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+// sync specific flags.
+var syncFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "conflict",
+		Value: string(syncConflictNewer),
+		Usage: "resolve objects changed on both sides by 'newer', 'source', 'target' or 'keep-both'",
+	},
+	cli.StringFlag{
+		Name:  "baseline",
+		Usage: "file of the baseline snapshot, defaults to a file in the mc config folder",
+	},
+	cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "print the copies and removals without applying them",
+	},
+	cli.StringFlag{
+		Name:  "compare",
+		Value: string(compareETag),
+		Usage: "compare objects of the same size by their 'etag', 'checksum' or 'content'",
+	},
+}
+
+// Keep two folders in sync both ways.
+var syncCmd = cli.Command{
+	Name:         "sync",
+	Usage:        "synchronize creates, updates and removals between two folders both ways",
+	Action:       mainSync,
+	OnUsageError: onUsageError,
+	Before:       setGlobalsFromContext,
+	Flags:        append(append(append(append(syncFlags, encFlags...), concurrencyFlags...), retryFlags...), globalFlags...),
+	CustomHelpTemplate: `NAME:
+  {{.HelpName}} - {{.Usage}}
+
+USAGE:
+  {{.HelpName}} [FLAGS] SOURCE TARGET
+
+FLAGS:
+  {{range .VisibleFlags}}{{.}}
+  {{end}}
+DESCRIPTION:
+  Sync compares both folders to a baseline snapshot saved at the end of the previous sync. Objects
+  created, modified or removed on one side since then are copied or removed on the other side, an
+  object missing from one side is only removed from the other side when it is in the baseline.
+
+  Objects changed on both sides are conflicting unless they have the same content, conflicts are
+  resolved by '--conflict':
+    newer     - the last modified object wins (default)
+    source    - the object of SOURCE wins
+    target    - the object of TARGET wins
+    keep-both - the object of TARGET is kept on both sides as 'NAME.sync-conflict-DATE-TIME.EXT'
+                and overwritten by the object of SOURCE
+  A modification wins over a removal, unless the removing side is preferred by '--conflict'.
+
+  The first sync of two folders has no baseline, it copies the objects found on one side only.
+
+EXAMPLES:
+  1. Synchronize a local folder with a bucket.
+     {{.Prompt}} {{.HelpName}} ~/Documents myminio/documents
+
+  2. Print what a sync would copy and remove without applying it.
+     {{.Prompt}} {{.HelpName}} --dry-run ~/Documents myminio/documents
+
+  3. Synchronize two buckets, keeping both objects when they are modified on both sides.
+     {{.Prompt}} {{.HelpName}} --conflict keep-both site1/projects site2/projects
+
+  4. Synchronize two buckets, the objects of the first bucket win the conflicts.
+     {{.Prompt}} {{.HelpName}} --conflict source site1/projects site2/projects
+
+  5. Synchronize with a baseline saved next to the local folder.
+     {{.Prompt}} {{.HelpName}} --baseline ~/.documents-sync.json ~/Documents myminio/documents
+`,
+}
+
+// syncMessage container for sync status messages.
+type syncMessage struct {
+	Status   string `json:"status"`
+	Action   string `json:"action"`
+	Source   string `json:"source,omitempty"`
+	Target   string `json:"target"`
+	Kept     string `json:"kept,omitempty"`
+	Conflict bool   `json:"conflict,omitempty"`
+	DryRun   bool   `json:"dryRun,omitempty"`
+}
+
+// String colorized sync message.
+func (s syncMessage) String() string {
+	var msg string
+	switch s.Action {
+	case "remove":
+		msg = fmt.Sprintf("Removed `%s`.", s.Target)
+	case "keep-both":
+		msg = fmt.Sprintf("`%s` -> `%s`, kept the previous object as `%s`.", s.Source, s.Target, s.Kept)
+	default:
+		msg = fmt.Sprintf("`%s` -> `%s`", s.Source, s.Target)
+	}
+	if s.Conflict {
+		msg += " (conflict)"
+	}
+	if s.DryRun {
+		msg = "(dry run) " + msg
+	}
+	return console.Colorize("Sync", msg)
+}
+
+// JSON jsonified sync message.
+func (s syncMessage) JSON() string {
+	s.Status = "success"
+	syncMessageBytes, e := json.MarshalIndent(s, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+	return string(syncMessageBytes)
+}
+
+// syncSide - one of the two folders kept in sync.
+type syncSide struct {
+	name  string // folder as given on the command line
+	alias string
+	url   string // expanded URL of the folder
+	root  string // path of the folder in the listed URLs
+}
+
+// newSyncSide - expands the alias of a folder, local folders are made
+// absolute to find the same baseline from any working directory.
+func newSyncSide(aliasedURL string) (*syncSide, *probe.Error) {
+	alias, urlStr, _, err := expandAlias(aliasedURL)
+	if err != nil {
+		return nil, err.Trace(aliasedURL)
+	}
+	u := newClientURL(urlStr)
+	if u.Type == fileSystem {
+		abs, e := filepath.Abs(urlStr)
+		if e != nil {
+			return nil, probe.NewError(e).Trace(aliasedURL)
+		}
+		urlStr = abs
+	}
+	separator := string(u.Separator)
+	if !strings.HasSuffix(urlStr, separator) {
+		urlStr += separator
+	}
+	if !strings.HasSuffix(aliasedURL, separator) {
+		aliasedURL += separator
+	}
+	return &syncSide{
+		name:  aliasedURL,
+		alias: alias,
+		url:   urlStr,
+		root:  newClientURL(urlStr).Path,
+	}, nil
+}
+
+// list - returns the objects of the folder by their path relative to
+// the folder, a folder which does not exist yet is empty.
+func (s *syncSide) list(ctx context.Context) (map[string]*ClientContent, *probe.Error) {
+	clnt, err := newClientFromAlias(s.alias, s.url)
+	if err != nil {
+		return nil, err.Trace(s.name)
+	}
+	objects := make(map[string]*ClientContent)
+	for content := range clnt.List(ctx, ListOptions{Recursive: true, ShowDir: DirNone}) {
+		if content.Err != nil {
+			switch content.Err.ToGoError().(type) {
+			case PathNotFound, ObjectMissing:
+				continue
+			}
+			return nil, content.Err.Trace(s.name)
+		}
+		if content.Type.IsDir() {
+			continue
+		}
+		key := filepath.ToSlash(strings.TrimPrefix(content.URL.Path, s.root))
+		objects[key] = content
+	}
+	return objects, nil
+}
+
+// join - returns the expanded URL of an object of the folder.
+func (s *syncSide) join(key string) string {
+	return urlJoinPath(s.url, key)
+}
+
+// syncJob - the state of a sync of two folders.
+type syncJob struct {
+	a, b         *syncSide
+	baseline     *syncBaseline
+	conflict     syncConflictPolicy
+	comparer     *contentComparer
+	encKeyDB     map[string][]prefixSSEPair
+	retry        retryPolicy
+	parallelOpts parallelOptions
+	dryRun       bool
+}
+
+// equal - returns true if two objects have the same content.
+func (j *syncJob) equal(ctx context.Context, a, b *ClientContent) (bool, *probe.Error) {
+	if a.Size != b.Size {
+		return false, nil
+	}
+	if j.comparer == nil {
+		return a.Time.Equal(b.Time), nil
+	}
+	return j.comparer.equal(ctx, a, b)
+}
+
+// message - returns the message printed for a decision, decisions only
+// updating the baseline are not printed.
+func (j *syncJob) message(d syncDecision) (syncMessage, bool) {
+	msg := syncMessage{Conflict: d.conflict, DryRun: j.dryRun}
+	switch d.op {
+	case syncOpCopyToB:
+		msg.Action, msg.Source, msg.Target = "copy", j.a.name+d.key, j.b.name+d.key
+	case syncOpCopyToA:
+		msg.Action, msg.Source, msg.Target = "copy", j.b.name+d.key, j.a.name+d.key
+	case syncOpRemoveA:
+		msg.Action, msg.Target = "remove", j.a.name+d.key
+	case syncOpRemoveB:
+		msg.Action, msg.Target = "remove", j.b.name+d.key
+	case syncOpKeepBoth:
+		msg.Action, msg.Source, msg.Target = "keep-both", j.a.name+d.key, j.b.name+d.key
+		msg.Kept = syncConflictKey(d.key, d.b.Time)
+	default:
+		return msg, false
+	}
+	return msg, true
+}
+
+// copy - copies an object of one folder to the other folder.
+func (j *syncJob) copy(ctx context.Context, from *syncSide, content *ClientContent, to *syncSide, key string) *probe.Error {
+	urls := URLs{
+		SourceAlias:   from.alias,
+		SourceContent: content,
+		TargetAlias:   to.alias,
+		TargetContent: &ClientContent{URL: *newClientURL(to.join(key))},
+	}
+	return retryWithMessage(ctx, j.retry, from.name+strings.TrimPrefix(content.URL.Path, from.root), to.name+key, func() *probe.Error {
+		return uploadSourceToTargetURL(ctx, uploadSourceToTargetURLOpts{urls: urls, encKeyDB: j.encKeyDB}).Error
+	})
+}
+
+// remove - removes an object from a folder.
+func (j *syncJob) remove(ctx context.Context, side *syncSide, key string) *probe.Error {
+	urlPath := newClientURL(side.join(key)).Path
+	clnt, err := newClient(filepath.ToSlash(filepath.Join(side.alias, urlPath)))
+	if err != nil {
+		return err.Trace(side.name + key)
+	}
+	return retryWithMessage(ctx, j.retry, "", side.name+key, func() *probe.Error {
+		contentCh := make(chan *ClientContent, 1)
+		contentCh <- &ClientContent{URL: *newClientURL(urlPath)}
+		close(contentCh)
+		var err *probe.Error
+		for result := range clnt.Remove(ctx, false, false, false, false, contentCh) {
+			if result.Err != nil && err == nil {
+				err = result.Err
+			}
+		}
+		return err
+	})
+}
+
+// stat - returns the current version of an object of a folder.
+func (j *syncJob) stat(ctx context.Context, side *syncSide, key string) (*ClientContent, *probe.Error) {
+	urlStr := side.join(key)
+	clnt, err := newClientFromAlias(side.alias, urlStr)
+	if err != nil {
+		return nil, err.Trace(side.name + key)
+	}
+	sse := getSSE(filepath.ToSlash(filepath.Join(side.alias, newClientURL(urlStr).Path)), j.encKeyDB[side.alias])
+	content, err := clnt.Stat(ctx, StatOptions{sse: sse})
+	if err != nil {
+		return nil, err.Trace(side.name + key)
+	}
+	return content, nil
+}
+
+// settle - records the current versions of an object in the baseline
+// once both sides are in sync, missing versions are read back.
+func (j *syncJob) settle(ctx context.Context, key string, a, b *ClientContent) *probe.Error {
+	var err *probe.Error
+	if a == nil {
+		if a, err = j.stat(ctx, j.a, key); err != nil {
+			return err
+		}
+	}
+	if b == nil {
+		if b, err = j.stat(ctx, j.b, key); err != nil {
+			return err
+		}
+	}
+	j.baseline.set(key, a, b)
+	return nil
+}
+
+// apply - brings an object in sync, the baseline of the object is only
+// updated once it succeeded so that a failed object is retried by the
+// next sync.
+func (j *syncJob) apply(ctx context.Context, d syncDecision) *probe.Error {
+	switch d.op {
+	case syncOpNone:
+		j.baseline.set(d.key, d.a, d.b)
+	case syncOpForget:
+		j.baseline.forget(d.key)
+	case syncOpCopyToB:
+		if err := j.copy(ctx, j.a, d.a, j.b, d.key); err != nil {
+			return err
+		}
+		return j.settle(ctx, d.key, d.a, nil)
+	case syncOpCopyToA:
+		if err := j.copy(ctx, j.b, d.b, j.a, d.key); err != nil {
+			return err
+		}
+		return j.settle(ctx, d.key, nil, d.b)
+	case syncOpRemoveA:
+		if err := j.remove(ctx, j.a, d.key); err != nil {
+			return err
+		}
+		j.baseline.forget(d.key)
+	case syncOpRemoveB:
+		if err := j.remove(ctx, j.b, d.key); err != nil {
+			return err
+		}
+		j.baseline.forget(d.key)
+	case syncOpKeepBoth:
+		kept := syncConflictKey(d.key, d.b.Time)
+		if err := j.copy(ctx, j.b, d.b, j.a, kept); err != nil {
+			return err
+		}
+		if err := j.copy(ctx, j.b, d.b, j.b, kept); err != nil {
+			return err
+		}
+		if err := j.settle(ctx, kept, nil, nil); err != nil {
+			return err
+		}
+		if err := j.copy(ctx, j.a, d.a, j.b, d.key); err != nil {
+			return err
+		}
+		return j.settle(ctx, d.key, d.a, nil)
+	}
+	return nil
+}
+
+// run - lists both folders, plans and applies the changes, and saves
+// the baseline. The number of objects which failed is returned.
+func (j *syncJob) run(ctx context.Context) (int, *probe.Error) {
+	a, err := j.a.list(ctx)
+	if err != nil {
+		return 0, err
+	}
+	b, err := j.b.list(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	equal := func(a, b *ClientContent) (bool, *probe.Error) {
+		return j.equal(ctx, a, b)
+	}
+	decisions := planSync(a, b, j.baseline, j.conflict, equal)
+
+	var failed int
+	if j.dryRun {
+		for _, d := range decisions {
+			if d.err != nil {
+				failed++
+				errorIf(d.err, "Unable to compare `"+j.a.name+d.key+"` and `"+j.b.name+d.key+"`.")
+				continue
+			}
+			if msg, ok := j.message(d); ok {
+				printMsg(msg)
+			}
+		}
+		return failed, nil
+	}
+
+	resultCh := make(chan URLs)
+	pm := newParallelManager(resultCh, j.parallelOpts)
+	go func() {
+		for _, d := range decisions {
+			d := d
+			var size int64
+			if d.a != nil {
+				size = d.a.Size
+			}
+			pm.queueTask(func() URLs {
+				err := d.err
+				if err == nil {
+					err = j.apply(ctx, d)
+				}
+				if err != nil {
+					errorIf(err, "Unable to sync `"+d.key+"`.")
+					return URLs{Error: err}
+				}
+				if msg, ok := j.message(d); ok {
+					printMsg(msg)
+				}
+				return URLs{}
+			}, size)
+		}
+		pm.stopAndWait()
+		close(resultCh)
+	}()
+	for urls := range resultCh {
+		if urls.Error != nil {
+			failed++
+		}
+	}
+
+	if ctx.Err() != nil {
+		return failed, probe.NewError(ctx.Err())
+	}
+	return failed, j.baseline.save()
+}
+
+// checkSyncSyntax - validate all the passed arguments
+func checkSyncSyntax(cliCtx *cli.Context) {
+	if len(cliCtx.Args()) != 2 {
+		showCommandHelpAndExit(cliCtx, globalErrorExitStatus)
+	}
+}
+
+// mainSync is the handle for "mc sync" command.
+func mainSync(cliCtx *cli.Context) error {
+	checkSyncSyntax(cliCtx)
+	console.SetColor("Sync", color.New(color.FgGreen, color.Bold))
+
+	ctx, cancelSync := context.WithCancel(globalContext)
+	defer cancelSync()
+
+	encKeyDB, err := validateAndCreateEncryptionKeys(cliCtx)
+	fatalIf(err, "Unable to parse encryption keys.")
+
+	conflict, err := parseSyncConflictPolicy(cliCtx.String("conflict"))
+	fatalIf(err, "Invalid conflict policy, valid values are newer, source, target and keep-both.")
+
+	parallelOpts, err := parallelOptionsFromContext(cliCtx)
+	fatalIf(err, "Invalid worker bounds or concurrency mode.")
+
+	args := cliCtx.Args()
+	a, err := newSyncSide(args.Get(0))
+	fatalIf(err, "Unable to sync `"+args.Get(0)+"`.")
+	b, err := newSyncSide(args.Get(1))
+	fatalIf(err, "Unable to sync `"+args.Get(1)+"`.")
+
+	baselinePath := cliCtx.String("baseline")
+	if baselinePath == "" {
+		baselinePath, err = defaultSyncBaselinePath(a.url, b.url)
+		fatalIf(err, "Unable to locate the sync baseline.")
+	}
+	baseline, err := loadSyncBaseline(baselinePath, a.url, b.url)
+	fatalIf(err, "Unable to load the sync baseline `"+baselinePath+"`.")
+
+	j := &syncJob{
+		a:            a,
+		b:            b,
+		baseline:     baseline,
+		conflict:     conflict,
+		comparer:     newContentComparer(mustParseCompareFlag(cliCtx), a.alias, b.alias, encKeyDB),
+		encKeyDB:     encKeyDB,
+		retry:        retryPolicyFromContext(cliCtx),
+		parallelOpts: parallelOpts,
+		dryRun:       cliCtx.Bool("dry-run"),
+	}
+	failed, err := j.run(ctx)
+	fatalIf(err, "Unable to sync `"+a.name+"` and `"+b.name+"`.")
+	if failed > 0 {
+		return exitStatus(globalErrorExitStatus)
+	}
+	return nil
+}
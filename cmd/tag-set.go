@@ -57,7 +57,7 @@ var tagSetCmd = cli.Command{
 	Action:       mainSetTag,
 	OnUsageError: onUsageError,
 	Before:       setGlobalsFromContext,
-	Flags:        append(tagSetFlags, globalFlags...),
+	Flags:        append(append(tagSetFlags, retryFlags...), globalFlags...),
 	CustomHelpTemplate: `NAME:
   {{.HelpName}} - {{.Usage}}
 
@@ -91,6 +91,9 @@ EXAMPLES:
 
   7. Assign tags to all the objects on a bucket, excluding folders
      {{.Prompt}} {{.HelpName}} myminio/testbucket --exclude-folders --recursive "key1=value1&key2=value2&key3=value3"
+
+  8. Assign tags to all the objects on a bucket, retrying every object up to 3 times on transient errors
+     {{.Prompt}} {{.HelpName}} myminio/testbucket --recursive --max-retries 3 "key1=value1&key2=value2&key3=value3"
 `,
 }
 
@@ -99,6 +102,7 @@ type tagSetMessage struct {
 	Status    string `json:"status"`
 	Name      string `json:"name"`
 	VersionID string `json:"versionID"`
+	Retries   int    `json:"retries"`
 }
 
 // tagSetMessage console colorized output.
@@ -145,13 +149,15 @@ func parseSetTagSyntax(ctx *cli.Context) (targetURL, versionID string, timeRef t
 }
 
 // Set tags to a bucket or to a specified object/version
-func setTags(ctx context.Context, clnt Client, versionID, tags string) {
+func setTags(ctx context.Context, clnt Client, versionID, tags string, retry retryPolicy) {
 	targetName := clnt.GetURL().String()
 	if versionID != "" {
 		targetName += " (" + versionID + ")"
 	}
 
-	err := clnt.SetTags(ctx, versionID, tags)
+	retries, err := retryObjectWithMessage(ctx, retry, "", targetName, func(int) *probe.Error {
+		return clnt.SetTags(ctx, versionID, tags)
+	})
 	if err != nil {
 		fatalIf(err.Trace(tags), "Failed to set tags for "+targetName)
 		return
@@ -160,16 +166,17 @@ func setTags(ctx context.Context, clnt Client, versionID, tags string) {
 		Status:    "success",
 		Name:      clnt.GetURL().String(),
 		VersionID: versionID,
+		Retries:   retries,
 	})
 }
 
-func setTagsSingle(ctx context.Context, alias, url, versionID, tags string) *probe.Error {
+func setTagsSingle(ctx context.Context, alias, url, versionID, tags string, retry retryPolicy) *probe.Error {
 	newClnt, err := newClientFromAlias(alias, url)
 	if err != nil {
 		return err
 	}
 
-	setTags(ctx, newClnt, versionID, tags)
+	setTags(ctx, newClnt, versionID, tags, retry)
 	return nil
 }
 
@@ -184,12 +191,14 @@ func mainSetTag(cliCtx *cli.Context) error {
 		timeRef = time.Now().UTC()
 	}
 
+	retry := retryPolicyFromContext(cliCtx)
+
 	clnt, err := newClient(targetURL)
 	fatalIf(err.Trace(cliCtx.Args()...), "Unable to initialize target "+targetURL)
 
 	alias, urlStr, _ := mustExpandAlias(targetURL)
 	if timeRef.IsZero() && !withVersions && !recursive && !excludeFolders {
-		err := setTagsSingle(ctx, alias, urlStr, versionID, tags)
+		err := setTagsSingle(ctx, alias, urlStr, versionID, tags, retry)
 		fatalIf(err.Trace(), "Unable to set tags on `%s`", targetURL)
 		return nil
 	}
@@ -214,7 +223,7 @@ func mainSetTag(cliCtx *cli.Context) error {
 			break
 		}
 
-		err := setTagsSingle(ctx, alias, content.URL.String(), content.VersionID, tags)
+		err := setTagsSingle(ctx, alias, content.URL.String(), content.VersionID, tags, retry)
 		if err != nil {
 			errorIf(err.Trace(clnt.GetURL().String()), "Invalid URL")
 			continue
@@ -0,0 +1,241 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dustin/go-humanize"
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/pkg/v2/console"
+)
+
+const (
+	// Largest object a single CopyObject call can copy.
+	maxCopyObjectSize = 5 * humanize.GiByte
+
+	// Objects from this size on are copied in parallel parts.
+	minComposeObjectSize = 64 * humanize.MiByte
+)
+
+// transferStrategy - how an object is transferred to its target.
+type transferStrategy string
+
+const (
+	transferStream  transferStrategy = "stream"  // read from source, upload to target
+	transferCopy    transferStrategy = "copy"    // server side CopyObject
+	transferCompose transferStrategy = "compose" // server side ComposeObject
+)
+
+// transferPlan - the strategy chosen for an object and why.
+type transferPlan struct {
+	Strategy transferStrategy
+	Reason   string
+}
+
+// isServerSide - returns true if the data does not go through mc.
+func (p transferPlan) isServerSide() bool {
+	return p.Strategy != transferStream
+}
+
+// useComposeObject - returns true if a server side copy of this size
+// must, or is better, done with ComposeObject.
+func useComposeObject(size int64, disableMultipart bool) bool {
+	if size > maxCopyObjectSize {
+		return true
+	}
+	return !disableMultipart && size >= minComposeObjectSize
+}
+
+// planTransfer - chooses how to transfer an object. Server side copy is
+// used whenever the source and the target aliases resolve to the same
+// endpoint with the same credentials, otherwise the data is streamed.
+func planTransfer(urls URLs, isZip bool) transferPlan {
+	sourceAlias, targetAlias := urls.SourceAlias, urls.TargetAlias
+	switch {
+	case isZip:
+		return transferPlan{transferStream, "source is extracted from a zip archive"}
+	case urls.Checksum.IsSet():
+		return transferPlan{transferStream, "checksum verification needs the data"}
+	case sourceAlias == "" && targetAlias == "":
+		return transferPlan{transferCopy, "source and target are on the local filesystem"}
+	case sourceAlias == "" || targetAlias == "":
+		return transferPlan{transferStream, "source or target is on the local filesystem"}
+	}
+
+	reason := "same alias"
+	if sourceAlias != targetAlias {
+		same, why := isSameEndpointScope(sourceAlias, targetAlias)
+		if !same {
+			return transferPlan{transferStream, why}
+		}
+		reason = why
+	}
+
+	if useComposeObject(urls.SourceContent.Size, urls.DisableMultipart) {
+		return transferPlan{transferCompose, reason}
+	}
+	return transferPlan{transferCopy, reason}
+}
+
+// endpointScope - whether two aliases share endpoint and credentials,
+// and why.
+type endpointScope struct {
+	same   bool
+	reason string
+}
+
+// Scopes of the pairs of aliases compared so far, the aliases do not
+// change while a command runs.
+var (
+	endpointScopesMu sync.Mutex
+	endpointScopes   = make(map[[2]string]endpointScope)
+)
+
+// isSameEndpointScope - returns true if both aliases point at the same
+// endpoint with the same credentials, so that a server side copy issued
+// through the target alias may read the source. Aliases are compared
+// once per pair.
+func isSameEndpointScope(sourceAlias, targetAlias string) (bool, string) {
+	key := [2]string{sourceAlias, targetAlias}
+	endpointScopesMu.Lock()
+	scope, ok := endpointScopes[key]
+	endpointScopesMu.Unlock()
+	if !ok {
+		scope.same, scope.reason = compareEndpointScope(sourceAlias, targetAlias)
+		endpointScopesMu.Lock()
+		endpointScopes[key] = scope
+		endpointScopesMu.Unlock()
+	}
+	return scope.same, scope.reason
+}
+
+// compareEndpointScope - compares the endpoints and the credentials of
+// two aliases, static keys or the provider they are sourced from.
+func compareEndpointScope(sourceAlias, targetAlias string) (bool, string) {
+	_, _, sourceCfg, err := expandAlias(sourceAlias)
+	if err != nil || sourceCfg == nil {
+		return false, "unable to resolve alias `" + sourceAlias + "`"
+	}
+	_, _, targetCfg, err := expandAlias(targetAlias)
+	if err != nil || targetCfg == nil {
+		return false, "unable to resolve alias `" + targetAlias + "`"
+	}
+
+	if normalizeEndpoint(sourceCfg.URL) != normalizeEndpoint(targetCfg.URL) {
+		return false, "aliases point at different endpoints"
+	}
+	if sourceCfg.AccessKey != targetCfg.AccessKey || sourceCfg.SessionToken != targetCfg.SessionToken {
+		return false, "aliases use different credentials"
+	}
+	sourceProvider, targetProvider := sourceCfg.CredentialProvider, targetCfg.CredentialProvider
+	if (sourceProvider == nil) != (targetProvider == nil) || sourceProvider != nil && *sourceProvider != *targetProvider {
+		return false, "aliases use different credential providers"
+	}
+	return true, "aliases share endpoint and credentials"
+}
+
+// normalizeEndpoint - returns a comparable form of an alias URL.
+func normalizeEndpoint(endpoint string) string {
+	u, e := url.Parse(endpoint)
+	if e != nil {
+		return endpoint
+	}
+	host, port := strings.ToLower(u.Hostname()), u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	return strings.ToLower(u.Scheme) + "://" + host + ":" + port + strings.TrimSuffix(u.Path, "/")
+}
+
+// transferPlanMessage container for transfer plan messages.
+type transferPlanMessage struct {
+	Status   string           `json:"status"`
+	Source   string           `json:"source"`
+	Target   string           `json:"target"`
+	Size     int64            `json:"size"`
+	Strategy transferStrategy `json:"strategy"`
+	Reason   string           `json:"reason"`
+}
+
+// String colorized transfer plan message.
+func (t transferPlanMessage) String() string {
+	return console.Colorize("Plan", fmt.Sprintf("%-8s", t.Strategy)) + " " +
+		fmt.Sprintf("`%s` -> `%s` (%s, %s)", t.Source, t.Target, humanize.IBytes(uint64(t.Size)), t.Reason)
+}
+
+// JSON jsonified transfer plan message.
+func (t transferPlanMessage) JSON() string {
+	t.Status = "success"
+	buf, e := json.MarshalIndent(t, "", " ")
+	fatalIf(probe.NewError(e), "Unable to marshal into JSON.")
+
+	return string(buf)
+}
+
+// doCopyPlan - prints the strategy chosen for every object to be copied,
+// without copying anything.
+func doCopyPlan(ctx context.Context, cli *cli.Context, encryptionKeys map[string][]prefixSSEPair) error {
+	sourceURLs := cli.Args()[:len(cli.Args())-1]
+	targetURL := cli.Args()[len(cli.Args())-1] // Last one is target
+
+	opts := prepareCopyURLsOpts{
+		sourceURLs:  sourceURLs,
+		targetURL:   targetURL,
+		isRecursive: cli.Bool("recursive"),
+		encKeyDB:    encryptionKeys,
+		olderThan:   cli.String("older-than"),
+		newerThan:   cli.String("newer-than"),
+		timeRef:     parseRewindFlag(cli.String("rewind")),
+		versionID:   cli.String("version-id"),
+		isZip:       cli.Bool("zip"),
+	}
+
+	checksum := mustParseChecksumFlag(cli)
+	console.SetColor("Plan", color.New(color.FgCyan, color.Bold))
+
+	for cpURLs := range prepareCopyURLs(ctx, opts) {
+		if cpURLs.Error != nil {
+			printCopyURLsError(&cpURLs)
+			return exitStatus(globalErrorExitStatus)
+		}
+		cpURLs.Checksum = checksum
+		cpURLs.DisableMultipart = cli.Bool("disable-multipart")
+
+		plan := planTransfer(cpURLs, opts.isZip)
+		printMsg(transferPlanMessage{
+			Source:   filepath.ToSlash(filepath.Join(cpURLs.SourceAlias, cpURLs.SourceContent.URL.Path)),
+			Target:   filepath.ToSlash(filepath.Join(cpURLs.TargetAlias, cpURLs.TargetContent.URL.Path)),
+			Size:     cpURLs.SourceContent.Size,
+			Strategy: plan.Strategy,
+			Reason:   plan.Reason,
+		})
+	}
+	return nil
+}
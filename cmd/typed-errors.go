@@ -191,3 +191,139 @@ var errSSEClientKeyFormat = func(msg string) *probe.Error {
 	m += msg
 	return probe.NewError(sseClientKeyFormatErr(errors.New(m))).Untrace()
 }
+
+type sessionNotFoundErr error
+
+var errSessionNotFound = func(name string) *probe.Error {
+	msg := "Session `" + name + "` not found."
+	return probe.NewError(sessionNotFoundErr(errors.New(msg))).Untrace()
+}
+
+type sessionExistsErr error
+
+var errSessionExists = func(name string) *probe.Error {
+	msg := "Session `" + name + "` already exists. Use `mc session resume " + name + "` to continue it."
+	return probe.NewError(sessionExistsErr(errors.New(msg))).Untrace()
+}
+
+type sessionVersionErr error
+
+var errSessionVersion = func(name, version string) *probe.Error {
+	msg := "Session `" + name + "` has unsupported version `" + version + "`, expected `" + globalSessionConfigVersion + "`."
+	return probe.NewError(sessionVersionErr(errors.New(msg))).Untrace()
+}
+
+type invalidCredentialStoreErr error
+
+var errInvalidCredentialStore = func(store string) *probe.Error {
+	msg := "Invalid credential store `" + store + "`, valid stores are `passphrase` and `helper:NAME`."
+	return probe.NewError(invalidCredentialStoreErr(errors.New(msg))).Untrace()
+}
+
+type credentialUnsealErr error
+
+var errCredentialUnseal = func(alias string) *probe.Error {
+	msg := "Unable to decrypt the credentials of alias `" + alias + "`, please check the passphrase."
+	return probe.NewError(credentialUnsealErr(errors.New(msg))).Untrace()
+}
+
+type credentialPassphraseErr error
+
+var errCredentialPassphrase = func() *probe.Error {
+	msg := "A passphrase is required to access encrypted credentials, set `" + mcEnvCredentialPassphrase + "` when not running in a terminal."
+	return probe.NewError(credentialPassphraseErr(errors.New(msg))).Untrace()
+}
+
+type cseKeyFormatErr error
+
+var errCSEKeyFormat = func(keyFile string) *probe.Error {
+	msg := "Client side encryption key file `" + keyFile + "` should hold a 32 bytes key, either raw, hex or base64 encoded."
+	return probe.NewError(cseKeyFormatErr(errors.New(msg))).Untrace()
+}
+
+type cseNotEncryptedErr error
+
+var errCSENotEncrypted = func() *probe.Error {
+	msg := "Object is not client side encrypted."
+	return probe.NewError(cseNotEncryptedErr(errors.New(msg))).Untrace()
+}
+
+type cseDecryptErr error
+
+var errCSEDecrypt = func() *probe.Error {
+	msg := "Unable to decrypt the client side encryption key of the object, please check the key file."
+	return probe.NewError(cseDecryptErr(errors.New(msg))).Untrace()
+}
+
+type mirrorStateMismatchErr error
+
+var errMirrorStateMismatch = func(path string) *probe.Error {
+	msg := "Mirror state `" + path + "` was saved by a mirror of another source or target."
+	return probe.NewError(mirrorStateMismatchErr(errors.New(msg))).Untrace()
+}
+
+type syncBaselineMismatchErr error
+
+var errSyncBaselineMismatch = func(path string) *probe.Error {
+	msg := "Sync baseline `" + path + "` was saved by a sync of other folders."
+	return probe.NewError(syncBaselineMismatchErr(errors.New(msg))).Untrace()
+}
+
+type mirrorMaxDeleteErr error
+
+var errMirrorMaxDelete = func(removals int, targets int64, threshold string) *probe.Error {
+	msg := fmt.Sprintf("Mirror would remove %d of %d target objects, more than --max-delete %s. No object was removed.", removals, targets, threshold)
+	return probe.NewError(mirrorMaxDeleteErr(errors.New(msg))).Untrace()
+}
+
+type invalidCronScheduleErr error
+
+var errInvalidCronSchedule = func(spec, reason string) *probe.Error {
+	msg := "Invalid cron schedule `" + spec + "`: " + reason + "."
+	return probe.NewError(invalidCronScheduleErr(errors.New(msg))).Untrace()
+}
+
+type scheduleJobNotFoundErr error
+
+var errScheduleJobNotFound = func(name string) *probe.Error {
+	msg := "Scheduled job `" + name + "` does not exist."
+	return probe.NewError(scheduleJobNotFoundErr(errors.New(msg))).Untrace()
+}
+
+type scheduleJobExistsErr error
+
+var errScheduleJobExists = func(name string) *probe.Error {
+	msg := "Scheduled job `" + name + "` already exists."
+	return probe.NewError(scheduleJobExistsErr(errors.New(msg))).Untrace()
+}
+
+type scheduleJobRunningErr error
+
+var errScheduleJobRunning = func(name string, pid int) *probe.Error {
+	msg := "Scheduled job `" + name + "` is already running."
+	if pid > 0 {
+		msg = fmt.Sprintf("Scheduled job `%s` is already running in process %d.", name, pid)
+	}
+	return probe.NewError(scheduleJobRunningErr(errors.New(msg))).Untrace()
+}
+
+type scheduleCommandErr error
+
+var errScheduleCommand = func(command string) *probe.Error {
+	msg := "`" + command + "` is not an mc command which can be scheduled."
+	return probe.NewError(scheduleCommandErr(errors.New(msg))).Untrace()
+}
+
+type scheduleCommandArgsErr error
+
+var errScheduleCommandArgs = func(command string, minArgs int) *probe.Error {
+	msg := fmt.Sprintf("`%s` is missing arguments, the command takes at least %d.", command, minArgs)
+	return probe.NewError(scheduleCommandArgsErr(errors.New(msg))).Untrace()
+}
+
+type sourceChangedErr error
+
+var errSourceChanged = func(source string) *probe.Error {
+	msg := "Source `" + source + "` was modified since the session was planned, refusing to resume its upload."
+	return probe.NewError(sourceChangedErr(errors.New(msg))).Untrace()
+}
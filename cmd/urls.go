@@ -19,6 +19,7 @@ package cmd
 
 import (
 	"github.com/minio/mc/pkg/probe"
+	"github.com/minio/minio-go/v7"
 )
 
 // URLs contains source and target urls
@@ -31,7 +32,9 @@ type URLs struct {
 	TotalSize        int64
 	MD5              bool
 	DisableMultipart bool
+	Checksum         minio.ChecksumType
 	encKeyDB         map[string][]prefixSSEPair
+	targetMissing    bool         // the comparison found no target object
 	Error            *probe.Error `json:"-"`
 	ErrorCond        differType   `json:"-"`
 }
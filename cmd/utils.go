@@ -20,6 +20,7 @@ package cmd
 import (
 	"bytes"
 	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"math"
@@ -38,6 +39,7 @@ import (
 	"github.com/minio/minio-go/v7"
 
 	jwtgo "github.com/golang-jwt/jwt/v4"
+	"github.com/minio/mc/pkg/limiter"
 	"github.com/minio/mc/pkg/probe"
 	"github.com/minio/pkg/v2/console"
 )
@@ -135,7 +137,7 @@ func splitStr(path, sep string, n int) []string {
 
 // NewS3Config simply creates a new Config struct using the passed
 // parameters.
-func NewS3Config(alias, urlStr string, aliasCfg *aliasConfigV10) *Config {
+func NewS3Config(alias, urlStr string, aliasCfg *aliasConfigV11) (*Config, *probe.Error) {
 	// We have a valid alias and hostConfig. We populate the
 	// credentials from the match found in the config file.
 	s3Config := new(Config)
@@ -146,8 +148,8 @@ func NewS3Config(alias, urlStr string, aliasCfg *aliasConfigV10) *Config {
 	s3Config.Insecure = globalInsecure
 	s3Config.ConnReadDeadline = globalConnReadDeadline
 	s3Config.ConnWriteDeadline = globalConnWriteDeadline
-	s3Config.UploadLimit = int64(globalLimitUpload)
-	s3Config.DownloadLimit = int64(globalLimitDownload)
+	s3Config.UploadLimit = globalLimitUpload
+	s3Config.DownloadLimit = globalLimitDownload
 
 	s3Config.HostURL = urlStr
 	s3Config.Alias = alias
@@ -157,8 +159,59 @@ func NewS3Config(alias, urlStr string, aliasCfg *aliasConfigV10) *Config {
 		s3Config.SessionToken = aliasCfg.SessionToken
 		s3Config.Signature = aliasCfg.API
 		s3Config.Lookup = getLookupType(aliasCfg.Path)
+		s3Config.CredentialProvider = aliasCfg.CredentialProvider
+		if err := applyAliasConnSettings(s3Config, aliasCfg); err != nil {
+			return nil, err.Trace(alias)
+		}
+	}
+	return s3Config, nil
+}
+
+// applyAliasConnSettings - applies the connection settings stored with
+// an alias, settings passed on the command line take precedence.
+func applyAliasConnSettings(s3Config *Config, aliasCfg *aliasConfigV11) *probe.Error {
+	var e error
+	if s3Config.UploadLimit == nil && aliasCfg.UploadLimit != "" {
+		if s3Config.UploadLimit, e = limiter.ParseSchedule(aliasCfg.UploadLimit); e != nil {
+			return probe.NewError(e).Trace(aliasCfg.UploadLimit)
+		}
+	}
+	if s3Config.DownloadLimit == nil && aliasCfg.DownloadLimit != "" {
+		if s3Config.DownloadLimit, e = limiter.ParseSchedule(aliasCfg.DownloadLimit); e != nil {
+			return probe.NewError(e).Trace(aliasCfg.DownloadLimit)
+		}
+	}
+	if s3Config.ConnReadDeadline <= 0 && aliasCfg.ConnReadDeadline != "" {
+		if s3Config.ConnReadDeadline, e = time.ParseDuration(aliasCfg.ConnReadDeadline); e != nil {
+			return probe.NewError(e).Trace(aliasCfg.ConnReadDeadline)
+		}
+	}
+	if s3Config.ConnWriteDeadline <= 0 && aliasCfg.ConnWriteDeadline != "" {
+		if s3Config.ConnWriteDeadline, e = time.ParseDuration(aliasCfg.ConnWriteDeadline); e != nil {
+			return probe.NewError(e).Trace(aliasCfg.ConnWriteDeadline)
+		}
+	}
+	s3Config.Insecure = s3Config.Insecure || aliasCfg.Insecure
+	s3Config.MaxConcurrency = aliasCfg.MaxConcurrency
+
+	if aliasCfg.CABundle != "" {
+		pem, e := os.ReadFile(aliasCfg.CABundle)
+		if e != nil {
+			return probe.NewError(e).Trace(aliasCfg.CABundle)
+		}
+
+		// Trust the bundle in addition to the system and mc certificates.
+		rootCAs := x509.NewCertPool()
+		if globalRootCAs != nil {
+			rootCAs = globalRootCAs.Clone()
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return errInvalidArgument().Trace(aliasCfg.CABundle)
+		}
+		s3Config.RootCAs = rootCAs
+		s3Config.CABundle = aliasCfg.CABundle
 	}
-	return s3Config
+	return nil
 }
 
 // lineTrunc - truncates a string to the given maximum length by
@@ -353,7 +406,7 @@ func httpClient(reqTimeout time.Duration) *http.Client {
 	}
 }
 
-func getPrometheusToken(hostConfig *aliasConfigV10) (string, error) {
+func getPrometheusToken(hostConfig *aliasConfigV11) (string, error) {
 	jwt := jwtgo.NewWithClaims(jwtgo.SigningMethodHS512, jwtgo.RegisteredClaims{
 		ExpiresAt: jwtgo.NewNumericDate(UTCNow().Add(defaultPrometheusJWTExpiry)),
 		Subject:   hostConfig.AccessKey,
@@ -18,8 +18,12 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/minio/mc/pkg/probe"
 )
 
 func TestParseAttribute(t *testing.T) {
@@ -81,3 +85,34 @@ func TestParseAttribute(t *testing.T) {
 
 	}
 }
+
+// newTestFolder - stubs loadMcConfig with a default configuration for
+// the duration of the test, returns a temporary folder and its src sub
+// folder holding the files, named by slash separated paths.
+func newTestFolder(t *testing.T, files map[string]string) (root, srcDir string) {
+	t.Helper()
+	load := loadMcConfig
+	t.Cleanup(func() { loadMcConfig = load })
+	loadMcConfig = func() (*configV11, *probe.Error) { return newMcConfig(), nil }
+
+	root = t.TempDir()
+	srcDir = filepath.Join(root, "src")
+	if e := os.MkdirAll(srcDir, 0o755); e != nil {
+		t.Fatal(e)
+	}
+	for name, data := range files {
+		writeTestFile(t, filepath.Join(srcDir, filepath.FromSlash(name)), data)
+	}
+	return root, srcDir
+}
+
+// writeTestFile - writes a file, creating its parent folders.
+func writeTestFile(t *testing.T, name, data string) {
+	t.Helper()
+	if e := os.MkdirAll(filepath.Dir(name), 0o755); e != nil {
+		t.Fatal(e)
+	}
+	if e := os.WriteFile(name, []byte(data), 0o644); e != nil {
+		t.Fatal(e)
+	}
+}
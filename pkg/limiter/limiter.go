@@ -22,21 +22,79 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/juju/ratelimit"
 )
 
+// scheduledBucket hands out the token bucket for the rate scheduled at
+// the current time, buckets are kept per rate so that switching back
+// and forth between windows does not reset them.
+type scheduledBucket struct {
+	schedule Schedule
+
+	mu      sync.Mutex
+	buckets map[int64]*ratelimit.Bucket
+}
+
+func newScheduledBucket(schedule Schedule) *scheduledBucket {
+	if schedule.IsUnlimited() {
+		return nil
+	}
+	return &scheduledBucket{
+		schedule: schedule,
+		buckets:  make(map[int64]*ratelimit.Bucket),
+	}
+}
+
+// current returns the bucket for the rate in effect now, nil if unlimited.
+func (s *scheduledBucket) current() *ratelimit.Bucket {
+	rate := s.schedule.RateAt(time.Now())
+	if rate <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[rate]
+	if !ok {
+		b = ratelimit.NewBucketWithRate(float64(rate), rate)
+		s.buckets[rate] = b
+	}
+	return b
+}
+
+// scheduledReader limits a reader with the rate in effect at each read,
+// long running transfers follow the schedule as it changes.
+type scheduledReader struct {
+	r io.Reader
+	b *scheduledBucket
+}
+
+func (s *scheduledReader) Read(buf []byte) (int, error) {
+	n, err := s.r.Read(buf)
+	if n <= 0 {
+		return n, err
+	}
+	if b := s.b.current(); b != nil {
+		b.Wait(int64(n))
+	}
+	return n, err
+}
+
 type limiter struct {
-	upload    *ratelimit.Bucket
-	download  *ratelimit.Bucket
+	upload    *scheduledBucket
+	download  *scheduledBucket
 	transport http.RoundTripper // HTTP transport that needs to be intercepted
 }
 
-func (l limiter) limitReader(r io.Reader, b *ratelimit.Bucket) io.Reader {
+func (l limiter) limitReader(r io.Reader, b *scheduledBucket) io.Reader {
 	if b == nil {
 		return r
 	}
-	return ratelimit.Reader(r, b)
+	return &scheduledReader{r: r, b: b}
 }
 
 // RoundTrip executes user provided request and response hooks for each HTTP call.
@@ -68,28 +126,16 @@ func (l limiter) RoundTrip(req *http.Request) (res *http.Response, err error) {
 	return res, err
 }
 
-// New return a ratelimited transport
-func New(uploadLimit, downloadLimit int64, transport http.RoundTripper) http.RoundTripper {
-	if uploadLimit == 0 && downloadLimit == 0 {
+// New return a ratelimited transport, the upload and download rates
+// follow their schedules while the process runs.
+func New(uploadSchedule, downloadSchedule Schedule, transport http.RoundTripper) http.RoundTripper {
+	if uploadSchedule.IsUnlimited() && downloadSchedule.IsUnlimited() {
 		return transport
 	}
 
-	var (
-		uploadBucket   *ratelimit.Bucket
-		downloadBucket *ratelimit.Bucket
-	)
-
-	if uploadLimit > 0 {
-		uploadBucket = ratelimit.NewBucketWithRate(float64(uploadLimit), uploadLimit)
-	}
-
-	if downloadLimit > 0 {
-		downloadBucket = ratelimit.NewBucketWithRate(float64(downloadLimit), downloadLimit)
-	}
-
 	return &limiter{
-		upload:    uploadBucket,
-		download:  downloadBucket,
+		upload:    newScheduledBucket(uploadSchedule),
+		download:  newScheduledBucket(downloadSchedule),
 		transport: transport,
 	}
 }
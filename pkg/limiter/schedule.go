@@ -0,0 +1,139 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package limiter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const day = 24 * time.Hour
+
+// Window is a daily time window with its rate in bytes per second,
+// a rate of zero means unlimited. Start and End are wall clock times
+// of the day, as offsets from 00:00, a window with End before Start
+// wraps around midnight and a window with Start equal to End spans the
+// whole day.
+type Window struct {
+	Start, End time.Duration
+	Rate       int64
+}
+
+// contains returns true if the time of day falls in the window.
+func (w Window) contains(offset time.Duration) bool {
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return offset >= w.Start && offset < w.End
+	default:
+		return offset >= w.Start || offset < w.End
+	}
+}
+
+// Schedule is a list of daily windows, the first window containing
+// the current time of day applies. Outside of all windows the rate
+// is unlimited.
+type Schedule []Window
+
+// Fixed returns a schedule applying the same rate all day long.
+func Fixed(rate int64) Schedule {
+	if rate <= 0 {
+		return nil
+	}
+	return Schedule{{Rate: rate}}
+}
+
+// RateAt returns the rate in bytes per second at the given time. The
+// windows are compared with the wall clock, so they keep their times on
+// days when daylight saving time starts or ends.
+func (s Schedule) RateAt(t time.Time) int64 {
+	hour, minute, sec := t.Clock()
+	offset := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute +
+		time.Duration(sec)*time.Second + time.Duration(t.Nanosecond())
+	for _, w := range s {
+		if w.contains(offset) {
+			return w.Rate
+		}
+	}
+	return 0
+}
+
+// IsUnlimited returns true if the schedule never limits the rate.
+func (s Schedule) IsUnlimited() bool {
+	for _, w := range s {
+		if w.Rate > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSchedule parses either a single rate such as "10MiB", or a comma
+// separated list of daily windows such as "09:00-18:00=10MiB,18:00-09:00=0".
+func ParseSchedule(s string) (Schedule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.Contains(s, "=") {
+		rate, e := humanize.ParseBytes(s)
+		if e != nil {
+			return nil, e
+		}
+		return Fixed(int64(rate)), nil
+	}
+
+	var schedule Schedule
+	for _, entry := range strings.Split(s, ",") {
+		window, rateStr, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule window `%s`, expected HH:MM-HH:MM=RATE", entry)
+		}
+		startStr, endStr, ok := strings.Cut(window, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule window `%s`, expected HH:MM-HH:MM=RATE", entry)
+		}
+		start, e := parseTimeOfDay(startStr)
+		if e != nil {
+			return nil, e
+		}
+		end, e := parseTimeOfDay(endStr)
+		if e != nil {
+			return nil, e
+		}
+		rate, e := humanize.ParseBytes(strings.TrimSpace(rateStr))
+		if e != nil {
+			return nil, e
+		}
+		schedule = append(schedule, Window{Start: start, End: end, Rate: int64(rate)})
+	}
+	return schedule, nil
+}
+
+// parseTimeOfDay parses HH:MM as an offset from midnight.
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, e := time.Parse("15:04", strings.TrimSpace(s))
+	if e != nil {
+		return 0, fmt.Errorf("invalid time of day `%s`, expected HH:MM", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
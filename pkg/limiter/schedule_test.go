@@ -0,0 +1,88 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package limiter
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	testCases := []struct {
+		schedule string
+		at       string
+		rate     int64
+		fail     bool
+	}{
+		{"", "12:00", 0, false},
+		{"1KiB", "03:00", 1024, false},
+		{"09:00-18:00=10MiB,18:00-09:00=0", "09:00", 10 << 20, false},
+		{"09:00-18:00=10MiB,18:00-09:00=0", "17:59", 10 << 20, false},
+		{"09:00-18:00=10MiB,18:00-09:00=0", "18:00", 0, false},
+		{"09:00-18:00=10MiB,18:00-09:00=0", "02:00", 0, false},
+		{"22:00-06:00=1MiB", "23:30", 1 << 20, false},
+		{"22:00-06:00=1MiB", "05:59", 1 << 20, false},
+		{"22:00-06:00=1MiB", "12:00", 0, false},
+		{"09:00-18:00", "", 0, true},
+		{"0900-1800=1MiB", "", 0, true},
+		{"25:00-18:00=1MiB", "", 0, true},
+		{"09:00-18:00=fast", "", 0, true},
+	}
+
+	for i, testCase := range testCases {
+		schedule, e := ParseSchedule(testCase.schedule)
+		if testCase.fail {
+			if e == nil {
+				t.Fatalf("Test %d: expected `%s` to fail", i+1, testCase.schedule)
+			}
+			continue
+		}
+		if e != nil {
+			t.Fatalf("Test %d: unexpected error %v", i+1, e)
+		}
+		at, _ := time.Parse("15:04", testCase.at)
+		at = time.Date(2024, 1, 1, at.Hour(), at.Minute(), 0, 0, time.Local)
+		if rate := schedule.RateAt(at); rate != testCase.rate {
+			t.Fatalf("Test %d: expected rate %d at %s, got %d", i+1, testCase.rate, testCase.at, rate)
+		}
+	}
+}
+
+func TestRateAtDaylightSaving(t *testing.T) {
+	loc, e := time.LoadLocation("America/New_York")
+	if e != nil {
+		t.Skip("no time zone database")
+	}
+	schedule, e := ParseSchedule("10:00-11:00=1MiB")
+	if e != nil {
+		t.Fatal(e)
+	}
+	// Clocks go forward on March 10 and back on November 3 2024, 10:30
+	// is then one hour less or more after midnight.
+	for i, at := range []time.Time{
+		time.Date(2024, time.March, 10, 10, 30, 0, 0, loc),
+		time.Date(2024, time.November, 3, 10, 30, 0, 0, loc),
+	} {
+		if rate := schedule.RateAt(at); rate != 1<<20 {
+			t.Fatalf("Test %d: Expected the rate of the window at %s, got %d", i+1, at, rate)
+		}
+		if rate := schedule.RateAt(at.Add(time.Hour)); rate != 0 {
+			t.Fatalf("Test %d: Expected no rate at %s, got %d", i+1, at.Add(time.Hour), rate)
+		}
+	}
+}
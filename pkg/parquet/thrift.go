@@ -0,0 +1,118 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Types of the thrift compact protocol.
+const (
+	compactI32    = 5
+	compactI64    = 6
+	compactBinary = 8
+	compactList   = 9
+	compactStruct = 12
+)
+
+// thriftWriter encodes structs with the thrift compact protocol, the
+// protocol of the parquet page headers and file metadata.
+type thriftWriter struct {
+	bytes.Buffer
+	lastID  int16
+	lastIDs []int16
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	t.Write(buf[:n])
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.varint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.WriteByte(typ)
+		t.zigzag(int64(id))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, compactI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, compactI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) string(id int16, v string) {
+	t.fieldHeader(id, compactBinary)
+	t.varint(uint64(len(v)))
+	t.WriteString(v)
+}
+
+// listBegin - starts a list field of size elements of type typ.
+func (t *thriftWriter) listBegin(id int16, typ byte, size int) {
+	t.fieldHeader(id, compactList)
+	if size < 15 {
+		t.WriteByte(byte(size)<<4 | typ)
+		return
+	}
+	t.WriteByte(0xf0 | typ)
+	t.varint(uint64(size))
+}
+
+func (t *thriftWriter) i32List(id int16, values ...int32) {
+	t.listBegin(id, compactI32, len(values))
+	for _, v := range values {
+		t.zigzag(int64(v))
+	}
+}
+
+func (t *thriftWriter) stringList(id int16, values ...string) {
+	t.listBegin(id, compactBinary, len(values))
+	for _, v := range values {
+		t.varint(uint64(len(v)))
+		t.WriteString(v)
+	}
+}
+
+// structBegin - starts a struct field, or a list element when id is 0.
+func (t *thriftWriter) structBegin(id int16) {
+	if id != 0 {
+		t.fieldHeader(id, compactStruct)
+	}
+	t.lastIDs = append(t.lastIDs, t.lastID)
+	t.lastID = 0
+}
+
+// structEnd - ends a struct with the stop field.
+func (t *thriftWriter) structEnd() {
+	t.WriteByte(0)
+	t.lastID = t.lastIDs[len(t.lastIDs)-1]
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
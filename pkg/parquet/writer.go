@@ -0,0 +1,336 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package parquet implements a minimal Apache Parquet writer for flat
+// schemas of required columns, one PLAIN encoded data page per column
+// chunk, with optional GZIP compression of the pages.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Type of a column.
+type Type int
+
+// Types of the columns, timestamps are stored in milliseconds.
+const (
+	Boolean Type = iota
+	Int64
+	String
+	Timestamp
+)
+
+// Column of the schema.
+type Column struct {
+	Name string
+	Type Type
+}
+
+// Parquet enums of the format specification.
+const (
+	typeBoolean   = 0
+	typeInt64     = 2
+	typeByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionRequired = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	codecGzip          = 2
+	pageTypeData       = 0
+)
+
+var magic = []byte("PAR1")
+
+// DefaultRowGroupSize is the number of rows buffered per row group.
+const DefaultRowGroupSize = 128 * 1024
+
+// columnChunk - the metadata of a written column chunk.
+type columnChunk struct {
+	offset           int64
+	values           int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// rowGroup - the metadata of a written row group.
+type rowGroup struct {
+	columns []columnChunk
+	rows    int64
+	size    int64
+}
+
+// Writer writes rows to a parquet file, rows are buffered per row group
+// and the file metadata is written by Close.
+type Writer struct {
+	w            io.Writer
+	offset       int64
+	columns      []Column
+	compress     bool
+	RowGroupSize int
+
+	rows      int
+	values    []bytes.Buffer
+	bools     [][]bool
+	rowGroups []rowGroup
+	closed    bool
+}
+
+// NewWriter returns a writer of the columns to w, pages are compressed
+// with GZIP if compress is set.
+func NewWriter(w io.Writer, columns []Column, compress bool) *Writer {
+	return &Writer{
+		w:            w,
+		columns:      columns,
+		compress:     compress,
+		RowGroupSize: DefaultRowGroupSize,
+		values:       make([]bytes.Buffer, len(columns)),
+		bools:        make([][]bool, len(columns)),
+	}
+}
+
+func (w *Writer) write(p []byte) error {
+	n, e := w.w.Write(p)
+	w.offset += int64(n)
+	return e
+}
+
+// Write appends a row, values are bool, int64, string and time.Time in
+// the order of the columns.
+func (w *Writer) Write(row []interface{}) error {
+	if w.closed {
+		return errors.New("parquet: write to a closed writer")
+	}
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: %d values for %d columns", len(row), len(w.columns))
+	}
+	if w.offset == 0 {
+		if e := w.write(magic); e != nil {
+			return e
+		}
+	}
+	for i, column := range w.columns {
+		var ok bool
+		switch column.Type {
+		case Boolean:
+			var v bool
+			if v, ok = row[i].(bool); ok {
+				w.bools[i] = append(w.bools[i], v)
+			}
+		case Int64:
+			var v int64
+			if v, ok = row[i].(int64); ok {
+				w.values[i].Write(binary.LittleEndian.AppendUint64(nil, uint64(v)))
+			}
+		case Timestamp:
+			var v time.Time
+			if v, ok = row[i].(time.Time); ok {
+				w.values[i].Write(binary.LittleEndian.AppendUint64(nil, uint64(v.UnixMilli())))
+			}
+		case String:
+			var v string
+			if v, ok = row[i].(string); ok {
+				w.values[i].Write(binary.LittleEndian.AppendUint32(nil, uint32(len(v))))
+				w.values[i].WriteString(v)
+			}
+		}
+		if !ok {
+			return fmt.Errorf("parquet: invalid value %v of column %s", row[i], column.Name)
+		}
+	}
+	w.rows++
+	if w.rows >= w.RowGroupSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// plainBooleans - bit packs booleans, least significant bit first.
+func plainBooleans(values []bool) []byte {
+	data := make([]byte, (len(values)+7)/8)
+	for i, v := range values {
+		if v {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return data
+}
+
+// flush - writes the buffered rows as a row group.
+func (w *Writer) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	group := rowGroup{rows: int64(w.rows)}
+	for i, column := range w.columns {
+		data := w.values[i].Bytes()
+		if column.Type == Boolean {
+			data = plainBooleans(w.bools[i])
+		}
+		uncompressedSize := len(data)
+		if w.compress {
+			var buf bytes.Buffer
+			zw := gzip.NewWriter(&buf)
+			if _, e := zw.Write(data); e != nil {
+				return e
+			}
+			if e := zw.Close(); e != nil {
+				return e
+			}
+			data = buf.Bytes()
+		}
+
+		var header thriftWriter
+		header.structBegin(0)
+		header.i32(1, pageTypeData)
+		header.i32(2, int32(uncompressedSize))
+		header.i32(3, int32(len(data)))
+		header.structBegin(5)
+		header.i32(1, int32(w.rows))
+		header.i32(2, encodingPlain)
+		header.i32(3, encodingRLE)
+		header.i32(4, encodingRLE)
+		header.structEnd()
+		header.structEnd()
+
+		chunk := columnChunk{
+			offset:           w.offset,
+			values:           int64(w.rows),
+			uncompressedSize: int64(header.Len() + uncompressedSize),
+			compressedSize:   int64(header.Len() + len(data)),
+		}
+		if e := w.write(header.Bytes()); e != nil {
+			return e
+		}
+		if e := w.write(data); e != nil {
+			return e
+		}
+		group.columns = append(group.columns, chunk)
+		group.size += chunk.uncompressedSize
+
+		w.values[i].Reset()
+		w.bools[i] = w.bools[i][:0]
+	}
+	w.rowGroups = append(w.rowGroups, group)
+	w.rows = 0
+	return nil
+}
+
+// physicalType - returns the parquet type and converted type of a column.
+func (c Column) physicalType() (typ, converted int32) {
+	switch c.Type {
+	case Boolean:
+		return typeBoolean, -1
+	case Int64:
+		return typeInt64, -1
+	case Timestamp:
+		return typeInt64, convertedTimestampMillis
+	default:
+		return typeByteArray, convertedUTF8
+	}
+}
+
+// Close writes the remaining rows and the file metadata, the
+// underlying writer is not closed.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if w.offset == 0 {
+		if e := w.write(magic); e != nil {
+			return e
+		}
+	}
+	if e := w.flush(); e != nil {
+		return e
+	}
+
+	var numRows int64
+	for _, group := range w.rowGroups {
+		numRows += group.rows
+	}
+	codec := int32(codecUncompressed)
+	if w.compress {
+		codec = codecGzip
+	}
+
+	var meta thriftWriter
+	meta.structBegin(0)
+	meta.i32(1, 1)
+	meta.listBegin(2, compactStruct, len(w.columns)+1)
+	meta.structBegin(0)
+	meta.string(4, "schema")
+	meta.i32(5, int32(len(w.columns)))
+	meta.structEnd()
+	for _, column := range w.columns {
+		typ, converted := column.physicalType()
+		meta.structBegin(0)
+		meta.i32(1, typ)
+		meta.i32(3, repetitionRequired)
+		meta.string(4, column.Name)
+		if converted >= 0 {
+			meta.i32(6, converted)
+		}
+		meta.structEnd()
+	}
+	meta.i64(3, numRows)
+	meta.listBegin(4, compactStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		meta.structBegin(0)
+		meta.listBegin(1, compactStruct, len(group.columns))
+		for i, chunk := range group.columns {
+			typ, _ := w.columns[i].physicalType()
+			meta.structBegin(0)
+			meta.i64(2, chunk.offset)
+			meta.structBegin(3)
+			meta.i32(1, typ)
+			meta.i32List(2, encodingPlain, encodingRLE)
+			meta.stringList(3, w.columns[i].Name)
+			meta.i32(4, codec)
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.uncompressedSize)
+			meta.i64(7, chunk.compressedSize)
+			meta.i64(9, chunk.offset)
+			meta.structEnd()
+			meta.structEnd()
+		}
+		meta.i64(2, group.size)
+		meta.i64(3, group.rows)
+		meta.structEnd()
+	}
+	meta.string(6, "mc")
+	meta.structEnd()
+
+	if e := w.write(meta.Bytes()); e != nil {
+		return e
+	}
+	if e := w.write(binary.LittleEndian.AppendUint32(nil, uint32(meta.Len()))); e != nil {
+		return e
+	}
+	return w.write(magic)
+}
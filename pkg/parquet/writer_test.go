@@ -0,0 +1,326 @@
+// Copyright (c) 2015-2024 MinIO, Inc.
+//
+// This file is part of MinIO Object Storage stack
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes compact protocol structs into maps of field ids.
+type thriftReader struct {
+	*bytes.Reader
+}
+
+func (t thriftReader) varint() int64 {
+	v, e := binary.ReadUvarint(t)
+	if e != nil {
+		panic(e)
+	}
+	return v2i(v)
+}
+
+func v2i(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (t thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case compactI32, compactI64:
+		return t.varint()
+	case compactBinary:
+		n, _ := binary.ReadUvarint(t)
+		buf := make([]byte, n)
+		io.ReadFull(t, buf)
+		return string(buf)
+	case compactList:
+		header, _ := t.ReadByte()
+		size := int(header >> 4)
+		if size == 15 {
+			n, _ := binary.ReadUvarint(t)
+			size = int(n)
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = t.value(header & 0x0f)
+		}
+		return list
+	case compactStruct:
+		return t.structure()
+	}
+	panic("unexpected type")
+}
+
+func (t thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var id int16
+	for {
+		header, _ := t.ReadByte()
+		if header == 0 {
+			return fields
+		}
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			id = int16(t.varint())
+		}
+		fields[id] = t.value(header & 0x0f)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	columns := []Column{
+		{Name: "key", Type: String},
+		{Name: "size", Type: Int64},
+		{Name: "lastModified", Type: Timestamp},
+		{Name: "isLatest", Type: Boolean},
+	}
+	modTime := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	rows := [][]interface{}{
+		{"a", int64(1), modTime, true},
+		{"b/c", int64(2), modTime, false},
+		{"", int64(0), modTime, true},
+	}
+
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, columns, compress)
+		w.RowGroupSize = 2
+		for _, row := range rows {
+			if e := w.Write(row); e != nil {
+				t.Fatal(e)
+			}
+		}
+		if e := w.Write([]interface{}{"a"}); e == nil {
+			t.Fatal("Expected a row missing values to fail")
+		}
+		if e := w.Close(); e != nil {
+			t.Fatal(e)
+		}
+
+		data := buf.Bytes()
+		if !bytes.HasPrefix(data, magic) || !bytes.HasSuffix(data, magic) {
+			t.Fatal("Expected the parquet magic at both ends")
+		}
+		metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+		meta := thriftReader{bytes.NewReader(data[len(data)-8-metaLen : len(data)-8])}.structure()
+		if meta[3] != int64(len(rows)) {
+			t.Fatalf("Expected %d rows, got %v", len(rows), meta[3])
+		}
+		if schema := meta[2].([]interface{}); len(schema) != len(columns)+1 {
+			t.Fatalf("Expected %d schema elements, got %d", len(columns)+1, len(schema))
+		}
+
+		// Decode the keys and sizes of all row groups.
+		var keys []string
+		var sizes []int64
+		for _, group := range meta[4].([]interface{}) {
+			chunks := group.(map[int16]interface{})[1].([]interface{})
+			for i, chunk := range chunks {
+				columnMeta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+				r := thriftReader{bytes.NewReader(data[columnMeta[9].(int64):])}
+				header := r.structure()
+				page := make([]byte, header[3].(int64))
+				io.ReadFull(r, page)
+				if compress {
+					zr, e := gzip.NewReader(bytes.NewReader(page))
+					if e != nil {
+						t.Fatal(e)
+					}
+					if page, e = io.ReadAll(zr); e != nil {
+						t.Fatal(e)
+					}
+				}
+				if int64(len(page)) != header[2].(int64) {
+					t.Fatalf("Expected an uncompressed page of %d bytes, got %d", header[2], len(page))
+				}
+				values := header[5].(map[int16]interface{})[1].(int64)
+				for n := int64(0); n < values; n++ {
+					switch i {
+					case 0:
+						size := binary.LittleEndian.Uint32(page)
+						keys = append(keys, string(page[4:4+size]))
+						page = page[4+size:]
+					case 1:
+						sizes = append(sizes, int64(binary.LittleEndian.Uint64(page)))
+						page = page[8:]
+					}
+				}
+			}
+		}
+		if !reflect.DeepEqual(keys, []string{"a", "b/c", ""}) || !reflect.DeepEqual(sizes, []int64{1, 2, 0}) {
+			t.Fatalf("Unexpected keys %v and sizes %v", keys, sizes)
+		}
+	}
+}
+
+var (
+	goldenColumns = []Column{
+		{Name: "bucket", Type: String},
+		{Name: "key", Type: String},
+		{Name: "size", Type: Int64},
+		{Name: "lastModified", Type: Timestamp},
+		{Name: "isLatest", Type: Boolean},
+	}
+	goldenRows = [][]interface{}{
+		{"datalake", "2024/05/a.parquet", int64(1048576), time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC), true},
+		{"datalake", "2024/05/b.csv", int64(0), time.Date(2024, 5, 6, 7, 8, 9, 500000000, time.UTC), false},
+		{"datalake", "résumé.txt", int64(-1), time.Date(1969, 12, 31, 23, 59, 59, 0, time.UTC), true},
+	}
+)
+
+// decodeFile decodes the rows of a parquet file with the numbers of the
+// format specification rather than the constants of the writer, checking
+// the footer, the schema and the pages along the way.
+func decodeFile(t *testing.T, data []byte) (columns []Column, rows [][]interface{}) {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+		t.Fatal("Expected the parquet magic at both ends")
+	}
+	metaLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := thriftReader{bytes.NewReader(data[len(data)-8-metaLen : len(data)-8])}.structure()
+	if meta[1] != int64(1) {
+		t.Fatalf("Expected version 1, got %v", meta[1])
+	}
+
+	// FileMetaData.schema: a root element with the number of columns,
+	// then one required element per column.
+	schema := meta[2].([]interface{})
+	if root := schema[0].(map[int16]interface{}); root[5] != int64(len(schema)-1) {
+		t.Fatalf("Expected a root of %d columns, got %v", len(schema)-1, root)
+	}
+	for _, element := range schema[1:] {
+		fields := element.(map[int16]interface{})
+		if fields[3] != int64(0) {
+			t.Fatalf("Expected a required column, got %v", fields)
+		}
+		column := Column{Name: fields[4].(string)}
+		switch [2]interface{}{fields[1], fields[6]} {
+		case [2]interface{}{int64(0), nil}:
+			column.Type = Boolean
+		case [2]interface{}{int64(2), nil}:
+			column.Type = Int64
+		case [2]interface{}{int64(2), int64(9)}:
+			column.Type = Timestamp
+		case [2]interface{}{int64(6), int64(0)}:
+			column.Type = String
+		default:
+			t.Fatalf("Unexpected column %v", fields)
+		}
+		columns = append(columns, column)
+	}
+
+	for _, group := range meta[4].([]interface{}) {
+		groupFields := group.(map[int16]interface{})
+		numRows := groupFields[3].(int64)
+		groupRows := make([][]interface{}, numRows)
+		for i, chunk := range groupFields[1].([]interface{}) {
+			columnMeta := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			if columnMeta[4] != int64(0) || columnMeta[5] != numRows {
+				t.Fatalf("Expected %d uncompressed values, got %v", numRows, columnMeta)
+			}
+
+			// PageHeader of a PLAIN encoded data page, followed by
+			// the page of total_compressed_size with its header.
+			offset := columnMeta[9].(int64)
+			r := thriftReader{bytes.NewReader(data[offset:])}
+			header := r.structure()
+			headerLen := int64(len(data[offset:])) - int64(r.Len())
+			dataPage := header[5].(map[int16]interface{})
+			if header[1] != int64(0) || dataPage[1] != numRows || dataPage[2] != int64(0) ||
+				header[2] != header[3] || headerLen+header[3].(int64) != columnMeta[7] {
+				t.Fatalf("Unexpected page header %v of column %v", header, columnMeta)
+			}
+			page := data[offset+headerLen : offset+headerLen+header[3].(int64)]
+
+			for n := range groupRows {
+				var value interface{}
+				switch columns[i].Type {
+				case Boolean:
+					value = page[n/8]&(1<<(n%8)) != 0
+				case Int64:
+					value = int64(binary.LittleEndian.Uint64(page))
+					page = page[8:]
+				case Timestamp:
+					value = time.UnixMilli(int64(binary.LittleEndian.Uint64(page))).UTC()
+					page = page[8:]
+				case String:
+					size := binary.LittleEndian.Uint32(page)
+					value = string(page[4 : 4+size])
+					page = page[4+size:]
+				}
+				groupRows[n] = append(groupRows[n], value)
+			}
+		}
+		rows = append(rows, groupRows...)
+	}
+	if meta[3] != int64(len(rows)) {
+		t.Fatalf("Expected %d rows in the footer, got %v", len(rows), meta[3])
+	}
+	return columns, rows
+}
+
+// TestWriterGolden compares the output of the writer with a golden file,
+// so that a change of the encoding is caught, and decodes the golden file
+// independently of the writer. The golden file can be checked with other
+// parquet implementations as well, e.g.
+//
+//	python3 -c 'import pyarrow.parquet as pq; print(pq.read_table("testdata/inventory.parquet").to_pylist())'
+//
+// must print the golden rows, in two row groups.
+func TestWriterGolden(t *testing.T) {
+	golden, e := os.ReadFile("testdata/inventory.parquet")
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	columns, rows := decodeFile(t, golden)
+	if !reflect.DeepEqual(columns, goldenColumns) {
+		t.Fatalf("Expected the columns %v, got %v", goldenColumns, columns)
+	}
+	// Timestamps are stored in milliseconds.
+	expected := make([][]interface{}, len(goldenRows))
+	for i, row := range goldenRows {
+		expected[i] = append([]interface{}{}, row...)
+		expected[i][3] = row[3].(time.Time).Truncate(time.Millisecond)
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Fatalf("Expected the rows %v, got %v", expected, rows)
+	}
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, goldenColumns, false)
+	w.RowGroupSize = 2
+	for _, row := range goldenRows {
+		if e = w.Write(row); e != nil {
+			t.Fatal(e)
+		}
+	}
+	if e = w.Close(); e != nil {
+		t.Fatal(e)
+	}
+	if !bytes.Equal(buf.Bytes(), golden) {
+		t.Fatalf("Expected the golden file of %d bytes, got %d bytes", len(golden), buf.Len())
+	}
+}